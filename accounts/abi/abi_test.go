@@ -1164,3 +1164,85 @@ func TestUnpackRevert(t *testing.T) {
 		})
 	}
 }
+
+func TestUnpackPanic(t *testing.T) {
+	t.Parallel()
+
+	var cases = []struct {
+		input     string
+		expect    uint64
+		reason    string
+		message   string
+		expectErr bool
+	}{
+		{"", 0, "", "", true},
+		{"08c379a00000000000000000000000000000000000000000000000000000000000000011", 0, "", "", true},
+		{"4e487b710000000000000000000000000000000000000000000000000000000000000011", 0x11, "arithmetic underflow or overflow", "panic: arithmetic underflow or overflow (0x11)", false},
+		{"4e487b710000000000000000000000000000000000000000000000000000000000000099", 0x99, "", "panic: unknown panic (0x99)", false},
+	}
+	for index, c := range cases {
+		t.Run(fmt.Sprintf("case %d", index), func(t *testing.T) {
+			got, err := UnpackPanic(common.Hex2Bytes(c.input))
+			if c.expectErr {
+				if err == nil {
+					t.Fatalf("Expected non-nil error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.Uint64() != c.expect {
+				t.Fatalf("Code mismatch, want %#x, got %#x", c.expect, got)
+			}
+			if reason := PanicReason(got); reason != c.reason {
+				t.Fatalf("Reason mismatch, want %q, got %q", c.reason, reason)
+			}
+			if message := PanicMessage(got); message != c.message {
+				t.Fatalf("Message mismatch, want %q, got %q", c.message, message)
+			}
+		})
+	}
+}
+
+func TestUnpackError(t *testing.T) {
+	t.Parallel()
+
+	abi, err := JSON(strings.NewReader(`[
+		{"type":"error","name":"Insufficient","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]},
+		{"type":"error","name":"Unauthorized","inputs":[{"name":"","type":"address"}]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := abi.Errors["Insufficient"].Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(crypto.Keccak256([]byte("Insufficient(uint256,uint256)"))[:4], data...)
+
+	e, args, err := abi.UnpackError(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e.Name != "Insufficient" {
+		t.Fatalf("Error mismatch, want Insufficient, got %v", e.Name)
+	}
+	if args["available"].(*big.Int).Uint64() != 1 || args["required"].(*big.Int).Uint64() != 2 {
+		t.Fatalf("Arguments mismatch, got %v", args)
+	}
+	data, err = abi.Errors["Unauthorized"].Inputs.Pack(common.Address{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(crypto.Keccak256([]byte("Unauthorized(address)"))[:4], data...)
+	if _, args, err = abi.UnpackError(data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if args["arg0"].(common.Address) != (common.Address{0x01}) {
+		t.Fatalf("Arguments mismatch, got %v", args)
+	}
+	if _, _, err := abi.UnpackError(common.Hex2Bytes("deadbeef")); err == nil {
+		t.Fatalf("Expected error for unknown selector")
+	}
+}
@@ -17,15 +17,90 @@
 package abi
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
+
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 var (
 	errBadBool = errors.New("abi: improperly encoded boolean value")
 )
 
+// panicSelector is the function selector of the builtin Panic(uint256) error
+// raised by solidity on failed assertions and runtime checks.
+var panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+// panicReasons maps the solidity panic codes to a human readable description.
+// See: https://docs.soliditylang.org/en/latest/control-structures.html#panic-via-assert-and-error-via-require
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// UnpackPanic resolves the abi-encoded panic code of a Panic(uint256) revert.
+func UnpackPanic(data []byte) (*big.Int, error) {
+	if len(data) < 4 {
+		return nil, errors.New("invalid data for unpacking")
+	}
+	if !bytes.Equal(data[:4], panicSelector) {
+		return nil, errors.New("invalid data for unpacking")
+	}
+	typ, _ := NewType("uint256", "", nil)
+	unpacked, err := (Arguments{{Type: typ}}).Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	return unpacked[0].(*big.Int), nil
+}
+
+// PanicReason returns the description of a solidity panic code, or an empty
+// string if the code is unknown.
+func PanicReason(code *big.Int) string {
+	if !code.IsUint64() {
+		return ""
+	}
+	return panicReasons[code.Uint64()]
+}
+
+// PanicMessage returns the message describing a solidity panic, naming both its
+// reason and its code.
+func PanicMessage(code *big.Int) string {
+	reason := PanicReason(code)
+	if reason == "" {
+		reason = "unknown panic"
+	}
+	return fmt.Sprintf("panic: %v (%#x)", reason, code)
+}
+
+// UnpackError resolves abi-encoded revert data against the custom errors
+// declared in the ABI, returning the matching error and its named arguments.
+func (abi *ABI) UnpackError(data []byte) (*Error, map[string]interface{}, error) {
+	if len(data) < 4 {
+		return nil, nil, errors.New("invalid data for unpacking")
+	}
+	e, err := abi.ErrorByID(*(*[4]byte)(data[:4]))
+	if err != nil {
+		return nil, nil, err
+	}
+	args := make(map[string]interface{})
+	if err := e.Inputs.UnpackIntoMap(args, data[4:]); err != nil {
+		return nil, nil, err
+	}
+	return e, args, nil
+}
+
 // formatSliceString formats the reflection kind with the given slice size
 // and returns a formatted string representation.
 func formatSliceString(kind reflect.Kind, sliceSize int) string {
@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -33,10 +34,12 @@ import (
 	"github.com/ethereum/go-ethereum/console/prompt"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
@@ -69,6 +72,8 @@ Remove blockchain and state databases`,
 			dbExportCmd,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
+			dbMigrateStateCmd,
+			dbPruneHistoryCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -90,6 +95,30 @@ Remove blockchain and state databases`,
 		Description: `This command iterates the entire database for 32-byte keys, looking for rlp-encoded trie nodes.
 For each trie node encountered, it checks that the key corresponds to the keccak256(value). If this is not true, this indicates
 a data corruption.`,
+	}
+	dbMigrateStateCmd = &cli.Command{
+		Action: migrateState,
+		Name:   "migrate-state",
+		Usage:  "Convert the head state from the hash-based to the path-based scheme",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+		}, utils.NetworkFlags, utils.DatabasePathFlags),
+		Description: `This command rewrites the trie nodes of the head state keyed by their path,
+then deletes all the trie nodes keyed by their hash. All the states other than the head one
+are lost, so the node can't serve them or reorg below the head block afterwards.`,
+	}
+	dbPruneHistoryCmd = &cli.Command{
+		Action: pruneHistory,
+		Name:   "prune-history",
+		Usage:  "Prune the bodies and receipts of old blocks from the ancient store",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+			utils.HistoryRetainFlag,
+		}, utils.NetworkFlags, utils.DatabasePathFlags),
+		Description: `This command discards the bodies and receipts of the frozen blocks which are
+older than the number of recent blocks given by --history.retain, along with the lookup
+entries of their transactions. The block headers are retained. The pruned history can't
+be served over RPC or to peers anymore, it can only be restored by resyncing the chain.`,
 	}
 	dbStatCmd = &cli.Command{
 		Action: dbStats,
@@ -340,6 +369,138 @@ func checkStateContent(ctx *cli.Context) error {
 	return nil
 }
 
+func migrateState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	if trie.ReadStateScheme(db) == trie.PathScheme {
+		return errors.New("state is already stored in the path-based scheme")
+	}
+	head := rawdb.ReadHeadBlock(db)
+	if head == nil {
+		return errors.New("no head block")
+	}
+	var (
+		root      = head.Root()
+		triedb    = trie.NewDatabase(db)
+		batch     = db.NewBatch()
+		nodes     int
+		startTime = time.Now()
+		lastLog   = time.Now()
+	)
+	flush := func(force bool) error {
+		if !force && batch.ValueSize() < ethdb.IdealBatchSize {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	log.Info("Migrating state to the path-based scheme", "number", head.NumberU64(), "root", root)
+
+	// Rewrite the nodes of the account trie and all the storage tries by path
+	accTrie, err := trie.NewStateTrie(trie.StateTrieID(root), triedb)
+	if err != nil {
+		return err
+	}
+	accIt := accTrie.NodeIterator(nil)
+	for accIt.Next(true) {
+		if accIt.Hash() != (common.Hash{}) {
+			rawdb.WriteAccountTrieNode(batch, accIt.Path(), accIt.NodeBlob())
+			nodes++
+		}
+		if accIt.Leaf() {
+			var acc types.StateAccount
+			if err := rlp.DecodeBytes(accIt.LeafBlob(), &acc); err != nil {
+				return err
+			}
+			if acc.Root != types.EmptyRootHash {
+				owner := common.BytesToHash(accIt.LeafKey())
+				storageTrie, err := trie.NewStateTrie(trie.StorageTrieID(root, owner, acc.Root), triedb)
+				if err != nil {
+					return err
+				}
+				storageIt := storageTrie.NodeIterator(nil)
+				for storageIt.Next(true) {
+					if storageIt.Hash() != (common.Hash{}) {
+						rawdb.WriteStorageTrieNode(batch, owner, storageIt.Path(), storageIt.NodeBlob())
+						nodes++
+					}
+					if err := flush(false); err != nil {
+						return err
+					}
+				}
+				if err := storageIt.Error(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := flush(false); err != nil {
+			return err
+		}
+		if time.Since(lastLog) > 8*time.Second {
+			log.Info("Migrating state", "nodes", nodes, "at", fmt.Sprintf("%#x", accIt.Path()), "elapsed", common.PrettyDuration(time.Since(startTime)))
+			lastLog = time.Now()
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		return err
+	}
+	rawdb.WritePersistentStateID(batch, 0)
+	if err := flush(true); err != nil {
+		return err
+	}
+	log.Info("Rewrote state by path", "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(startTime)))
+
+	// Delete the hash-keyed nodes. Legacy contract codes are stored under their
+	// bare hash too, only the entries which look like trie nodes are deleted.
+	var (
+		deleted int
+		it      = rawdb.NewKeyLengthIterator(db.NewIterator(nil, nil), common.HashLength)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		if !rawdb.IsLegacyTrieNode(it.Key(), it.Value()) || !isTrieNodeBlob(it.Value()) {
+			continue
+		}
+		batch.Delete(it.Key())
+		deleted++
+
+		if err := flush(false); err != nil {
+			return err
+		}
+		if time.Since(lastLog) > 8*time.Second {
+			log.Info("Deleting hash-keyed trie nodes", "deleted", deleted, "at", fmt.Sprintf("%#x", it.Key()), "elapsed", common.PrettyDuration(time.Since(startTime)))
+			lastLog = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := flush(true); err != nil {
+		return err
+	}
+	log.Info("Migrated state to the path-based scheme", "nodes", nodes, "deleted", deleted, "elapsed", common.PrettyDuration(time.Since(startTime)))
+	return nil
+}
+
+// isTrieNodeBlob reports whether the blob is the encoding of a trie node, being
+// an RLP list of either two (short node) or seventeen (full node) items.
+func isTrieNodeBlob(blob []byte) bool {
+	content, _, err := rlp.SplitList(blob)
+	if err != nil {
+		return false
+	}
+	n, err := rlp.CountValues(content)
+	return err == nil && (n == 2 || n == 17)
+}
+
 func showLeveldbStats(db ethdb.KeyValueStater) {
 	if stats, err := db.Stat("leveldb.stats"); err != nil {
 		log.Warn("Failed to read database stats", "error", err)
@@ -364,6 +525,46 @@ func dbStats(ctx *cli.Context) error {
 	return nil
 }
 
+func pruneHistory(ctx *cli.Context) error {
+	retain := ctx.Uint64(utils.HistoryRetainFlag.Name)
+	if retain == 0 {
+		return fmt.Errorf("--%s is required to select the history to prune", utils.HistoryRetainFlag.Name)
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during history pruning, stopping")
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	number := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadBlockHash(db))
+	if number == nil {
+		return errors.New("no head block")
+	}
+	if *number < retain {
+		log.Info("Block history is shorter than the retention, nothing to prune", "head", *number, "retain", retain)
+		return nil
+	}
+	start := time.Now()
+	tail, err := rawdb.PruneHistory(db, *number-retain+1, stop)
+	if err != nil {
+		return err
+	}
+	log.Info("Block history pruned", "head", *number, "tail", tail, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
 func dbCompact(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	defer stack.Close()
@@ -515,7 +716,7 @@ func dbDumpTrie(ctx *cli.Context) error {
 		}
 	}
 	id := trie.StorageTrieID(common.BytesToHash(state), common.BytesToHash(account), common.BytesToHash(storage))
-	theTrie, err := trie.New(id, trie.NewDatabase(db))
+	theTrie, err := trie.New(id, utils.MakeTrieDatabase(ctx, db, false))
 	if err != nil {
 		return err
 	}
@@ -735,6 +936,8 @@ func showMetaData(ctx *cli.Context) error {
 		{"snapshotRecoveryNumber", pp(rawdb.ReadSnapshotRecoveryNumber(db))},
 		{"snapshotRoot", fmt.Sprintf("%v", rawdb.ReadSnapshotRoot(db))},
 		{"txIndexTail", pp(rawdb.ReadTxIndexTail(db))},
+		{"stateScheme", trie.ReadStateScheme(db)},
+		{"persistentStateID", fmt.Sprintf("%d", rawdb.ReadPersistentStateID(db))},
 		{"fastTxLookupLimit", pp(rawdb.ReadFastTxLookupLimit(db))},
 	}...)
 	table := tablewriter.NewWriter(os.Stdout)
@@ -0,0 +1,497 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	cli "github.com/urfave/cli/v2"
+)
+
+var (
+	rewardsFormatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "Export format of the reward records (csv, json)",
+		Value: "csv",
+	}
+	rewardsOutputFlag = &cli.StringFlag{
+		Name:  "output",
+		Usage: "File to write the reward records to (default = stdout)",
+	}
+
+	rewardsCommand = &cli.Command{
+		Name:  "rewards",
+		Usage: "A set of commands to inspect the reward records",
+		Description: `
+The rewards commands open the chain database read only and replay the validator
+and covenant nft reward records offline, so they can be used while the node is
+stopped or against a copy of its datadir.
+
+The records are rebuilt from the closest persisted checkpoint and the block
+rewards persisted for every canonical block. 'dump' and 'export' show the
+records as of a block, 'recompute' derives them from the block bodies and
+receipts instead, and 'diff' compares the persisted block rewards against that
+recomputation. The journalled records of the last shutdown are never read.
+`,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "dump",
+				Usage:     "Dump the reward records as of a specific block",
+				ArgsUsage: "[? <blockHash> | <blockNum>]",
+				Action:    dumpRewards,
+				Flags:     flags.Merge(utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth rewards dump [? <blockHash> | <blockNum>]
+dumps the validator and covenant nft reward records, including their daily and
+monthly histories, as of the given canonical block as JSON. The records are
+replayed from the closest checkpoint using the persisted block rewards.
+
+If no block is provided, the latest block is used.
+`,
+			},
+			{
+				Name:      "recompute",
+				Usage:     "Recompute the reward records from the chain over a block range",
+				ArgsUsage: "<from> [? <blockHash> | <blockNum>]",
+				Action:    recomputeRewards,
+				Flags:     flags.Merge(utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth rewards recompute <from> [? <blockHash> | <blockNum>]
+recomputes the rewards of the canonical blocks starting from <from> up to the
+given block from the block bodies and receipts, ignoring the persisted block
+rewards and checkpoints, and dumps the resulting records as JSON.
+
+Nothing is written to the database, use debug.rebuildRewards on a running node
+to replace corrupted reward data.
+`,
+			},
+			{
+				Name:      "diff",
+				Usage:     "Compare the persisted reward records against recomputed ones",
+				ArgsUsage: "<from> [? <blockHash> | <blockNum>]",
+				Action:    diffRewards,
+				Flags:     flags.Merge(utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth rewards diff <from> [? <blockHash> | <blockNum>]
+replays the reward records as of the given block twice, once from the persisted
+block rewards and once recomputing the rewards of the blocks starting from
+<from>, and prints every record and total which differs. The command fails if
+any difference is found.
+`,
+			},
+			{
+				Name:      "export",
+				Usage:     "Export the reward totals as of a specific block for accounting",
+				ArgsUsage: "[? <blockHash> | <blockNum>]",
+				Action:    exportRewards,
+				Flags: flags.Merge([]cli.Flag{
+					rewardsFormatFlag,
+					rewardsOutputFlag,
+				}, utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth rewards export [? <blockHash> | <blockNum>]
+exports one row per validator and covenant nft with the running, daily, moon and
+purge totals as of the given canonical block. The amounts are decimal wei. The
+format is chosen with --format and the rows are written to stdout unless an
+--output file is given.
+
+If no block is provided, the latest block is used.
+`,
+			},
+		},
+	}
+)
+
+// rewardsEnv is the read only chain access needed to replay reward records.
+type rewardsEnv struct {
+	db      ethdb.Database
+	config  *params.ChainConfig
+	engine  consensus.Engine
+	genesis *types.Header
+}
+
+// openRewardsEnv opens the chain database read only and resolves the chain
+// configuration and consensus engine needed to replay the reward records.
+func openRewardsEnv(ctx *cli.Context, stack *node.Node) (*rewardsEnv, error) {
+	db := utils.MakeChainDatabase(ctx, stack, true)
+
+	hash := rawdb.ReadCanonicalHash(db, 0)
+	genesis := rawdb.ReadHeader(db, hash, 0)
+	config := rawdb.ReadChainConfig(db, hash)
+	if genesis == nil || config == nil {
+		db.Close()
+		return nil, errors.New("genesis block or chain config not found")
+	}
+	ethashConfig := ethconfig.Defaults.Ethash
+	engine := ethconfig.CreateConsensusEngine(stack, &ethashConfig, config.Clique, nil, false, db)
+
+	return &rewardsEnv{db: db, config: config, engine: engine, genesis: genesis}, nil
+}
+
+// Close releases the database and the consensus engine.
+func (env *rewardsEnv) Close() {
+	env.engine.Close()
+	env.db.Close()
+}
+
+// header resolves a block number or hash argument into a canonical header. An
+// empty argument resolves to the latest block.
+func (env *rewardsEnv) header(arg string) (*types.Header, error) {
+	var header *types.Header
+	switch {
+	case arg == "":
+		header = rawdb.ReadHeadHeader(env.db)
+	case hashish(arg):
+		hash := common.HexToHash(arg)
+		number := rawdb.ReadHeaderNumber(env.db, hash)
+		if number == nil || rawdb.ReadCanonicalHash(env.db, *number) != hash {
+			return nil, fmt.Errorf("canonical block %x not found", hash)
+		}
+		header = rawdb.ReadHeader(env.db, hash, *number)
+	default:
+		number, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		header = rawdb.ReadHeader(env.db, rawdb.ReadCanonicalHash(env.db, number), number)
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %s not found", arg)
+	}
+	return header, nil
+}
+
+// replay recreates the reward records as of the given block, recomputing the
+// rewards of the blocks starting from recompute if it's non-zero.
+func (env *rewardsEnv) replay(header *types.Header, recompute uint64) (*types.NodeRewardStorage, error) {
+	log.Info("Replaying reward records", "number", header.Number, "hash", header.Hash(), "recompute", recompute)
+	return rewards.Replay(env.db, env.config, env.engine, env.genesis, header.Number.Uint64(), recompute)
+}
+
+// parseRewardsRange parses the <from> [block] arguments of the commands which
+// recompute the reward records.
+func parseRewardsRange(ctx *cli.Context, env *rewardsEnv) (uint64, *types.Header, error) {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		return 0, nil, fmt.Errorf("expected 1 or 2 arguments (from and block), got %d", ctx.NArg())
+	}
+	from, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	header, err := env.header(ctx.Args().Get(1))
+	if err != nil {
+		return 0, nil, err
+	}
+	if from > header.Number.Uint64() {
+		return 0, nil, fmt.Errorf("range start %d above block %d", from, header.Number)
+	}
+	// Block zero doesn't credit any rewards, but zero disables recomputing
+	if from == 0 {
+		from = 1
+	}
+	return from, header, nil
+}
+
+// rewardsDump is the JSON representation of the reward records as of a block.
+type rewardsDump struct {
+	Number             uint64                              `json:"number"`
+	Hash               common.Hash                         `json:"hash"`
+	Day                uint64                              `json:"day"`
+	Validators         []*types.RPCValidatorRewardRecord   `json:"validators"`
+	Covenants          []*types.RPCCovenantNFTRewardRecord `json:"covenants"`
+	TotalSupply        *hexutil.Big                        `json:"totalSupply"`
+	TotalFeeValidators *hexutil.Big                        `json:"totalFeeValidators"`
+	TotalFeeCovenants  *hexutil.Big                        `json:"totalFeeCovenants"`
+}
+
+// writeRewardsDump writes the given reward records to stdout as JSON.
+func writeRewardsDump(header *types.Header, storage *types.NodeRewardStorage) error {
+	dump := &rewardsDump{
+		Number:             header.Number.Uint64(),
+		Hash:               header.Hash(),
+		Day:                storage.Day,
+		Validators:         make([]*types.RPCValidatorRewardRecord, 0, len(storage.ValidatorRecords)),
+		Covenants:          make([]*types.RPCCovenantNFTRewardRecord, 0, len(storage.CovenantRecords)),
+		TotalSupply:        (*hexutil.Big)(storage.TotalSupply()),
+		TotalFeeValidators: (*hexutil.Big)(bigOrZero(storage.TotalFeeValidators)),
+		TotalFeeCovenants:  (*hexutil.Big)(bigOrZero(storage.TotalFeeCovenants)),
+	}
+	for i := range storage.ValidatorRecords {
+		dump.Validators = append(dump.Validators, types.NewRPCValidatorRewardRecord(&storage.ValidatorRecords[i]))
+	}
+	for i := range storage.CovenantRecords {
+		dump.Covenants = append(dump.Covenants, types.NewRPCCovenantNFTRewardRecord(&storage.CovenantRecords[i]))
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dump)
+}
+
+func dumpRewards(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	if ctx.NArg() > 1 {
+		return fmt.Errorf("expected 1 argument (number or hash), got %d", ctx.NArg())
+	}
+	env, err := openRewardsEnv(ctx, stack)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	header, err := env.header(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	storage, err := env.replay(header, 0)
+	if err != nil {
+		return err
+	}
+	return writeRewardsDump(header, storage)
+}
+
+func recomputeRewards(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	env, err := openRewardsEnv(ctx, stack)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	from, header, err := parseRewardsRange(ctx, env)
+	if err != nil {
+		return err
+	}
+	storage, err := env.replay(header, from)
+	if err != nil {
+		return err
+	}
+	return writeRewardsDump(header, storage)
+}
+
+func diffRewards(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	env, err := openRewardsEnv(ctx, stack)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	from, header, err := parseRewardsRange(ctx, env)
+	if err != nil {
+		return err
+	}
+	stored, err := env.replay(header, 0)
+	if err != nil {
+		return err
+	}
+	recomputed, err := env.replay(header, from)
+	if err != nil {
+		return err
+	}
+	var (
+		have  = rewardsRows(stored)
+		want  = rewardsRows(recomputed)
+		diffs int
+	)
+	index := make(map[string]*rewardsRow)
+	for _, row := range want {
+		index[row.key()] = row
+	}
+	for _, row := range have {
+		other, ok := index[row.key()]
+		if !ok {
+			fmt.Printf("%s: only in stored records\n", row.key())
+			diffs++
+			continue
+		}
+		delete(index, row.key())
+		for _, field := range row.diff(other) {
+			fmt.Printf("%s: %s\n", row.key(), field)
+			diffs++
+		}
+	}
+	for _, row := range want {
+		if _, ok := index[row.key()]; ok {
+			fmt.Printf("%s: only in recomputed records\n", row.key())
+			diffs++
+		}
+	}
+	for _, total := range []struct {
+		name       string
+		have, want *big.Int
+	}{
+		{"total supply", stored.TotalSupply(), recomputed.TotalSupply()},
+		{"validator fees", bigOrZero(stored.TotalFeeValidators), bigOrZero(recomputed.TotalFeeValidators)},
+		{"covenant fees", bigOrZero(stored.TotalFeeCovenants), bigOrZero(recomputed.TotalFeeCovenants)},
+	} {
+		if total.have.Cmp(total.want) != 0 {
+			fmt.Printf("%s: stored %v, recomputed %v\n", total.name, total.have, total.want)
+			diffs++
+		}
+	}
+	if diffs > 0 {
+		return fmt.Errorf("%d differences found in reward records as of block #%d", diffs, header.Number)
+	}
+	log.Info("Reward records match", "number", header.Number, "hash", header.Hash(), "from", from)
+	return nil
+}
+
+func exportRewards(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	if ctx.NArg() > 1 {
+		return fmt.Errorf("expected 1 argument (number or hash), got %d", ctx.NArg())
+	}
+	format := ctx.String(rewardsFormatFlag.Name)
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	env, err := openRewardsEnv(ctx, stack)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	header, err := env.header(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	storage, err := env.replay(header, 0)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = os.Stdout
+	if path := ctx.String(rewardsOutputFlag.Name); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	rows := rewardsRows(storage)
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Number  uint64        `json:"number"`
+			Hash    common.Hash   `json:"hash"`
+			Records []*rewardsRow `json:"records"`
+		}{header.Number.Uint64(), header.Hash(), rows})
+	}
+	w := csv.NewWriter(out)
+	w.Write([]string{"type", "id", "tier", "running_total", "earned_today", "earned_this_moon", "earned_since_last_purge"})
+	for _, row := range rows {
+		w.Write([]string{row.Type, row.ID, row.Tier, row.RunningTotal, row.EarnedToday, row.EarnedThisMoon, row.EarnedSinceLastPurge})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// rewardsRow is the flat representation of a reward record used for exporting
+// and comparing the records. Amounts are decimal wei.
+type rewardsRow struct {
+	Type                 string `json:"type"`
+	ID                   string `json:"id"`
+	Tier                 string `json:"tier,omitempty"`
+	RunningTotal         string `json:"runningTotal"`
+	EarnedToday          string `json:"earnedToday"`
+	EarnedThisMoon       string `json:"earnedThisMoon"`
+	EarnedSinceLastPurge string `json:"earnedSinceLastPurge"`
+}
+
+// rewardsRows flattens the validator and covenant records of the storage.
+func rewardsRows(storage *types.NodeRewardStorage) []*rewardsRow {
+	newRow := func(kind, id, tier string, record *types.RewardRecord) *rewardsRow {
+		return &rewardsRow{
+			Type:                 kind,
+			ID:                   id,
+			Tier:                 tier,
+			RunningTotal:         bigOrZero(record.RunningTotal).String(),
+			EarnedToday:          bigOrZero(record.AmountEarnedToday).String(),
+			EarnedThisMoon:       bigOrZero(record.AmountEarnedThisMoon).String(),
+			EarnedSinceLastPurge: bigOrZero(record.AmountEarnedSinceLastPurge).String(),
+		}
+	}
+	rows := make([]*rewardsRow, 0, len(storage.ValidatorRecords)+len(storage.CovenantRecords))
+	for _, v := range storage.ValidatorRecords {
+		rows = append(rows, newRow("validator", v.Address.Hex(), "", v.RewardRecord))
+	}
+	for _, c := range storage.CovenantRecords {
+		rows = append(rows, newRow("covenant", strconv.FormatUint(c.TokenID, 10), c.Tier.String(), c.RewardRecord))
+	}
+	return rows
+}
+
+// key returns the unique identifier of the record.
+func (row *rewardsRow) key() string {
+	return row.Type + " " + row.ID
+}
+
+// diff returns the description of every field differing from the other row.
+func (row *rewardsRow) diff(other *rewardsRow) []string {
+	var diffs []string
+	for _, field := range []struct {
+		name       string
+		have, want string
+	}{
+		{"tier", row.Tier, other.Tier},
+		{"running total", row.RunningTotal, other.RunningTotal},
+		{"earned today", row.EarnedToday, other.EarnedToday},
+		{"earned this moon", row.EarnedThisMoon, other.EarnedThisMoon},
+		{"earned since last purge", row.EarnedSinceLastPurge, other.EarnedSinceLastPurge},
+	} {
+		if field.have != field.want {
+			diffs = append(diffs, fmt.Sprintf("%s stored %s, recomputed %s", field.name, field.have, field.want))
+		}
+	}
+	return diffs
+}
+
+func bigOrZero(b *big.Int) *big.Int {
+	if b == nil {
+		return new(big.Int)
+	}
+	return b
+}
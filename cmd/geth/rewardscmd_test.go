@@ -0,0 +1,74 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRewardsExport does a basic test of "geth rewards export" on the reward
+// records of the test-genesis.
+func TestRewardsExport(t *testing.T) {
+	datadir := initGeth(t)
+
+	geth := runGeth(t, "--datadir", datadir, "rewards", "export", "0")
+	geth.Expect(`
+type,id,tier,running_total,earned_today,earned_this_moon,earned_since_last_purge
+`)
+	geth.ExpectExit()
+
+	outfile := filepath.Join(t.TempDir(), "rewards.json")
+	geth = runGeth(t, "--datadir", datadir, "rewards", "export", "--format", "json", "--output", outfile)
+	geth.WaitExit()
+	if have, want := geth.ExitStatus(), 0; have != want {
+		t.Fatalf("exit error, have %d want %d", have, want)
+	}
+	blob, err := os.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export struct {
+		Number  uint64            `json:"number"`
+		Records []json.RawMessage `json:"records"`
+	}
+	if err := json.Unmarshal(blob, &export); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+	if export.Number != 0 || len(export.Records) != 0 {
+		t.Fatalf("unexpected export: %s", blob)
+	}
+}
+
+// TestRewardsDiff checks that "geth rewards diff" succeeds on untouched records
+// and rejects invalid ranges.
+func TestRewardsDiff(t *testing.T) {
+	datadir := initGeth(t)
+
+	geth := runGeth(t, "--datadir", datadir, "rewards", "diff", "0")
+	geth.WaitExit()
+	if have, want := geth.ExitStatus(), 0; have != want {
+		t.Errorf("exit error, have %d want %d", have, want)
+	}
+	geth = runGeth(t, "--datadir", datadir, "rewards", "diff", "10", "0")
+	geth.WaitExit()
+	if have, want := geth.ExitStatus(), 1; have != want {
+		t.Errorf("exit error for invalid range, have %d want %d", have, want)
+	}
+}
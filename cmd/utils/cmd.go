@@ -20,17 +20,22 @@ package utils
 import (
 	"bufio"
 	"compress/gzip"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -38,8 +43,10 @@ import (
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/debug"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/urfave/cli/v2"
 )
@@ -287,6 +294,285 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 	return nil
 }
 
+// ExportHistory exports blockchain history into the specified directory,
+// following the Era1 format. Every archive holds step blocks, the first one
+// starting at block first, which must be a multiple of step.
+func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) error {
+	log.Info("Exporting blockchain history", "dir", dir)
+	if step == 0 || step > uint64(era.MaxEra1Size) {
+		return fmt.Errorf("invalid archive size %d, must be in [1, %d]", step, era.MaxEra1Size)
+	}
+	if first%step != 0 {
+		return fmt.Errorf("first block %d is not a multiple of the archive size %d", first, step)
+	}
+	if head := bc.CurrentBlock().NumberU64(); head < last {
+		log.Warn("Last block beyond head, setting last = head", "head", head, "last", last)
+		last = head
+	}
+	network := "unknown"
+	if name, ok := params.NetworkNames[bc.Config().ChainID.String()]; ok {
+		network = name
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	var (
+		start     = time.Now()
+		reported  = time.Now()
+		checksums []string
+	)
+	for i := first; i <= last; i += step {
+		err := func() error {
+			filename := filepath.Join(dir, era.Filename(network, int(i/step), common.Hash{}))
+			f, err := os.Create(filename)
+			if err != nil {
+				return fmt.Errorf("could not create era file: %w", err)
+			}
+			defer f.Close()
+
+			w := era.NewBuilder(f)
+			for j := uint64(0); j < step && j <= last-i; j++ {
+				var (
+					n     = i + j
+					block = bc.GetBlockByNumber(n)
+				)
+				if block == nil {
+					return fmt.Errorf("export failed on #%d: not found", n)
+				}
+				receipts := bc.GetReceiptsByHash(block.Hash())
+				if receipts == nil {
+					return fmt.Errorf("export failed on #%d: receipts not found", n)
+				}
+				td := bc.GetTd(block.Hash(), block.NumberU64())
+				if td == nil {
+					return fmt.Errorf("export failed on #%d: total difficulty not found", n)
+				}
+				if err := w.Add(block, receipts, td); err != nil {
+					return err
+				}
+			}
+			root, err := w.Finalize()
+			if err != nil {
+				return fmt.Errorf("export failed to finalize %d: %w", i/step, err)
+			}
+			// Set correct filename with root.
+			if err := os.Rename(filename, filepath.Join(dir, era.Filename(network, int(i/step), root))); err != nil {
+				return err
+			}
+			// Compute checksum of entire Era1.
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			h := sha256.New()
+			if _, err := io.Copy(h, f); err != nil {
+				return fmt.Errorf("unable to calculate checksum: %w", err)
+			}
+			checksums = append(checksums, common.BytesToHash(h.Sum(nil)).Hex())
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting blocks", "exported", i, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "checksums.txt"), []byte(strings.Join(checksums, "\n")), os.ModePerm); err != nil {
+		return err
+	}
+	log.Info("Exported blockchain history", "dir", dir, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// ImportHistory imports Era1 files containing historical block information.
+// Every archive is checked against the checksums file in the directory and its
+// accumulator is recomputed from the contained blocks before any of them are
+// written to the database. If trusted accumulator roots are given, the archive
+// at each position must also match the root at the same position.
+//
+// Blocks already known to the chain must match its canonical hashes. The bodies
+// and receipts of the known blocks whose history was pruned are restored, the
+// remaining known blocks are skipped. Unknown blocks must extend the chain.
+func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, network string, accumulators []common.Hash) error {
+	entries, err := era.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no era1 files found for network %s in %s", network, dir)
+	}
+	checksums, err := readList(filepath.Join(dir, "checksums.txt"))
+	if err != nil {
+		return fmt.Errorf("unable to read checksums.txt: %w", err)
+	}
+	if len(checksums) != len(entries) {
+		return fmt.Errorf("expected equal number of checksums and entries, have: %d checksums, %d entries", len(checksums), len(entries))
+	}
+	if accumulators != nil && len(accumulators) < len(entries) {
+		return fmt.Errorf("missing trusted accumulators, have: %d accumulators, %d entries", len(accumulators), len(entries))
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		imported = 0
+		restored = 0
+		cutoff   = chain.HistoryPruningCutoff()
+
+		// Restored blocks within the transaction lookup limit get their
+		// lookup entries back, lowering the index tail to the first of them.
+		head    = chain.CurrentBlock().NumberU64()
+		limit   = chain.TxLookupLimit()
+		reindex *uint64
+	)
+	for i, filename := range entries {
+		err := func() error {
+			f, err := os.Open(filepath.Join(dir, filename))
+			if err != nil {
+				return fmt.Errorf("unable to open era: %w", err)
+			}
+			defer f.Close()
+
+			// Validate checksum.
+			h := sha256.New()
+			if _, err := io.Copy(h, f); err != nil {
+				return fmt.Errorf("unable to recalculate checksum: %w", err)
+			}
+			if have, want := common.BytesToHash(h.Sum(nil)).Hex(), checksums[i]; have != want {
+				return fmt.Errorf("checksum mismatch: have %s, want %s", have, want)
+			}
+			e, err := era.From(f)
+			if err != nil {
+				return fmt.Errorf("error opening era: %w", err)
+			}
+			// Check the contents against the accumulator root named in
+			// the file, or the trusted one, before writing anything.
+			root, err := e.Accumulator()
+			if err != nil {
+				return fmt.Errorf("error reading accumulator: %w", err)
+			}
+			if !strings.HasSuffix(filename, "-"+root.Hex()[2:10]+".era1") {
+				return fmt.Errorf("accumulator %x does not match file name", root)
+			}
+			if accumulators != nil && root != accumulators[i] {
+				return fmt.Errorf("accumulator mismatch for %s: have %x, want %x", filename, root, accumulators[i])
+			}
+			if err := era.Verify(e, root); err != nil {
+				return fmt.Errorf("error verifying %s: %w", filename, err)
+			}
+			it, err := era.NewIterator(e)
+			if err != nil {
+				return fmt.Errorf("error making era reader: %w", err)
+			}
+			var (
+				headers  []*types.Header
+				blocks   types.Blocks
+				receipts []types.Receipts
+				parentTd *big.Int // Total difficulty of the first unknown block's parent
+				batch    = db.NewBatch()
+			)
+			for it.Next() {
+				if err := it.Error(); err != nil {
+					return err
+				}
+				block, rs, err := it.BlockAndReceipts()
+				if err != nil {
+					return fmt.Errorf("error reading block %d: %w", it.Number(), err)
+				}
+				if hash := chain.GetCanonicalHash(block.NumberU64()); hash != (common.Hash{}) {
+					if hash != block.Hash() {
+						return fmt.Errorf("block #%d mismatch: have %x, want %x", block.NumberU64(), block.Hash(), hash)
+					}
+					if block.NumberU64() != 0 && block.NumberU64() < cutoff {
+						rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
+						rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), rs)
+						if limit == 0 || head < limit || block.NumberU64() > head-limit {
+							rawdb.WriteTxLookupEntriesByBlock(batch, block)
+							if reindex == nil {
+								number := block.NumberU64()
+								reindex = &number
+							}
+						}
+						restored++
+					}
+					continue
+				}
+				if len(blocks) == 0 {
+					td, err := it.TotalDifficulty()
+					if err != nil {
+						return fmt.Errorf("error reading total difficulty %d: %w", it.Number(), err)
+					}
+					parentTd = new(big.Int).Sub(td, block.Difficulty())
+				}
+				headers = append(headers, block.Header())
+				blocks = append(blocks, block)
+				receipts = append(receipts, rs)
+			}
+			if err := it.Error(); err != nil {
+				return err
+			}
+			if err := batch.Write(); err != nil {
+				return fmt.Errorf("error restoring history: %w", err)
+			}
+			if len(blocks) == 0 {
+				return nil
+			}
+			// Ensure the archive continues the already imported chain.
+			parent := blocks[0].NumberU64() - 1
+			if have := chain.GetTd(blocks[0].ParentHash(), parent); have == nil || have.Cmp(parentTd) != 0 {
+				return fmt.Errorf("archive does not continue the chain at #%d", parent)
+			}
+			if _, err := chain.InsertHeaderChain(headers, 100); err != nil {
+				return fmt.Errorf("error inserting headers: %w", err)
+			}
+			if _, err := chain.InsertReceiptChain(blocks, receipts, math.MaxUint64); err != nil {
+				return fmt.Errorf("error inserting body: %w", err)
+			}
+			imported += len(blocks)
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Importing Era files", "head", chain.CurrentFastBlock().NumberU64(), "imported", imported, "restored", restored, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if tail := rawdb.ReadTxIndexTail(db); reindex != nil && tail != nil && *reindex < *tail {
+		rawdb.WriteTxIndexTail(db, *reindex)
+	}
+	log.Info("Imported blockchain history", "dir", dir, "blocks", imported, "restored", restored, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// ReadAccumulators reads a newline-separated list of trusted accumulator roots
+// from a file, in the order of the archives they belong to.
+func ReadAccumulators(filename string) ([]common.Hash, error) {
+	lines, err := readList(filename)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]common.Hash, len(lines))
+	for i, line := range lines {
+		blob, err := hexutil.Decode(strings.TrimSpace(line))
+		if err != nil || len(blob) != common.HashLength {
+			return nil, fmt.Errorf("invalid accumulator on line %d: %q", i+1, line)
+		}
+		roots[i] = common.BytesToHash(blob)
+	}
+	return roots, nil
+}
+
+// readList reads a newline-separated list of values from a file.
+func readList(filename string) ([]string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSpace(string(b)), "\n"), nil
+}
+
 // ImportPreimages imports a batch of exported hash preimages into the database.
 // It's a part of the deprecated functionality, should be removed in the future.
 func ImportPreimages(db ethdb.Database, fn string) error {
@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -42,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -73,6 +75,7 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 	pcsclite "github.com/gballet/go-libpcsclite"
 	gopsutil "github.com/shirou/gopsutil/mem"
 	"github.com/urfave/cli/v2"
@@ -103,6 +106,12 @@ var (
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
 		Category: flags.EthCategory,
 	}
+	DBEngineFlag = &cli.StringFlag{
+		Name:     "db.engine",
+		Usage:    "Backing database implementation to use ('leveldb' or 'pebble')",
+		Value:    "leveldb",
+		Category: flags.EthCategory,
+	}
 	MinFreeDiskSpaceFlag = &flags.DirectoryFlag{
 		Name:     "datadir.minfreedisk",
 		Usage:    "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -238,6 +247,17 @@ var (
 		Value:    "full",
 		Category: flags.EthCategory,
 	}
+	StateSchemeFlag = &cli.StringFlag{
+		Name:     "state.scheme",
+		Usage:    "Scheme to use for storing ethereum state ('hash' or 'path'), picked from the database if not set",
+		Category: flags.EthCategory,
+	}
+	StateHistoryFlag = &cli.Uint64Flag{
+		Name:     "state.history",
+		Usage:    "Number of recent blocks to retain state history for, used for deep reorgs in the path scheme (0 = entire chain)",
+		Value:    ethconfig.Defaults.StateHistory,
+		Category: flags.EthCategory,
+	}
 	SnapshotFlag = &cli.BoolFlag{
 		Name:     "snapshot",
 		Usage:    `Enables snapshot-database mode (default = enable)`,
@@ -250,6 +270,28 @@ var (
 		Value:    ethconfig.Defaults.TxLookupLimit,
 		Category: flags.EthCategory,
 	}
+	HistoryRetainFlag = &cli.Uint64Flag{
+		Name:     "history.retain",
+		Usage:    "Number of recent blocks to retain the bodies and receipts for, older ones are pruned from the ancient store (0 = entire chain)",
+		Value:    ethconfig.Defaults.HistoryRetain,
+		Category: flags.EthCategory,
+	}
+	TraceIndexFlag = &cli.BoolFlag{
+		Name:     "traceindex",
+		Usage:    "Enables the background index of the internal calls of all transactions (requires the state of the first indexed block)",
+		Category: flags.EthCategory,
+	}
+	TraceIndexRetentionFlag = &cli.Uint64Flag{
+		Name:     "traceindex.retention",
+		Usage:    "Number of recent blocks to maintain the internal call index for (0 = entire chain)",
+		Value:    ethconfig.Defaults.TraceIndexRetention,
+		Category: flags.EthCategory,
+	}
+	SupplyTrackerFlag = &cli.BoolFlag{
+		Name:     "supplytracker",
+		Usage:    "Enables tracking the ether issued and burnt by the imported blocks, audited against the state on startup (incompatible with --vmtrace)",
+		Category: flags.EthCategory,
+	}
 	LightKDFFlag = &cli.BoolFlag{
 		Name:     "lightkdf",
 		Usage:    "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -446,6 +488,57 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolAllowFlag = &cli.StringFlag{
+		Name:     "txpool.allow",
+		Usage:    "Comma separated accounts permitted to submit transactions (default = everyone)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolDenyFlag = &cli.StringFlag{
+		Name:     "txpool.deny",
+		Usage:    "Comma separated accounts whose sent or received transactions are rejected",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolNoCreateFlag = &cli.BoolFlag{
+		Name:     "txpool.nocreate",
+		Usage:    "Restricts contract creation to the accounts in --txpool.creators",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolCreatorsFlag = &cli.StringFlag{
+		Name:     "txpool.creators",
+		Usage:    "Comma separated accounts permitted to deploy contracts if creation is restricted",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolSenderRateFlag = &cli.Uint64Flag{
+		Name:     "txpool.senderrate",
+		Usage:    "Maximum number of transactions admitted per account and minute (0 = unlimited)",
+		Value:    ethconfig.Defaults.TxPool.Filter.SenderRate,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolMaxCalldataFlag = &cli.Uint64Flag{
+		Name:     "txpool.maxcalldata",
+		Usage:    "Maximum calldata size of admitted transactions in bytes (0 = unlimited)",
+		Value:    ethconfig.Defaults.TxPool.Filter.MaxCalldata,
+		Category: flags.TxPoolCategory,
+	}
+	// Blob transaction pool settings
+	BlobPoolDataDirFlag = &cli.StringFlag{
+		Name:     "blobpool.datadir",
+		Usage:    "Data directory to store blob transactions in",
+		Value:    ethconfig.Defaults.BlobPool.Datadir,
+		Category: flags.BlobPoolCategory,
+	}
+	BlobPoolDataCapFlag = &cli.Uint64Flag{
+		Name:     "blobpool.datacap",
+		Usage:    "Disk space to allocate for pending blob transactions (soft limit)",
+		Value:    ethconfig.Defaults.BlobPool.Datacap,
+		Category: flags.BlobPoolCategory,
+	}
+	BlobPoolPriceBumpFlag = &cli.Uint64Flag{
+		Name:     "blobpool.pricebump",
+		Usage:    "Price bump percentage to replace an already existing blob transaction",
+		Value:    ethconfig.Defaults.BlobPool.PriceBump,
+		Category: flags.BlobPoolCategory,
+	}
 
 	// Performance tuning settings
 	CacheFlag = &cli.IntFlag{
@@ -574,6 +667,18 @@ var (
 		Value:    ethconfig.Defaults.Miner.NewPayloadTimeout,
 		Category: flags.MinerCategory,
 	}
+	MinerBundleLifetimeFlag = &cli.DurationFlag{
+		Name:     "miner.bundle-lifetime",
+		Usage:    "Maximum time a private bundle waits for inclusion before being dropped",
+		Value:    ethconfig.Defaults.Miner.BundleLifetime,
+		Category: flags.MinerCategory,
+	}
+	MinerOrderingFlag = &cli.StringFlag{
+		Name:     "miner.ordering",
+		Usage:    "Transaction ordering strategy for block building (price, fifo, roundrobin)",
+		Value:    ethconfig.Defaults.Miner.Ordering,
+		Category: flags.MinerCategory,
+	}
 
 	// Account settings
 	UnlockedAccountFlag = &cli.StringFlag{
@@ -606,6 +711,17 @@ var (
 		Usage:    "Record information useful for VM and contract debugging",
 		Category: flags.VMCategory,
 	}
+	VMTraceFlag = &cli.StringFlag{
+		Name:     "vmtrace",
+		Usage:    "Name of the tracer which should record the internal VM operations of the imported blocks (costly)",
+		Category: flags.VMCategory,
+	}
+	VMTraceJsonConfigFlag = &cli.StringFlag{
+		Name:     "vmtrace.jsonconfig",
+		Usage:    "Tracer configuration (JSON)",
+		Value:    "{}",
+		Category: flags.VMCategory,
+	}
 
 	// API options.
 	RPCGlobalGasCapFlag = &cli.Uint64Flag{
@@ -745,6 +861,30 @@ var (
 		Value:    strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
 		Category: flags.APICategory,
 	}
+	GraphQLMaxCostFlag = &cli.Uint64Flag{
+		Name:     "graphql.maxcost",
+		Usage:    "Maximum estimated and resolved cost of a GraphQL query or subscription event (0 = unlimited)",
+		Value:    node.DefaultConfig.GraphQLMaxCost,
+		Category: flags.APICategory,
+	}
+	GraphQLMaxDepthFlag = &cli.IntFlag{
+		Name:     "graphql.maxdepth",
+		Usage:    "Maximum nesting depth of the fields selected by a GraphQL query (0 = unlimited)",
+		Value:    node.DefaultConfig.GraphQLMaxDepth,
+		Category: flags.APICategory,
+	}
+	GraphQLTimeoutFlag = &cli.DurationFlag{
+		Name:     "graphql.timeout",
+		Usage:    "Maximum execution time of a GraphQL query or subscription event (0 = HTTP timeouts for queries, 1s for events)",
+		Value:    node.DefaultConfig.GraphQLTimeout,
+		Category: flags.APICategory,
+	}
+	GraphQLMaxResponseSizeFlag = &cli.IntFlag{
+		Name:     "graphql.maxresponsesize",
+		Usage:    "Maximum size of a GraphQL response in bytes (0 = unlimited)",
+		Value:    node.DefaultConfig.GraphQLMaxResponseSize,
+		Category: flags.APICategory,
+	}
 	WSEnabledFlag = &cli.BoolFlag{
 		Name:     "ws",
 		Usage:    "Enable the WS-RPC server",
@@ -1017,6 +1157,8 @@ var (
 		DataDirFlag,
 		AncientFlag,
 		RemoteDBFlag,
+		DBEngineFlag,
+		StateSchemeFlag,
 		HttpHeaderFlag,
 	}
 )
@@ -1232,6 +1374,18 @@ func setGraphQL(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(GraphQLVirtualHostsFlag.Name) {
 		cfg.GraphQLVirtualHosts = SplitAndTrim(ctx.String(GraphQLVirtualHostsFlag.Name))
 	}
+	if ctx.IsSet(GraphQLMaxCostFlag.Name) {
+		cfg.GraphQLMaxCost = ctx.Uint64(GraphQLMaxCostFlag.Name)
+	}
+	if ctx.IsSet(GraphQLMaxDepthFlag.Name) {
+		cfg.GraphQLMaxDepth = ctx.Int(GraphQLMaxDepthFlag.Name)
+	}
+	if ctx.IsSet(GraphQLTimeoutFlag.Name) {
+		cfg.GraphQLTimeout = ctx.Duration(GraphQLTimeoutFlag.Name)
+	}
+	if ctx.IsSet(GraphQLMaxResponseSizeFlag.Name) {
+		cfg.GraphQLMaxResponseSize = ctx.Int(GraphQLMaxResponseSizeFlag.Name)
+	}
 }
 
 // setWS creates the WebSocket RPC listener interface string from the set
@@ -1487,6 +1641,15 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.JWTSecret = ctx.String(JWTSecretFlag.Name)
 	}
 
+	if ctx.IsSet(DBEngineFlag.Name) {
+		dbEngine := ctx.String(DBEngineFlag.Name)
+		if dbEngine != "leveldb" && dbEngine != "pebble" {
+			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", dbEngine)
+		}
+		log.Info(fmt.Sprintf("Using %s as db engine", dbEngine))
+		cfg.DBEngine = dbEngine
+	}
+
 	if ctx.IsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.String(ExternalSignerFlag.Name)
 	}
@@ -1621,6 +1784,52 @@ func setTxPool(ctx *cli.Context, cfg *txpool.Config) {
 	if ctx.IsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.Duration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.IsSet(TxPoolAllowFlag.Name) {
+		cfg.Filter.Allow = splitAddresses(ctx, TxPoolAllowFlag.Name)
+	}
+	if ctx.IsSet(TxPoolDenyFlag.Name) {
+		cfg.Filter.Deny = splitAddresses(ctx, TxPoolDenyFlag.Name)
+	}
+	if ctx.IsSet(TxPoolNoCreateFlag.Name) {
+		cfg.Filter.NoCreate = ctx.Bool(TxPoolNoCreateFlag.Name)
+	}
+	if ctx.IsSet(TxPoolCreatorsFlag.Name) {
+		cfg.Filter.Creators = splitAddresses(ctx, TxPoolCreatorsFlag.Name)
+	}
+	if ctx.IsSet(TxPoolSenderRateFlag.Name) {
+		cfg.Filter.SenderRate = ctx.Uint64(TxPoolSenderRateFlag.Name)
+	}
+	if ctx.IsSet(TxPoolMaxCalldataFlag.Name) {
+		cfg.Filter.MaxCalldata = ctx.Uint64(TxPoolMaxCalldataFlag.Name)
+	}
+}
+
+// splitAddresses parses the comma separated account list of the given flag.
+func splitAddresses(ctx *cli.Context, name string) []common.Address {
+	var addrs []common.Address
+	for _, account := range strings.Split(ctx.String(name), ",") {
+		trimmed := strings.TrimSpace(account)
+		if trimmed == "" {
+			continue
+		}
+		if !common.IsHexAddress(trimmed) {
+			Fatalf("Invalid account in --%s: %s", name, trimmed)
+		}
+		addrs = append(addrs, common.HexToAddress(trimmed))
+	}
+	return addrs
+}
+
+func setBlobPool(ctx *cli.Context, cfg *blobpool.Config) {
+	if ctx.IsSet(BlobPoolDataDirFlag.Name) {
+		cfg.Datadir = ctx.String(BlobPoolDataDirFlag.Name)
+	}
+	if ctx.IsSet(BlobPoolDataCapFlag.Name) {
+		cfg.Datacap = ctx.Uint64(BlobPoolDataCapFlag.Name)
+	}
+	if ctx.IsSet(BlobPoolPriceBumpFlag.Name) {
+		cfg.PriceBump = ctx.Uint64(BlobPoolPriceBumpFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1673,6 +1882,15 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerNewPayloadTimeout.Name) {
 		cfg.NewPayloadTimeout = ctx.Duration(MinerNewPayloadTimeout.Name)
 	}
+	if ctx.IsSet(MinerBundleLifetimeFlag.Name) {
+		cfg.BundleLifetime = ctx.Duration(MinerBundleLifetimeFlag.Name)
+	}
+	if ctx.IsSet(MinerOrderingFlag.Name) {
+		cfg.Ordering = ctx.String(MinerOrderingFlag.Name)
+		if _, err := miner.NewOrderingStrategy(cfg.Ordering); err != nil {
+			Fatalf("Invalid --%s: %v", MinerOrderingFlag.Name, err)
+		}
+	}
 }
 
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1757,6 +1975,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(LightServeFlag.Name) && ctx.Uint64(TxLookupLimitFlag.Name) != 0 {
 		log.Warn("LES server cannot serve old transaction status and cannot connect below les/4 protocol version if transaction lookup index is limited")
 	}
+	if ctx.IsSet(LightServeFlag.Name) && ctx.Uint64(HistoryRetainFlag.Name) != 0 {
+		log.Warn("LES server cannot serve the bodies and receipts of pruned blocks if the block history is limited")
+	}
 	var ks *keystore.KeyStore
 	if keystores := stack.AccountManager().Backends(keystore.KeyStoreType); len(keystores) > 0 {
 		ks = keystores[0].(*keystore.KeyStore)
@@ -1764,6 +1985,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setEtherbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO, ctx.String(SyncModeFlag.Name) == "light")
 	setTxPool(ctx, &cfg.TxPool)
+	setBlobPool(ctx, &cfg.BlobPool)
 	setEthash(ctx, cfg)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
@@ -1809,6 +2031,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(GCModeFlag.Name) {
 		cfg.NoPruning = ctx.String(GCModeFlag.Name) == "archive"
 	}
+	if ctx.IsSet(StateSchemeFlag.Name) {
+		cfg.StateScheme = parseStateScheme(ctx)
+		if cfg.StateScheme == trie.PathScheme && cfg.NoPruning {
+			Fatalf("--%s=path is incompatible with --%s=archive", StateSchemeFlag.Name, GCModeFlag.Name)
+		}
+	}
+	if ctx.IsSet(StateHistoryFlag.Name) {
+		cfg.StateHistory = ctx.Uint64(StateHistoryFlag.Name)
+	}
 	if ctx.IsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.Bool(CacheNoPrefetchFlag.Name)
 	}
@@ -1821,6 +2052,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.Uint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.IsSet(HistoryRetainFlag.Name) {
+		cfg.HistoryRetain = ctx.Uint64(HistoryRetainFlag.Name)
+	}
+	if ctx.IsSet(TraceIndexFlag.Name) {
+		cfg.TraceIndex = ctx.Bool(TraceIndexFlag.Name)
+	}
+	if ctx.IsSet(TraceIndexRetentionFlag.Name) {
+		cfg.TraceIndexRetention = ctx.Uint64(TraceIndexRetentionFlag.Name)
+	}
+	if ctx.IsSet(SupplyTrackerFlag.Name) {
+		cfg.SupplyTracker = ctx.Bool(SupplyTrackerFlag.Name)
+	}
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheTrieFlag.Name) / 100
 	}
@@ -1855,6 +2098,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.Bool(VMEnableDebugFlag.Name)
 	}
+	if ctx.IsSet(VMTraceFlag.Name) {
+		if name := ctx.String(VMTraceFlag.Name); name != "" {
+			cfg.VMTrace = name
+			cfg.VMTraceJsonConfig = ctx.String(VMTraceJsonConfigFlag.Name)
+		}
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -2045,7 +2294,13 @@ func RegisterEthStatsService(stack *node.Node, backend ethapi.Backend, url strin
 
 // RegisterGraphQLService adds the GraphQL API to the node.
 func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cfg *node.Config) {
-	err := graphql.New(stack, backend, filterSystem, cfg.GraphQLCors, cfg.GraphQLVirtualHosts)
+	limits := graphql.Limits{
+		MaxCost:         cfg.GraphQLMaxCost,
+		MaxDepth:        cfg.GraphQLMaxDepth,
+		Timeout:         cfg.GraphQLTimeout,
+		MaxResponseSize: cfg.GraphQLMaxResponseSize,
+	}
+	err := graphql.New(stack, backend, filterSystem, cfg.GraphQLCors, cfg.GraphQLVirtualHosts, limits)
 	if err != nil {
 		Fatalf("Failed to register the GraphQL service: %v", err)
 	}
@@ -2261,6 +2516,10 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 	if gcmode := ctx.String(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
+	scheme, err := trie.ParseStateScheme(parseStateScheme(ctx), chainDb)
+	if err != nil {
+		Fatalf("%v", err)
+	}
 	cache := &core.CacheConfig{
 		TrieCleanLimit:      ethconfig.Defaults.TrieCleanCache,
 		TrieCleanNoPrefetch: ctx.Bool(CacheNoPrefetchFlag.Name),
@@ -2269,6 +2528,9 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 		TrieTimeLimit:       ethconfig.Defaults.TrieTimeout,
 		SnapshotLimit:       ethconfig.Defaults.SnapshotCache,
 		Preimages:           ctx.Bool(CachePreimagesFlag.Name),
+		StateScheme:         scheme,
+		StateHistory:        ctx.Uint64(StateHistoryFlag.Name),
+		HistoryRetain:       ctx.Uint64(HistoryRetainFlag.Name),
 	}
 	if cache.TrieDirtyDisabled && !cache.Preimages {
 		cache.Preimages = true
@@ -2289,6 +2551,13 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 		cache.TrieDirtyLimit = ctx.Int(CacheFlag.Name) * ctx.Int(CacheGCFlag.Name) / 100
 	}
 	vmcfg := vm.Config{EnablePreimageRecording: ctx.Bool(VMEnableDebugFlag.Name)}
+	if name := ctx.String(VMTraceFlag.Name); name != "" {
+		t, err := tracers.NewLive(name, json.RawMessage(ctx.String(VMTraceJsonConfigFlag.Name)))
+		if err != nil {
+			Fatalf("Failed to create tracer %q: %v", name, err)
+		}
+		vmcfg.Tracer = t
+	}
 
 	// Disable transaction indexing/unindexing by default.
 	chain, err := core.NewBlockChain(chainDb, cache, gspec, nil, engine, vmcfg, nil, nil)
@@ -2298,6 +2567,36 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 	return chain, chainDb
 }
 
+// parseStateScheme converts the value of the state scheme flag into the node
+// scheme identifier, or an empty string if the flag is not set.
+func parseStateScheme(ctx *cli.Context) string {
+	switch scheme := ctx.String(StateSchemeFlag.Name); scheme {
+	case "":
+		return ""
+	case "hash":
+		return trie.HashScheme
+	case "path":
+		return trie.PathScheme
+	default:
+		Fatalf("--%s must be either 'hash' or 'path', got %q", StateSchemeFlag.Name, scheme)
+	}
+	return ""
+}
+
+// MakeTrieDatabase constructs a trie database on top of the given key-value
+// store, using the state scheme of the persisted state or the one requested
+// by the user if the database is empty.
+func MakeTrieDatabase(ctx *cli.Context, disk ethdb.Database, preimages bool) *trie.Database {
+	scheme, err := trie.ParseStateScheme(parseStateScheme(ctx), disk)
+	if err != nil {
+		Fatalf("%v", err)
+	}
+	return trie.NewDatabaseWithConfig(disk, &trie.Config{
+		Preimages: preimages,
+		Scheme:    scheme,
+	})
+}
+
 // MakeConsolePreloads retrieves the absolute paths for the console JavaScript
 // scripts to preload before starting.
 func MakeConsolePreloads(ctx *cli.Context) []string {
@@ -0,0 +1,275 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	historyCount uint64 = 128
+	historyStep  uint64 = 16
+)
+
+func TestHistoryImportAndExport(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+
+	// Generate chain.
+	db, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), int(historyCount), func(i int, g *core.BlockGen) {
+		if i == 0 {
+			return
+		}
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     uint64(i - 1),
+			GasTipCap: common.Big0,
+			GasFeeCap: g.PrevBlock(0).BaseFee(),
+			Gas:       50000,
+			To:        &common.Address{0xaa},
+			Value:     big.NewInt(int64(i)),
+			Data:      nil,
+		})
+		if err != nil {
+			t.Fatalf("error creating tx: %v", err)
+		}
+		g.AddTx(tx)
+	})
+
+	// Initialize BlockChain.
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("error inserting chain: %v", err)
+	}
+
+	// Make temp directory for era files.
+	dir := t.TempDir()
+
+	// Export history to temp directory.
+	if err := ExportHistory(chain, dir, 0, historyCount, historyStep); err != nil {
+		t.Fatalf("error exporting history: %v", err)
+	}
+
+	// Read checksums.
+	b, err := os.ReadFile(filepath.Join(dir, "checksums.txt"))
+	if err != nil {
+		t.Fatalf("failed to read checksums: %v", err)
+	}
+	checksums := strings.Split(string(b), "\n")
+
+	// Verify each Era.
+	entries, _ := era.ReadDir(dir, "mainnet")
+	if want := int(historyCount/historyStep) + 1; len(entries) != want || len(checksums) != want {
+		t.Fatalf("wrong number of archives: have %d (%d checksums), want %d", len(entries), len(checksums), want)
+	}
+	for i, filename := range entries {
+		func() {
+			e, err := era.Open(filepath.Join(dir, filename))
+			if err != nil {
+				t.Fatalf("error opening era file: %v", err)
+			}
+			defer e.Close()
+
+			root, err := e.Accumulator()
+			if err != nil {
+				t.Fatalf("error reading accumulator: %v", err)
+			}
+			if err := era.Verify(e, root); err != nil {
+				t.Fatalf("era %d failed verification: %v", i, err)
+			}
+			it, err := era.NewIterator(e)
+			if err != nil {
+				t.Fatalf("error making era reader: %v", err)
+			}
+			for j := 0; it.Next(); j++ {
+				n := i*int(historyStep) + j
+				if it.Error() != nil {
+					t.Fatalf("error reading block entry %d: %v", n, it.Error())
+				}
+				block, receipts, err := it.BlockAndReceipts()
+				if err != nil {
+					t.Fatalf("error reading block entry %d: %v", n, err)
+				}
+				want := chain.GetBlockByNumber(uint64(n))
+				if want, got := uint64(n), block.NumberU64(); want != got {
+					t.Fatalf("blocks out of order: want %d, got %d", want, got)
+				}
+				if want.Hash() != block.Hash() {
+					t.Fatalf("block hash mismatch %d: want %s, got %s", n, want.Hash().Hex(), block.Hash().Hex())
+				}
+				if got := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); got != want.TxHash() {
+					t.Fatalf("tx hash %d mismatch: want %s, got %s", n, want.TxHash(), got)
+				}
+				if got := types.DeriveSha(receipts, trie.NewStackTrie(nil)); got != want.ReceiptHash() {
+					t.Fatalf("receipt root %d mismatch: want %s, got %s", n, want.ReceiptHash(), got)
+				}
+			}
+		}()
+	}
+
+	// Now import Era.
+	freezer := t.TempDir()
+	db2, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), freezer, "", false)
+	if err != nil {
+		t.Fatalf("error creating database: %v", err)
+	}
+	defer db2.Close()
+
+	imported, err := core.NewBlockChain(db2, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	if err := ImportHistory(imported, db2, dir, "mainnet", nil); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if have, want := imported.CurrentFastBlock().Hash(), chain.CurrentBlock().Hash(); have != want {
+		t.Fatalf("imported chain does not match expected, have (%d, %s) want (%d, %s)", imported.CurrentFastBlock().NumberU64(), have, chain.CurrentBlock().NumberU64(), want)
+	}
+	for n := uint64(1); n <= historyCount; n++ {
+		want := chain.GetBlockByNumber(n)
+		have := imported.GetBlockByNumber(n)
+		if have == nil || have.Hash() != want.Hash() {
+			t.Fatalf("imported block %d mismatch", n)
+		}
+		if !reflect.DeepEqual(imported.GetTd(want.Hash(), n), chain.GetTd(want.Hash(), n)) {
+			t.Fatalf("imported total difficulty %d mismatch", n)
+		}
+		if have, want := len(imported.GetReceiptsByHash(want.Hash())), len(chain.GetReceiptsByHash(want.Hash())); have != want {
+			t.Fatalf("imported receipts %d mismatch: have %d, want %d", n, have, want)
+		}
+	}
+
+	// Prune the imported history and restore it from the archives, checking
+	// them against the trusted accumulators.
+	tail, err := rawdb.PruneHistory(db2, historyCount/2, nil)
+	if err != nil || tail != historyCount/2 {
+		t.Fatalf("failed to prune history: tail %d, err %v", tail, err)
+	}
+	if body := rawdb.ReadBody(db2, chain.GetCanonicalHash(1), 1); body != nil {
+		t.Fatalf("body of pruned block still present")
+	}
+	var accumulators []common.Hash
+	for _, filename := range entries {
+		e, err := era.Open(filepath.Join(dir, filename))
+		if err != nil {
+			t.Fatalf("error opening era file: %v", err)
+		}
+		root, err := e.Accumulator()
+		e.Close()
+		if err != nil {
+			t.Fatalf("error reading accumulator: %v", err)
+		}
+		accumulators = append(accumulators, root)
+	}
+	tampered := append([]common.Hash{}, accumulators...)
+	tampered[1] = common.Hash{0x01}
+	if err := ImportHistory(imported, db2, dir, "mainnet", tampered); err == nil || !strings.Contains(err.Error(), "accumulator mismatch") {
+		t.Fatalf("expected accumulator mismatch, got %v", err)
+	}
+	if err := ImportHistory(imported, db2, dir, "mainnet", accumulators); err != nil {
+		t.Fatalf("failed to restore history: %v", err)
+	}
+	for n := uint64(1); n < tail; n++ {
+		hash := chain.GetCanonicalHash(n)
+		if body := rawdb.ReadBody(db2, hash, n); body == nil || types.DeriveSha(types.Transactions(body.Transactions), trie.NewStackTrie(nil)) != chain.GetHeaderByNumber(n).TxHash {
+			t.Fatalf("restored body %d mismatch", n)
+		}
+		if have, want := len(rawdb.ReadRawReceipts(db2, hash, n)), len(chain.GetReceiptsByHash(hash)); have != want {
+			t.Fatalf("restored receipts %d mismatch: have %d, want %d", n, have, want)
+		}
+		for _, tx := range chain.GetBlockByNumber(n).Transactions() {
+			if number := rawdb.ReadTxLookupEntry(db2, tx.Hash()); number == nil || *number != n {
+				t.Fatalf("restored lookup entry of tx %x in block %d missing", tx.Hash(), n)
+			}
+		}
+	}
+	if indexed := rawdb.ReadTxIndexTail(db2); indexed == nil || *indexed != 1 {
+		t.Fatalf("transaction index tail not restored: %v", indexed)
+	}
+}
+
+func TestHistoryImportTampered(t *testing.T) {
+	genesis := &core.Genesis{Config: params.TestChainConfig}
+	db, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), int(historyStep), nil)
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("error inserting chain: %v", err)
+	}
+	dir := t.TempDir()
+	if err := ExportHistory(chain, dir, 0, historyStep-1, historyStep); err != nil {
+		t.Fatalf("error exporting history: %v", err)
+	}
+	entries, err := era.ReadDir(dir, "mainnet")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("wrong archives: %v (%v)", entries, err)
+	}
+	// Flip a byte in the middle of the archive.
+	filename := filepath.Join(dir, entries[0])
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	db2, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("error creating database: %v", err)
+	}
+	defer db2.Close()
+
+	imported, err := core.NewBlockChain(db2, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	if err := ImportHistory(imported, db2, dir, "mainnet", nil); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if head := imported.CurrentFastBlock().NumberU64(); head != 0 {
+		t.Fatalf("tampered archive was imported up to block %d", head)
+	}
+}
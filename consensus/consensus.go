@@ -90,7 +90,7 @@ type Engine interface {
 	// Note: The block header and state database might be updated to reflect any
 	// consensus rules that happen at finalization (e.g. block rewards).
 	Finalize(chain ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction,
-		uncles []*types.Header)
+		uncles []*types.Header, withdrawals []*types.Withdrawal)
 
 	// FinalizeAndAssemble runs any post-transaction state modifications (e.g. block
 	// rewards) and assembles the final block.
@@ -98,7 +98,7 @@ type Engine interface {
 	// Note: The block header and state database might be updated to reflect any
 	// consensus rules that happen at finalization (e.g. block rewards).
 	FinalizeAndAssemble(chain ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction,
-		uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error)
+		uncles []*types.Header, receipts []*types.Receipt, withdrawals []*types.Withdrawal) (*types.Block, error)
 
 	// Seal generates a new sealing request for the given input block and pushes
 	// the result into the given channel.
@@ -121,6 +121,14 @@ type Engine interface {
 	Close() error
 }
 
+// Rewarder is an optional interface for consensus engines which can attribute
+// the rewards paid out by a block to validators and covenant nfts.
+type Rewarder interface {
+	// BlockRewards returns the rewards credited by the given block. The fees are
+	// the transaction fees collected by the block producer.
+	BlockRewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header, fees *big.Int) []*types.BlockReward
+}
+
 // PoW is a consensus engine based on proof-of-work.
 type PoW interface {
 	Engine
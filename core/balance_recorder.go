@@ -0,0 +1,192 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BalanceRecorder is a BlockchainLogger recording every balance change made by
+// a block, attributed to its reason, along with the ether burnt by the fees of
+// its transactions and the covenant rewards the consensus engine attributes to
+// it. It needs to be set both as the logger of the state and as the tracer of
+// the vm config the block is processed with.
+type BalanceRecorder struct {
+	config   *params.ChainConfig
+	rewarder consensus.Rewarder // Engine attributing the covenant rewards, nil if none
+	changes  []*tracing.BalanceChange
+
+	block   *types.Block   // Block being processed currently
+	fees    *big.Int       // Tips paid by the transactions processed so far
+	tip     *big.Int       // Tip per gas paid by the transaction being executed
+	txs     int            // Number of transactions processed so far
+	txIndex int            // Index of the transaction being executed, -1 if none
+	from    common.Address // Sender of the transaction being executed
+	burn    *big.Int       // Fee burnt per gas by the transaction being executed
+	dataFee *big.Int       // Fee burnt per data gas by the transaction being executed
+	blobs   int            // Number of blobs carried by the transaction being executed
+}
+
+// NewBalanceRecorder creates a recorder of the balance changes made by a block.
+// The covenant rewards are only recorded if the engine is a consensus.Rewarder.
+func NewBalanceRecorder(config *params.ChainConfig, engine consensus.Engine) *BalanceRecorder {
+	rewarder, _ := engine.(consensus.Rewarder)
+	return &BalanceRecorder{config: config, rewarder: rewarder, txIndex: -1}
+}
+
+// Changes returns the balance changes recorded since the block started.
+func (r *BalanceRecorder) Changes() []*tracing.BalanceChange {
+	return r.changes
+}
+
+func (r *BalanceRecorder) record(addr common.Address, delta *big.Int, reason tracing.BalanceChangeReason) {
+	r.changes = append(r.changes, &tracing.BalanceChange{
+		TxIndex: r.txIndex,
+		Address: addr,
+		Delta:   delta,
+		Reason:  reason,
+	})
+}
+
+// OnBlockStart implements BlockchainLogger, discarding the changes recorded for
+// any previous block.
+func (r *BalanceRecorder) OnBlockStart(block *types.Block, td *big.Int, finalized, safe *types.Block) {
+	r.changes, r.txs, r.txIndex = nil, 0, -1
+	r.block, r.fees = block, new(big.Int)
+}
+
+// OnBlockEnd implements BlockchainLogger, recording the covenant rewards of the
+// block if it was processed successfully. They are attributed by the engine
+// from the tips paid, the same way the reward records credit them.
+func (r *BalanceRecorder) OnBlockEnd(err error) {
+	block := r.block
+	r.block = nil
+	if err != nil || block == nil || r.rewarder == nil {
+		return
+	}
+	for _, reward := range r.rewarder.BlockRewards(r.config, block.Header(), block.Uncles(), r.fees) {
+		if reward.Type != types.CovenantReward || reward.Reward == nil || reward.Reward.Sign() == 0 {
+			continue
+		}
+		r.changes = append(r.changes, &tracing.BalanceChange{
+			TxIndex: -1,
+			TokenID: reward.TokenID,
+			Delta:   new(big.Int).Set(reward.Reward),
+			Reason:  tracing.BalanceIncreaseRewardCovenant,
+		})
+	}
+}
+
+// OnGenesisBlock implements BlockchainLogger, recording the allocated balances
+// in address order.
+func (r *BalanceRecorder) OnGenesisBlock(genesis *types.Block, alloc GenesisAlloc) {
+	r.changes, r.txs, r.txIndex = nil, 0, -1
+	r.block, r.fees = nil, nil
+
+	addrs := make([]common.Address, 0, len(alloc))
+	for addr, account := range alloc {
+		if account.Balance != nil && account.Balance.Sign() > 0 {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+	for _, addr := range addrs {
+		r.record(addr, new(big.Int).Set(alloc[addr].Balance), tracing.BalanceIncreaseGenesisBalance)
+	}
+}
+
+// OnTxStart implements BlockchainLogger, tracking the fees the transaction will
+// burn and pay.
+func (r *BalanceRecorder) OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address) {
+	r.txIndex, r.from = r.txs, from
+	r.tip = tx.EffectiveGasTipValue(env.Context.BaseFee)
+
+	r.burn, r.dataFee, r.blobs = env.Context.BaseFee, nil, len(tx.BlobHashes())
+	if r.blobs > 0 && env.Context.ExcessDataGas != nil {
+		r.dataFee = misc.CalcBlobFee(*env.Context.ExcessDataGas)
+	}
+}
+
+// OnTxEnd implements BlockchainLogger, recording the fees burnt by the executed
+// transaction.
+func (r *BalanceRecorder) OnTxEnd(receipt *types.Receipt, err error) {
+	if err == nil {
+		if r.fees != nil {
+			r.fees.Add(r.fees, new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), r.tip))
+		}
+		burnt := new(big.Int)
+		if r.burn != nil {
+			burnt.Mul(new(big.Int).SetUint64(receipt.GasUsed), r.burn)
+		}
+		if r.dataFee != nil {
+			dataGas := new(big.Int).SetUint64(uint64(r.blobs) * params.BlobTxDataGasPerBlob)
+			burnt.Add(burnt, dataGas.Mul(dataGas, r.dataFee))
+		}
+		if burnt.Sign() > 0 {
+			r.record(common.Address{}, burnt, tracing.BalanceChangeBaseFeeBurn)
+		}
+	}
+	r.txs++
+	r.txIndex = -1
+}
+
+// OnBalanceChange implements tracing.StateLogger, recording the change.
+func (r *BalanceRecorder) OnBalanceChange(addr common.Address, prev, cur *big.Int, reason tracing.BalanceChangeReason) {
+	if cur.Cmp(prev) != 0 {
+		r.record(addr, new(big.Int).Sub(cur, prev), reason)
+	}
+}
+
+func (r *BalanceRecorder) OnNonceChange(addr common.Address, prev, new uint64) {}
+
+func (r *BalanceRecorder) OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+}
+
+func (r *BalanceRecorder) OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+}
+
+func (r *BalanceRecorder) OnLog(log *types.Log) {}
+
+func (r *BalanceRecorder) CaptureTxStart(gasLimit uint64) {}
+
+func (r *BalanceRecorder) CaptureTxEnd(restGas uint64) {}
+
+func (r *BalanceRecorder) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+
+func (r *BalanceRecorder) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+
+func (r *BalanceRecorder) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+func (r *BalanceRecorder) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+func (r *BalanceRecorder) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (r *BalanceRecorder) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
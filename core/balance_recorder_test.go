@@ -0,0 +1,193 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the balance recorder attributes every balance change of a block,
+// including the ones undone by reverted calls, and the fees it burns.
+func TestBalanceRecorder(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		coinbase = common.Address{0xc0}
+		caller   = common.HexToAddress("0xaa")
+		reverts  = common.HexToAddress("0xbb")
+		gspec    = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// Call 0xbb with 1 wei and no data, then stop
+				caller: {Balance: common.Big0, Code: []byte{
+					byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+					byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0xbb, byte(vm.GAS),
+					byte(vm.CALL), byte(vm.STOP),
+				}},
+				reverts: {Balance: common.Big0, Code: []byte{byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.REVERT)}},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 1, func(i int, b *BlockGen) {
+		b.SetCoinbase(coinbase)
+		tx, _ := types.SignTx(types.NewTransaction(0, caller, big.NewInt(10), 100000, new(big.Int).Add(b.BaseFee(), common.Big1), nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	statedb, _ := chain.StateAt(chain.Genesis().Root())
+	recorder := NewBalanceRecorder(gspec.Config, chain.Engine())
+	statedb.SetLogger(recorder)
+	recorder.OnBlockStart(blocks[0], nil, nil, nil)
+	receipts, _, _, err := chain.Processor().Process(blocks[0], statedb, vm.Config{Tracer: recorder})
+	if err != nil {
+		t.Fatalf("failed to process block: %v", err)
+	}
+	var (
+		reasons = make(map[tracing.BalanceChangeReason]int)
+		deltas  = make(map[common.Address]*big.Int)
+		burnt   *big.Int
+	)
+	for _, change := range recorder.Changes() {
+		reasons[change.Reason]++
+
+		wantIndex := 0
+		if change.Reason == tracing.BalanceIncreaseRewardMineBlock {
+			wantIndex = -1
+		}
+		if change.TxIndex != wantIndex {
+			t.Errorf("%v change: transaction index mismatch: have %d, want %d", change.Reason, change.TxIndex, wantIndex)
+		}
+		if change.Reason == tracing.BalanceChangeBaseFeeBurn {
+			burnt = change.Delta
+			continue
+		}
+		if deltas[change.Address] == nil {
+			deltas[change.Address] = new(big.Int)
+		}
+		deltas[change.Address].Add(deltas[change.Address], change.Delta)
+	}
+	want := map[tracing.BalanceChangeReason]int{
+		tracing.BalanceDecreaseGasBuy:               1,
+		tracing.BalanceChangeTransfer:               4,
+		tracing.BalanceChangeRevert:                 2,
+		tracing.BalanceIncreaseGasReturn:            1,
+		tracing.BalanceIncreaseRewardTransactionFee: 1,
+		tracing.BalanceChangeBaseFeeBurn:            1,
+		tracing.BalanceIncreaseRewardMineBlock:      1,
+	}
+	for reason, count := range want {
+		if reasons[reason] != count {
+			t.Errorf("%v balance changes mismatch: have %d, want %d", reason, reasons[reason], count)
+		}
+	}
+	if want := new(big.Int).Mul(new(big.Int).SetUint64(receipts[0].GasUsed), blocks[0].BaseFee()); burnt == nil || burnt.Cmp(want) != 0 {
+		t.Errorf("burnt fee mismatch: have %v, want %v", burnt, want)
+	}
+	// The recorded changes must add up to the changes of the state
+	parent, _ := chain.StateAt(chain.Genesis().Root())
+	for account, delta := range deltas {
+		if have := new(big.Int).Sub(statedb.GetBalance(account), parent.GetBalance(account)); have.Cmp(delta) != 0 {
+			t.Errorf("account %x: balance delta mismatch: have %v, want %v", account, delta, have)
+		}
+	}
+	if deltas[reverts] == nil || deltas[reverts].Sign() != 0 {
+		t.Errorf("reverted transfer not undone: %v", deltas[reverts])
+	}
+}
+
+// covenantEngine is a consensus engine crediting the tips of every block to a
+// covenant nft on top of the rewards of the wrapped engine.
+type covenantEngine struct {
+	consensus.Engine
+	tokenID uint64
+}
+
+func (e *covenantEngine) BlockRewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header, fees *big.Int) []*types.BlockReward {
+	rewards := e.Engine.(consensus.Rewarder).BlockRewards(config, header, uncles, new(big.Int))
+	return append(rewards, &types.BlockReward{Type: types.CovenantReward, TokenID: e.tokenID, Reward: fees, Fee: new(big.Int)})
+}
+
+// Tests that the balance recorder reports the covenant rewards attributed by the
+// consensus engine, which are not paid in the state.
+func TestBalanceRecorderCovenantReward(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+		tip    = big.NewInt(params.GWei)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 1, func(i int, b *BlockGen) {
+		for nonce := uint64(0); nonce < 2; nonce++ {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0x2}, big.NewInt(10), params.TxGas, new(big.Int).Add(b.BaseFee(), tip), nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	engine := &covenantEngine{Engine: ethash.NewFaker(), tokenID: 7}
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	statedb, _ := chain.StateAt(chain.Genesis().Root())
+	recorder := NewBalanceRecorder(gspec.Config, engine)
+	statedb.SetLogger(recorder)
+	recorder.OnBlockStart(blocks[0], nil, nil, nil)
+	if _, _, _, err := chain.Processor().Process(blocks[0], statedb, vm.Config{Tracer: recorder}); err != nil {
+		t.Fatalf("failed to process block: %v", err)
+	}
+	recorder.OnBlockEnd(nil)
+
+	var covenant []*tracing.BalanceChange
+	for _, change := range recorder.Changes() {
+		if change.Reason == tracing.BalanceIncreaseRewardCovenant {
+			covenant = append(covenant, change)
+		}
+	}
+	if len(covenant) != 1 {
+		t.Fatalf("covenant reward count mismatch: have %d, want 1", len(covenant))
+	}
+	fees := new(big.Int).SetUint64(receipts[0][0].GasUsed + receipts[0][1].GasUsed)
+	fees.Mul(fees, tip)
+	if change := covenant[0]; change.TxIndex != -1 || change.TokenID != 7 || change.Address != (common.Address{}) || change.Delta.Cmp(fees) != 0 {
+		t.Errorf("covenant reward mismatch: index %d, token %d, address %x, delta %v, want %v", change.TxIndex, change.TokenID, change.Address, change.Delta, fees)
+	}
+}
@@ -35,8 +35,10 @@ import (
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -136,6 +138,9 @@ type CacheConfig struct {
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
+	StateScheme         string        // Scheme used to store the state trie nodes, hash scheme if empty
+	StateHistory        uint64        // Number of recent state histories retained in path scheme, zero to retain all
+	HistoryRetain       uint64        // Number of recent blocks whose bodies and receipts are retained, zero to retain all
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
@@ -151,6 +156,32 @@ var defaultCacheConfig = &CacheConfig{
 	SnapshotWait:   true,
 }
 
+// BlockchainLogger is used to collect traces of the blocks imported into the
+// chain. Setting it as the tracer of the vm.Config passed to NewBlockChain makes
+// the chain invoke it for every block it processes, on top of the usual call
+// frame and opcode level events.
+// Note that reference types are actual chain data structures; make copies if
+// you need to retain them beyond the current call.
+type BlockchainLogger interface {
+	vm.EVMLogger
+	tracing.StateLogger
+
+	// OnBlockStart is called before executing a block, with the total difficulty
+	// of its parent and the current finalized and safe blocks (nil if unknown).
+	OnBlockStart(block *types.Block, td *big.Int, finalized, safe *types.Block)
+	// OnBlockEnd is called after the block was executed and validated, with
+	// the error rejecting it if any.
+	OnBlockEnd(err error)
+	// OnGenesisBlock is called on startup if the chain is at its genesis block.
+	OnGenesisBlock(genesis *types.Block, alloc GenesisAlloc)
+
+	// OnTxStart is called before executing a transaction of the block.
+	OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address)
+	// OnTxEnd is called after a transaction was executed, with its receipt or
+	// the error rendering it invalid.
+	OnTxEnd(receipt *types.Receipt, err error)
+}
+
 // BlockChain represents the canonical chain given a database with a genesis
 // block. The Blockchain manages chain imports, reverts, chain reorganisations.
 //
@@ -171,6 +202,8 @@ type BlockChain struct {
 
 	db            ethdb.Database // Low level persistent database to store final content in
 	snaps         *snapshot.Tree // Snapshot tree for fast trie leaf access
+	rewards       *rewards.Store // Reward records of validators and covenant nfts
+	rewardsReq    chan struct{}  // Notification channel to sync the reward records
 	triegc        *prque.Prque   // Priority queue mapping block numbers to tries to gc
 	gcproc        time.Duration  // Accumulates canonical block processing for trie dumping
 	lastWrite     uint64         // Last block when the state was flushed
@@ -224,6 +257,7 @@ type BlockChain struct {
 	processor  Processor // Block transaction processor interface
 	forker     *ForkChoice
 	vmConfig   vm.Config
+	logger     BlockchainLogger
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -233,12 +267,18 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if cacheConfig == nil {
 		cacheConfig = defaultCacheConfig
 	}
-
+	// The path scheme overwrites the stale states in place, so it can't
+	// retain the historical ones for an archive node.
+	if cacheConfig.StateScheme == trie.PathScheme && cacheConfig.TrieDirtyDisabled {
+		return nil, errors.New("archive mode is not supported by the path-based state scheme")
+	}
 	// Open trie database with provided config
 	triedb := trie.NewDatabaseWithConfig(db, &trie.Config{
-		Cache:     cacheConfig.TrieCleanLimit,
-		Journal:   cacheConfig.TrieCleanJournal,
-		Preimages: cacheConfig.Preimages,
+		Cache:        cacheConfig.TrieCleanLimit,
+		Journal:      cacheConfig.TrieCleanJournal,
+		Preimages:    cacheConfig.Preimages,
+		Scheme:       cacheConfig.StateScheme,
+		StateHistory: cacheConfig.StateHistory,
 	})
 	// Setup the genesis block, commit the provided genesis specification
 	// to database if the genesis block is not present yet, or load the
@@ -263,6 +303,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		flushInterval: int64(cacheConfig.TrieTimeLimit),
 		triegc:        prque.New(nil),
 		quit:          make(chan struct{}),
+		rewardsReq:    make(chan struct{}, 1),
 		chainmu:       syncx.NewClosableMutex(),
 		bodyCache:     lru.NewCache[common.Hash, *types.Body](bodyCacheLimit),
 		bodyRLPCache:  lru.NewCache[common.Hash, rlp.RawValue](bodyCacheLimit),
@@ -273,6 +314,12 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		engine:        engine,
 		vmConfig:      vmConfig,
 	}
+	// Chain-level tracers only observe the blocks being imported, not the other
+	// users of the chain's vm config
+	if logger, ok := vmConfig.Tracer.(BlockchainLogger); ok {
+		bc.logger = logger
+		bc.vmConfig.Debug, bc.vmConfig.Tracer = false, nil
+	}
 	bc.forker = NewForkChoice(bc, shouldPreserve)
 	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
@@ -386,6 +433,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		}
 	}
 
+	// Load the reward records, they are brought up to date in the background
+	bc.rewards = rewards.New(bc.db, chainConfig, engine, bc.genesisBlock.Header())
+
 	// Load any existing snapshot, regenerating it if loading failed
 	if bc.cacheConfig.SnapshotLimit > 0 {
 		// If the chain was rewound past the snapshot persistent layer (causing
@@ -427,15 +477,32 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
-		bc.SetHead(compat.RewindTo)
+		if compat.RewindToTime > 0 {
+			bc.SetHeadWithTimestamp(compat.RewindToTime)
+		} else {
+			bc.SetHead(compat.RewindToBlock)
+		}
 		rawdb.WriteChainConfig(db, genesisHash, chainConfig)
 	}
-	// Start tx indexer/unindexer if required.
-	if txLookupLimit != nil {
-		bc.txLookupLimit = *txLookupLimit
+	// Hand the genesis allocation to the tracer if no block was imported yet
+	if bc.logger != nil && bc.CurrentBlock().NumberU64() == 0 {
+		alloc, err := getGenesisState(bc.db, bc.genesisBlock.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get genesis state: %w", err)
+		}
+		bc.logger.OnGenesisBlock(bc.genesisBlock, alloc)
+	}
+	// Start the reward accounting, following the chain head.
+	bc.wg.Add(1)
+	go bc.updateRewards()
 
+	// Start tx indexer/unindexer and history pruner if required.
+	if txLookupLimit != nil || cacheConfig.HistoryRetain != 0 {
+		if txLookupLimit != nil {
+			bc.txLookupLimit = *txLookupLimit
+		}
 		bc.wg.Add(1)
-		go bc.maintainTxIndex()
+		go bc.maintainTxIndex(txLookupLimit != nil)
 	}
 	return bc, nil
 }
@@ -536,6 +603,14 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	return err
 }
 
+// SetHeadWithTimestamp rewinds the local chain to a new head that has at max
+// the given timestamp. Depending on whether the node was fast synced or full
+// synced and in which state, the method will try to delete minimal data from
+// disk whilst retaining chain consistency.
+func (bc *BlockChain) SetHeadWithTimestamp(timestamp uint64) error {
+	return bc.SetHead(bc.hc.numberAtTime(timestamp))
+}
+
 // SetFinalized sets the finalized block.
 func (bc *BlockChain) SetFinalized(block *types.Block) {
 	bc.currentFinalizedBlock.Store(block)
@@ -600,7 +675,7 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 					if root != (common.Hash{}) && !beyondRoot && newHeadBlock.Root() == root {
 						beyondRoot, rootNumber = true, newHeadBlock.NumberU64()
 					}
-					if !bc.HasState(newHeadBlock.Root()) {
+					if !bc.HasState(newHeadBlock.Root()) && !bc.triedb.Recoverable(newHeadBlock.Root()) {
 						log.Trace("Block state missing, rewinding further", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash())
 						if pivot == nil || newHeadBlock.NumberU64() > *pivot {
 							parent := bc.GetBlock(newHeadBlock.ParentHash(), newHeadBlock.NumberU64()-1)
@@ -622,13 +697,23 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 							// rewinding destination can be the earliest block stored in the chain
 							// if the historical chain pruning is enabled. In that case the logic
 							// needs to be improved here.
-							if !bc.HasState(bc.genesisBlock.Root()) {
+							if !bc.HasState(bc.genesisBlock.Root()) && !bc.triedb.Recoverable(bc.genesisBlock.Root()) {
+								if bc.triedb.Scheme().Name() == trie.PathScheme {
+									log.Crit("Genesis state is unrecoverable", "root", bc.genesisBlock.Root())
+								}
 								if err := CommitGenesisState(bc.db, bc.triedb, bc.genesisBlock.Hash()); err != nil {
 									log.Crit("Failed to commit genesis state", "err", err)
 								}
 								log.Debug("Recommitted genesis state to disk")
 							}
 						}
+						// The path scheme only keeps the recent states, roll
+						// the persisted one back if the target is older.
+						if !bc.HasState(newHeadBlock.Root()) {
+							if err := bc.triedb.Recover(newHeadBlock.Root()); err != nil {
+								log.Crit("Failed to roll back state", "err", err)
+							}
+						}
 						log.Debug("Rewound to block with state", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash())
 						break
 					}
@@ -722,8 +807,12 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 		log.Error("SetHead invalidated finalized block")
 		bc.SetFinalized(nil)
 	}
-
-	return rootNumber, bc.loadLastState()
+	if err := bc.loadLastState(); err != nil {
+		return rootNumber, err
+	}
+	// Roll the reward records back to the new head
+	bc.triggerRewards()
+	return rootNumber, nil
 }
 
 // SnapSyncCommitHead sets the current head block to the one defined by the hash
@@ -735,6 +824,12 @@ func (bc *BlockChain) SnapSyncCommitHead(hash common.Hash) error {
 		return fmt.Errorf("non existent block [%x..]", hash[:4])
 	}
 	root := block.Root()
+
+	// The synced state was written directly into the database, reload the
+	// persisted state of the path scheme on top of it.
+	if err := bc.triedb.Enable(root); err != nil {
+		return err
+	}
 	if !bc.HasState(root) {
 		return fmt.Errorf("non existent state [%x..]", root[:4])
 	}
@@ -751,6 +846,7 @@ func (bc *BlockChain) SnapSyncCommitHead(hash common.Hash) error {
 	if bc.snaps != nil {
 		bc.snaps.Rebuild(root)
 	}
+	bc.triggerRewards()
 	log.Info("Committed new head block", "number", block.Number(), "hash", hash)
 	return nil
 }
@@ -891,6 +987,10 @@ func (bc *BlockChain) stopWithoutSaving() {
 func (bc *BlockChain) Stop() {
 	bc.stopWithoutSaving()
 
+	// Ensure that the reward records of the head block are journalled to disk.
+	if bc.rewards != nil {
+		bc.rewards.Journal()
+	}
 	// Ensure that the entirety of the state snapshot is journalled to disk.
 	var snapBase common.Hash
 	if bc.snaps != nil {
@@ -905,7 +1005,17 @@ func (bc *BlockChain) Stop() {
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
 	//  - HEAD-1:   So we don't do large reorgs if our HEAD becomes an uncle
 	//  - HEAD-127: So we have a hard limit on the number of blocks reexecuted
-	if !bc.cacheConfig.TrieDirtyDisabled {
+	//
+	// The path scheme only persists HEAD, the older states can be recovered
+	// from the state histories.
+	if bc.triedb.Scheme().Name() == trie.PathScheme {
+		recent := bc.CurrentBlock()
+
+		log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
+		if err := bc.triedb.Commit(recent.Root(), true, nil); err != nil {
+			log.Error("Failed to commit recent state trie", "err", err)
+		}
+	} else if !bc.cacheConfig.TrieDirtyDisabled {
 		triedb := bc.triedb
 
 		for _, offset := range []uint64{0, 1, TriesInMemory - 1} {
@@ -1313,6 +1423,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if bc.cacheConfig.TrieDirtyDisabled {
 		return bc.triedb.Commit(root, false, nil)
 	}
+	// The path scheme maintains the in-memory states on its own
+	if bc.triedb.Scheme().Name() == trie.PathScheme {
+		return nil
+	}
 	// Full but not archive node, do proper garbage collection
 	bc.triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 	bc.triegc.Push(root, -int64(block.NumberU64()))
@@ -1372,9 +1486,36 @@ func (bc *BlockChain) WriteBlockAndSetHead(block *types.Block, receipts []*types
 	}
 	defer bc.chainmu.Unlock()
 
+	// The block was executed outside of the chain, e.g. by the local miner,
+	// replay it for the logger to observe it like the imported ones.
+	if bc.logger != nil {
+		bc.traceBlock(block)
+	}
 	return bc.writeBlockAndSetHead(block, receipts, logs, state, emitHeadEvent)
 }
 
+// traceBlock re-executes a block on top of its parent state with the blockchain
+// logger attached. The resulting state is discarded.
+func (bc *BlockChain) traceBlock(block *types.Block) {
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		log.Warn("Failed to trace block, parent unknown", "number", block.Number(), "hash", block.Hash())
+		return
+	}
+	statedb, err := state.New(parent.Root, bc.stateCache, bc.snaps)
+	if err != nil {
+		log.Warn("Failed to trace block, parent state unavailable", "number", block.Number(), "hash", block.Hash(), "err", err)
+		return
+	}
+	vmConfig := bc.vmConfig
+	vmConfig.Debug, vmConfig.Tracer = true, bc.logger
+	statedb.SetLogger(bc.logger)
+	bc.logger.OnBlockStart(block, bc.GetTd(block.ParentHash(), block.NumberU64()-1), bc.CurrentFinalizedBlock(), bc.CurrentSafeBlock())
+
+	_, _, _, err = bc.processor.Process(block, statedb, vmConfig)
+	bc.logger.OnBlockEnd(err)
+}
+
 // writeBlockAndSetHead is the internal implementation of WriteBlockAndSetHead.
 // This function expects the chain mutex to be held.
 func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
@@ -1491,7 +1632,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 	}
 
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
-	SenderCacher.RecoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
+	SenderCacher.RecoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number(), chain[0].Time()), chain)
 
 	var (
 		stats     = insertStats{startTime: mclock.Now()}
@@ -1702,10 +1843,19 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		}
 
 		// Process block using the parent state as reference point
+		vmConfig := bc.vmConfig
+		if bc.logger != nil {
+			vmConfig.Debug, vmConfig.Tracer = true, bc.logger
+			statedb.SetLogger(bc.logger)
+			bc.logger.OnBlockStart(block, bc.GetTd(block.ParentHash(), block.NumberU64()-1), bc.CurrentFinalizedBlock(), bc.CurrentSafeBlock())
+		}
 		substart := time.Now()
-		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, vmConfig)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
+			if bc.logger != nil {
+				bc.logger.OnBlockEnd(err)
+			}
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return it.index, err
 		}
@@ -1727,9 +1877,15 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		substart = time.Now()
 		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
 			bc.reportBlock(block, receipts, err)
+			if bc.logger != nil {
+				bc.logger.OnBlockEnd(err)
+			}
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return it.index, err
 		}
+		if bc.logger != nil {
+			bc.logger.OnBlockEnd(nil)
+		}
 		proctime := time.Since(start)
 
 		// Update the metrics touched during block validation
@@ -1999,7 +2155,7 @@ func (bc *BlockChain) recoverAncestors(block *types.Block) (common.Hash, error)
 // the processing of a block. These logs are later announced as deleted or reborn.
 func (bc *BlockChain) collectLogs(b *types.Block, removed bool) []*types.Log {
 	receipts := rawdb.ReadRawReceipts(bc.db, b.Hash(), b.NumberU64())
-	receipts.DeriveFields(bc.chainConfig, b.Hash(), b.NumberU64(), b.Transactions())
+	receipts.DeriveFields(bc.chainConfig, b.Hash(), b.NumberU64(), b.Time(), b.Transactions())
 
 	var logs []*types.Log
 	for _, receipt := range receipts {
@@ -2250,6 +2406,73 @@ func (bc *BlockChain) updateFutureBlocks() {
 	}
 }
 
+// updateRewards keeps the reward records in sync with the chain head. Syncing
+// might replay many blocks, e.g. after a snap sync, so it runs in the background
+// without holding the chain lock, and head updates arriving meanwhile are
+// coalesced into a single follow-up sync.
+func (bc *BlockChain) updateRewards() {
+	defer bc.wg.Done()
+
+	var (
+		done   chan struct{}                  // Non-nil if a background sync is running
+		dirty  = true                         // Flag whether the head moved since the last sync
+		headCh = make(chan ChainHeadEvent, 1) // Buffered to avoid locking up the event feed
+	)
+	sub := bc.SubscribeChainHeadEvent(headCh)
+	if sub == nil {
+		return
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		if dirty && done == nil {
+			dirty, done = false, make(chan struct{})
+			go func() {
+				defer close(done)
+				bc.syncRewards()
+			}()
+		}
+		select {
+		case <-headCh:
+			dirty = true
+		case <-bc.rewardsReq:
+			dirty = true
+		case <-done:
+			done = nil
+		case <-bc.quit:
+			bc.rewards.Close()
+			if done != nil {
+				<-done
+			}
+			return
+		}
+	}
+}
+
+// syncRewards moves the reward records to the current head. Failures on missing
+// chain data are only reported once, the store doesn't retry them until the
+// failed block is reorged out or the records are rebuilt.
+func (bc *BlockChain) syncRewards() {
+	head := bc.CurrentBlock()
+	if err := bc.rewards.Sync(head.Header()); err != nil && !errors.Is(err, rewards.ErrStalled) {
+		select {
+		case <-bc.quit:
+			return // Aborted by the shutdown
+		default:
+		}
+		log.Error("Failed to update reward records", "number", head.Number(), "hash", head.Hash(), "err", err)
+	}
+}
+
+// triggerRewards schedules a sync of the reward records for head changes which
+// are not announced via ChainHeadEvent.
+func (bc *BlockChain) triggerRewards() {
+	select {
+	case bc.rewardsReq <- struct{}{}:
+	default:
+	}
+}
+
 // skipBlock returns 'true', if the block being imported can be skipped over, meaning
 // that the block does not need to be processed but can be considered already fully 'done'.
 func (bc *BlockChain) skipBlock(err error, it *insertIterator) bool {
@@ -2295,6 +2518,10 @@ func (bc *BlockChain) skipBlock(err error, it *insertIterator) bool {
 func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{}) {
 	defer func() { close(done) }()
 
+	// The transactions of the pruned blocks can't be indexed, never index
+	// below the history tail.
+	pruned, _ := bc.db.Tail()
+
 	// The tail flag is not existent, it means the node is just initialized
 	// and all blocks(may from ancient store) are not indexed yet.
 	if tail == nil {
@@ -2302,6 +2529,9 @@ func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{})
 		if bc.txLookupLimit != 0 && head >= bc.txLookupLimit {
 			from = head - bc.txLookupLimit + 1
 		}
+		if from < pruned {
+			from = pruned
+		}
 		rawdb.IndexTransactions(bc.db, from, head+1, bc.quit)
 		return
 	}
@@ -2315,22 +2545,41 @@ func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{})
 			if end > head+1 {
 				end = head + 1
 			}
-			rawdb.IndexTransactions(bc.db, 0, end, bc.quit)
+			rawdb.IndexTransactions(bc.db, pruned, end, bc.quit)
 		}
 		return
 	}
 	// Update the transaction index to the new chain state
-	if head-bc.txLookupLimit+1 < *tail {
+	if from := head - bc.txLookupLimit + 1; from < *tail {
 		// Reindex a part of missing indices and rewind index tail to HEAD-limit
-		rawdb.IndexTransactions(bc.db, head-bc.txLookupLimit+1, *tail, bc.quit)
+		if from < pruned {
+			from = pruned
+		}
+		rawdb.IndexTransactions(bc.db, from, *tail, bc.quit)
 	} else {
 		// Unindex a part of stale indices and forward index tail to HEAD-limit
 		rawdb.UnindexTransactions(bc.db, *tail, head-bc.txLookupLimit+1, bc.quit)
 	}
 }
 
+// pruneHistory discards the bodies and receipts of the frozen blocks which are
+// older than the configured history retention.
+func (bc *BlockChain) pruneHistory(head uint64) {
+	retain := bc.cacheConfig.HistoryRetain
+	if retain == 0 || head < retain {
+		return
+	}
+	if _, err := rawdb.PruneHistory(bc.db, head-retain+1, bc.quit); err != nil {
+		select {
+		case <-bc.quit:
+		default:
+			log.Error("Failed to prune block history", "err", err)
+		}
+	}
+}
+
 // maintainTxIndex is responsible for the construction and deletion of the
-// transaction index.
+// transaction index, as well as for pruning the expired block history.
 //
 // User can use flag `txlookuplimit` to specify a "recentness" block, below
 // which ancient tx indices get deleted. If `txlookuplimit` is 0, it means
@@ -2339,7 +2588,11 @@ func (bc *BlockChain) indexBlocks(tail *uint64, head uint64, done chan struct{})
 // The user can adjust the txlookuplimit value for each launch after sync,
 // Geth will automatically construct the missing indices or delete the extra
 // indices.
-func (bc *BlockChain) maintainTxIndex() {
+//
+// The block history is pruned before updating the indices, since the lookup
+// entries of the pruned transactions are deleted along with the bodies. If
+// index is false, only the history is maintained.
+func (bc *BlockChain) maintainTxIndex(index bool) {
 	defer bc.wg.Done()
 
 	// Listening to chain events and manipulate the transaction indexes.
@@ -2358,7 +2611,14 @@ func (bc *BlockChain) maintainTxIndex() {
 		case head := <-headCh:
 			if done == nil {
 				done = make(chan struct{})
-				go bc.indexBlocks(rawdb.ReadTxIndexTail(bc.db), head.Block.NumberU64(), done)
+				go func(head uint64) {
+					bc.pruneHistory(head)
+					if !index {
+						close(done)
+						return
+					}
+					bc.indexBlocks(rawdb.ReadTxIndexTail(bc.db), head, done)
+				}(head.Block.NumberU64())
 			}
 		case <-done:
 			done = nil
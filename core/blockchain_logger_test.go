@@ -0,0 +1,181 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// balanceChange is a balance modification reported to the test logger.
+type balanceChange struct {
+	addr   common.Address
+	delta  *big.Int
+	reason tracing.BalanceChangeReason
+}
+
+// testChainLogger is a BlockchainLogger recording the chain-level events.
+type testChainLogger struct {
+	genesis  *types.Block
+	alloc    GenesisAlloc
+	blocks   []uint64
+	ended    []error
+	txs      []common.Hash
+	receipts []*types.Receipt
+	balances []balanceChange
+	nonces   map[common.Address]uint64
+	frames   int
+}
+
+func newTestChainLogger() *testChainLogger {
+	return &testChainLogger{nonces: make(map[common.Address]uint64)}
+}
+
+func (l *testChainLogger) CaptureTxStart(gasLimit uint64) {}
+func (l *testChainLogger) CaptureTxEnd(restGas uint64)    {}
+func (l *testChainLogger) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	l.frames++
+}
+func (l *testChainLogger) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+func (l *testChainLogger) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	l.frames++
+}
+func (l *testChainLogger) CaptureExit(output []byte, gasUsed uint64, err error) {}
+func (l *testChainLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+func (l *testChainLogger) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (l *testChainLogger) OnBlockStart(block *types.Block, td *big.Int, finalized, safe *types.Block) {
+	l.blocks = append(l.blocks, block.NumberU64())
+}
+func (l *testChainLogger) OnBlockEnd(err error) { l.ended = append(l.ended, err) }
+func (l *testChainLogger) OnGenesisBlock(genesis *types.Block, alloc GenesisAlloc) {
+	l.genesis, l.alloc = genesis, alloc
+}
+func (l *testChainLogger) OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address) {
+	l.txs = append(l.txs, tx.Hash())
+}
+func (l *testChainLogger) OnTxEnd(receipt *types.Receipt, err error) {
+	l.receipts = append(l.receipts, receipt)
+}
+func (l *testChainLogger) OnBalanceChange(addr common.Address, prev, cur *big.Int, reason tracing.BalanceChangeReason) {
+	l.balances = append(l.balances, balanceChange{addr, new(big.Int).Sub(cur, prev), reason})
+}
+func (l *testChainLogger) OnNonceChange(addr common.Address, prev, new uint64) { l.nonces[addr] = new }
+func (l *testChainLogger) OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+}
+func (l *testChainLogger) OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+}
+func (l *testChainLogger) OnLog(log *types.Log) {}
+
+// Tests that a chain-level tracer is notified of the genesis allocation and of
+// the execution of every imported block.
+func TestBlockchainLogger(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		coinbase = common.Address{0xc0}
+		to       = common.Address{0x10}
+		gspec    = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(coinbase)
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), to, big.NewInt(1000), params.TxGas, new(big.Int).Add(b.BaseFee(), common.Big1), nil), signer, key)
+		b.AddTx(tx)
+	})
+	logger := newTestChainLogger()
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{Tracer: logger}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.GetVMConfig().Tracer != nil {
+		t.Fatal("chain-level tracer leaked into the vm config of the chain")
+	}
+	if logger.genesis == nil || logger.genesis.Hash() != chain.Genesis().Hash() || !reflect.DeepEqual(logger.alloc, gspec.Alloc) {
+		t.Fatalf("genesis block not reported: have %v, alloc %v", logger.genesis, logger.alloc)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if want := []uint64{1, 2}; !reflect.DeepEqual(logger.blocks, want) {
+		t.Fatalf("started blocks mismatch: have %v, want %v", logger.blocks, want)
+	}
+	if want := []error{nil, nil}; !reflect.DeepEqual(logger.ended, want) {
+		t.Fatalf("ended blocks mismatch: have %v, want %v", logger.ended, want)
+	}
+	if len(logger.txs) != 2 || len(logger.receipts) != 2 || logger.frames != 2 {
+		t.Fatalf("transaction events mismatch: %d started, %d ended, %d frames", len(logger.txs), len(logger.receipts), logger.frames)
+	}
+	for i, block := range blocks {
+		if logger.txs[i] != block.Transactions()[0].Hash() || logger.receipts[i].TxHash != logger.txs[i] {
+			t.Errorf("transaction %d mismatch: started %x, ended %x", i, logger.txs[i], logger.receipts[i].TxHash)
+		}
+	}
+	if have := logger.nonces[addr]; have != 2 {
+		t.Errorf("nonce mismatch: have %d, want 2", have)
+	}
+	// Every balance change is attributed, and the changes add up to the state
+	var (
+		reasons  = make(map[tracing.BalanceChangeReason]int)
+		balances = make(map[common.Address]*big.Int)
+	)
+	for _, change := range logger.balances {
+		reasons[change.reason]++
+		if balances[change.addr] == nil {
+			balances[change.addr] = new(big.Int)
+		}
+		balances[change.addr].Add(balances[change.addr], change.delta)
+	}
+	want := map[tracing.BalanceChangeReason]int{
+		tracing.BalanceDecreaseGasBuy:               2,
+		tracing.BalanceChangeTransfer:               4,
+		tracing.BalanceIncreaseRewardTransactionFee: 2,
+		tracing.BalanceIncreaseRewardMineBlock:      2,
+	}
+	for reason, count := range want {
+		if reasons[reason] != count {
+			t.Errorf("%v balance changes mismatch: have %d, want %d", reason, reasons[reason], count)
+		}
+	}
+	statedb, _ := chain.State()
+	for account, delta := range balances {
+		have := statedb.GetBalance(account)
+		if alloc, ok := gspec.Alloc[account]; ok {
+			have = new(big.Int).Sub(have, alloc.Balance)
+		}
+		if have.Cmp(delta) != 0 {
+			t.Errorf("account %x: balance delta mismatch: have %v, want %v", account, delta, have)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -208,6 +209,16 @@ func (bc *BlockChain) GetBlocksFromHash(hash common.Hash, n int) (blocks []*type
 	return
 }
 
+// HistoryPruningCutoff returns the number of the first block whose body and
+// receipts are retained, the history of all older blocks has been pruned.
+func (bc *BlockChain) HistoryPruningCutoff() uint64 {
+	tail, err := bc.db.Tail()
+	if err != nil {
+		return 0 // No ancient store, nothing pruned
+	}
+	return tail
+}
+
 // GetReceiptsByHash retrieves the receipts for all transactions in a given block.
 func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	if receipts, ok := bc.receiptsCache.Get(hash); ok {
@@ -217,7 +228,11 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	if number == nil {
 		return nil
 	}
-	receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.chainConfig)
+	header := bc.GetHeader(hash, *number)
+	if header == nil {
+		return nil
+	}
+	receipts := rawdb.ReadReceipts(bc.db, hash, *number, header.Time, bc.chainConfig)
 	if receipts == nil {
 		return nil
 	}
@@ -334,6 +349,11 @@ func (bc *BlockChain) Snapshots() *snapshot.Tree {
 	return bc.snaps
 }
 
+// RewardStore returns the reward records of validators and covenant nfts.
+func (bc *BlockChain) RewardStore() *rewards.Store {
+	return bc.rewards
+}
+
 // Validator returns the current validator.
 func (bc *BlockChain) Validator() Validator {
 	return bc.validator
@@ -411,3 +431,13 @@ func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 func (bc *BlockChain) SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscription {
 	return bc.scope.Track(bc.blockProcFeed.Subscribe(ch))
 }
+
+// SubscribeRewardsEvent registers a subscription of rewards.RewardsEvent.
+func (bc *BlockChain) SubscribeRewardsEvent(ch chan<- rewards.RewardsEvent) event.Subscription {
+	return bc.scope.Track(bc.rewards.SubscribeRewardsEvent(ch))
+}
+
+// SubscribePurgeEvent registers a subscription of rewards.PurgeEvent.
+func (bc *BlockChain) SubscribePurgeEvent(ch chan<- rewards.PurgeEvent) event.Subscription {
+	return bc.scope.Track(bc.rewards.SubscribePurgeEvent(ch))
+}
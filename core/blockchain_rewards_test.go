@@ -0,0 +1,401 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	rewardTestKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	rewardTestAddress = crypto.PubkeyToAddress(rewardTestKey.PublicKey)
+	rewardTestGenesis = &Genesis{
+		Config:  params.TestChainConfig,
+		Alloc:   GenesisAlloc{rewardTestAddress: {Balance: big.NewInt(params.Ether)}},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}
+)
+
+// makeRewardChain generates blocks spread over multiple reward days, paying the
+// block rewards and transaction fees to the given coinbase.
+func makeRewardChain(db ethdb.Database, parent *types.Block, n int, coinbase common.Address) []*types.Block {
+	signer := types.LatestSigner(rewardTestGenesis.Config)
+	blocks, _ := GenerateChain(rewardTestGenesis.Config, parent, ethash.NewFaker(), db, n, func(i int, b *BlockGen) {
+		b.SetCoinbase(coinbase)
+		b.OffsetTime(rewards.DayLength / 4)
+
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(rewardTestAddress), common.Address{0xff}, big.NewInt(1), params.TxGas, big.NewInt(2*params.InitialBaseFee), nil), signer, rewardTestKey)
+		b.AddTx(tx)
+	})
+	return blocks
+}
+
+// newRewardChain creates a blockchain on top of the given database, importing
+// the given blocks.
+func newRewardChain(t *testing.T, db ethdb.Database, blocks []*types.Block) *BlockChain {
+	chain, err := NewBlockChain(db, nil, rewardTestGenesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+	waitRewards(t, chain)
+	return chain
+}
+
+// waitRewards waits until the reward records caught up with the chain head.
+func waitRewards(t *testing.T, chain *BlockChain) {
+	t.Helper()
+
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		if chain.RewardStore().Head().Hash() == chain.CurrentBlock().Hash() {
+			return
+		}
+	}
+	t.Fatalf("reward records stuck at #%d, head #%d", chain.RewardStore().Head().Number, chain.CurrentBlock().Number())
+}
+
+// encodeRewards returns the RLP encoding of the chain's reward records.
+func encodeRewards(t *testing.T, chain *BlockChain) []byte {
+	blob, err := rlp.EncodeToBytes(chain.RewardStore().Storage())
+	if err != nil {
+		t.Fatalf("failed to encode reward records: %v", err)
+	}
+	return blob
+}
+
+// checkRewards verifies that the chain's reward records match the ones of a
+// fresh chain importing only the given blocks.
+func checkRewards(t *testing.T, chain *BlockChain, blocks []*types.Block) {
+	t.Helper()
+
+	if head := chain.RewardStore().Head(); head.Hash() != chain.CurrentBlock().Hash() {
+		t.Fatalf("reward head mismatch: have #%d, want #%d", head.Number, chain.CurrentBlock().Number())
+	}
+	ref := newRewardChain(t, rawdb.NewMemoryDatabase(), blocks)
+	defer ref.Stop()
+
+	if have, want := encodeRewards(t, chain), encodeRewards(t, ref); !bytes.Equal(have, want) {
+		t.Fatalf("reward records mismatch:\nhave %v\nwant %v", chain.RewardStore().Storage(), ref.RewardStore().Storage())
+	}
+}
+
+// Tests that reorgs roll back the rewards of the dropped blocks.
+func TestRewardsReorg(t *testing.T) {
+	genDb, _, _ := GenerateChainWithGenesis(rewardTestGenesis, ethash.NewFaker(), 0, nil)
+	genesis := rewardTestGenesis.ToBlock()
+	var (
+		chainA = makeRewardChain(genDb, genesis, 20, common.Address{0xaa})
+		chainB = makeRewardChain(genDb, chainA[9], 15, common.Address{0xbb})
+	)
+	chain := newRewardChain(t, rawdb.NewMemoryDatabase(), chainA)
+	defer chain.Stop()
+	checkRewards(t, chain, chainA)
+
+	if _, err := chain.InsertChain(chainB); err != nil {
+		t.Fatalf("failed to import side chain: %v", err)
+	}
+	if chain.CurrentBlock().Hash() != chainB[len(chainB)-1].Hash() {
+		t.Fatalf("side chain not canonical")
+	}
+	waitRewards(t, chain)
+	checkRewards(t, chain, append(append([]*types.Block{}, chainA[:10]...), chainB...))
+
+	storage := chain.RewardStore().Storage()
+	if storage.Day == 0 {
+		t.Fatalf("no reward days closed")
+	}
+	if record := storage.ValidatorRecord(common.Address{0xbb}); record == nil || len(record.Daily) == 0 {
+		t.Fatalf("missing daily history of side chain validator: %v", record)
+	}
+}
+
+// Tests that rewinding the chain rolls back the rewards, using checkpoints.
+func TestRewardsSetHead(t *testing.T) {
+	genDb, _, _ := GenerateChainWithGenesis(rewardTestGenesis, ethash.NewFaker(), 0, nil)
+	genesis := rewardTestGenesis.ToBlock()
+	blocks := makeRewardChain(genDb, genesis, rewards.CheckpointInterval+100, common.Address{0xaa})
+
+	db := rawdb.NewMemoryDatabase()
+	chain := newRewardChain(t, db, blocks)
+	defer chain.Stop()
+
+	hash := blocks[rewards.CheckpointInterval-1].Hash()
+	if rawdb.ReadRewardCheckpoint(db, hash, rewards.CheckpointInterval) == nil {
+		t.Fatalf("missing reward checkpoint")
+	}
+	// Rewind to a block with state, replaying from the checkpoint
+	head := rewards.CheckpointInterval + 10
+	if err := chain.SetHead(uint64(head)); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if have := chain.CurrentBlock().NumberU64(); have != uint64(head) {
+		t.Fatalf("chain head mismatch: have %d, want %d", have, head)
+	}
+	waitRewards(t, chain)
+	checkRewards(t, chain, blocks[:head])
+
+	// Rewind below the checkpoint, the chain might rewind further to find state
+	if err := chain.SetHead(10); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	waitRewards(t, chain)
+	checkRewards(t, chain, blocks[:chain.CurrentBlock().NumberU64()])
+}
+
+// Tests that the reward records survive restarts, with or without journal.
+func TestRewardsRestart(t *testing.T) {
+	genDb, _, _ := GenerateChainWithGenesis(rewardTestGenesis, ethash.NewFaker(), 0, nil)
+	genesis := rewardTestGenesis.ToBlock()
+	blocks := makeRewardChain(genDb, genesis, 30, common.Address{0xaa})
+
+	db := rawdb.NewMemoryDatabase()
+	chain := newRewardChain(t, db, blocks)
+	want := encodeRewards(t, chain)
+	chain.Stop()
+
+	if rawdb.ReadRewardHead(db) != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("reward records not journalled")
+	}
+	// Restart with the journal
+	chain = newRewardChain(t, db, nil)
+	if have := encodeRewards(t, chain); !bytes.Equal(have, want) {
+		t.Fatalf("reward records mismatch after restart")
+	}
+	chain.stopWithoutSaving()
+
+	// Restart with a corrupted journal
+	db.Put([]byte("RewardStorage"), []byte{0xde, 0xad})
+	chain = newRewardChain(t, db, nil)
+	defer chain.Stop()
+	if have := encodeRewards(t, chain); !bytes.Equal(have, want) {
+		t.Fatalf("reward records mismatch after restart with corrupted journal")
+	}
+}
+
+// Tests that corrupted block rewards can be recomputed from the chain.
+func TestRewardsRebuild(t *testing.T) {
+	genDb, _, _ := GenerateChainWithGenesis(rewardTestGenesis, ethash.NewFaker(), 0, nil)
+	genesis := rewardTestGenesis.ToBlock()
+	blocks := makeRewardChain(genDb, genesis, 30, common.Address{0xaa})
+
+	db := rawdb.NewMemoryDatabase()
+	chain := newRewardChain(t, db, blocks)
+	defer chain.Stop()
+	want := encodeRewards(t, chain)
+
+	// Corrupt the rewards of a block and force the records to be replayed
+	bogus := []*types.BlockReward{{Type: types.CovenantReward, TokenID: 1, Reward: big.NewInt(1), Fee: big.NewInt(1)}}
+	rawdb.WriteBlockRewards(db, blocks[15].Hash(), blocks[15].NumberU64(), bogus)
+	if err := chain.SetHead(10); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks[10:]); err != nil {
+		t.Fatalf("failed to reimport blocks: %v", err)
+	}
+	waitRewards(t, chain)
+	if have := encodeRewards(t, chain); bytes.Equal(have, want) {
+		t.Fatalf("corrupted block rewards not used")
+	}
+	// Rebuild the records and ensure the corruption is gone
+	if err := chain.RewardStore().Rebuild(12, chain.CurrentBlock().Header()); err != nil {
+		t.Fatalf("failed to rebuild reward records: %v", err)
+	}
+	if have := encodeRewards(t, chain); !bytes.Equal(have, want) {
+		t.Fatalf("reward records mismatch after rebuild")
+	}
+}
+
+// Tests that a block whose receipts are missing stalls the accounting without
+// being retried on every sync, until the records are rebuilt.
+func TestRewardsStalled(t *testing.T) {
+	genDb, _, _ := GenerateChainWithGenesis(rewardTestGenesis, ethash.NewFaker(), 0, nil)
+	genesis := rewardTestGenesis.ToBlock()
+	blocks := makeRewardChain(genDb, genesis, 30, common.Address{0xaa})
+
+	db := rawdb.NewMemoryDatabase()
+	chain := newRewardChain(t, db, blocks)
+	defer chain.Stop()
+	want := encodeRewards(t, chain)
+
+	// Drop the receipts of a block and force its rewards to be recomputed
+	block := blocks[15]
+	receipts := rawdb.ReadRawReceipts(db, block.Hash(), block.NumberU64())
+	rawdb.DeleteReceipts(db, block.Hash(), block.NumberU64())
+
+	store := chain.RewardStore()
+	if err := store.Rebuild(12, chain.CurrentBlock().Header()); err == nil {
+		t.Fatalf("rebuild succeeded without receipts")
+	}
+	if have := store.Head().Number.Uint64(); have != block.NumberU64()-1 {
+		t.Fatalf("reward head mismatch: have #%d, want #%d", have, block.NumberU64()-1)
+	}
+	if err := store.Sync(chain.CurrentBlock().Header()); !errors.Is(err, rewards.ErrStalled) {
+		t.Fatalf("sync error mismatch: have %v, want %v", err, rewards.ErrStalled)
+	}
+	// Restore the receipts, a rebuild retries the failed block
+	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts)
+	if err := store.Rebuild(12, chain.CurrentBlock().Header()); err != nil {
+		t.Fatalf("failed to rebuild reward records: %v", err)
+	}
+	if have := encodeRewards(t, chain); !bytes.Equal(have, want) {
+		t.Fatalf("reward records mismatch after rebuild")
+	}
+}
+
+// Tests that purges are run on the purge days and rewinding the chain hides the
+// results of the purges dropped.
+func TestRewardsPurge(t *testing.T) {
+	genDb, _, _ := GenerateChainWithGenesis(rewardTestGenesis, ethash.NewFaker(), 0, nil)
+	genesis := rewardTestGenesis.ToBlock()
+	blocks := makeRewardChain(genDb, genesis, 4*types.PurgePeriod+8, common.Address{0xaa})
+
+	chain := newRewardChain(t, rawdb.NewMemoryDatabase(), blocks)
+	defer chain.Stop()
+
+	store := chain.RewardStore()
+	if have := store.Storage().Purges(); have != 1 {
+		t.Fatalf("purge count mismatch: have %d, want %d", have, 1)
+	}
+	if store.PurgeResult(0) == nil {
+		t.Fatalf("missing purge result")
+	}
+	if store.PurgeResult(1) != nil {
+		t.Fatalf("purge result available ahead of the purge")
+	}
+	if err := chain.SetHead(2 * types.PurgePeriod); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	waitRewards(t, chain)
+	if store.PurgeResult(0) != nil {
+		t.Fatalf("purge result available after rewinding the purge")
+	}
+}
+
+// Tests that the reward records can be replayed as of any block without writing
+// to the database, optionally recomputing the persisted block rewards.
+func TestRewardsReplay(t *testing.T) {
+	genDb, _, _ := GenerateChainWithGenesis(rewardTestGenesis, ethash.NewFaker(), 0, nil)
+	genesis := rewardTestGenesis.ToBlock()
+	blocks := makeRewardChain(genDb, genesis, rewards.CheckpointInterval+100, common.Address{0xaa})
+
+	db := rawdb.NewMemoryDatabase()
+	chain := newRewardChain(t, db, blocks)
+	defer chain.Stop()
+
+	replay := func(number uint64, recompute uint64) []byte {
+		storage, err := rewards.Replay(db, chain.Config(), chain.Engine(), genesis.Header(), number, recompute)
+		if err != nil {
+			t.Fatalf("failed to replay reward records: %v", err)
+		}
+		blob, _ := rlp.EncodeToBytes(storage)
+		return blob
+	}
+	if have, want := replay(chain.CurrentBlock().NumberU64(), 0), encodeRewards(t, chain); !bytes.Equal(have, want) {
+		t.Fatalf("replayed reward records mismatch")
+	}
+	// Corrupt the rewards of a block, only recomputing should get rid of them
+	number := uint64(rewards.CheckpointInterval + 10)
+	bogus := []*types.BlockReward{{Type: types.CovenantReward, TokenID: 1, Reward: big.NewInt(1), Fee: big.NewInt(1)}}
+	rawdb.WriteBlockRewards(db, blocks[number-1].Hash(), number, bogus)
+
+	if bytes.Equal(replay(number+5, 0), replay(number+5, number)) {
+		t.Fatalf("corrupted block rewards not used")
+	}
+	ref := newRewardChain(t, rawdb.NewMemoryDatabase(), blocks[:number+5])
+	defer ref.Stop()
+
+	if have, want := replay(number+5, number-20), encodeRewards(t, ref); !bytes.Equal(have, want) {
+		t.Fatalf("recomputed reward records mismatch")
+	}
+	// Replaying must not have persisted anything
+	if rawdb.ReadBlockRewards(db, blocks[number-1].Hash(), number)[0].TokenID != 1 {
+		t.Fatalf("replay overwrote the block rewards")
+	}
+}
+
+// Tests that the rewards and purges of new canonical blocks are announced, but
+// blocks accounted again after a reorg only if they weren't canonical before.
+func TestRewardsEvents(t *testing.T) {
+	genDb, _, _ := GenerateChainWithGenesis(rewardTestGenesis, ethash.NewFaker(), 0, nil)
+	genesis := rewardTestGenesis.ToBlock()
+	var (
+		chainA = makeRewardChain(genDb, genesis, 4*types.PurgePeriod+4, common.Address{0xaa})
+		chainB = makeRewardChain(genDb, chainA[29], 4*types.PurgePeriod, common.Address{0xbb})
+	)
+	chain := newRewardChain(t, rawdb.NewMemoryDatabase(), nil)
+	defer chain.Stop()
+
+	var (
+		rewardsCh = make(chan rewards.RewardsEvent, 1024)
+		purgeCh   = make(chan rewards.PurgeEvent, 1024)
+	)
+	rewardsSub := chain.SubscribeRewardsEvent(rewardsCh)
+	defer rewardsSub.Unsubscribe()
+	purgeSub := chain.SubscribePurgeEvent(purgeCh)
+	defer purgeSub.Unsubscribe()
+
+	check := func(blocks []*types.Block, coinbase common.Address, purges int) {
+		t.Helper()
+		for _, block := range blocks {
+			ev := <-rewardsCh
+			if ev.Header.Hash() != block.Hash() {
+				t.Fatalf("rewards event mismatch: have #%d, want #%d", ev.Header.Number, block.Number())
+			}
+			if len(ev.Rewards) != 1 || ev.Rewards[0].Address != coinbase {
+				t.Fatalf("block #%d: rewards mismatch: %v", block.Number(), ev.Rewards)
+			}
+		}
+		for i := 0; i < purges; i++ {
+			if ev := <-purgeCh; ev.Index != 0 {
+				t.Fatalf("purge index mismatch: have %d, want %d", ev.Index, 0)
+			}
+		}
+		select {
+		case ev := <-rewardsCh:
+			t.Fatalf("unexpected rewards event for #%d", ev.Header.Number)
+		case ev := <-purgeCh:
+			t.Fatalf("unexpected purge event for #%d", ev.Header.Number)
+		default:
+		}
+	}
+	if _, err := chain.InsertChain(chainA); err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+	check(chainA, common.Address{0xaa}, 1)
+
+	if _, err := chain.InsertChain(chainB); err != nil {
+		t.Fatalf("failed to import side chain: %v", err)
+	}
+	check(chainB, common.Address{0xbb}, 1)
+}
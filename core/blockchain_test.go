@@ -832,9 +832,10 @@ func TestFastVsFullChains(t *testing.T) {
 		}
 
 		// Check receipts.
-		freceipts := rawdb.ReadReceipts(fastDb, hash, num, fast.Config())
-		anreceipts := rawdb.ReadReceipts(ancientDb, hash, num, fast.Config())
-		areceipts := rawdb.ReadReceipts(archiveDb, hash, num, fast.Config())
+		blockTime := fast.GetHeaderByHash(hash).Time
+		freceipts := rawdb.ReadReceipts(fastDb, hash, num, blockTime, fast.Config())
+		anreceipts := rawdb.ReadReceipts(ancientDb, hash, num, blockTime, fast.Config())
+		areceipts := rawdb.ReadReceipts(archiveDb, hash, num, blockTime, fast.Config())
 		if types.DeriveSha(freceipts, trie.NewStackTrie(nil)) != types.DeriveSha(areceipts, trie.NewStackTrie(nil)) {
 			t.Errorf("block #%d [%x]: receipts mismatch: fastdb %v, ancientdb %v, archivedb %v", num, hash, freceipts, anreceipts, areceipts)
 		}
@@ -4238,7 +4239,7 @@ func TestEIP3651(t *testing.T) {
 
 	gspec.Config.BerlinBlock = common.Big0
 	gspec.Config.LondonBlock = common.Big0
-	gspec.Config.ShanghaiBlock = common.Big0
+	gspec.Config.ShanghaiTime = new(uint64)
 	signer := types.LatestSigner(gspec.Config)
 
 	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 1, func(i int, b *BlockGen) {
@@ -4295,3 +4296,147 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+// Tests that a chain using the path-based state scheme keeps the recent states
+// in memory, persists the head state on shutdown and rolls the persisted state
+// back when rewinding below it.
+func TestPathSchemeStateRewind(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+		config = &CacheConfig{
+			TrieCleanLimit: 256,
+			TrieDirtyLimit: 256,
+			TrieTimeLimit:  5 * time.Minute,
+			SnapshotLimit:  0,
+			StateScheme:    trie.PathScheme,
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2*TriesInMemory, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{byte(i)}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	// Archive mode can't be combined with the path scheme
+	archive := *config
+	archive.TrieDirtyDisabled = true
+	if _, err := NewBlockChain(rawdb.NewMemoryDatabase(), &archive, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil); err == nil {
+		t.Fatal("archive mode accepted with the path scheme")
+	}
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if !chain.HasState(blocks[len(blocks)-1].Root()) || !chain.HasState(blocks[len(blocks)-TriesInMemory].Root()) {
+		t.Fatal("recent state unavailable")
+	}
+	if chain.HasState(blocks[0].Root()) {
+		t.Fatal("stale state available")
+	}
+	chain.Stop()
+
+	// Reopen the chain, the head state must survive the restart
+	chain, err = NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("head mismatch: have %d, want %d", head.NumberU64(), blocks[len(blocks)-1].NumberU64())
+	}
+	if !chain.HasState(blocks[len(blocks)-1].Root()) {
+		t.Fatal("head state unavailable after restart")
+	}
+	// Rewind below the persisted state, which has to be rolled back
+	if err := chain.SetHead(blocks[9].NumberU64()); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[9].Hash() {
+		t.Fatalf("head mismatch after rewind: have %d, want %d", head.NumberU64(), blocks[9].NumberU64())
+	}
+	if !chain.HasState(blocks[9].Root()) {
+		t.Fatal("rewound state unavailable")
+	}
+	// The chain must be able to progress on top of the recovered state
+	if _, err := chain.InsertChain(blocks[10:]); err != nil {
+		t.Fatalf("failed to reinsert chain: %v", err)
+	}
+}
+
+// Tests that the block history is pruned below the configured retention while
+// keeping the headers, and that the pruned transactions aren't reindexed.
+func TestHistoryPruning(t *testing.T) {
+	var (
+		testBankKey, _  = crypto.GenerateKey()
+		testBankAddress = crypto.PubkeyToAddress(testBankKey.PublicKey)
+		testBankFunds   = big.NewInt(1000000000000000000)
+
+		gspec = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		engine = ethash.NewFaker()
+		nonce  = uint64(0)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, engine, 128, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, common.HexToAddress("0xdeadbeef"), big.NewInt(1000), params.TxGas, big.NewInt(10*params.InitialBaseFee), nil), types.HomesteadSigner{}, testBankKey)
+		gen.AddTx(tx)
+		nonce += 1
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer db.Close()
+	rawdb.WriteAncientBlocks(db, append([]*types.Block{gspec.ToBlock()}, blocks...), append([]types.Receipts{{}}, receipts...), big.NewInt(0))
+
+	cacheConfig := *defaultCacheConfig
+	cacheConfig.HistoryRetain = 64
+
+	limit := uint64(0)
+	chain, err := NewBlockChain(db, &cacheConfig, gspec, nil, engine, vm.Config{}, nil, &limit)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	chain.indexBlocks(nil, 128, make(chan struct{}))
+	chain.pruneHistory(128)
+
+	if cutoff := chain.HistoryPruningCutoff(); cutoff != 65 {
+		t.Fatalf("pruning cutoff mismatch: have %d, want %d", cutoff, 65)
+	}
+	for _, block := range blocks {
+		var (
+			number = block.NumberU64()
+			pruned = number < 65
+		)
+		if chain.GetHeaderByNumber(number) == nil {
+			t.Fatalf("block %d: header missing", number)
+		}
+		if (chain.GetBlockByNumber(number) == nil) != pruned {
+			t.Fatalf("block %d: body pruned mismatch, want %v", number, pruned)
+		}
+		if (chain.GetReceiptsByHash(block.Hash()) == nil) != pruned {
+			t.Fatalf("block %d: receipts pruned mismatch, want %v", number, pruned)
+		}
+		if (rawdb.ReadTxLookupEntry(db, block.Transactions()[0].Hash()) == nil) != pruned {
+			t.Fatalf("block %d: tx lookup pruned mismatch, want %v", number, pruned)
+		}
+	}
+	// The indexer must not attempt to index the pruned transactions
+	chain.indexBlocks(rawdb.ReadTxIndexTail(db), 128, make(chan struct{}))
+	if tail := rawdb.ReadTxIndexTail(db); tail == nil || *tail != 65 {
+		t.Fatalf("tx index tail mismatch: have %v, want %d", tail, 65)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -71,7 +72,7 @@ func ReadGenesis(db ethdb.Database) (*Genesis, error) {
 	if (stored == common.Hash{}) {
 		return nil, fmt.Errorf("invalid genesis hash in database: %x", stored)
 	}
-	blob := rawdb.ReadGenesisStateSpec(db, stored)
+	blob := readGenesisStateSpec(db, stored)
 	if blob == nil {
 		return nil, fmt.Errorf("genesis state missing from db")
 	}
@@ -125,7 +126,7 @@ func (ga *GenesisAlloc) deriveHash() (common.Hash, error) {
 		return common.Hash{}, err
 	}
 	for addr, account := range *ga {
-		statedb.AddBalance(addr, account.Balance)
+		statedb.AddBalance(addr, account.Balance, tracing.BalanceIncreaseGenesisBalance)
 		statedb.SetCode(addr, account.Code)
 		statedb.SetNonce(addr, account.Nonce)
 		for key, value := range account.Storage {
@@ -138,13 +139,13 @@ func (ga *GenesisAlloc) deriveHash() (common.Hash, error) {
 // flush is very similar with deriveHash, but the main difference is
 // all the generated states will be persisted into the given database.
 // Also, the genesis state specification will be flushed as well.
-func (ga *GenesisAlloc) flush(db ethdb.Database, triedb *trie.Database) error {
+func (ga *GenesisAlloc) flush(db ethdb.Database, triedb *trie.Database, blockhash common.Hash) error {
 	statedb, err := state.New(common.Hash{}, state.NewDatabaseWithNodeDB(db, triedb), nil)
 	if err != nil {
 		return err
 	}
 	for addr, account := range *ga {
-		statedb.AddBalance(addr, account.Balance)
+		statedb.AddBalance(addr, account.Balance, tracing.BalanceIncreaseGenesisBalance)
 		statedb.SetCode(addr, account.Code)
 		statedb.SetNonce(addr, account.Nonce)
 		for key, value := range account.Storage {
@@ -166,18 +167,28 @@ func (ga *GenesisAlloc) flush(db ethdb.Database, triedb *trie.Database) error {
 	if err != nil {
 		return err
 	}
-	rawdb.WriteGenesisStateSpec(db, root, blob)
+	rawdb.WriteGenesisStateSpec(db, blockhash, blob)
 	return nil
 }
 
 // CommitGenesisState loads the stored genesis state with the given block
 // hash and commits it into the provided trie database.
 func CommitGenesisState(db ethdb.Database, triedb *trie.Database, hash common.Hash) error {
+	alloc, err := getGenesisState(db, hash)
+	if err != nil {
+		return err
+	}
+	return alloc.flush(db, triedb, hash)
+}
+
+// getGenesisState loads the stored genesis allocation of the genesis block with
+// the given hash.
+func getGenesisState(db ethdb.Database, hash common.Hash) (GenesisAlloc, error) {
 	var alloc GenesisAlloc
-	blob := rawdb.ReadGenesisStateSpec(db, hash)
+	blob := readGenesisStateSpec(db, hash)
 	if len(blob) != 0 {
 		if err := alloc.UnmarshalJSON(blob); err != nil {
-			return err
+			return nil, err
 		}
 	} else {
 		// Genesis allocation is missing and there are several possibilities:
@@ -201,10 +212,25 @@ func CommitGenesisState(db ethdb.Database, triedb *trie.Database, hash common.Ha
 		if genesis != nil {
 			alloc = genesis.Alloc
 		} else {
-			return errors.New("not found")
+			return nil, errors.New("not found")
 		}
 	}
-	return alloc.flush(db, triedb)
+	return alloc, nil
+}
+
+// readGenesisStateSpec loads the stored genesis state specification of the
+// genesis block with the given hash. Databases initialized by older versions
+// keyed the specification by the genesis state root instead, which is looked
+// up as a fallback.
+func readGenesisStateSpec(db ethdb.Database, hash common.Hash) []byte {
+	if blob := rawdb.ReadGenesisStateSpec(db, hash); blob != nil {
+		return blob
+	}
+	header := rawdb.ReadHeader(db, hash, 0)
+	if header == nil {
+		return nil
+	}
+	return rawdb.ReadGenesisStateSpec(db, header.Root)
 }
 
 // GenesisAccount is an account in the state of the genesis block.
@@ -322,9 +348,11 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, triedb *trie.Database, gen
 		return genesis.Config, block.Hash(), nil
 	}
 	// We have the genesis block in database(perhaps in ancient database)
-	// but the corresponding state is missing.
+	// but the corresponding state is missing. The path scheme only retains
+	// the recent states, the genesis state is only missing there if there's
+	// no persisted state at all.
 	header := rawdb.ReadHeader(db, stored, 0)
-	if _, err := state.New(header.Root, state.NewDatabaseWithNodeDB(db, triedb), nil); err != nil {
+	if _, err := state.New(header.Root, state.NewDatabaseWithNodeDB(db, triedb), nil); err != nil && (triedb.Scheme().Name() != trie.PathScheme || trie.ReadStateScheme(db) != trie.PathScheme) {
 		if genesis == nil {
 			genesis = DefaultGenesisBlock()
 		}
@@ -371,12 +399,12 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, triedb *trie.Database, gen
 	}
 	// Check config compatibility and write the config. Compatibility errors
 	// are returned to the caller unless we're already at block zero.
-	height := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
-	if height == nil {
-		return newcfg, stored, fmt.Errorf("missing block number for head header hash")
+	head := rawdb.ReadHeadHeader(db)
+	if head == nil {
+		return newcfg, stored, fmt.Errorf("missing head header")
 	}
-	compatErr := storedcfg.CheckCompatible(newcfg, *height)
-	if compatErr != nil && *height != 0 && compatErr.RewindTo != 0 {
+	compatErr := storedcfg.CheckCompatible(newcfg, head.Number.Uint64(), head.Time)
+	if compatErr != nil && ((head.Number.Uint64() != 0 && compatErr.RewindToBlock != 0) || (head.Time != 0 && compatErr.RewindToTime != 0)) {
 		return newcfg, stored, compatErr
 	}
 	// Don't overwrite if the old is identical to the new
@@ -476,7 +504,15 @@ func (g *Genesis) ToBlock() *types.Block {
 			head.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
 		}
 	}
-	return types.NewBlock(head, nil, nil, nil, trie.NewStackTrie(nil))
+	var withdrawals []*types.Withdrawal
+	if g.Config != nil && g.Config.IsShanghai(g.Timestamp) {
+		withdrawals = make([]*types.Withdrawal, 0)
+	}
+	if g.Config != nil && g.Config.IsCancun(g.Timestamp) {
+		head.DataGasUsed = new(uint64)
+		head.ExcessDataGas = new(uint64)
+	}
+	return types.NewBlockWithWithdrawals(head, nil, nil, nil, withdrawals, trie.NewStackTrie(nil))
 }
 
 // Commit writes the block and state of a genesis specification to the database.
@@ -499,7 +535,7 @@ func (g *Genesis) Commit(db ethdb.Database, triedb *trie.Database) (*types.Block
 	// All the checks has passed, flush the states derived from the genesis
 	// specification as well as the specification itself into the provided
 	// database.
-	if err := g.Alloc.flush(db, triedb); err != nil {
+	if err := g.Alloc.flush(db, triedb, block.Hash()); err != nil {
 		return nil, err
 	}
 	rawdb.WriteTd(db, block.Hash(), block.NumberU64(), block.Difficulty())
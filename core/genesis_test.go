@@ -132,10 +132,10 @@ func TestSetupGenesis(t *testing.T) {
 			wantHash:   customghash,
 			wantConfig: customg.Config,
 			wantErr: &params.ConfigCompatError{
-				What:         "Homestead fork block",
-				StoredConfig: big.NewInt(2),
-				NewConfig:    big.NewInt(3),
-				RewindTo:     1,
+				What:          "Homestead fork block",
+				StoredBlock:   big.NewInt(2),
+				NewBlock:      big.NewInt(3),
+				RewindToBlock: 1,
 			},
 		},
 	}
@@ -244,3 +244,29 @@ func TestReadWriteGenesisAlloc(t *testing.T) {
 		}
 	}
 }
+
+func TestReadLegacyGenesisAlloc(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{{1}: {Balance: big.NewInt(1)}},
+		}
+		block = genesis.MustCommit(db)
+	)
+	// Move the specification to the legacy key of the genesis state root
+	blob := rawdb.ReadGenesisStateSpec(db, block.Hash())
+	if blob == nil {
+		t.Fatal("genesis state specification missing")
+	}
+	rawdb.DeleteGenesisStateSpec(db, block.Hash())
+	rawdb.WriteGenesisStateSpec(db, block.Root(), blob)
+
+	reload, err := ReadGenesis(db)
+	if err != nil {
+		t.Fatalf("failed to read legacy genesis: %v", err)
+	}
+	if !reflect.DeepEqual(reload.Alloc, genesis.Alloc) {
+		t.Fatalf("genesis allocation mismatch: have %v, want %v", reload.Alloc, genesis.Alloc)
+	}
+}
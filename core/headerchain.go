@@ -23,6 +23,7 @@ import (
 	"math"
 	"math/big"
 	mrand "math/rand"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -563,6 +564,28 @@ type (
 	DeleteBlockContentCallback func(ethdb.KeyValueWriter, common.Hash, uint64)
 )
 
+// SetHeadWithTimestamp rewinds the local chain to the last header with a
+// timestamp not exceeding the given one. Everything above the new head will
+// be deleted and the new one set.
+func (hc *HeaderChain) SetHeadWithTimestamp(time uint64, updateFn UpdateHeadBlocksCallback, delFn DeleteBlockContentCallback) {
+	hc.SetHead(hc.numberAtTime(time), updateFn, delFn)
+}
+
+// numberAtTime returns the number of the last canonical header with a timestamp
+// not exceeding the given one. Canonical timestamps are strictly increasing, so
+// the header can be binary searched for.
+func (hc *HeaderChain) numberAtTime(time uint64) uint64 {
+	head := hc.CurrentHeader().Number.Uint64()
+	n := sort.Search(int(head+1), func(i int) bool {
+		header := hc.GetHeaderByNumber(uint64(i))
+		return header == nil || header.Time > time
+	})
+	if n == 0 {
+		return 0 // Genesis can't be rewound
+	}
+	return uint64(n - 1)
+}
+
 // SetHead rewinds the local chain to a new head. Everything above the new head
 // will be deleted and the new one set.
 func (hc *HeaderChain) SetHead(head uint64, updateFn UpdateHeadBlocksCallback, delFn DeleteBlockContentCallback) {
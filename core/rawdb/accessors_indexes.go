@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -130,8 +131,12 @@ func ReadReceipt(db ethdb.Reader, hash common.Hash, config *params.ChainConfig)
 	if blockHash == (common.Hash{}) {
 		return nil, common.Hash{}, 0, 0
 	}
+	blockHeader := ReadHeader(db, blockHash, *blockNumber)
+	if blockHeader == nil {
+		return nil, common.Hash{}, 0, 0
+	}
 	// Read all the receipts from the block and return the one with the matching hash
-	receipts := ReadReceipts(db, blockHash, *blockNumber, config)
+	receipts := ReadReceipts(db, blockHash, *blockNumber, blockHeader.Time, config)
 	for receiptIndex, receipt := range receipts {
 		if receipt.TxHash == hash {
 			return receipt, blockHash, *blockNumber, uint64(receiptIndex)
@@ -175,3 +180,86 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// ReadInternalCalls retrieves the internal call summaries of the given block
+// from the trace index.
+func ReadInternalCalls(db ethdb.KeyValueReader, hash common.Hash, number uint64) []*types.InternalCall {
+	data, _ := db.Get(internalCallsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var calls []*types.InternalCall
+	if err := rlp.DecodeBytes(data, &calls); err != nil {
+		log.Error("Invalid internal calls RLP", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return calls
+}
+
+// HasInternalCalls verifies the existence of the internal call summaries of the
+// given block in the trace index.
+func HasInternalCalls(db ethdb.KeyValueReader, hash common.Hash, number uint64) bool {
+	has, _ := db.Has(internalCallsKey(number, hash))
+	return has
+}
+
+// WriteInternalCalls stores the internal call summaries of the given block into
+// the trace index.
+func WriteInternalCalls(db ethdb.KeyValueWriter, hash common.Hash, number uint64, calls []*types.InternalCall) {
+	data, err := rlp.EncodeToBytes(calls)
+	if err != nil {
+		log.Crit("Failed to RLP encode internal calls", "err", err)
+	}
+	if err := db.Put(internalCallsKey(number, hash), data); err != nil {
+		log.Crit("Failed to store internal calls", "err", err)
+	}
+}
+
+// ReadTraceIndexTail retrieves the number of the oldest block whose internal
+// calls are kept by the trace index.
+func ReadTraceIndexTail(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(traceIndexTailKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteTraceIndexTail stores the number of the oldest block whose internal
+// calls are kept by the trace index.
+func WriteTraceIndexTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(traceIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the trace index tail", "err", err)
+	}
+}
+
+// DeleteInternalCallsRange removes the internal call summaries of all the blocks,
+// canonical or not, in the given number range (to is exclusive).
+func DeleteInternalCallsRange(db ethdb.KeyValueStore, from uint64, to uint64) {
+	start, end := internalCallsKey(from, common.Hash{}), internalCallsKey(to, common.Hash{})
+	it := db.NewIterator(nil, start)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		if bytes.Compare(it.Key(), end) >= 0 {
+			break
+		}
+		if len(it.Key()) != len(internalCallsPrefix)+8+common.HashLength {
+			continue
+		}
+		batch.Delete(it.Key())
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to delete internal calls", "err", err)
+			}
+			batch.Reset()
+		}
+	}
+	if it.Error() != nil {
+		log.Crit("Failed to iterate internal calls", "err", it.Error())
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete internal calls", "err", err)
+	}
+}
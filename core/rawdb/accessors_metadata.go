@@ -82,7 +82,7 @@ func WriteChainConfig(db ethdb.KeyValueWriter, hash common.Hash, cfg *params.Cha
 }
 
 // ReadGenesisStateSpec retrieves the genesis state specification based on the
-// given genesis hash.
+// given genesis hash. Legacy databases store it by the genesis state root.
 func ReadGenesisStateSpec(db ethdb.KeyValueReader, hash common.Hash) []byte {
 	data, _ := db.Get(genesisStateSpecKey(hash))
 	return data
@@ -95,6 +95,14 @@ func WriteGenesisStateSpec(db ethdb.KeyValueWriter, hash common.Hash, data []byt
 	}
 }
 
+// DeleteGenesisStateSpec removes the genesis state specification stored under
+// the given hash.
+func DeleteGenesisStateSpec(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(genesisStateSpecKey(hash)); err != nil {
+		log.Crit("Failed to delete genesis state", "err", err)
+	}
+}
+
 // crashList is a list of unclean-shutdown-markers, for rlp-encoding to the
 // database
 type crashList struct {
@@ -0,0 +1,184 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadNodeRewardStorage retrieves the validator and covenant reward records.
+func ReadNodeRewardStorage(db ethdb.KeyValueReader) *types.NodeRewardStorage {
+	data, _ := db.Get(rewardStorageKey)
+	if len(data) == 0 {
+		return nil
+	}
+	storage := new(types.NodeRewardStorage)
+	if err := rlp.DecodeBytes(data, storage); err != nil {
+		log.Error("Invalid reward storage RLP", "err", err)
+		return nil
+	}
+	return storage
+}
+
+// WriteNodeRewardStorage stores the validator and covenant reward records.
+func WriteNodeRewardStorage(db ethdb.KeyValueWriter, storage *types.NodeRewardStorage) {
+	data, err := rlp.EncodeToBytes(storage)
+	if err != nil {
+		log.Crit("Failed to RLP encode reward storage", "err", err)
+	}
+	if err := db.Put(rewardStorageKey, data); err != nil {
+		log.Crit("Failed to store reward storage", "err", err)
+	}
+}
+
+// DeleteNodeRewardStorage removes the reward records.
+func DeleteNodeRewardStorage(db ethdb.KeyValueWriter) {
+	if err := db.Delete(rewardStorageKey); err != nil {
+		log.Crit("Failed to remove reward storage", "err", err)
+	}
+}
+
+// ReadRewardHead retrieves the hash of the block the journalled reward records
+// belong to.
+func ReadRewardHead(db ethdb.KeyValueReader) common.Hash {
+	data, _ := db.Get(rewardHeadKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteRewardHead stores the hash of the block the journalled reward records
+// belong to.
+func WriteRewardHead(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Put(rewardHeadKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store reward head", "err", err)
+	}
+}
+
+// DeleteRewardHead removes the reward head marker.
+func DeleteRewardHead(db ethdb.KeyValueWriter) {
+	if err := db.Delete(rewardHeadKey); err != nil {
+		log.Crit("Failed to remove reward head", "err", err)
+	}
+}
+
+// ReadBlockRewards retrieves the rewards credited by the given block.
+func ReadBlockRewards(db ethdb.KeyValueReader, hash common.Hash, number uint64) []*types.BlockReward {
+	data, _ := db.Get(blockRewardsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var rewards []*types.BlockReward
+	if err := rlp.DecodeBytes(data, &rewards); err != nil {
+		log.Error("Invalid block rewards RLP", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return rewards
+}
+
+// HasBlockRewards verifies the existence of the rewards credited by the given block.
+func HasBlockRewards(db ethdb.KeyValueReader, hash common.Hash, number uint64) bool {
+	has, _ := db.Has(blockRewardsKey(number, hash))
+	return has
+}
+
+// WriteBlockRewards stores the rewards credited by the given block.
+func WriteBlockRewards(db ethdb.KeyValueWriter, hash common.Hash, number uint64, rewards []*types.BlockReward) {
+	data, err := rlp.EncodeToBytes(rewards)
+	if err != nil {
+		log.Crit("Failed to RLP encode block rewards", "err", err)
+	}
+	if err := db.Put(blockRewardsKey(number, hash), data); err != nil {
+		log.Crit("Failed to store block rewards", "err", err)
+	}
+}
+
+// DeleteBlockRewards removes the rewards credited by the given block.
+func DeleteBlockRewards(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockRewardsKey(number, hash)); err != nil {
+		log.Crit("Failed to remove block rewards", "err", err)
+	}
+}
+
+// ReadRewardCheckpoint retrieves the reward records as of the given block.
+func ReadRewardCheckpoint(db ethdb.KeyValueReader, hash common.Hash, number uint64) *types.NodeRewardStorage {
+	data, _ := db.Get(rewardCheckpointKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	storage := new(types.NodeRewardStorage)
+	if err := rlp.DecodeBytes(data, storage); err != nil {
+		log.Error("Invalid reward checkpoint RLP", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return storage
+}
+
+// WriteRewardCheckpoint stores the reward records as of the given block.
+func WriteRewardCheckpoint(db ethdb.KeyValueWriter, hash common.Hash, number uint64, storage *types.NodeRewardStorage) {
+	data, err := rlp.EncodeToBytes(storage)
+	if err != nil {
+		log.Crit("Failed to RLP encode reward checkpoint", "err", err)
+	}
+	if err := db.Put(rewardCheckpointKey(number, hash), data); err != nil {
+		log.Crit("Failed to store reward checkpoint", "err", err)
+	}
+}
+
+// DeleteRewardCheckpoint removes the reward records as of the given block.
+func DeleteRewardCheckpoint(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(rewardCheckpointKey(number, hash)); err != nil {
+		log.Crit("Failed to remove reward checkpoint", "err", err)
+	}
+}
+
+// ReadPurgeHistoryResult retrieves the result of the purge cycle with the given index.
+func ReadPurgeHistoryResult(db ethdb.KeyValueReader, index uint64) *types.PurgeHistoryResult {
+	data, _ := db.Get(purgeHistoryKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	result := new(types.PurgeHistoryResult)
+	if err := rlp.DecodeBytes(data, result); err != nil {
+		log.Error("Invalid purge result RLP", "index", index, "err", err)
+		return nil
+	}
+	return result
+}
+
+// WritePurgeHistoryResult stores the result of the purge cycle with the given index.
+func WritePurgeHistoryResult(db ethdb.KeyValueWriter, index uint64, result *types.PurgeHistoryResult) {
+	data, err := rlp.EncodeToBytes(result)
+	if err != nil {
+		log.Crit("Failed to RLP encode purge result", "err", err)
+	}
+	if err := db.Put(purgeHistoryKey(index), data); err != nil {
+		log.Crit("Failed to store purge result", "err", err)
+	}
+}
+
+// DeletePurgeHistoryResult removes the result of the purge cycle with the given index.
+func DeletePurgeHistoryResult(db ethdb.KeyValueWriter, index uint64) {
+	if err := db.Delete(purgeHistoryKey(index)); err != nil {
+		log.Crit("Failed to remove purge result", "err", err)
+	}
+}
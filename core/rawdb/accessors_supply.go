@@ -0,0 +1,82 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadSupplyDelta retrieves the supply change caused by the given block.
+func ReadSupplyDelta(db ethdb.KeyValueReader, hash common.Hash, number uint64) *types.SupplyDelta {
+	data, _ := db.Get(supplyDeltaKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	delta := new(types.SupplyDelta)
+	if err := rlp.DecodeBytes(data, delta); err != nil {
+		log.Error("Invalid supply delta RLP", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return delta
+}
+
+// WriteSupplyDelta stores the supply change caused by the given block.
+func WriteSupplyDelta(db ethdb.KeyValueWriter, delta *types.SupplyDelta) {
+	data, err := rlp.EncodeToBytes(delta)
+	if err != nil {
+		log.Crit("Failed to RLP encode supply delta", "err", err)
+	}
+	if err := db.Put(supplyDeltaKey(delta.Number, delta.Hash), data); err != nil {
+		log.Crit("Failed to store supply delta", "err", err)
+	}
+}
+
+// DeleteSupplyDelta removes the supply change caused by the given block.
+func DeleteSupplyDelta(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(supplyDeltaKey(number, hash)); err != nil {
+		log.Crit("Failed to remove supply delta", "err", err)
+	}
+}
+
+// ReadSupplyAudit retrieves the result of the last audit of the tracked supply.
+func ReadSupplyAudit(db ethdb.KeyValueReader) *types.SupplyAudit {
+	data, _ := db.Get(supplyAuditKey)
+	if len(data) == 0 {
+		return nil
+	}
+	audit := new(types.SupplyAudit)
+	if err := rlp.DecodeBytes(data, audit); err != nil {
+		log.Error("Invalid supply audit RLP", "err", err)
+		return nil
+	}
+	return audit
+}
+
+// WriteSupplyAudit stores the result of the last audit of the tracked supply.
+func WriteSupplyAudit(db ethdb.KeyValueWriter, audit *types.SupplyAudit) {
+	data, err := rlp.EncodeToBytes(audit)
+	if err != nil {
+		log.Crit("Failed to RLP encode supply audit", "err", err)
+	}
+	if err := db.Put(supplyAuditKey, data); err != nil {
+		log.Crit("Failed to store supply audit", "err", err)
+	}
+}
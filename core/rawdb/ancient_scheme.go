@@ -34,14 +34,15 @@ const (
 	chainFreezerDifficultyTable = "diffs"
 )
 
-// chainFreezerNoSnappy configures whether compression is disabled for the ancient-tables.
-// Hashes and difficulties don't compress well.
-var chainFreezerNoSnappy = map[string]bool{
-	chainFreezerHeaderTable:     false,
-	chainFreezerHashTable:       true,
-	chainFreezerBodiesTable:     false,
-	chainFreezerReceiptTable:    false,
-	chainFreezerDifficultyTable: true,
+// chainFreezerTableConfigs configures the settings for the ancient-tables.
+// Hashes and difficulties don't compress well. Bodies and receipts can be
+// pruned to expire the block history, while the headers are always retained.
+var chainFreezerTableConfigs = map[string]freezerTableConfig{
+	chainFreezerHeaderTable:     {noSnappy: false, prunable: false},
+	chainFreezerHashTable:       {noSnappy: true, prunable: false},
+	chainFreezerBodiesTable:     {noSnappy: false, prunable: true},
+	chainFreezerReceiptTable:    {noSnappy: false, prunable: true},
+	chainFreezerDifficultyTable: {noSnappy: true, prunable: false},
 }
 
 // The list of identifiers of ancient stores.
@@ -17,6 +17,7 @@
 package rawdb
 
 import (
+	"errors"
 	"runtime"
 	"sync/atomic"
 	"time"
@@ -352,3 +353,47 @@ func UnindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 func unindexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
 	unindexTransactions(db, from, to, interrupt, hook)
 }
+
+// PruneHistory discards the bodies and receipts of the blocks below the given
+// number from the ancient store, while keeping their headers. Only frozen
+// blocks can be pruned, so the target is capped at the number of ancients.
+//
+// The transaction lookup entries of the pruned blocks are deleted beforehand,
+// as they can't be resolved without the bodies anymore. The tx index tail is
+// forwarded accordingly. The new history tail is returned.
+func PruneHistory(db ethdb.Database, tail uint64, interrupt chan struct{}) (uint64, error) {
+	frozen, err := db.Ancients()
+	if err != nil {
+		return 0, err
+	}
+	if tail > frozen {
+		tail = frozen
+	}
+	prev, err := db.Tail()
+	if err != nil {
+		return 0, err
+	}
+	if tail <= prev {
+		return prev, nil
+	}
+	// Drop the lookup entries of the pruned transactions, skipping the range
+	// that's already unindexed.
+	from := prev
+	if indexed := ReadTxIndexTail(db); indexed != nil && *indexed > from {
+		from = *indexed
+	}
+	if from < tail {
+		unindexTransactions(db, from, tail, interrupt, nil)
+		if indexed := ReadTxIndexTail(db); indexed == nil || *indexed < tail {
+			return prev, errors.New("transaction unindexing interrupted")
+		}
+	}
+	if err := db.TruncateTail(tail); err != nil {
+		return prev, err
+	}
+	if err := db.Sync(); err != nil {
+		return prev, err
+	}
+	log.Info("Pruned block history", "from", prev, "to", tail)
+	return tail, nil
+}
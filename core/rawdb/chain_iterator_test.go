@@ -206,3 +206,72 @@ func TestIndexTransactions(t *testing.T) {
 	verify(8, 11, true, 8)
 	verify(0, 8, false, 8)
 }
+
+func TestPruneHistory(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer db.Close()
+
+	// Freeze the first 8 blocks and keep the rest in the key-value store
+	var (
+		to       = common.BytesToAddress([]byte{0x11})
+		blocks   []*types.Block
+		receipts []types.Receipts
+	)
+	for i := uint64(0); i <= 10; i++ {
+		tx := types.NewTx(&types.LegacyTx{Nonce: i, GasPrice: big.NewInt(11111), Gas: 1111, To: &to, Value: big.NewInt(111)})
+		block := types.NewBlock(&types.Header{Number: big.NewInt(int64(i))}, []*types.Transaction{tx}, nil, nil, newHasher())
+		blocks = append(blocks, block)
+		receipts = append(receipts, types.Receipts{{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}}})
+		WriteTxLookupEntriesByBlock(db, block)
+	}
+	if _, err := WriteAncientBlocks(db, blocks[:8], receipts[:8], big.NewInt(100)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	for i := 8; i < len(blocks); i++ {
+		WriteBlock(db, blocks[i])
+		WriteReceipts(db, blocks[i].Hash(), blocks[i].NumberU64(), receipts[i])
+		WriteCanonicalHash(db, blocks[i].Hash(), blocks[i].NumberU64())
+	}
+	// Prune the history below block 5 and ensure only the headers are retained
+	tail, err := PruneHistory(db, 5, nil)
+	if err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if tail != 5 {
+		t.Fatalf("history tail mismatch: have %d, want %d", tail, 5)
+	}
+	for i, block := range blocks {
+		var (
+			hash, number = block.Hash(), block.NumberU64()
+			pruned       = number < 5
+		)
+		if ReadHeaderRLP(db, hash, number) == nil {
+			t.Errorf("block %d: header missing", i)
+		}
+		if (ReadBodyRLP(db, hash, number) == nil) != pruned {
+			t.Errorf("block %d: body pruned mismatch, want %v", i, pruned)
+		}
+		if (ReadReceiptsRLP(db, hash, number) == nil) != pruned {
+			t.Errorf("block %d: receipts pruned mismatch, want %v", i, pruned)
+		}
+		if (ReadTxLookupEntry(db, block.Transactions()[0].Hash()) == nil) != pruned {
+			t.Errorf("block %d: tx lookup pruned mismatch, want %v", i, pruned)
+		}
+	}
+	if indexed := ReadTxIndexTail(db); indexed == nil || *indexed != 5 {
+		t.Errorf("tx index tail mismatch: have %v, want %d", indexed, 5)
+	}
+	// Pruning can't go past the ancient store
+	if tail, err = PruneHistory(db, 10, nil); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if tail != 8 {
+		t.Fatalf("history tail mismatch: have %d, want %d", tail, 8)
+	}
+	if ReadBodyRLP(db, blocks[8].Hash(), 8) == nil {
+		t.Errorf("live block body pruned")
+	}
+}
@@ -15,3 +15,48 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 package rawdb
+
+import (
+	"testing"
+)
+
+// Tests that the engine of an existing database is detected and used when no
+// engine is requested, and that opening it with a different engine fails.
+func TestOpenDatabaseEngine(t *testing.T) {
+	if !PebbleEnabled {
+		t.Skip("pebble not supported on this platform")
+	}
+	for _, engine := range []string{dbLeveldb, dbPebble} {
+		dir := t.TempDir()
+		if kind := hasPreexistingDb(dir); kind != "" {
+			t.Fatalf("empty directory detected as %s database", kind)
+		}
+		db, err := Open(OpenOptions{Type: engine, Directory: dir})
+		if err != nil {
+			t.Fatalf("failed to create %s database: %v", engine, err)
+		}
+		if err := db.Put([]byte("key"), []byte("value")); err != nil {
+			t.Fatalf("failed to write to %s database: %v", engine, err)
+		}
+		db.Close()
+
+		if kind := hasPreexistingDb(dir); kind != engine {
+			t.Fatalf("database engine mismatch: have %s, want %s", kind, engine)
+		}
+		other := dbPebble
+		if engine == dbPebble {
+			other = dbLeveldb
+		}
+		if _, err := Open(OpenOptions{Type: other, Directory: dir}); err == nil {
+			t.Fatalf("%s database opened as %s", engine, other)
+		}
+		db, err = Open(OpenOptions{Directory: dir})
+		if err != nil {
+			t.Fatalf("failed to reopen %s database: %v", engine, err)
+		}
+		if value, err := db.Get([]byte("key")); err != nil || string(value) != "value" {
+			t.Fatalf("%s database content mismatch: %q, %v", engine, value, err)
+		}
+		db.Close()
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/binary"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/metrics"
 )
 
@@ -84,6 +85,23 @@ var (
 	// transitionStatusKey tracks the eth2 transition status.
 	transitionStatusKey = []byte("eth2-transition")
 
+	// rewardStorageKey tracks the latest validator and covenant reward records.
+	rewardStorageKey = []byte("RewardStorage")
+
+	// rewardHeadKey tracks the block the journalled reward records belong to.
+	rewardHeadKey = []byte("RewardHead")
+
+	// supplyAuditKey tracks the result of the last audit of the tracked supply.
+	supplyAuditKey = []byte("SupplyAudit")
+
+	// traceIndexTailKey tracks the oldest block whose internal calls are kept by
+	// the trace index.
+	traceIndexTailKey = []byte("TraceIndexTail")
+
+	// persistentStateIDKey tracks the id of the latest state persisted by the
+	// path-based trie database.
+	persistentStateIDKey = []byte("LastStateID")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -99,6 +117,9 @@ var (
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 	skeletonHeaderPrefix  = []byte("S") // skeletonHeaderPrefix + num (uint64 big endian) -> header
+	TrieNodeAccountPrefix = []byte("A") // TrieNodeAccountPrefix + hexPath -> trie node
+	TrieNodeStoragePrefix = []byte("O") // TrieNodeStoragePrefix + accountHash + hexPath -> trie node
+	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
 
 	PreimagePrefix = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-")  // config prefix for the db
@@ -109,6 +130,9 @@ var (
 	// BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 	BloomBitsIndexPrefix = []byte("iB")
 
+	// TraceIndexPrefix is the data table of the internal call indexer to track its progress
+	TraceIndexPrefix = []byte("iT")
+
 	ChtPrefix           = []byte("chtRootV2-") // ChtPrefix + chtNum (uint64 big endian) -> trie root hash
 	ChtTablePrefix      = []byte("cht-")
 	ChtIndexTablePrefix = []byte("chtIndexV2-")
@@ -119,6 +143,15 @@ var (
 
 	CliqueSnapshotPrefix = []byte("clique-")
 
+	purgeHistoryPrefix     = []byte("purge-history-")     // purgeHistoryPrefix + index (uint64 big endian) -> purge result
+	blockRewardsPrefix     = []byte("block-rewards-")     // blockRewardsPrefix + num (uint64 big endian) + hash -> block rewards
+	rewardCheckpointPrefix = []byte("reward-checkpoint-") // rewardCheckpointPrefix + num (uint64 big endian) + hash -> reward records
+
+	internalCallsPrefix = []byte("internal-calls-") // internalCallsPrefix + num (uint64 big endian) + hash -> internal call summaries
+	supplyDeltaPrefix   = []byte("supply-delta-")   // supplyDeltaPrefix + num (uint64 big endian) + hash -> supply delta
+
+	stateHistoryPrefix = []byte("state-history-") // stateHistoryPrefix + id (uint64 big endian) -> reverse trie node diff
+
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
 )
@@ -236,3 +269,94 @@ func configKey(hash common.Hash) []byte {
 func genesisStateSpecKey(hash common.Hash) []byte {
 	return append(genesisPrefix, hash.Bytes()...)
 }
+
+// purgeHistoryKey = purgeHistoryPrefix + index (uint64 big endian)
+func purgeHistoryKey(index uint64) []byte {
+	return append(purgeHistoryPrefix, encodeBlockNumber(index)...)
+}
+
+// blockRewardsKey = blockRewardsPrefix + num (uint64 big endian) + hash
+func blockRewardsKey(number uint64, hash common.Hash) []byte {
+	return append(append(blockRewardsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// rewardCheckpointKey = rewardCheckpointPrefix + num (uint64 big endian) + hash
+func rewardCheckpointKey(number uint64, hash common.Hash) []byte {
+	return append(append(rewardCheckpointPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// internalCallsKey = internalCallsPrefix + num (uint64 big endian) + hash
+func internalCallsKey(number uint64, hash common.Hash) []byte {
+	return append(append(internalCallsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// supplyDeltaKey = supplyDeltaPrefix + num (uint64 big endian) + hash
+func supplyDeltaKey(number uint64, hash common.Hash) []byte {
+	return append(append(supplyDeltaPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// accountTrieNodeKey = TrieNodeAccountPrefix + nodePath.
+func accountTrieNodeKey(path []byte) []byte {
+	return append(TrieNodeAccountPrefix, path...)
+}
+
+// storageTrieNodeKey = TrieNodeStoragePrefix + accountHash + nodePath.
+func storageTrieNodeKey(accountHash common.Hash, path []byte) []byte {
+	return append(append(TrieNodeStoragePrefix, accountHash.Bytes()...), path...)
+}
+
+// IsLegacyTrieNode reports whether a provided database entry is a legacy trie
+// node. The characteristics of legacy trie node are:
+// - the key length is equal to 32
+// - the key is the hash of val
+func IsLegacyTrieNode(key []byte, val []byte) bool {
+	if len(key) != common.HashLength {
+		return false
+	}
+	return bytes.Equal(key, crypto.Keccak256(val))
+}
+
+// IsAccountTrieNode reports whether a provided database entry is an account
+// trie node in path-based state scheme, if so return the node path as well.
+func IsAccountTrieNode(key []byte) (bool, []byte) {
+	if !bytes.HasPrefix(key, TrieNodeAccountPrefix) {
+		return false, nil
+	}
+	// The remaining key should only consist a hex node path
+	// whose length is in the range 0 to 64 (64 is excluded
+	// since leaves are always wrapped with shortNode).
+	if len(key) >= len(TrieNodeAccountPrefix)+common.HashLength*2 {
+		return false, nil
+	}
+	return true, key[len(TrieNodeAccountPrefix):]
+}
+
+// IsStorageTrieNode reports whether a provided database entry is a storage
+// trie node in path-based state scheme, if so return the owner and the node
+// path as well.
+func IsStorageTrieNode(key []byte) (bool, common.Hash, []byte) {
+	if !bytes.HasPrefix(key, TrieNodeStoragePrefix) {
+		return false, common.Hash{}, nil
+	}
+	// The remaining key consists of 2 parts:
+	// - 32 bytes account hash
+	// - hex node path whose length is in the range 0 to 64
+	if len(key) < len(TrieNodeStoragePrefix)+common.HashLength {
+		return false, common.Hash{}, nil
+	}
+	if len(key) >= len(TrieNodeStoragePrefix)+common.HashLength+common.HashLength*2 {
+		return false, common.Hash{}, nil
+	}
+	accountHash := common.BytesToHash(key[len(TrieNodeStoragePrefix) : len(TrieNodeStoragePrefix)+common.HashLength])
+	return true, accountHash, key[len(TrieNodeStoragePrefix)+common.HashLength:]
+}
+
+// stateIDKey = stateIDPrefix + root (32 bytes)
+func stateIDKey(root common.Hash) []byte {
+	return append(stateIDPrefix, root.Bytes()...)
+}
+
+// stateHistoryKey = stateHistoryPrefix + id (uint64 big endian)
+func stateHistoryKey(id uint64) []byte {
+	return append(stateHistoryPrefix, encodeBlockNumber(id)...)
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rewards
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// tierShares is the percentage of the covenant nfts ranked into each tier by a
+// purge. The rounding remainder is ranked into the lowest tier.
+var tierShares = [...]int{
+	types.Ascendance: 5,
+	types.Paladin:    10,
+	types.Templar:    20,
+	types.Cavalier:   25,
+}
+
+// Purge ranks the covenant nfts of the given records by the SCREE won since the
+// previous purge and moves every nft by at most one tier towards the tier of its
+// rank. The tiers of the records are updated in place and the moves returned.
+//
+// Nfts are ranked by the amount won, ties are broken in favour of the nft in the
+// higher tier and then of the lower token id. The tiers are filled from the top
+// in ranking order according to tierShares, so small sets of nfts might leave
+// the upper tiers empty.
+func Purge(storage *types.NodeRewardStorage) *types.PurgeHistoryResult {
+	records := storage.CovenantRecords
+
+	// Rank the nfts, best first
+	ranking := make([]int, len(records))
+	for i := range ranking {
+		ranking[i] = i
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		return outranks(&records[ranking[i]], &records[ranking[j]])
+	})
+	// Fill the tiers top-down and move every nft a step towards its target
+	var (
+		promoted [types.Ascendance + 1][]*types.CovenantNFTRewardRecord
+		demoted  [types.Ascendance + 1][]*types.CovenantNFTRewardRecord
+
+		target = types.Ascendance
+		filled int
+	)
+	for _, i := range ranking {
+		for target > types.Legionnaire && filled >= len(records)*tierShares[target]/100 {
+			target, filled = target-1, 0
+		}
+		filled++
+
+		record := &records[i]
+		switch {
+		case record.Tier < target:
+			promoted[record.Tier] = append(promoted[record.Tier], record)
+			record.Tier++
+		case record.Tier > target:
+			demoted[record.Tier] = append(demoted[record.Tier], record)
+			record.Tier--
+		}
+	}
+	// Promotions are listed best first, demotions worst first
+	list := func(moved []*types.CovenantNFTRewardRecord, best bool) []uint64 {
+		sort.Slice(moved, func(i, j int) bool {
+			if best {
+				return outranks(moved[i], moved[j])
+			}
+			return outranks(moved[j], moved[i])
+		})
+		ids := make([]uint64, len(moved))
+		for i, record := range moved {
+			ids[i] = record.TokenID
+		}
+		return ids
+	}
+	return &types.PurgeHistoryResult{
+		DemotedAscendance:   list(demoted[types.Ascendance], false),
+		PromotedPaladin:     list(promoted[types.Paladin], true),
+		DemotedPaladin:      list(demoted[types.Paladin], false),
+		PromotedTemplar:     list(promoted[types.Templar], true),
+		DemotedTemplar:      list(demoted[types.Templar], false),
+		PromotedCavalier:    list(promoted[types.Cavalier], true),
+		DemotedCavalier:     list(demoted[types.Cavalier], false),
+		PromotedLegionnaire: list(promoted[types.Legionnaire], true),
+	}
+}
+
+// outranks reports whether nft a ranks before nft b in a purge, comparing their
+// tiers prior to the purge. The moved nfts are only compared against the ones
+// moved out of the same tier, so ordering them after the move is equivalent.
+func outranks(a, b *types.CovenantNFTRewardRecord) bool {
+	if cmp := a.AmountEarnedSinceLastPurge.Cmp(b.AmountEarnedSinceLastPurge); cmp != 0 {
+		return cmp > 0
+	}
+	if a.Tier != b.Tier {
+		return a.Tier > b.Tier
+	}
+	return a.TokenID < b.TokenID
+}
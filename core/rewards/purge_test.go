@@ -0,0 +1,95 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rewards
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// purgeNFT is a covenant nft ranked by a purge test.
+type purgeNFT struct {
+	TokenID uint64                `json:"tokenID"`
+	Tier    string                `json:"tier"`
+	Earned  *math.HexOrDecimal256 `json:"earned"`
+}
+
+// purgeTest defines a single purge to check against the expected moves.
+type purgeTest struct {
+	Description string                    `json:"description"`
+	NFTs        []purgeNFT                `json:"nfts"`
+	Result      *types.PurgeHistoryResult `json:"result"`
+	Tiers       map[uint64]string         `json:"tiers"`
+}
+
+// Iterates over all the input-output datasets in the purge test harness and
+// runs the purge engine against them.
+func TestPurge(t *testing.T) {
+	files, err := os.ReadDir(filepath.Join("testdata", "purge"))
+	if err != nil {
+		t.Fatalf("failed to retrieve purge tests: %v", err)
+	}
+	tiers := make(map[string]types.CovenantTier)
+	for tier := types.Legionnaire; tier <= types.Ascendance; tier++ {
+		tiers[tier.String()] = tier
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(strings.TrimSuffix(file.Name(), ".json"), func(t *testing.T) {
+			t.Parallel()
+
+			blob, err := os.ReadFile(filepath.Join("testdata", "purge", file.Name()))
+			if err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			}
+			test := new(purgeTest)
+			if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			storage := new(types.NodeRewardStorage)
+			for _, nft := range test.NFTs {
+				tier, ok := tiers[nft.Tier]
+				if !ok {
+					t.Fatalf("unknown tier %q", nft.Tier)
+				}
+				storage.Credit(&types.BlockReward{Type: types.CovenantReward, TokenID: nft.TokenID, Reward: (*big.Int)(nft.Earned)})
+				storage.CovenantRecord(nft.TokenID).Tier = tier
+			}
+			if have := Purge(storage); !reflect.DeepEqual(have, test.Result) {
+				t.Errorf("result mismatch:\nhave %v\nwant %v", have, test.Result)
+			}
+			have := make(map[uint64]string)
+			for _, record := range storage.CovenantRecords {
+				have[record.TokenID] = record.Tier.String()
+			}
+			if !reflect.DeepEqual(have, test.Tiers) {
+				t.Errorf("tiers mismatch:\nhave %v\nwant %v", have, test.Tiers)
+			}
+		})
+	}
+}
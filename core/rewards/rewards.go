@@ -0,0 +1,534 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package rewards implements the persistent accounting of the rewards credited
+// to validators and covenant nfts by the canonical chain.
+package rewards
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// DayLength is the length of a reward accounting day in seconds. Days are
+	// counted from the genesis timestamp.
+	DayLength = 24 * 60 * 60
+
+	// CheckpointInterval is the number of blocks between two persisted copies
+	// of the reward records. Rolling the records back replays at most this
+	// many blocks on top of the closest checkpoint.
+	CheckpointInterval = 1024
+)
+
+var (
+	// errMissingBlock is returned if a canonical block needed to account the
+	// rewards is not available in the database.
+	errMissingBlock = errors.New("missing block")
+
+	// errMissingReceipts is returned if the receipts of a canonical block needed
+	// to account the transaction fees are not available in the database.
+	errMissingReceipts = errors.New("missing receipts")
+
+	// errClosed is returned if the accounting is aborted by closing the store.
+	errClosed = errors.New("reward store closed")
+
+	// ErrStalled is returned if the reward records can't be moved past a
+	// canonical block whose body or receipts are missing. The block is retried
+	// after a rebuild, a restart, or once it isn't canonical anymore.
+	ErrStalled = errors.New("reward accounting stalled")
+)
+
+// Store maintains the reward records of the canonical chain. The records of the
+// current head are kept in memory, while the rewards credited by every block and
+// periodic checkpoints of the records are persisted, so the records can be moved
+// to any canonical block after a reorg, a rewind or a restart.
+type Store struct {
+	db      ethdb.Database
+	config  *params.ChainConfig
+	engine  consensus.Engine
+	genesis *types.Header
+
+	head    *types.Header            // Block the reward records are current at
+	storage *types.NodeRewardStorage // Reward records as of the head block
+	lock    sync.RWMutex
+
+	readonly  bool   // Flag whether to account the records without persisting anything
+	recompute uint64 // First block whose persisted rewards are ignored, 0 if none
+	closed    int32  // Flag whether the store was closed, aborting any accounting
+
+	failed *failure // Canonical block the accounting failed on, nil if none
+
+	notify      uint64        // First block whose accounting is announced to subscribers
+	events      []interface{} // Events queued while holding the lock
+	rewardsFeed event.Feed
+	purgeFeed   event.Feed
+	scope       event.SubscriptionScope
+}
+
+// RewardsEvent is posted when the rewards credited by a canonical block have been
+// accounted. Blocks which are accounted again after a reorg are only announced
+// if they were not part of the canonical chain before.
+type RewardsEvent struct {
+	Header  *types.Header
+	Rewards []*types.BlockReward
+}
+
+// PurgeEvent is posted when a purge is run on the closing of a purge period.
+type PurgeEvent struct {
+	Header *types.Header // First block after the closed purge period
+	Index  uint64
+	Result *types.PurgeHistoryResult
+}
+
+// failure is a canonical block whose rewards couldn't be accounted because its
+// body or receipts are missing.
+type failure struct {
+	number uint64
+	hash   common.Hash
+	err    error
+}
+
+// New loads the reward records journalled on the last shutdown. If the journal
+// is missing or unusable, the records start at the genesis block and are rebuilt
+// from the closest checkpoint by the first Sync.
+func New(db ethdb.Database, config *params.ChainConfig, engine consensus.Engine, genesis *types.Header) *Store {
+	s := &Store{
+		db:      db,
+		config:  config,
+		engine:  engine,
+		genesis: genesis,
+		head:    genesis,
+		storage: new(types.NodeRewardStorage),
+	}
+	if hash := rawdb.ReadRewardHead(db); hash != (common.Hash{}) {
+		var header *types.Header
+		if number := rawdb.ReadHeaderNumber(db, hash); number != nil {
+			header = rawdb.ReadHeader(db, hash, *number)
+		}
+		storage := rawdb.ReadNodeRewardStorage(db)
+		if header != nil && storage != nil {
+			s.head, s.storage = header, storage
+		} else {
+			log.Warn("Reward journal unusable, rebuilding", "hash", hash)
+		}
+	}
+	return s
+}
+
+// Replay recreates the reward records as of the given canonical block without
+// persisting anything, so it is usable on read only databases. If recompute is
+// non-zero, the rewards of the blocks starting from it are recomputed from the
+// block bodies and receipts instead of being read from the database, and the
+// checkpoints covering them are ignored.
+func Replay(db ethdb.Database, config *params.ChainConfig, engine consensus.Engine, genesis *types.Header, number uint64, recompute uint64) (*types.NodeRewardStorage, error) {
+	s := &Store{
+		db:        db,
+		config:    config,
+		engine:    engine,
+		genesis:   genesis,
+		head:      genesis,
+		storage:   new(types.NodeRewardStorage),
+		readonly:  true,
+		recompute: recompute,
+		notify:    math.MaxUint64,
+	}
+	if err := s.load(number); err != nil {
+		return nil, err
+	}
+	return s.storage, nil
+}
+
+// Head returns the block the reward records are current at.
+func (s *Store) Head() *types.Header {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.head
+}
+
+// Storage returns a copy of the reward records as of the head block.
+func (s *Store) Storage() *types.NodeRewardStorage {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.storage.Copy()
+}
+
+// PurgeResult returns the result of the purge with the given index, or nil if
+// the purge hasn't been run on the canonical chain yet.
+func (s *Store) PurgeResult(index uint64) *types.PurgeHistoryResult {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if index >= s.storage.Purges() {
+		return nil
+	}
+	return rawdb.ReadPurgeHistoryResult(s.db, index)
+}
+
+// SubscribeRewardsEvent registers a subscription of RewardsEvent.
+func (s *Store) SubscribeRewardsEvent(ch chan<- RewardsEvent) event.Subscription {
+	return s.scope.Track(s.rewardsFeed.Subscribe(ch))
+}
+
+// SubscribePurgeEvent registers a subscription of PurgeEvent.
+func (s *Store) SubscribePurgeEvent(ch chan<- PurgeEvent) event.Subscription {
+	return s.scope.Track(s.purgeFeed.Subscribe(ch))
+}
+
+// Sync moves the reward records to the given block, which must be part of the
+// canonical chain. Blocks of the records which are not part of the canonical
+// chain anymore are rolled back, missing canonical blocks are accounted.
+//
+// If the accounting failed on a canonical block at or below the given one
+// before, ErrStalled is returned without retrying it.
+func (s *Store) Sync(head *types.Header) error {
+	s.lock.Lock()
+	defer s.unlock()
+
+	return s.sync(head)
+}
+
+// Rebuild drops all rewards accounted after the given block and recomputes them
+// from the canonical chain up to the given head. It's meant to recover from
+// corrupted reward data, so everything after the closest checkpoint at or below
+// the block is recomputed from the block bodies and receipts.
+func (s *Store) Rebuild(from uint64, head *types.Header) error {
+	s.lock.Lock()
+	defer s.unlock()
+
+	// The rebuilt records replace the announced ones, don't announce them again
+	s.notify = head.Number.Uint64() + 1
+	s.failed = nil
+
+	var (
+		start = from - from%CheckpointInterval
+		batch = s.db.NewBatch()
+	)
+	for number := start + 1; number <= head.Number.Uint64(); number++ {
+		hash := rawdb.ReadCanonicalHash(s.db, number)
+		rawdb.DeleteBlockRewards(batch, hash, number)
+		if number%CheckpointInterval == 0 {
+			rawdb.DeleteRewardCheckpoint(batch, hash, number)
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Rebuilding reward records", "from", start, "to", head.Number)
+	return s.load(head.Number.Uint64())
+}
+
+// Close aborts any running accounting. The records stay consistent with the
+// last accounted block, so they can still be journalled.
+func (s *Store) Close() {
+	atomic.StoreInt32(&s.closed, 1)
+}
+
+// unlock releases the write lock and announces the events queued while holding
+// it, so slow subscribers don't block readers of the records.
+func (s *Store) unlock() {
+	events := s.events
+	s.events = nil
+	s.lock.Unlock()
+
+	for _, ev := range events {
+		switch ev := ev.(type) {
+		case RewardsEvent:
+			s.rewardsFeed.Send(ev)
+		case PurgeEvent:
+			s.purgeFeed.Send(ev)
+		}
+	}
+}
+
+// Journal persists the reward records of the head block, so they can be reused
+// on the next startup without replaying any blocks.
+func (s *Store) Journal() {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	batch := s.db.NewBatch()
+	rawdb.WriteNodeRewardStorage(batch, s.storage)
+	rawdb.WriteRewardHead(batch, s.head.Hash())
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to journal reward records", "err", err)
+	}
+}
+
+// sync moves the reward records to the given canonical block.
+//
+// Note, this function assumes that the lock is held!
+func (s *Store) sync(head *types.Header) error {
+	if head.Hash() == s.head.Hash() {
+		return nil
+	}
+	number := head.Number.Uint64()
+	if f := s.failed; f != nil {
+		if number >= f.number && rawdb.ReadCanonicalHash(s.db, f.number) == f.hash {
+			return fmt.Errorf("%w: %v", ErrStalled, f.err)
+		}
+		s.failed = nil
+	}
+	ancestor, ok := s.canonicalAncestor(number)
+
+	// Announce only the blocks which weren't canonical before. If the ancestry
+	// is unknown, announce the blocks above the previous head.
+	if ok {
+		s.notify = ancestor + 1
+	} else {
+		s.notify = s.head.Number.Uint64() + 1
+	}
+	if !ok || ancestor < s.head.Number.Uint64() || number-ancestor > CheckpointInterval {
+		return s.load(number)
+	}
+	return s.extend(ancestor+1, number)
+}
+
+// canonicalAncestor returns the number of the newest block of the records which
+// is still canonical, considering only blocks at or below the given number. The
+// flag is false if the ancestry of the records is not available anymore.
+func (s *Store) canonicalAncestor(limit uint64) (uint64, bool) {
+	var (
+		hash   = s.head.Hash()
+		number = s.head.Number.Uint64()
+	)
+	for number > 0 && (number > limit || rawdb.ReadCanonicalHash(s.db, number) != hash) {
+		header := rawdb.ReadHeader(s.db, hash, number)
+		if header == nil {
+			return 0, false
+		}
+		hash, number = header.ParentHash, number-1
+	}
+	return number, true
+}
+
+// load recreates the reward records as of the given canonical block from the
+// closest checkpoint below it.
+func (s *Store) load(number uint64) error {
+	limit := number
+	if s.recompute > 0 && s.recompute <= number {
+		limit = s.recompute - 1
+	}
+	var (
+		start   = limit - limit%CheckpointInterval
+		head    = s.genesis
+		storage *types.NodeRewardStorage
+	)
+	for ; start > 0; start -= CheckpointInterval {
+		hash := rawdb.ReadCanonicalHash(s.db, start)
+		if storage = rawdb.ReadRewardCheckpoint(s.db, hash, start); storage != nil {
+			if head = rawdb.ReadHeader(s.db, hash, start); head != nil {
+				break
+			}
+		}
+	}
+	if start == 0 {
+		head, storage = s.genesis, new(types.NodeRewardStorage)
+	}
+	s.head, s.storage = head, storage
+	return s.extend(start+1, number)
+}
+
+// extend accounts the rewards of the given range of canonical blocks on top of
+// the current records.
+func (s *Store) extend(from, to uint64) error {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for number := from; number <= to; number++ {
+		if atomic.LoadInt32(&s.closed) == 1 {
+			return errClosed
+		}
+		hash := rawdb.ReadCanonicalHash(s.db, number)
+		header := rawdb.ReadHeader(s.db, hash, number)
+		if header == nil {
+			return s.fail(number, hash, fmt.Errorf("%w #%d [%x..]", errMissingBlock, number, hash[:4]))
+		}
+		if header.ParentHash != s.head.Hash() {
+			return fmt.Errorf("non contiguous reward accounting: #%d [%x..] is not a child of #%d [%x..]", number, hash[:4], s.head.Number, s.head.Hash().Bytes()[:4])
+		}
+		var rewards []*types.BlockReward
+		if s.stored(number) && rawdb.HasBlockRewards(s.db, hash, number) {
+			rewards = rawdb.ReadBlockRewards(s.db, hash, number)
+		} else {
+			block := rawdb.ReadBlock(s.db, hash, number)
+			if block == nil {
+				return s.fail(number, hash, fmt.Errorf("%w #%d [%x..]", errMissingBlock, number, hash[:4]))
+			}
+			var err error
+			if rewards, err = s.blockRewards(block); err != nil {
+				return s.fail(number, hash, err)
+			}
+		}
+		s.apply(header, rewards)
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Accounting block rewards", "number", number, "target", to, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	return nil
+}
+
+// fail records the canonical block the accounting failed on, so it isn't
+// retried on every sync. Replays don't record failures, they are one-off.
+//
+// Note, this function assumes that the lock is held!
+func (s *Store) fail(number uint64, hash common.Hash, err error) error {
+	if !s.readonly {
+		s.failed = &failure{number: number, hash: hash, err: err}
+	}
+	return err
+}
+
+// stored reports whether the persisted rewards of the given block can be used.
+func (s *Store) stored(number uint64) bool {
+	return s.recompute == 0 || number < s.recompute
+}
+
+// blockRewards returns the rewards credited by the given block, computing and
+// persisting them if they were not accounted before.
+func (s *Store) blockRewards(block *types.Block) ([]*types.BlockReward, error) {
+	hash, number := block.Hash(), block.NumberU64()
+	if s.stored(number) && rawdb.HasBlockRewards(s.db, hash, number) {
+		return rawdb.ReadBlockRewards(s.db, hash, number), nil
+	}
+	fees, err := s.blockFees(block)
+	if err != nil {
+		return nil, err
+	}
+	rewards := CalcBlockRewards(s.config, s.engine, block.Header(), block.Uncles(), fees)
+	if !s.readonly {
+		rawdb.WriteBlockRewards(s.db, hash, number, rewards)
+	}
+	return rewards, nil
+}
+
+// blockFees returns the total of the transaction fees paid to the producer of
+// the given block.
+func (s *Store) blockFees(block *types.Block) (*big.Int, error) {
+	fees := new(big.Int)
+	if len(block.Transactions()) == 0 {
+		return fees, nil
+	}
+	receipts := rawdb.ReadRawReceipts(s.db, block.Hash(), block.NumberU64())
+	if len(receipts) != len(block.Transactions()) {
+		return nil, fmt.Errorf("%w #%d [%x..]", errMissingReceipts, block.NumberU64(), block.Hash().Bytes()[:4])
+	}
+	return CalcBlockFees(block, receipts), nil
+}
+
+// apply books the rewards of the given block into the records, closing all days
+// which passed since the previous block and running the purges due on them.
+// Purge results are keyed by the purge index, so replaying a purge after a
+// reorg overwrites the stale result.
+//
+// Note, this function assumes that the lock is held!
+func (s *Store) apply(header *types.Header, rewards []*types.BlockReward) {
+	notify := header.Number.Uint64() >= s.notify && s.scope.Count() > 0
+
+	for day := s.day(header); s.storage.Day < day; {
+		if s.storage.IsPurgeDay() {
+			index, result := s.storage.Purges(), Purge(s.storage)
+			if !s.readonly {
+				rawdb.WritePurgeHistoryResult(s.db, index, result)
+			}
+			if notify {
+				s.events = append(s.events, PurgeEvent{Header: header, Index: index, Result: result})
+			}
+		}
+		s.storage.CloseDay()
+	}
+	for _, reward := range rewards {
+		s.storage.Credit(reward)
+	}
+	s.head = header
+
+	if notify {
+		s.events = append(s.events, RewardsEvent{Header: header, Rewards: rewards})
+	}
+	if number := header.Number.Uint64(); number%CheckpointInterval == 0 && !s.readonly {
+		rawdb.WriteRewardCheckpoint(s.db, header.Hash(), number, s.storage)
+	}
+}
+
+// day returns the accounting day the given block belongs to.
+func (s *Store) day(header *types.Header) uint64 {
+	if header.Time <= s.genesis.Time {
+		return 0
+	}
+	return (header.Time - s.genesis.Time) / DayLength
+}
+
+// CalcBlockFees returns the total of the transaction fees paid to the producer
+// of the given block, derived from the gas used by each of its receipts.
+func CalcBlockFees(block *types.Block, receipts types.Receipts) *big.Int {
+	var (
+		fees    = new(big.Int)
+		gasUsed = new(big.Int)
+		prevGas uint64
+	)
+	for i, tx := range block.Transactions() {
+		gasUsed.SetUint64(receipts[i].CumulativeGasUsed - prevGas)
+		prevGas = receipts[i].CumulativeGasUsed
+
+		fees.Add(fees, gasUsed.Mul(gasUsed, tx.EffectiveGasTipValue(block.BaseFee())))
+	}
+	return fees
+}
+
+// CalcBlockRewards returns the rewards credited by the given block. Engines not
+// implementing consensus.Rewarder don't issue rewards, the fees are credited to
+// the block's author.
+func CalcBlockRewards(config *params.ChainConfig, engine consensus.Engine, header *types.Header, uncles []*types.Header, fees *big.Int) []*types.BlockReward {
+	if rewarder, ok := engine.(consensus.Rewarder); ok {
+		return rewarder.BlockRewards(config, header, uncles, fees)
+	}
+	author, err := engine.Author(header)
+	if err != nil {
+		log.Warn("Failed to retrieve block author", "number", header.Number, "hash", header.Hash(), "err", err)
+		return nil
+	}
+	return []*types.BlockReward{{
+		Type:    types.ValidatorReward,
+		Address: author,
+		Reward:  new(big.Int),
+		Fee:     fees,
+	}}
+}
@@ -20,6 +20,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
 )
 
 // journalEntry is a modification entry in the state change journal that can be
@@ -169,6 +170,9 @@ func (ch suicideChange) revert(s *StateDB) {
 	obj := s.getStateObject(*ch.account)
 	if obj != nil {
 		obj.suicided = ch.prev
+		if s.logger != nil && ch.prevbalance.Sign() > 0 {
+			s.logger.OnBalanceChange(*ch.account, obj.Balance(), ch.prevbalance, tracing.BalanceChangeRevert)
+		}
 		obj.setBalance(ch.prevbalance)
 	}
 }
@@ -187,7 +191,11 @@ func (ch touchChange) dirtied() *common.Address {
 }
 
 func (ch balanceChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setBalance(ch.prev)
+	obj := s.getStateObject(*ch.account)
+	if s.logger != nil {
+		s.logger.OnBalanceChange(*ch.account, obj.Balance(), ch.prev, tracing.BalanceChangeRevert)
+	}
+	obj.setBalance(ch.prev)
 }
 
 func (ch balanceChange) dirtied() *common.Address {
@@ -195,7 +203,11 @@ func (ch balanceChange) dirtied() *common.Address {
 }
 
 func (ch nonceChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setNonce(ch.prev)
+	obj := s.getStateObject(*ch.account)
+	if s.logger != nil {
+		s.logger.OnNonceChange(*ch.account, obj.Nonce(), ch.prev)
+	}
+	obj.setNonce(ch.prev)
 }
 
 func (ch nonceChange) dirtied() *common.Address {
@@ -203,7 +215,11 @@ func (ch nonceChange) dirtied() *common.Address {
 }
 
 func (ch codeChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setCode(common.BytesToHash(ch.prevhash), ch.prevcode)
+	obj := s.getStateObject(*ch.account)
+	if s.logger != nil {
+		s.logger.OnCodeChange(*ch.account, common.BytesToHash(obj.CodeHash()), obj.code, common.BytesToHash(ch.prevhash), ch.prevcode)
+	}
+	obj.setCode(common.BytesToHash(ch.prevhash), ch.prevcode)
 }
 
 func (ch codeChange) dirtied() *common.Address {
@@ -211,7 +227,11 @@ func (ch codeChange) dirtied() *common.Address {
 }
 
 func (ch storageChange) revert(s *StateDB) {
-	s.getStateObject(*ch.account).setState(ch.key, ch.prevalue)
+	obj := s.getStateObject(*ch.account)
+	if s.logger != nil {
+		s.logger.OnStorageChange(*ch.account, ch.key, obj.GetState(s.db, ch.key), ch.prevalue)
+	}
+	obj.setState(ch.key, ch.prevalue)
 }
 
 func (ch storageChange) dirtied() *common.Address {
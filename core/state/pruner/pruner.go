@@ -91,6 +91,11 @@ type Pruner struct {
 
 // NewPruner creates the pruner instance.
 func NewPruner(db ethdb.Database, config Config) (*Pruner, error) {
+	// The path scheme overwrites the stale states in place, there's nothing
+	// left to prune.
+	if trie.ReadStateScheme(db) == trie.PathScheme {
+		return nil, errors.New("state pruning is not supported by the path-based state scheme")
+	}
 	headBlock := rawdb.ReadHeadBlock(db)
 	if headBlock == nil {
 		return nil, errors.New("failed to load head block")
@@ -369,7 +369,7 @@ func (dl *diskLayer) generateRange(ctx *generatorContext, trieId *trie.ID, prefi
 		}
 		root, nodes, _ := snapTrie.Commit(false)
 		if nodes != nil {
-			snapTrieDb.Update(trie.NewWithNodeSet(nodes))
+			snapTrieDb.Update(root, emptyRoot, trie.NewWithNodeSet(nodes))
 		}
 		snapTrieDb.Commit(root, false, nil)
 	}
@@ -202,7 +202,7 @@ func (t *testHelper) Commit() common.Hash {
 	if nodes != nil {
 		t.nodes.Merge(nodes)
 	}
-	t.triedb.Update(t.nodes)
+	t.triedb.Update(root, emptyRoot, t.nodes)
 	t.triedb.Commit(root, false, nil)
 	return root
 }
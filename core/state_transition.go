@@ -23,6 +23,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	cmath "github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -82,6 +84,9 @@ type Message interface {
 	IsFake() bool
 	Data() []byte
 	AccessList() types.AccessList
+
+	BlobGasFeeCap() *big.Int
+	BlobHashes() []common.Hash
 }
 
 // ExecutionResult includes all output after executing given evm
@@ -194,15 +199,34 @@ func (st *StateTransition) to() common.Address {
 	return *st.msg.To()
 }
 
+// dataGasUsed returns the amount of data gas consumed by the blobs of the message.
+func (st *StateTransition) dataGasUsed() uint64 {
+	return uint64(len(st.msg.BlobHashes())) * params.BlobTxDataGasPerBlob
+}
+
 func (st *StateTransition) buyGas() error {
 	mgval := new(big.Int).SetUint64(st.msg.Gas())
 	mgval = mgval.Mul(mgval, st.gasPrice)
-	balanceCheck := mgval
+	balanceCheck := new(big.Int).Set(mgval)
 	if st.gasFeeCap != nil {
 		balanceCheck = new(big.Int).SetUint64(st.msg.Gas())
 		balanceCheck = balanceCheck.Mul(balanceCheck, st.gasFeeCap)
 		balanceCheck.Add(balanceCheck, st.value)
 	}
+	if dataGas := st.dataGasUsed(); dataGas > 0 {
+		// Check that the user has enough funds to cover dataGasUsed * tx.BlobGasFeeCap
+		blobBalanceCheck := new(big.Int).SetUint64(dataGas)
+		blobBalanceCheck.Mul(blobBalanceCheck, st.msg.BlobGasFeeCap())
+		balanceCheck.Add(balanceCheck, blobBalanceCheck)
+
+		// Pay for dataGasUsed * actual blob fee, unless fees are explicitly
+		// disabled (eth_call)
+		if !st.evm.Config.NoBaseFee || st.msg.BlobGasFeeCap().BitLen() > 0 {
+			blobFee := new(big.Int).SetUint64(dataGas)
+			blobFee.Mul(blobFee, misc.CalcBlobFee(*st.evm.Context.ExcessDataGas))
+			mgval.Add(mgval, blobFee)
+		}
+	}
 	if have, want := st.state.GetBalance(st.msg.From()), balanceCheck; have.Cmp(want) < 0 {
 		return fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, st.msg.From().Hex(), have, want)
 	}
@@ -212,7 +236,7 @@ func (st *StateTransition) buyGas() error {
 	st.gas += st.msg.Gas()
 
 	st.initialGas = st.msg.Gas()
-	st.state.SubBalance(st.msg.From(), mgval)
+	st.state.SubBalance(st.msg.From(), mgval, tracing.BalanceDecreaseGasBuy)
 	return nil
 }
 
@@ -261,6 +285,29 @@ func (st *StateTransition) preCheck() error {
 			}
 		}
 	}
+	// Make sure the blobs are well formed and the data gas fee cap covers the
+	// current data gas price (post cancun)
+	if blobFeeCap := st.msg.BlobGasFeeCap(); blobFeeCap != nil {
+		if st.evm.Context.ExcessDataGas == nil {
+			return fmt.Errorf("%w: address %v", ErrBlobTxUnsupported, st.msg.From().Hex())
+		}
+		blobHashes := st.msg.BlobHashes()
+		if len(blobHashes) == 0 {
+			return fmt.Errorf("%w: address %v", ErrMissingBlobHashes, st.msg.From().Hex())
+		}
+		for i, hash := range blobHashes {
+			if hash[0] != params.BlobTxHashVersion {
+				return fmt.Errorf("blob %d hash version mismatch (have %d, supported %d)", i, hash[0], params.BlobTxHashVersion)
+			}
+		}
+		// Skip the checks if the fee cap is zero and fees were explicitly disabled (eth_call)
+		if !st.evm.Config.NoBaseFee || blobFeeCap.BitLen() > 0 {
+			if blobFee := misc.CalcBlobFee(*st.evm.Context.ExcessDataGas); blobFeeCap.Cmp(blobFee) < 0 {
+				return fmt.Errorf("%w: address %v, maxFeePerDataGas: %s dataGasFee: %s", ErrBlobFeeCapTooLow,
+					st.msg.From().Hex(), blobFeeCap, blobFee)
+			}
+		}
+	}
 	return st.buyGas()
 }
 
@@ -300,7 +347,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	var (
 		msg              = st.msg
 		sender           = vm.AccountRef(msg.From())
-		rules            = st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber, st.evm.Context.Random != nil)
+		rules            = st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber, st.evm.Context.Random != nil, st.evm.Context.Time.Uint64())
 		contractCreation = msg.To() == nil
 	)
 
@@ -355,7 +402,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	} else {
 		fee := new(big.Int).SetUint64(st.gasUsed())
 		fee.Mul(fee, effectiveTip)
-		st.state.AddBalance(st.evm.Context.Coinbase, fee)
+		st.state.AddBalance(st.evm.Context.Coinbase, fee, tracing.BalanceIncreaseRewardTransactionFee)
 	}
 
 	return &ExecutionResult{
@@ -375,7 +422,7 @@ func (st *StateTransition) refundGas(refundQuotient uint64) {
 
 	// Return ETH for remaining gas, exchanged at the original rate.
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
-	st.state.AddBalance(st.msg.From(), remaining)
+	st.state.AddBalance(st.msg.From(), remaining, tracing.BalanceIncreaseGasReturn)
 
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
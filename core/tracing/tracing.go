@@ -0,0 +1,147 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracing defines the interfaces and types used to observe the changes
+// made to the state while the chain is being processed.
+package tracing
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BalanceChangeReason is used to indicate the reason for a balance change, useful
+// for tracing and reporting.
+type BalanceChangeReason byte
+
+const (
+	BalanceChangeUnspecified BalanceChangeReason = 0
+
+	// BalanceIncreaseRewardMineUncle is a reward for mining an uncle block.
+	BalanceIncreaseRewardMineUncle BalanceChangeReason = 1
+	// BalanceIncreaseRewardMineBlock is a reward for mining a block.
+	BalanceIncreaseRewardMineBlock BalanceChangeReason = 2
+	// BalanceIncreaseWithdrawal is ether withdrawn from the beacon chain.
+	BalanceIncreaseWithdrawal BalanceChangeReason = 3
+	// BalanceIncreaseGenesisBalance is ether allocated at the genesis block.
+	BalanceIncreaseGenesisBalance BalanceChangeReason = 4
+
+	// BalanceIncreaseRewardTransactionFee is the transaction tip increasing the
+	// coinbase balance.
+	BalanceIncreaseRewardTransactionFee BalanceChangeReason = 5
+	// BalanceDecreaseGasBuy is spent to purchase gas for execution of a
+	// transaction. Part of this gas will be burnt as per EIP-1559 rules.
+	BalanceDecreaseGasBuy BalanceChangeReason = 6
+	// BalanceIncreaseGasReturn is ether returned for unused gas at the end of
+	// execution.
+	BalanceIncreaseGasReturn BalanceChangeReason = 7
+
+	// BalanceIncreaseDaoContract is ether sent to the DAO refund contract.
+	BalanceIncreaseDaoContract BalanceChangeReason = 8
+	// BalanceDecreaseDaoAccount is ether taken from a DAO account to be moved
+	// to the refund contract.
+	BalanceDecreaseDaoAccount BalanceChangeReason = 9
+
+	// BalanceChangeTransfer is ether transferred via a call. It is a decrease
+	// for the sender and an increase for the recipient.
+	BalanceChangeTransfer BalanceChangeReason = 10
+	// BalanceChangeTouchAccount is a transfer of zero value. It is only there
+	// to touch-create an account.
+	BalanceChangeTouchAccount BalanceChangeReason = 11
+
+	// BalanceIncreaseSelfdestruct is added to the recipient as indicated by a
+	// selfdestructing account.
+	BalanceIncreaseSelfdestruct BalanceChangeReason = 12
+	// BalanceDecreaseSelfdestruct is deducted from a contract due to
+	// self-destruct.
+	BalanceDecreaseSelfdestruct BalanceChangeReason = 13
+	// BalanceDecreaseSelfdestructBurn is ether that is sent to an already
+	// self-destructed account within the same tx (captured at end of tx). Note
+	// it doesn't account for a self-destruct which appoints itself as
+	// recipient.
+	BalanceDecreaseSelfdestructBurn BalanceChangeReason = 14
+
+	// BalanceIncreaseRewardCovenant is a reward credited to the holder of a
+	// covenant nft. It is never reported as a state change, as covenant rewards
+	// are booked in the reward records of the nft instead of a balance, but is
+	// part of the balance change records of a block.
+	BalanceIncreaseRewardCovenant BalanceChangeReason = 15
+	// BalanceChangeBaseFeeBurn is the base fee and data fee burnt by a
+	// transaction. It is never reported as a state change, as the burnt ether
+	// was already deducted from the sender by the gas purchase, but is part of
+	// the balance change records of a block.
+	BalanceChangeBaseFeeBurn BalanceChangeReason = 16
+	// BalanceChangeRevert is the undoing of an earlier change, made by a call
+	// frame which was reverted.
+	BalanceChangeRevert BalanceChangeReason = 17
+)
+
+var balanceChangeReasons = map[BalanceChangeReason]string{
+	BalanceChangeUnspecified:            "unspecified",
+	BalanceIncreaseRewardMineUncle:      "uncle_reward",
+	BalanceIncreaseRewardMineBlock:      "block_reward",
+	BalanceIncreaseWithdrawal:           "withdrawal",
+	BalanceIncreaseGenesisBalance:       "genesis_balance",
+	BalanceIncreaseRewardTransactionFee: "transaction_fee",
+	BalanceDecreaseGasBuy:               "gas_buy",
+	BalanceIncreaseGasReturn:            "gas_return",
+	BalanceIncreaseDaoContract:          "dao_contract",
+	BalanceDecreaseDaoAccount:           "dao_account",
+	BalanceChangeTransfer:               "transfer",
+	BalanceChangeTouchAccount:           "touch_account",
+	BalanceIncreaseSelfdestruct:         "selfdestruct_increase",
+	BalanceDecreaseSelfdestruct:         "selfdestruct_decrease",
+	BalanceDecreaseSelfdestructBurn:     "selfdestruct_burn",
+	BalanceIncreaseRewardCovenant:       "covenant_reward",
+	BalanceChangeBaseFeeBurn:            "base_fee_burn",
+	BalanceChangeRevert:                 "revert",
+}
+
+// String implements fmt.Stringer.
+func (r BalanceChangeReason) String() string {
+	if name, ok := balanceChangeReasons[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// BalanceChange is a modification of an account balance, attributed to the
+// reason it was made for. Ether burnt by the base fee is recorded against the
+// zero address, and covenant rewards against the zero address and the rewarded
+// nft, without the change being reflected in the state.
+type BalanceChange struct {
+	TxIndex int                 // Index of the transaction making the change, -1 for block-level changes
+	Address common.Address      // Account whose balance changed
+	TokenID uint64              // Covenant nft credited by a covenant reward
+	Delta   *big.Int            // Signed amount the balance changed by
+	Reason  BalanceChangeReason // Reason of the change
+}
+
+// StateLogger is notified of every modification made to the state. The calls
+// are made as the changes happen, and changes reverted by a failing call frame
+// are undone by reporting the inverse change (apart from logs, which are
+// discarded along with the failing transaction).
+// Note that reference types are actual state data; make copies if you need to
+// retain them beyond the current call.
+type StateLogger interface {
+	OnBalanceChange(addr common.Address, prev, new *big.Int, reason BalanceChangeReason)
+	OnNonceChange(addr common.Address, prev, new uint64)
+	OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte)
+	OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash)
+	OnLog(log *types.Log)
+}
@@ -0,0 +1,928 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package blobpool implements the EIP-4844 blob transaction pool.
+package blobpool
+
+import (
+	"container/heap"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// maxBlobsPerTransaction is the maximum number of blobs a single transaction
+	// is allowed to contain. Whilst the spec states it's unlimited, the block
+	// data slots are protocol bound, which implicitly also limit this.
+	maxBlobsPerTransaction = params.BlobTxMaxDataGasPerBlock / params.BlobTxDataGasPerBlob
+
+	// txMaxSize is the maximum size a single transaction can have, outside
+	// the included blobs. Since blob transactions are pulled instead of pushed,
+	// and only a small metadata is kept in ram, the rest is on disk, there is
+	// no critical limit that should be enforced. Still, capping it to some sane
+	// limit can never hurt.
+	txMaxSize = 1024 * 1024
+
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// databaseCache and databaseHandles are the resource allowances of the
+	// persistent blob store. Blobs are written once and read rarely, so there
+	// is no point in wasting memory on caching.
+	databaseCache   = 16
+	databaseHandles = 16
+)
+
+var (
+	// errMissingSidecar is returned if a blob transaction is submitted to the
+	// pool without its blobs, commitments and proofs.
+	errMissingSidecar = errors.New("missing blob sidecar")
+
+	// errNonceGapped is returned if a blob transaction would leave a gap in the
+	// account's nonce sequence. Blob transactions can't be queued.
+	errNonceGapped = errors.New("nonce gapped")
+)
+
+var (
+	// Metrics for the blob pool contents
+	storedGauge = metrics.NewRegisteredGauge("blobpool/stored", nil) // Bytes of blob transactions stored on disk
+	txsGauge    = metrics.NewRegisteredGauge("blobpool/txs", nil)    // Number of blob transactions tracked
+
+	// Metrics for the transaction admission and eviction
+	validTxMeter    = metrics.NewRegisteredMeter("blobpool/valid", nil)
+	invalidTxMeter  = metrics.NewRegisteredMeter("blobpool/invalid", nil)
+	replaceTxMeter  = metrics.NewRegisteredMeter("blobpool/replace", nil)
+	evictionMeter   = metrics.NewRegisteredMeter("blobpool/eviction", nil)    // Dropped due to storage cap
+	underpriceMeter = metrics.NewRegisteredMeter("blobpool/underpriced", nil) // Dropped due to minimum tip changes
+	staleMeter      = metrics.NewRegisteredMeter("blobpool/stale", nil)       // Dropped due to inclusion or lost funds
+)
+
+// blockChain defines the minimal set of methods needed to back a blob pool with
+// a chain. Exists to allow mocking the live chain out of tests.
+type blockChain interface {
+	CurrentBlock() *types.Block
+	StateAt(root common.Hash) (*state.StateDB, error)
+
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// blobTxMeta is the minimal subset of types.BlobTx necessary to validate and
+// schedule the blob transactions into the following blocks. Only ever add the
+// bare minimum needed fields to keep the size down (and thus number of entries
+// larger with the same memory consumption).
+type blobTxMeta struct {
+	tx   *types.Transaction // Transaction stripped of its sidecar, to serve pending queries
+	hash common.Hash        // Transaction hash to maintain the lookup table
+	size uint64             // Byte size of the full transaction, sidecar included
+
+	nonce      uint64   // Needed to prioritize inclusion order within an account
+	costCap    *big.Int // Needed to validate cumulative balance sufficiency
+	execTipCap *big.Int // Needed to prioritize inclusion order across accounts and validate replacement price bump
+	execFeeCap *big.Int // Needed to validate replacement price bump
+	blobFeeCap *big.Int // Needed to validate replacement price bump
+
+	basefeeJumps float64 // Absolute number of 1559 fee adjustments needed to reach the tx's fee cap
+	blobfeeJumps float64 // Absolute number of 4844 fee adjustments needed to reach the tx's blob fee cap
+
+	evictionExecTip      *big.Int // Worst gas tip across all previous nonces
+	evictionExecFeeJumps float64  // Worst base fee (converted to fee jumps) across all previous nonces
+	evictionBlobFeeJumps float64  // Worst blob fee (converted to fee jumps) across all previous nonces
+}
+
+// newBlobTxMeta retrieves the indexed metadata fields from a blob transaction
+// and assembles a helper struct to track in memory.
+func newBlobTxMeta(tx *types.Transaction, size uint64) *blobTxMeta {
+	meta := &blobTxMeta{
+		tx:         tx.WithoutBlobTxSidecar(),
+		hash:       tx.Hash(),
+		size:       size,
+		nonce:      tx.Nonce(),
+		costCap:    tx.Cost(),
+		execTipCap: tx.GasTipCap(),
+		execFeeCap: tx.GasFeeCap(),
+		blobFeeCap: tx.BlobGasFeeCap(),
+	}
+	meta.basefeeJumps = dynamicFeeJumps(meta.execFeeCap)
+	meta.blobfeeJumps = dynamicFeeJumps(meta.blobFeeCap)
+
+	return meta
+}
+
+// BlobPool is the transaction pool dedicated to EIP-4844 blob transactions.
+//
+// Blob transactions are special snowflakes that are designed for a very specific
+// purpose (rollups) and are expected to adhere to that specific use case. These
+// behavioural expectations allow us to design a transaction pool that is more
+// robust (i.e. resending issues) and more resilient to DoS attacks (e.g. replace
+// spam) than the generic transaction pool. These are the differences:
+//
+//   - Blob transactions are large. The pool persists them into a dedicated disk
+//     store, keeping only a small metadata index in memory. The store is capped
+//     to a configured size and survives node restarts.
+//
+//   - Blob transactions are pulled, not pushed. The pool never broadcasts them,
+//     only announces their hashes, leaving it to the remote side to fetch them.
+//
+//   - Nonce gaps are not allowed. Blob transactions are meant for rollups that
+//     submit their data sequentially, so the pool has no queue at all: a new tx
+//     must either replace an existing nonce or extend the account's sequence.
+//
+//   - Replacements need a large price bump (100% by default) on all three fee
+//     caps, making replace spam expensive and keeping the pool stable.
+//
+// When the pool runs over its storage cap, it evicts from the account with the
+// worst bottleneck transaction. Fees are compared on a logarithmic scale: each
+// fee cap is converted into the number of 12.5% base fee (or blob fee) jumps it
+// can absorb, and the eviction priority of a transaction is log2 of the distance
+// between that and the current network fee. Capping positive priorities at zero
+// makes every includable transaction equal, ordered only by its tip, whereas
+// underwater ones get evicted by how many fee jumps away they are from being
+// includable. Since later nonces can't be included before earlier ones, each
+// transaction inherits the worst fees of all its predecessors, and evictions
+// always drop the highest nonce of the chosen account.
+type BlobPool struct {
+	config      Config                 // Pool configuration
+	chainconfig *params.ChainConfig    // Chain configuration to gate blob transactions on
+	chain       blockChain             // Chain object to access the state through
+	signer      types.Signer           // Transaction signer to use for sender recovery
+	store       ethdb.Database         // Persistent data store for the full blob transactions
+	reserve     txpool.AddressReserver // Account reservations shared with other pools, nil if none
+	filter      *txpool.Filter         // Admission policies shared with other pools, nil if none
+
+	head    *types.Header  // Current head of the chain
+	state   *state.StateDB // Current state at the head of the chain
+	gasTip  *big.Int       // Currently accepted minimum gas tip
+	basefee *big.Int       // Base fee of the next block to be included
+	blobfee *big.Int       // Blob fee of the next block to be included
+
+	index  map[common.Address][]*blobTxMeta // Blob transactions grouped by accounts, sorted by nonce
+	spent  map[common.Address]*big.Int      // Expenditure tracking for individual accounts
+	lookup map[common.Hash]common.Address   // Lookup table mapping transaction hashes to senders
+	evict  *evictHeap                       // Heap of cheapest accounts for eviction
+	stored uint64                           // Useful data size of all transactions on disk
+
+	txFeed event.Feed              // Event feed to send out new tx events on pool inclusion
+	scope  event.SubscriptionScope // Subscription scope to unsubscribe all on shutdown
+
+	chainHeadCh  chan core.ChainHeadEvent
+	chainHeadSub event.Subscription
+	wg           sync.WaitGroup // tracks loop
+
+	lock sync.RWMutex // Mutex protecting the pool during reorg handling
+}
+
+// New creates a new blob transaction pool to gather, sort and filter inbound
+// blob transactions from the network. Any transactions persisted by a previous
+// run are loaded back from disk. If reserve is non-nil, accounts are claimed
+// through it, so they are never tracked by another pool at the same time. If
+// filter is non-nil, submitted transactions must pass its admission policies.
+func New(config Config, chainconfig *params.ChainConfig, chain blockChain, reserve txpool.AddressReserver, filter *txpool.Filter) (*BlobPool, error) {
+	// Sanitize the input to ensure no vulnerable gas prices are set
+	config = (&config).sanitize()
+
+	// Open the persistent store, falling back to an ephemeral one if no data
+	// directory was configured
+	var (
+		store ethdb.Database
+		err   error
+	)
+	if config.Datadir == "" {
+		store = rawdb.NewMemoryDatabase()
+	} else {
+		store, err = rawdb.NewLevelDBDatabase(config.Datadir, databaseCache, databaseHandles, "eth/blobpool/", false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	head := chain.CurrentBlock().Header()
+	statedb, err := chain.StateAt(head.Root)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	pool := &BlobPool{
+		config:      config,
+		chainconfig: chainconfig,
+		chain:       chain,
+		signer:      types.LatestSigner(chainconfig),
+		store:       store,
+		reserve:     reserve,
+		filter:      filter,
+		head:        head,
+		state:       statedb,
+		gasTip:      new(big.Int),
+		index:       make(map[common.Address][]*blobTxMeta),
+		spent:       make(map[common.Address]*big.Int),
+		lookup:      make(map[common.Hash]common.Address),
+		chainHeadCh: make(chan core.ChainHeadEvent, chainHeadChanSize),
+	}
+	pool.basefee, pool.blobfee = pool.pendingFees(head)
+
+	// Index all transactions on disk and delete anything unprocessable
+	var fails []common.Hash
+
+	it := store.NewIterator(nil, nil)
+	for it.Next() {
+		if err := pool.parseTransaction(it.Key(), it.Value()); err != nil {
+			log.Warn("Dropping unparsable blob transaction", "key", common.BytesToHash(it.Key()), "err", err)
+			fails = append(fails, common.BytesToHash(it.Key()))
+		}
+	}
+	it.Release()
+
+	for _, hash := range fails {
+		if err := store.Delete(hash[:]); err != nil {
+			log.Error("Failed to delete unparsable blob transaction", "hash", hash, "err", err)
+		}
+	}
+	// Claim the accounts of the loaded transactions, dropping the ones already
+	// owned by another pool
+	for addr, txs := range pool.index {
+		if err := pool.reserveAccount(addr, true); err != nil {
+			log.Warn("Dropping blob transactions of reserved account", "address", addr, "txs", len(txs), "err", err)
+			pool.forget(txs)
+			delete(pool.index, addr)
+		}
+	}
+	// Sort the indexed transactions by nonce and delete anything gapped, stale
+	// or unaffordable by the current chain state
+	for addr := range pool.index {
+		pool.recheck(addr)
+	}
+	pool.evict = newPriceHeap(pool.basefee, pool.blobfee, pool.index)
+
+	// If the storage cap was lowered since the last run, drop the excess
+	for pool.stored > pool.config.Datacap {
+		pool.drop()
+	}
+	pool.updateGauges()
+
+	log.Info("Initialized blob transaction pool", "txs", len(pool.lookup), "stored", common.StorageSize(pool.stored), "datacap", common.StorageSize(pool.config.Datacap))
+
+	// Subscribe events from blockchain and start the main event loop.
+	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
+	pool.wg.Add(1)
+	go pool.loop()
+
+	return pool, nil
+}
+
+// parseTransaction is a callback method on pool creation that gets called for
+// each transaction on disk to create the in-memory metadata index.
+func (pool *BlobPool) parseTransaction(key []byte, blob []byte) error {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(blob); err != nil {
+		return err
+	}
+	if tx.Hash() != common.BytesToHash(key) {
+		return fmt.Errorf("hash mismatch: have %x, want %x", tx.Hash(), key)
+	}
+	if tx.BlobTxSidecar() == nil {
+		return errMissingSidecar
+	}
+	sender, err := types.Sender(pool.signer, tx)
+	if err != nil {
+		return err
+	}
+	pool.index[sender] = append(pool.index[sender], newBlobTxMeta(tx, uint64(len(blob))))
+	pool.lookup[tx.Hash()] = sender
+	pool.stored += uint64(len(blob))
+
+	return nil
+}
+
+// loop is the blob pool's main event loop, waiting for and reacting to chain
+// head events to drop included and no longer executable transactions.
+func (pool *BlobPool) loop() {
+	defer pool.wg.Done()
+
+	for {
+		select {
+		case ev := <-pool.chainHeadCh:
+			if ev.Block != nil {
+				pool.lock.Lock()
+				pool.reset(ev.Block.Header())
+				pool.lock.Unlock()
+			}
+
+		// System shutdown.
+		case <-pool.chainHeadSub.Err():
+			return
+		}
+	}
+}
+
+// Stop terminates the blob transaction pool and closes the persistent store.
+func (pool *BlobPool) Stop() {
+	// Unsubscribe all subscriptions registered from the pool
+	pool.scope.Close()
+
+	// Unsubscribe subscriptions registered from blockchain
+	pool.chainHeadSub.Unsubscribe()
+	pool.wg.Wait()
+
+	if err := pool.store.Close(); err != nil {
+		log.Error("Failed to close blob transaction store", "err", err)
+	}
+	log.Info("Blob transaction pool stopped")
+}
+
+// pendingFees calculates the base fee and blob fee of the block following the
+// given head.
+func (pool *BlobPool) pendingFees(head *types.Header) (*big.Int, *big.Int) {
+	basefee := new(big.Int)
+	if pool.chainconfig.IsLondon(new(big.Int).Add(head.Number, common.Big1)) {
+		basefee = misc.CalcBaseFee(pool.chainconfig, head)
+	}
+	var excessDataGas uint64
+	if head.ExcessDataGas != nil {
+		excessDataGas = misc.CalcExcessDataGas(*head.ExcessDataGas, *head.DataGasUsed)
+	}
+	return basefee, misc.CalcBlobFee(excessDataGas)
+}
+
+// reset retrieves the current state of the blockchain and ensures the content
+// of the blob pool is valid with regard to the chain state.
+//
+// Note, blob transactions included in blocks reorged out of the chain are not
+// reinjected, since blocks don't carry the sidecars needed to serve them.
+func (pool *BlobPool) reset(head *types.Header) {
+	statedb, err := pool.chain.StateAt(head.Root)
+	if err != nil {
+		log.Error("Failed to reset blobpool state", "err", err)
+		return
+	}
+	pool.head, pool.state = head, statedb
+	pool.basefee, pool.blobfee = pool.pendingFees(head)
+
+	for addr := range pool.index {
+		pool.recheck(addr)
+	}
+	pool.evict = newPriceHeap(pool.basefee, pool.blobfee, pool.index)
+	pool.updateGauges()
+}
+
+// recheck verifies the pool's content for a specific account and drops anything
+// that does not fit anymore (dangling or filled nonce, overdraft).
+func (pool *BlobPool) recheck(addr common.Address) {
+	txs := pool.index[addr]
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].nonce < txs[j].nonce
+	})
+	// Drop all transactions already included in the chain
+	var (
+		next = pool.state.GetNonce(addr)
+		drop []*blobTxMeta
+	)
+	for len(txs) > 0 && txs[0].nonce < next {
+		drop = append(drop, txs[0])
+		txs = txs[1:]
+	}
+	// Drop everything after a nonce gap or duplicate. Neither can happen during
+	// normal operation, but a crash may leave the disk store in a weird state.
+	for i := range txs {
+		if txs[i].nonce != next+uint64(i) {
+			drop = append(drop, txs[i:]...)
+			txs = txs[:i]
+			break
+		}
+	}
+	// Drop everything the account can no longer pay for
+	var (
+		balance = pool.state.GetBalance(addr)
+		spent   = new(big.Int)
+	)
+	for i, meta := range txs {
+		if new(big.Int).Add(spent, meta.costCap).Cmp(balance) > 0 {
+			drop = append(drop, txs[i:]...)
+			txs = txs[:i]
+			break
+		}
+		spent.Add(spent, meta.costCap)
+	}
+	if len(drop) > 0 {
+		staleMeter.Mark(int64(len(drop)))
+		pool.forget(drop)
+	}
+	if len(txs) == 0 {
+		delete(pool.index, addr)
+		delete(pool.spent, addr)
+		pool.reserveAccount(addr, false)
+		return
+	}
+	pool.index[addr] = txs
+	pool.spent[addr] = spent
+	updateEvictionFields(txs, 0)
+}
+
+// updateEvictionFields recalculates the cumulative eviction fields of the given
+// nonce-sorted transactions, starting from the specified offset.
+func updateEvictionFields(txs []*blobTxMeta, from int) {
+	for i := from; i < len(txs); i++ {
+		if i == 0 {
+			txs[i].evictionExecTip = txs[i].execTipCap
+			txs[i].evictionExecFeeJumps = txs[i].basefeeJumps
+			txs[i].evictionBlobFeeJumps = txs[i].blobfeeJumps
+			continue
+		}
+		prev := txs[i-1]
+
+		txs[i].evictionExecTip = prev.evictionExecTip
+		if txs[i].execTipCap.Cmp(txs[i].evictionExecTip) < 0 {
+			txs[i].evictionExecTip = txs[i].execTipCap
+		}
+		txs[i].evictionExecFeeJumps = prev.evictionExecFeeJumps
+		if txs[i].basefeeJumps < txs[i].evictionExecFeeJumps {
+			txs[i].evictionExecFeeJumps = txs[i].basefeeJumps
+		}
+		txs[i].evictionBlobFeeJumps = prev.evictionBlobFeeJumps
+		if txs[i].blobfeeJumps < txs[i].evictionBlobFeeJumps {
+			txs[i].evictionBlobFeeJumps = txs[i].blobfeeJumps
+		}
+	}
+}
+
+// forget removes the given transactions from the persistent store and the
+// lookup table. The caller is responsible for updating the account index.
+func (pool *BlobPool) forget(metas []*blobTxMeta) {
+	for _, meta := range metas {
+		if err := pool.store.Delete(meta.hash[:]); err != nil {
+			log.Error("Failed to delete blob transaction", "hash", meta.hash, "err", err)
+		}
+		delete(pool.lookup, meta.hash)
+		pool.stored -= meta.size
+	}
+}
+
+// drop removes the highest nonce transaction of the account with the worst
+// bottleneck, used to keep the pool within its storage cap.
+func (pool *BlobPool) drop() {
+	var (
+		from = pool.evict.addrs[0]
+		txs  = pool.index[from]
+		last = txs[len(txs)-1]
+	)
+	txs[len(txs)-1] = nil
+	txs = txs[:len(txs)-1]
+
+	if len(txs) == 0 {
+		heap.Pop(pool.evict)
+		delete(pool.index, from)
+		delete(pool.spent, from)
+		pool.reserveAccount(from, false)
+	} else {
+		pool.index[from] = txs
+		pool.spent[from] = new(big.Int).Sub(pool.spent[from], last.costCap)
+		heap.Fix(pool.evict, 0)
+	}
+	pool.forget([]*blobTxMeta{last})
+	evictionMeter.Mark(1)
+
+	log.Trace("Evicted blob transaction", "from", from, "nonce", last.nonce, "hash", last.hash)
+}
+
+// validateSidecar checks that the blobs, commitments and proofs attached to a
+// blob transaction are consistent with its blob hashes and verify correctly.
+// This is the expensive, state independent part of the validation, so it is
+// done without holding the pool lock.
+func validateSidecar(tx *types.Transaction) error {
+	sidecar := tx.BlobTxSidecar()
+	if sidecar == nil {
+		return errMissingSidecar
+	}
+	hashes := tx.BlobHashes()
+	if len(hashes) == 0 {
+		return core.ErrMissingBlobHashes
+	}
+	if len(hashes) > maxBlobsPerTransaction {
+		return fmt.Errorf("too many blobs in transaction: have %d, permitted %d", len(hashes), maxBlobsPerTransaction)
+	}
+	if len(sidecar.Blobs) != len(hashes) {
+		return fmt.Errorf("invalid number of %d blobs compared to %d blob hashes", len(sidecar.Blobs), len(hashes))
+	}
+	if len(sidecar.Commitments) != len(hashes) {
+		return fmt.Errorf("invalid number of %d blob commitments compared to %d blob hashes", len(sidecar.Commitments), len(hashes))
+	}
+	if len(sidecar.Proofs) != len(hashes) {
+		return fmt.Errorf("invalid number of %d blob proofs compared to %d blob hashes", len(sidecar.Proofs), len(hashes))
+	}
+	// Blob quantities match up, validate that the provers match with the
+	// transaction hash before getting to the cryptography
+	hasher := sha256.New()
+	for i, want := range hashes {
+		if have := kzg4844.CalcBlobHashV1(hasher, &sidecar.Commitments[i]); have != want {
+			return fmt.Errorf("blob %d: computed hash %#x mismatches transaction one %#x", i, have, want)
+		}
+	}
+	// Blob commitments match with the hashes in the transaction, verify the
+	// blobs themselves via KZG
+	for i := range sidecar.Blobs {
+		if err := kzg4844.VerifyBlobProof(sidecar.Blobs[i], sidecar.Commitments[i], sidecar.Proofs[i]); err != nil {
+			return fmt.Errorf("invalid blob %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// validateTx checks whether a transaction is valid according to the consensus
+// rules and adheres to some heuristic limits of the local node (price, nonce
+// ordering and replacement rules). The sidecar is expected to be validated.
+func (pool *BlobPool) validateTx(tx *types.Transaction) (common.Address, error) {
+	// Accept blob transactions only after Cancun activates. The next block's
+	// timestamp is unknown, but it's at least one second after the head.
+	if !pool.chainconfig.IsCancun(pool.head.Time + 1) {
+		return common.Address{}, core.ErrTxTypeNotSupported
+	}
+	// Reject transactions over defined size to prevent DOS attacks
+	if tx.WithoutBlobTxSidecar().Size() > txMaxSize {
+		return common.Address{}, txpool.ErrOversizedData
+	}
+	// Transactions can't be negative. This may never happen using RLP decoded
+	// transactions but may occur if you create a transaction using the RPC.
+	if tx.Value().Sign() < 0 {
+		return common.Address{}, txpool.ErrNegativeValue
+	}
+	// Ensure the transaction doesn't exceed the current block limit gas.
+	if pool.head.GasLimit < tx.Gas() {
+		return common.Address{}, txpool.ErrGasLimit
+	}
+	// Sanity check for extremely large numbers
+	if tx.GasFeeCap().BitLen() > 256 || tx.BlobGasFeeCap().BitLen() > 256 {
+		return common.Address{}, core.ErrFeeCapVeryHigh
+	}
+	if tx.GasTipCap().BitLen() > 256 {
+		return common.Address{}, core.ErrTipVeryHigh
+	}
+	// Ensure gasFeeCap is greater than or equal to gasTipCap.
+	if tx.GasFeeCapIntCmp(tx.GasTipCap()) < 0 {
+		return common.Address{}, core.ErrTipAboveFeeCap
+	}
+	// Make sure the transaction is signed properly.
+	from, err := types.Sender(pool.signer, tx)
+	if err != nil {
+		return common.Address{}, txpool.ErrInvalidSender
+	}
+	// Drop transactions under our own minimal accepted gas tip or blob fee
+	if tx.GasTipCapIntCmp(pool.gasTip) < 0 {
+		return common.Address{}, txpool.ErrUnderpriced
+	}
+	if tx.BlobGasFeeCapIntCmp(big.NewInt(params.BlobTxMinDataGasprice)) < 0 {
+		return common.Address{}, txpool.ErrUnderpriced
+	}
+	// Ensure the transaction has more gas than the basic tx fee.
+	intrGas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), false, true, true)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if tx.Gas() < intrGas {
+		return common.Address{}, core.ErrIntrinsicGas
+	}
+	// Ensure the transaction adheres to nonce ordering without gaps
+	var (
+		next = pool.state.GetNonce(from)
+		txs  = pool.index[from]
+	)
+	if tx.Nonce() < next {
+		return common.Address{}, core.ErrNonceTooLow
+	}
+	if tx.Nonce() > next+uint64(len(txs)) {
+		return common.Address{}, fmt.Errorf("%w: tx nonce %d, account nonce %d, pooled %d", errNonceGapped, tx.Nonce(), next, len(txs))
+	}
+	// Transactor should have enough funds to cover the cumulative costs of all
+	// its pooled transactions, discounting the one being replaced
+	spent := new(big.Int)
+	if pool.spent[from] != nil {
+		spent.Set(pool.spent[from])
+	}
+	if offset := int(tx.Nonce() - next); offset < len(txs) {
+		// The transaction replaces an existing one, ensure the fee bumps are
+		// large enough on all fee components
+		prev := txs[offset]
+		if !bumped(prev.execTipCap, tx.GasTipCap(), pool.config.PriceBump) ||
+			!bumped(prev.execFeeCap, tx.GasFeeCap(), pool.config.PriceBump) ||
+			!bumped(prev.blobFeeCap, tx.BlobGasFeeCap(), pool.config.PriceBump) {
+			return common.Address{}, txpool.ErrReplaceUnderpriced
+		}
+		spent.Sub(spent, prev.costCap)
+	}
+	if spent.Add(spent, tx.Cost()).Cmp(pool.state.GetBalance(from)) > 0 {
+		return common.Address{}, core.ErrInsufficientFunds
+	}
+	return from, nil
+}
+
+// bumped checks whether the new fee is at least the configured percentage above
+// the old one.
+func bumped(oldFee, newFee *big.Int, bump uint64) bool {
+	threshold := new(big.Int).Mul(oldFee, big.NewInt(int64(100+bump)))
+	threshold.Div(threshold, big.NewInt(100))
+	return newFee.Cmp(threshold) >= 0
+}
+
+// Add inserts a set of blob transactions into the pool if they pass validation
+// (both consensus validity and pool restrictions). Successfully added ones are
+// announced via the new transaction event, stripped of their sidecars.
+func (pool *BlobPool) Add(txs []*types.Transaction) []error {
+	var (
+		errs = make([]error, len(txs))
+		adds = make([]*types.Transaction, 0, len(txs))
+	)
+	for i, tx := range txs {
+		errs[i] = pool.add(tx)
+		if errs[i] == nil {
+			adds = append(adds, tx.WithoutBlobTxSidecar())
+		}
+	}
+	if len(adds) > 0 {
+		pool.txFeed.Send(core.NewTxsEvent{Txs: adds})
+	}
+	return errs
+}
+
+// add validates a single blob transaction and inserts it into the pool.
+func (pool *BlobPool) add(tx *types.Transaction) error {
+	if tx.Type() != types.BlobTxType {
+		return core.ErrTxTypeNotSupported
+	}
+	if pool.Has(tx.Hash()) {
+		return txpool.ErrAlreadyKnown
+	}
+	// Validate the blobs outside the lock, since that's the expensive part
+	if err := validateSidecar(tx); err != nil {
+		log.Trace("Discarding invalid blob transaction", "hash", tx.Hash(), "err", err)
+		invalidTxMeter.Mark(1)
+		return err
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	// The transaction might have been added concurrently while verifying
+	hash := tx.Hash()
+	if _, ok := pool.lookup[hash]; ok {
+		return txpool.ErrAlreadyKnown
+	}
+	from, err := pool.validateTx(tx)
+	if err != nil {
+		log.Trace("Discarding invalid blob transaction", "hash", hash, "err", err)
+		invalidTxMeter.Mark(1)
+		return err
+	}
+	// Reject transactions violating the admission policies of the pools
+	if pool.filter != nil {
+		if err := pool.filter.Check(from, tx); err != nil {
+			log.Debug("Rejected blob transaction by admission filter", "hash", hash, "from", from, "err", err)
+			return err
+		}
+	}
+	// If the account is not yet tracked, claim it from the other pools until
+	// all its transactions left the pool
+	if len(pool.index[from]) == 0 {
+		if err := pool.reserveAccount(from, true); err != nil {
+			return err
+		}
+	}
+	// Transaction permitted into the pool, persist it to disk
+	blob, err := tx.MarshalBinary()
+	if err == nil {
+		err = pool.store.Put(hash[:], blob)
+	}
+	if err != nil {
+		if len(pool.index[from]) == 0 {
+			pool.reserveAccount(from, false)
+		}
+		return err
+	}
+	var (
+		meta   = newBlobTxMeta(tx, uint64(len(blob)))
+		txs    = pool.index[from]
+		offset = int(tx.Nonce() - pool.state.GetNonce(from))
+		spent  = new(big.Int)
+	)
+	if pool.spent[from] != nil {
+		spent.Set(pool.spent[from])
+	}
+	if offset < len(txs) {
+		prev := txs[offset]
+		spent.Sub(spent, prev.costCap)
+		pool.forget([]*blobTxMeta{prev})
+		txs[offset] = meta
+		replaceTxMeter.Mark(1)
+	} else {
+		txs = append(txs, meta)
+	}
+	pool.index[from] = txs
+	pool.spent[from] = spent.Add(spent, meta.costCap)
+	pool.lookup[hash] = from
+	pool.stored += meta.size
+
+	updateEvictionFields(txs, offset)
+	if idx, ok := pool.evict.index[from]; ok {
+		heap.Fix(pool.evict, idx)
+	} else {
+		heap.Push(pool.evict, from)
+	}
+	// If the pool went over its storage cap, evict the cheapest transactions
+	for pool.stored > pool.config.Datacap {
+		pool.drop()
+	}
+	pool.updateGauges()
+
+	if _, ok := pool.lookup[hash]; !ok {
+		return txpool.ErrTxPoolOverflow
+	}
+	if pool.filter != nil {
+		pool.filter.Admit(from)
+	}
+	validTxMeter.Mark(1)
+	log.Trace("Pooled new blob transaction", "hash", hash, "from", from, "nonce", tx.Nonce())
+	return nil
+}
+
+// Get returns a blob transaction, sidecar included, if it is contained in the
+// pool and nil otherwise.
+func (pool *BlobPool) Get(hash common.Hash) *types.Transaction {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	if _, ok := pool.lookup[hash]; !ok {
+		return nil
+	}
+	blob, err := pool.store.Get(hash[:])
+	if err != nil {
+		log.Error("Tracked blob transaction missing from store", "hash", hash, "err", err)
+		return nil
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(blob); err != nil {
+		log.Error("Blob transaction corrupted in store", "hash", hash, "err", err)
+		return nil
+	}
+	return tx
+}
+
+// Has returns an indicator whether the pool has a transaction cached with the
+// given hash.
+func (pool *BlobPool) Has(hash common.Hash) bool {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	_, ok := pool.lookup[hash]
+	return ok
+}
+
+// Pending retrieves all currently executable blob transactions, grouped by
+// origin account and sorted by nonce. Transactions are cut off at the first
+// one that can't pay for the next block's base fee or blob fee.
+//
+// The returned transactions are stripped of their sidecars to avoid loading
+// blobs from disk; use Get to retrieve the full transaction if needed.
+func (pool *BlobPool) Pending(enforceTips bool) map[common.Address]types.Transactions {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	pending := make(map[common.Address]types.Transactions)
+	for addr, txs := range pool.index {
+		var list types.Transactions
+		for _, meta := range txs {
+			if meta.execFeeCap.Cmp(pool.basefee) < 0 || meta.blobFeeCap.Cmp(pool.blobfee) < 0 {
+				break
+			}
+			if enforceTips && meta.execTipCap.Cmp(pool.gasTip) < 0 {
+				break
+			}
+			list = append(list, meta.tx)
+		}
+		if len(list) > 0 {
+			pending[addr] = list
+		}
+	}
+	return pending
+}
+
+// SetGasTip updates the minimum gas tip required by the pool for a new
+// transaction, and drops all transactions below this threshold.
+func (pool *BlobPool) SetGasTip(tip *big.Int) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	old := pool.gasTip
+	pool.gasTip = new(big.Int).Set(tip)
+
+	// If the min tip increased, remove transactions below the new threshold
+	if old == nil || pool.gasTip.Cmp(old) > 0 {
+		for addr, txs := range pool.index {
+			for i, meta := range txs {
+				if meta.execTipCap.Cmp(pool.gasTip) < 0 {
+					underpriceMeter.Mark(int64(len(txs) - i))
+					pool.forget(txs[i:])
+
+					if i == 0 {
+						delete(pool.index, addr)
+						delete(pool.spent, addr)
+						pool.reserveAccount(addr, false)
+						break
+					}
+					spent := new(big.Int)
+					for _, kept := range txs[:i] {
+						spent.Add(spent, kept.costCap)
+					}
+					pool.index[addr] = txs[:i]
+					pool.spent[addr] = spent
+					break
+				}
+			}
+		}
+		pool.evict = newPriceHeap(pool.basefee, pool.blobfee, pool.index)
+		pool.updateGauges()
+	}
+	log.Info("Blob pool tip threshold updated", "tip", tip)
+}
+
+// DropFiltered drops the pooled transactions of the senders no longer permitted
+// by the admission filter, after its policies were changed. The number of dropped
+// transactions is returned.
+func (pool *BlobPool) DropFiltered() int {
+	if pool.filter == nil {
+		return 0
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	var dropped int
+	for addr, txs := range pool.index {
+		if pool.filter.Permitted(addr) {
+			continue
+		}
+		pool.forget(txs)
+		delete(pool.index, addr)
+		delete(pool.spent, addr)
+		pool.reserveAccount(addr, false)
+		dropped += len(txs)
+	}
+	if dropped > 0 {
+		pool.evict = newPriceHeap(pool.basefee, pool.blobfee, pool.index)
+		pool.updateGauges()
+	}
+	return dropped
+}
+
+// Stats retrieves the current number of transactions in the pool and the total
+// size of them on disk.
+func (pool *BlobPool) Stats() (int, uint64) {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	return len(pool.lookup), pool.stored
+}
+
+// SubscribeNewTxsEvent registers a subscription of NewTxsEvent and starts
+// sending event to the given channel.
+func (pool *BlobPool) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.txFeed.Subscribe(ch))
+}
+
+// reserveAccount claims or releases an account in the reservations shared with
+// the other transaction pools.
+func (pool *BlobPool) reserveAccount(addr common.Address, reserve bool) error {
+	if pool.reserve == nil {
+		return nil
+	}
+	return pool.reserve(addr, reserve)
+}
+
+// updateGauges updates the metrics tracking the pool contents.
+func (pool *BlobPool) updateGauges() {
+	storedGauge.Update(int64(pool.stored))
+	txsGauge.Update(int64(len(pool.lookup)))
+}
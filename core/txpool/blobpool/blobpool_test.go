@@ -0,0 +1,506 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package blobpool
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	emptyBlob          = kzg4844.Blob{}
+	emptyBlobCommit, _ = kzg4844.BlobToCommitment(emptyBlob)
+	emptyBlobProof, _  = kzg4844.ComputeBlobProof(emptyBlob, emptyBlobCommit)
+	emptyBlobVHash     = common.Hash(kzg4844.CalcBlobHashV1(sha256.New(), &emptyBlobCommit))
+)
+
+var testChainConfig *params.ChainConfig
+
+func init() {
+	cpy := *params.TestChainConfig
+	testChainConfig = &cpy
+	testChainConfig.ShanghaiTime = new(uint64)
+	testChainConfig.CancunTime = new(uint64)
+}
+
+// testBlockChain is a mock of the live chain for testing the pool.
+type testBlockChain struct {
+	head          *types.Header
+	statedb       *state.StateDB
+	chainHeadFeed *event.Feed
+}
+
+func newTestBlockChain() *testBlockChain {
+	var (
+		excessDataGas uint64
+		dataGasUsed   uint64
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	return &testBlockChain{
+		head: &types.Header{
+			Number:        big.NewInt(1),
+			GasLimit:      30_000_000,
+			GasUsed:       15_000_000,
+			BaseFee:       big.NewInt(params.InitialBaseFee),
+			ExcessDataGas: &excessDataGas,
+			DataGasUsed:   &dataGasUsed,
+		},
+		statedb:       statedb,
+		chainHeadFeed: new(event.Feed),
+	}
+}
+
+func (bc *testBlockChain) CurrentBlock() *types.Block {
+	return types.NewBlock(bc.head, nil, nil, nil, trie.NewStackTrie(nil))
+}
+
+func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
+	return bc.statedb, nil
+}
+
+func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return bc.chainHeadFeed.Subscribe(ch)
+}
+
+// makeTx creates a signed single-blob transaction with the given nonce and fee
+// caps, carrying its sidecar.
+func makeTx(nonce uint64, gasTipCap uint64, gasFeeCap uint64, blobFeeCap uint64, key *ecdsa.PrivateKey) *types.Transaction {
+	return types.MustSignNewTx(key, types.LatestSigner(testChainConfig), &types.BlobTx{
+		ChainID:    testChainConfig.ChainID,
+		Nonce:      nonce,
+		GasTipCap:  new(big.Int).SetUint64(gasTipCap),
+		GasFeeCap:  new(big.Int).SetUint64(gasFeeCap),
+		Gas:        21000,
+		Value:      big.NewInt(100),
+		BlobFeeCap: new(big.Int).SetUint64(blobFeeCap),
+		BlobHashes: []common.Hash{emptyBlobVHash},
+		Sidecar: &types.BlobTxSidecar{
+			Blobs:       []kzg4844.Blob{emptyBlob},
+			Commitments: []kzg4844.Commitment{emptyBlobCommit},
+			Proofs:      []kzg4844.Proof{emptyBlobProof},
+		},
+	})
+}
+
+// fundedKey creates a new account and credits it with the given balance.
+func fundedKey(chain *testBlockChain, balance *big.Int) (*ecdsa.PrivateKey, common.Address) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	chain.statedb.AddBalance(addr, balance, tracing.BalanceChangeUnspecified)
+	return key, addr
+}
+
+// verifyPoolInternals iterates over all the transactions in the pool and checks
+// the consistency of the indices, the lookup table and the disk store.
+func verifyPoolInternals(t *testing.T, pool *BlobPool) {
+	t.Helper()
+
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	var (
+		seen   = make(map[common.Hash]struct{})
+		stored uint64
+	)
+	for addr, txs := range pool.index {
+		if len(txs) == 0 {
+			t.Errorf("empty transaction list tracked for %x", addr)
+		}
+		spent := new(big.Int)
+		for i, meta := range txs {
+			if i > 0 && txs[i-1].nonce+1 != meta.nonce {
+				t.Errorf("nonce gap for %x: %d after %d", addr, meta.nonce, txs[i-1].nonce)
+			}
+			if have := pool.lookup[meta.hash]; have != addr {
+				t.Errorf("lookup mismatch for %x: have %x, want %x", meta.hash, have, addr)
+			}
+			if ok, _ := pool.store.Has(meta.hash[:]); !ok {
+				t.Errorf("transaction %x missing from store", meta.hash)
+			}
+			seen[meta.hash] = struct{}{}
+			stored += meta.size
+			spent.Add(spent, meta.costCap)
+		}
+		if pool.spent[addr].Cmp(spent) != 0 {
+			t.Errorf("spent mismatch for %x: have %v, want %v", addr, pool.spent[addr], spent)
+		}
+		if _, ok := pool.evict.index[addr]; !ok {
+			t.Errorf("account %x missing from eviction heap", addr)
+		}
+	}
+	if len(seen) != len(pool.lookup) {
+		t.Errorf("lookup size mismatch: have %d, want %d", len(pool.lookup), len(seen))
+	}
+	if len(pool.evict.addrs) != len(pool.index) {
+		t.Errorf("eviction heap size mismatch: have %d, want %d", len(pool.evict.addrs), len(pool.index))
+	}
+	if stored != pool.stored {
+		t.Errorf("stored size mismatch: have %d, want %d", pool.stored, stored)
+	}
+}
+
+// Tests that blob transactions are validated according to the pool rules upon
+// admission.
+func TestAdd(t *testing.T) {
+	chain := newTestBlockChain()
+
+	var (
+		key1, _    = fundedKey(chain, big.NewInt(params.Ether))
+		key2, addr = fundedKey(chain, big.NewInt(params.Ether))
+		key3, _    = fundedKey(chain, big.NewInt(1000))
+	)
+	chain.statedb.SetNonce(addr, 5)
+
+	pool, err := New(Config{}, testChainConfig, chain, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Stop()
+
+	var (
+		noSidecar  = makeTx(0, 1, 1000_000_000, 10, key1).WithoutBlobTxSidecar()
+		dynamicTx  = types.MustSignNewTx(key1, types.LatestSigner(testChainConfig), &types.DynamicFeeTx{ChainID: testChainConfig.ChainID, Gas: 21000, GasTipCap: common.Big1, GasFeeCap: big.NewInt(1000_000_000)})
+		tamperedTx = types.MustSignNewTx(key1, types.LatestSigner(testChainConfig), &types.BlobTx{
+			ChainID:    testChainConfig.ChainID,
+			Gas:        21000,
+			GasTipCap:  common.Big1,
+			GasFeeCap:  big.NewInt(1000_000_000),
+			BlobFeeCap: big.NewInt(10),
+			BlobHashes: []common.Hash{{0x01}},
+			Sidecar:    makeTx(0, 1, 1000_000_000, 10, key1).BlobTxSidecar(),
+		})
+	)
+	tests := []struct {
+		tx  *types.Transaction
+		err error
+	}{
+		{tx: dynamicTx, err: core.ErrTxTypeNotSupported}, // only blob transactions accepted
+		{tx: noSidecar, err: errMissingSidecar},          // blobs are mandatory
+		{tx: tamperedTx, err: errors.New("mismatches")},  // blobs must match the hashes
+		{tx: makeTx(0, 1, 1000_000_000, 10, key1)},       // first transaction accepted
+		{tx: makeTx(2, 1, 1000_000_000, 10, key1), err: errNonceGapped},
+		{tx: makeTx(1, 1, 1000_000_000, 10, key1)}, // contiguous nonce accepted
+		{tx: makeTx(1, 1, 1000_000_000, 10, key1), err: txpool.ErrAlreadyKnown},
+		{tx: makeTx(1, 2, 1500_000_000, 20, key1), err: txpool.ErrReplaceUnderpriced},
+		{tx: makeTx(1, 2, 2000_000_000, 19, key1), err: txpool.ErrReplaceUnderpriced},
+		{tx: makeTx(1, 2, 2000_000_000, 20, key1)}, // 100% bump on all fees replaces
+		{tx: makeTx(4, 1, 1000_000_000, 10, key2), err: core.ErrNonceTooLow},
+		{tx: makeTx(5, 1, 1000_000_000, 10, key2)}, // nonce from the state accepted
+		{tx: makeTx(0, 1, 1000_000_000, 10, key3), err: core.ErrInsufficientFunds},
+	}
+	for i, tt := range tests {
+		err := pool.Add([]*types.Transaction{tt.tx})[0]
+		switch {
+		case tt.err == nil && err != nil:
+			t.Errorf("test %d: failed to add transaction: %v", i, err)
+		case tt.err != nil && err == nil:
+			t.Errorf("test %d: transaction added, want error %v", i, tt.err)
+		case tt.err != nil && !errors.Is(err, tt.err) && !strings.Contains(err.Error(), tt.err.Error()):
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	if count, _ := pool.Stats(); count != 3 {
+		t.Errorf("pooled transaction count mismatch: have %d, want %d", count, 3)
+	}
+	verifyPoolInternals(t, pool)
+}
+
+// Tests that pending transactions are returned stripped of their sidecars, but
+// that the full transactions can be retrieved individually.
+func TestPendingAndGet(t *testing.T) {
+	chain := newTestBlockChain()
+	key, addr := fundedKey(chain, big.NewInt(params.Ether))
+
+	pool, err := New(Config{}, testChainConfig, chain, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Stop()
+
+	var (
+		includable = makeTx(0, 1, 1000_000_000, 10, key)
+		underwater = makeTx(1, 1, 1, 10, key) // fee cap below the base fee
+	)
+	if errs := pool.Add([]*types.Transaction{includable, underwater}); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("failed to add transactions: %v", errs)
+	}
+	pending := pool.Pending(false)
+	if len(pending[addr]) != 1 {
+		t.Fatalf("pending transaction count mismatch: have %d, want %d", len(pending[addr]), 1)
+	}
+	if pending[addr][0].Hash() != includable.Hash() {
+		t.Errorf("pending transaction mismatch: have %x, want %x", pending[addr][0].Hash(), includable.Hash())
+	}
+	if pending[addr][0].BlobTxSidecar() != nil {
+		t.Errorf("pending transaction carries sidecar")
+	}
+	tx := pool.Get(includable.Hash())
+	if tx == nil {
+		t.Fatalf("failed to retrieve pooled transaction")
+	}
+	if tx.BlobTxSidecar() == nil {
+		t.Fatalf("retrieved transaction missing sidecar")
+	}
+	if tx.BlobTxSidecar().Commitments[0] != emptyBlobCommit {
+		t.Errorf("retrieved commitment mismatch")
+	}
+}
+
+// Tests that the pool persists its transactions across restarts.
+func TestPersistence(t *testing.T) {
+	var (
+		chain  = newTestBlockChain()
+		config = Config{Datadir: t.TempDir()}
+	)
+	key, _ := fundedKey(chain, big.NewInt(params.Ether))
+
+	pool, err := New(config, testChainConfig, chain, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	txs := []*types.Transaction{
+		makeTx(0, 1, 1000_000_000, 10, key),
+		makeTx(1, 1, 1000_000_000, 10, key),
+	}
+	for i, err := range pool.Add(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	pool.Stop()
+
+	pool, err = New(config, testChainConfig, chain, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen blob pool: %v", err)
+	}
+	defer pool.Stop()
+
+	for i, tx := range txs {
+		if !pool.Has(tx.Hash()) {
+			t.Errorf("transaction %d: missing after restart", i)
+		}
+		if have := pool.Get(tx.Hash()); have == nil || have.BlobTxSidecar() == nil {
+			t.Errorf("transaction %d: sidecar missing after restart", i)
+		}
+	}
+	verifyPoolInternals(t, pool)
+}
+
+// Tests that transactions included in the chain or rendered unaffordable are
+// dropped from the pool on head changes.
+func TestReset(t *testing.T) {
+	chain := newTestBlockChain()
+	key, addr := fundedKey(chain, big.NewInt(params.Ether))
+
+	pool, err := New(Config{}, testChainConfig, chain, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Stop()
+
+	txs := []*types.Transaction{
+		makeTx(0, 1, 1000_000_000, 10, key),
+		makeTx(1, 1, 1000_000_000, 10, key),
+		makeTx(2, 1, 1000_000_000, 10, key),
+	}
+	for i, err := range pool.Add(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	// Include the first transaction and drain the funds below the last one
+	chain.statedb.SetNonce(addr, 1)
+	chain.statedb.SetBalance(addr, new(big.Int).Add(txs[1].Cost(), common.Big1), tracing.BalanceChangeUnspecified)
+
+	pool.lock.Lock()
+	pool.reset(chain.head)
+	pool.lock.Unlock()
+
+	if pool.Has(txs[0].Hash()) {
+		t.Errorf("included transaction not dropped")
+	}
+	if !pool.Has(txs[1].Hash()) {
+		t.Errorf("executable transaction dropped")
+	}
+	if pool.Has(txs[2].Hash()) {
+		t.Errorf("unaffordable transaction not dropped")
+	}
+	verifyPoolInternals(t, pool)
+}
+
+// Tests that the pool evicts the transactions with the worst fee bottleneck
+// when running over its storage cap.
+func TestEviction(t *testing.T) {
+	chain := newTestBlockChain()
+
+	var (
+		key1, _ = fundedKey(chain, big.NewInt(params.Ether))
+		key2, _ = fundedKey(chain, big.NewInt(params.Ether))
+		key3, _ = fundedKey(chain, big.NewInt(params.Ether))
+		key4, _ = fundedKey(chain, big.NewInt(params.Ether))
+
+		cheap  = makeTx(0, 1, params.InitialBaseFee/8, 10, key1) // fee cap way below the base fee
+		medium = makeTx(0, 1, params.InitialBaseFee, 10, key2)
+		pricey = makeTx(0, 2, params.InitialBaseFee*2, 10, key3)
+	)
+	blob, _ := cheap.MarshalBinary()
+
+	pool, err := New(Config{Datacap: uint64(2*len(blob) + len(blob)/2)}, testChainConfig, chain, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Stop()
+
+	for i, err := range pool.Add([]*types.Transaction{cheap, medium, pricey}) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if pool.Has(cheap.Hash()) {
+		t.Errorf("underpriced transaction not evicted")
+	}
+	if !pool.Has(medium.Hash()) || !pool.Has(pricey.Hash()) {
+		t.Errorf("includable transactions evicted")
+	}
+	// Adding a transaction worse than everything pooled should be rejected
+	cheapest := makeTx(0, 1, params.InitialBaseFee/8, 10, key4)
+	if err := pool.Add([]*types.Transaction{cheapest})[0]; !errors.Is(err, txpool.ErrTxPoolOverflow) {
+		t.Errorf("overflowing transaction error mismatch: have %v, want %v", err, txpool.ErrTxPoolOverflow)
+	}
+	verifyPoolInternals(t, pool)
+}
+
+// Tests that the pool claims the accounts of its transactions in the shared
+// reservations, rejecting accounts owned by another pool and releasing the ones
+// whose transactions all left the pool.
+func TestReservations(t *testing.T) {
+	chain := newTestBlockChain()
+
+	var (
+		key1, addr1 = fundedKey(chain, big.NewInt(params.Ether))
+		key2, addr2 = fundedKey(chain, big.NewInt(params.Ether))
+
+		reservations = txpool.NewReservations()
+		other        = reservations.Reserver()
+	)
+	pool, err := New(Config{}, testChainConfig, chain, reservations.Reserver(), nil)
+	if err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Stop()
+
+	// Accounts owned by another pool are rejected
+	if err := other(addr1, true); err != nil {
+		t.Fatalf("failed to reserve account: %v", err)
+	}
+	if err := pool.Add([]*types.Transaction{makeTx(0, 1, 1000_000_000, 10, key1)})[0]; !errors.Is(err, txpool.ErrAlreadyReserved) {
+		t.Fatalf("reserved account error mismatch: have %v, want %v", err, txpool.ErrAlreadyReserved)
+	}
+	if err := other(addr1, false); err != nil {
+		t.Fatalf("failed to release account: %v", err)
+	}
+	// Pooled accounts are owned by the pool until their transactions are dropped
+	txs := []*types.Transaction{
+		makeTx(0, 1, 1000_000_000, 10, key1),
+		makeTx(0, 1, 1000_000_000, 10, key2),
+		makeTx(1, 1, 1000_000_000, 10, key2),
+	}
+	for i, err := range pool.Add(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	for _, addr := range []common.Address{addr1, addr2} {
+		if err := other(addr, true); !errors.Is(err, txpool.ErrAlreadyReserved) {
+			t.Fatalf("pooled account %x error mismatch: have %v, want %v", addr, err, txpool.ErrAlreadyReserved)
+		}
+	}
+	// Include the first account's transaction and one of the second account's
+	chain.statedb.SetNonce(addr1, 1)
+	chain.statedb.SetNonce(addr2, 1)
+
+	pool.lock.Lock()
+	pool.reset(chain.head)
+	pool.lock.Unlock()
+
+	if err := other(addr1, true); err != nil {
+		t.Fatalf("drained account not released: %v", err)
+	}
+	if err := other(addr2, true); !errors.Is(err, txpool.ErrAlreadyReserved) {
+		t.Fatalf("pooled account error mismatch: have %v, want %v", err, txpool.ErrAlreadyReserved)
+	}
+	verifyPoolInternals(t, pool)
+}
+
+// Tests that the pool enforces the shared admission filter, and drops the
+// transactions of senders that are no longer permitted once it's reconfigured.
+func TestAdmissionFilter(t *testing.T) {
+	chain := newTestBlockChain()
+
+	var (
+		key1, addr1 = fundedKey(chain, big.NewInt(params.Ether))
+		key2, _     = fundedKey(chain, big.NewInt(params.Ether))
+
+		filter = txpool.NewFilter(txpool.FilterConfig{Deny: []common.Address{addr1}})
+	)
+	pool, err := New(Config{}, testChainConfig, chain, nil, filter)
+	if err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Stop()
+
+	if err := pool.Add([]*types.Transaction{makeTx(0, 1, 1000_000_000, 10, key1)})[0]; !errors.Is(err, txpool.ErrSenderDenied) {
+		t.Fatalf("denied sender error mismatch: have %v, want %v", err, txpool.ErrSenderDenied)
+	}
+	filter.SetConfig(txpool.FilterConfig{})
+	txs := []*types.Transaction{
+		makeTx(0, 1, 1000_000_000, 10, key1),
+		makeTx(1, 1, 1000_000_000, 10, key1),
+		makeTx(0, 1, 1000_000_000, 10, key2),
+	}
+	for i, err := range pool.Add(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	// Denying the sender again drops its pooled transactions
+	filter.SetConfig(txpool.FilterConfig{Deny: []common.Address{addr1}})
+	if dropped := pool.DropFiltered(); dropped != 2 {
+		t.Fatalf("dropped transaction count mismatch: have %d, want 2", dropped)
+	}
+	if pool.Has(txs[0].Hash()) || pool.Has(txs[1].Hash()) || !pool.Has(txs[2].Hash()) {
+		t.Fatalf("pool contents mismatch after filtering")
+	}
+	verifyPoolInternals(t, pool)
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package blobpool
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// Tests that the priority fees are calculated correctly as the log2 of the fee
+// jumps needed to go from the base fee to the tx's fee cap.
+func TestPriorityCalculation(t *testing.T) {
+	tests := []struct {
+		basefeeJumps float64
+		txfeeJumps   float64
+		result       int
+	}{
+		{basefeeJumps: 0, txfeeJumps: 0, result: 0},      // fees equal, no priority
+		{basefeeJumps: 10, txfeeJumps: 10.5, result: 0},  // less than a jump above, no priority
+		{basefeeJumps: 10, txfeeJumps: 11, result: 0},    // one jump above, log2(1) = 0
+		{basefeeJumps: 10, txfeeJumps: 12, result: 1},    // two jumps above, log2(2) = 1
+		{basefeeJumps: 10, txfeeJumps: 14, result: 2},    // four jumps above, log2(4) = 2
+		{basefeeJumps: 10, txfeeJumps: 15, result: 2},    // five jumps above, log2(5) = 2 (integral)
+		{basefeeJumps: 10, txfeeJumps: 9.5, result: 0},   // less than a jump below, no priority
+		{basefeeJumps: 10, txfeeJumps: 8, result: -1},    // two jumps below, -log2(2) = -1
+		{basefeeJumps: 10, txfeeJumps: 6.5, result: -2},  // partial jumps below round away from zero
+		{basefeeJumps: 10, txfeeJumps: -6, result: -4},   // sixteen jumps below, -log2(16) = -4
+		{basefeeJumps: -10, txfeeJumps: 22, result: 5},   // thirty-two jumps above, log2(32) = 5
+		{basefeeJumps: 0, txfeeJumps: 1000, result: 9},   // extreme fee caps stay within range
+		{basefeeJumps: 1000, txfeeJumps: 0, result: -9},  // extreme base fees stay within range
+		{basefeeJumps: 5.5, txfeeJumps: 5.25, result: 0}, // fractional differences don't count
+	}
+	for i, tt := range tests {
+		if prio := evictionPriority1D(tt.basefeeJumps, tt.txfeeJumps); prio != tt.result {
+			t.Errorf("test %d: priority mismatch: have %d, want %d", i, prio, tt.result)
+		}
+	}
+	// The combined priority must be the worse of the two components
+	if prio := evictionPriority(10, 14, 10, 8); prio != -1 {
+		t.Errorf("combined priority mismatch: have %d, want %d", prio, -1)
+	}
+	if prio := evictionPriority(10, 8, 10, 6.5); prio != -2 {
+		t.Errorf("combined priority mismatch: have %d, want %d", prio, -2)
+	}
+}
+
+// Tests that the dynamic fee jumps are calculated as the log1.125 of the fee.
+func TestDynamicFeeJumps(t *testing.T) {
+	tests := []struct {
+		fee   *big.Int
+		jumps float64
+	}{
+		{fee: big.NewInt(0), jumps: 0},
+		{fee: big.NewInt(1), jumps: 0},
+		{fee: big.NewInt(2), jumps: 1 / math.Log2(1.125)},
+		{fee: big.NewInt(1_000_000_000), jumps: math.Log2(1_000_000_000) / math.Log2(1.125)},
+	}
+	for i, tt := range tests {
+		if jumps := dynamicFeeJumps(tt.fee); math.Abs(jumps-tt.jumps) > 1e-9 {
+			t.Errorf("test %d: fee jumps mismatch: have %f, want %f", i, jumps, tt.jumps)
+		}
+	}
+	// Multiplying the fee by 1.125 should add exactly one jump
+	var (
+		base   = big.NewInt(8_000_000_000)
+		bumped = big.NewInt(9_000_000_000)
+	)
+	if diff := dynamicFeeJumps(bumped) - dynamicFeeJumps(base); math.Abs(diff-1) > 1e-9 {
+		t.Errorf("single bump jump mismatch: have %f, want 1", diff)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// rateWindow is the interval over which the per-sender rate limit is enforced.
+const rateWindow = time.Minute
+
+// FilterError is returned if a transaction is rejected by the admission filters
+// of the pools. Every rejection reason carries a distinct JSON-RPC error code.
+type FilterError struct {
+	code int
+	msg  string
+}
+
+func (e *FilterError) Error() string { return e.msg }
+
+// ErrorCode returns the JSON-RPC error code of the rejection reason.
+func (e *FilterError) ErrorCode() int { return e.code }
+
+var (
+	// ErrSenderNotAllowed is returned if an allowlist is configured and the
+	// sender of the transaction is not on it.
+	ErrSenderNotAllowed = &FilterError{code: -32010, msg: "sender not allowed"}
+
+	// ErrSenderDenied is returned if the sender of the transaction is denylisted.
+	ErrSenderDenied = &FilterError{code: -32011, msg: "sender denied"}
+
+	// ErrRecipientDenied is returned if the recipient of the transaction is
+	// denylisted.
+	ErrRecipientDenied = &FilterError{code: -32012, msg: "recipient denied"}
+
+	// ErrCreationNotAllowed is returned if contract creation is restricted and
+	// the sender of a contract creation is not a permitted creator.
+	ErrCreationNotAllowed = &FilterError{code: -32013, msg: "contract creation not allowed"}
+
+	// ErrSenderRateLimited is returned if the sender submitted more transactions
+	// within the rate window than permitted.
+	ErrSenderRateLimited = &FilterError{code: -32014, msg: "sender rate limited"}
+
+	// ErrCalldataTooLarge is returned if the calldata of the transaction exceeds
+	// the configured maximum size.
+	ErrCalldataTooLarge = &FilterError{code: -32015, msg: "calldata too large"}
+)
+
+var (
+	filterNotAllowedMeter = metrics.NewRegisteredMeter("txpool/filter/notallowed", nil)
+	filterDeniedMeter     = metrics.NewRegisteredMeter("txpool/filter/denied", nil)
+	filterCreationMeter   = metrics.NewRegisteredMeter("txpool/filter/creation", nil)
+	filterRateLimitMeter  = metrics.NewRegisteredMeter("txpool/filter/ratelimit", nil)
+	filterCalldataMeter   = metrics.NewRegisteredMeter("txpool/filter/calldata", nil)
+)
+
+// FilterConfig are the admission policies applied to transactions submitted to
+// the pools, either locally or from the network, and to private bundles.
+type FilterConfig struct {
+	Allow       []common.Address `json:"allow"`       // Senders permitted to submit transactions, empty permits everyone
+	Deny        []common.Address `json:"deny"`        // Senders and recipients whose transactions are rejected
+	NoCreate    bool             `json:"noCreate"`    // Whether contract creation is restricted to the Creators
+	Creators    []common.Address `json:"creators"`    // Senders permitted to deploy contracts if creation is restricted
+	SenderRate  uint64           `json:"senderRate"`  // Maximum transactions admitted per sender and minute (0 = unlimited)
+	MaxCalldata uint64           `json:"maxCalldata"` // Maximum calldata size of a transaction in bytes (0 = unlimited)
+}
+
+// rateCounter tracks the number of transactions admitted from a sender within
+// the current rate window.
+type rateCounter struct {
+	start time.Time
+	count uint64
+}
+
+// Filter enforces the admission policies of the transaction pools. A single
+// filter is shared by all pools and the miner's bundle submission, so the
+// policies and the per-sender rate limits span every entry point. It is safe
+// for concurrent use and can be reconfigured on the fly.
+type Filter struct {
+	config   FilterConfig
+	allow    map[common.Address]struct{}
+	deny     map[common.Address]struct{}
+	creators map[common.Address]struct{}
+	rates    map[common.Address]*rateCounter
+	expired  time.Time // Last time the elapsed rate windows were dropped
+	lock     sync.Mutex
+}
+
+// NewFilter creates an admission filter enforcing the given policies.
+func NewFilter(config FilterConfig) *Filter {
+	f := &Filter{rates: make(map[common.Address]*rateCounter)}
+	f.SetConfig(config)
+	return f
+}
+
+// addressSet converts a list of addresses into a set for quick lookups.
+func addressSet(addrs []common.Address) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// Config returns a copy of the currently enforced policies.
+func (f *Filter) Config() FilterConfig {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	config := f.config
+	config.Allow = append([]common.Address{}, f.config.Allow...)
+	config.Deny = append([]common.Address{}, f.config.Deny...)
+	config.Creators = append([]common.Address{}, f.config.Creators...)
+	return config
+}
+
+// SetConfig replaces the enforced policies. The admission counts of the senders
+// are retained, so a rate limit change takes effect within the current window.
+// Already pooled transactions are not affected, the pools need to drop the ones
+// of senders no longer permitted themselves.
+func (f *Filter) SetConfig(config FilterConfig) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	config.Allow = append([]common.Address{}, config.Allow...)
+	config.Deny = append([]common.Address{}, config.Deny...)
+	config.Creators = append([]common.Address{}, config.Creators...)
+
+	f.config = config
+	f.allow = addressSet(config.Allow)
+	f.deny = addressSet(config.Deny)
+	f.creators = addressSet(config.Creators)
+}
+
+// Permitted reports whether the sender may have transactions in the pools at
+// all, regardless of their contents.
+func (f *Filter) Permitted(from common.Address) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.deny[from]; ok {
+		return false
+	}
+	if len(f.allow) > 0 {
+		if _, ok := f.allow[from]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Check verifies that a transaction from the given sender passes all admission
+// policies and that the sender did not exhaust its rate limit yet. The check
+// does not count against the rate limit, the pools need to call Admit once they
+// accepted the transaction.
+func (f *Filter) Check(from common.Address, tx *types.Transaction) error {
+	return f.check(from, tx, time.Now())
+}
+
+// check is the implementation of Check, verifying the rate limit at the given
+// time.
+func (f *Filter) check(from common.Address, tx *types.Transaction, now time.Time) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.deny[from]; ok {
+		filterDeniedMeter.Mark(1)
+		return ErrSenderDenied
+	}
+	if len(f.allow) > 0 {
+		if _, ok := f.allow[from]; !ok {
+			filterNotAllowedMeter.Mark(1)
+			return ErrSenderNotAllowed
+		}
+	}
+	if to := tx.To(); to != nil {
+		if _, ok := f.deny[*to]; ok {
+			filterDeniedMeter.Mark(1)
+			return ErrRecipientDenied
+		}
+	} else if f.config.NoCreate {
+		if _, ok := f.creators[from]; !ok {
+			filterCreationMeter.Mark(1)
+			return ErrCreationNotAllowed
+		}
+	}
+	if f.config.MaxCalldata > 0 && uint64(len(tx.Data())) > f.config.MaxCalldata {
+		filterCalldataMeter.Mark(1)
+		return ErrCalldataTooLarge
+	}
+	if f.config.SenderRate > 0 {
+		rate := f.rates[from]
+		if rate != nil && now.Sub(rate.start) < rateWindow && rate.count >= f.config.SenderRate {
+			filterRateLimitMeter.Mark(1)
+			return ErrSenderRateLimited
+		}
+	}
+	return nil
+}
+
+// Admit counts a transaction accepted from the given sender against its rate
+// limit. Transactions rejected by the pools after passing Check, e.g. because
+// they were underpriced or already known, must not be admitted.
+func (f *Filter) Admit(from common.Address) {
+	f.admit(from, time.Now())
+}
+
+// admit is the implementation of Admit, counting the transaction at the given
+// time.
+func (f *Filter) admit(from common.Address, now time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.config.SenderRate == 0 {
+		return
+	}
+	// Forget the admission counts of senders with elapsed rate windows
+	if now.Sub(f.expired) >= rateWindow {
+		f.expire(now)
+	}
+	rate := f.rates[from]
+	if rate == nil || now.Sub(rate.start) >= rateWindow {
+		rate = &rateCounter{start: now}
+		f.rates[from] = rate
+	}
+	rate.count++
+}
+
+// expire drops the admission counts of all senders whose rate window elapsed.
+// The caller must hold the filter lock.
+func (f *Filter) expire(now time.Time) {
+	for addr, rate := range f.rates {
+		if now.Sub(rate.start) >= rateWindow {
+			delete(f.rates, addr)
+		}
+	}
+	f.expired = now
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrAlreadyReserved is returned if the sender address has transactions pooled
+// by a different transaction pool. For example, a legacy transaction is rejected
+// while a blob transaction of the same sender remains pooled, and vice versa.
+var ErrAlreadyReserved = errors.New("address already reserved")
+
+// AddressReserver is handed to a transaction pool to claim an account before it
+// pools the account's first transaction, and to release the account once its
+// last transaction left the pool.
+type AddressReserver func(addr common.Address, reserve bool) error
+
+// Reservations tracks which transaction pool owns each account, so the legacy
+// pool and the blob pool never track transactions of the same sender. Otherwise
+// both pools could accept the same nonce, and the miner would have to merge two
+// conflicting nonce sequences for a single account.
+type Reservations struct {
+	owners map[common.Address]int // Index of the pool owning each account
+	pools  int                    // Number of pools handed a reserver
+	lock   sync.Mutex
+}
+
+// NewReservations creates an empty account reservation tracker.
+func NewReservations() *Reservations {
+	return &Reservations{
+		owners: make(map[common.Address]int),
+	}
+}
+
+// Reserver creates the reservation callback of a new transaction pool.
+func (r *Reservations) Reserver() AddressReserver {
+	r.lock.Lock()
+	id := r.pools
+	r.pools++
+	r.lock.Unlock()
+
+	return func(addr common.Address, reserve bool) error {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		owner, exists := r.owners[addr]
+		if reserve {
+			if exists {
+				if owner == id {
+					// Double reservations are a bug in the pool, but ignore it
+					// to give the pool a chance to recover
+					log.Error("Pool attempted to reserve already-owned address", "address", addr)
+					return nil
+				}
+				return ErrAlreadyReserved
+			}
+			r.owners[addr] = id
+			return nil
+		}
+		// Ensure pools only release their own accounts, anything else is a
+		// programming error
+		if !exists {
+			log.Error("Pool attempted to release non-reserved address", "address", addr)
+			return errors.New("address not reserved")
+		}
+		if owner != id {
+			log.Error("Pool attempted to release non-owned address", "address", addr)
+			return errors.New("address not owned")
+		}
+		delete(r.owners, addr)
+		return nil
+	}
+}
@@ -167,6 +167,8 @@ type Config struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Filter FilterConfig // Initial admission policies of the filter shared by the pools
 }
 
 // DefaultConfig contains the default configurations for the transaction
@@ -250,8 +252,10 @@ type TxPool struct {
 	pendingNonces *noncer        // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *journal    // Journal of local transaction to back up to disk
+	locals  *accountSet     // Set of local transaction to exempt from eviction rules
+	journal *journal        // Journal of local transaction to back up to disk
+	filter  *Filter         // Admission policies shared with other pools, nil if none
+	reserve AddressReserver // Account reservations shared with other pools, nil if none
 
 	pending map[common.Address]*list     // All currently processable transactions
 	queue   map[common.Address]*list     // Queued but non-processable transactions
@@ -277,8 +281,10 @@ type txpoolResetRequest struct {
 }
 
 // NewTxPool creates a new transaction pool to gather, sort and filter inbound
-// transactions from the network.
-func NewTxPool(config Config, chainconfig *params.ChainConfig, chain blockChain) *TxPool {
+// transactions from the network. If reserve is non-nil, accounts are claimed
+// through it, so they are never tracked by another pool at the same time. If
+// filter is non-nil, submitted transactions must pass its admission policies.
+func NewTxPool(config Config, chainconfig *params.ChainConfig, chain blockChain, reserve AddressReserver, filter *Filter) *TxPool {
 	// Sanitize the input to ensure no vulnerable gas prices are set
 	config = (&config).sanitize()
 
@@ -300,6 +306,8 @@ func NewTxPool(config Config, chainconfig *params.ChainConfig, chain blockChain)
 		reorgShutdownCh: make(chan struct{}),
 		initDoneCh:      make(chan struct{}),
 		gasPrice:        new(big.Int).SetUint64(config.PriceLimit),
+		filter:          filter,
+		reserve:         reserve,
 	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
@@ -392,7 +400,7 @@ func (pool *TxPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					list := pool.queue[addr].Flatten()
 					for _, tx := range list {
-						pool.removeTx(tx.Hash(), true)
+						pool.removeTx(tx.Hash(), true, true)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
@@ -454,7 +462,7 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(price)
 		for _, tx := range drop {
-			pool.removeTx(tx.Hash(), false)
+			pool.removeTx(tx.Hash(), false, true)
 		}
 		pool.priced.Removed(len(drop))
 	}
@@ -559,6 +567,33 @@ func (pool *TxPool) Pending(enforceTips bool) map[common.Address]types.Transacti
 	return pending
 }
 
+// DropFiltered drops the pooled transactions of the senders no longer permitted
+// by the admission filter, after its policies were changed. The transactions of
+// the remaining senders are retained, even if they would not be admitted anymore.
+// The number of dropped transactions is returned.
+func (pool *TxPool) DropFiltered() int {
+	if pool.filter == nil {
+		return 0
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var dropped int
+	for _, accounts := range []map[common.Address]*list{pool.pending, pool.queue} {
+		for addr, list := range accounts {
+			if pool.filter.Permitted(addr) {
+				continue
+			}
+			txs := list.Flatten()
+			for _, tx := range txs {
+				pool.removeTx(tx.Hash(), true, true)
+			}
+			dropped += len(txs)
+		}
+	}
+	return dropped
+}
+
 // Locals retrieves the accounts currently considered local by the pool.
 func (pool *TxPool) Locals() []common.Address {
 	pool.mu.Lock()
@@ -594,6 +629,10 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if !pool.eip1559 && tx.Type() == types.DynamicFeeTxType {
 		return core.ErrTxTypeNotSupported
 	}
+	// Reject blob transactions, they are handled by the dedicated blob pool.
+	if tx.Type() == types.BlobTxType {
+		return core.ErrTxTypeNotSupported
+	}
 	// Reject transactions over defined size to prevent DOS attacks
 	if tx.Size() > txMaxSize {
 		return ErrOversizedData
@@ -672,6 +711,21 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
+	// If the account is not yet tracked, claim it from the other pools until
+	// all its transactions left the pool
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if pool.pending[from] == nil && pool.queue[from] == nil {
+		if err := pool.reserveAccount(from, true); err != nil {
+			return false, err
+		}
+		defer func() {
+			// Release the account if the transaction was rejected after all.
+			// Note, err is the named return value set by the return statements.
+			if err != nil {
+				pool.reserveAccount(from, false)
+			}
+		}()
+	}
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
@@ -706,11 +760,12 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "gasTipCap", tx.GasTipCap(), "gasFeeCap", tx.GasFeeCap())
 			underpricedTxMeter.Mark(1)
-			pool.removeTx(tx.Hash(), false)
+
+			sender, _ := types.Sender(pool.signer, tx)
+			pool.removeTx(tx.Hash(), false, sender != from)
 		}
 	}
 	// Try to replace an existing transaction in the pending pool
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -904,12 +959,24 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 		// Exclude transactions with invalid signatures as soon as
 		// possible and cache senders in transactions before
 		// obtaining lock
-		_, err := types.Sender(pool.signer, tx)
+		from, err := types.Sender(pool.signer, tx)
 		if err != nil {
 			errs[i] = ErrInvalidSender
 			invalidTxMeter.Mark(1)
 			continue
 		}
+		// Reject transactions violating the admission policies of the pools
+		if pool.filter != nil {
+			if err := pool.filter.Check(from, tx); err != nil {
+				if local {
+					log.Info("Rejected transaction by admission filter", "hash", tx.Hash(), "from", from, "err", err)
+				} else {
+					log.Debug("Rejected transaction by admission filter", "hash", tx.Hash(), "from", from, "err", err)
+				}
+				errs[i] = err
+				continue
+			}
+		}
 		// Accumulate all unknown transactions for deeper processing
 		news = append(news, tx)
 	}
@@ -919,7 +986,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 
 	// Process all the new transaction and merge any errors into the original slice
 	pool.mu.Lock()
-	newErrs, dirtyAddrs := pool.addTxsLocked(news, local)
+	newErrs, dirtyAddrs := pool.addTxsLocked(news, local, pool.filter)
 	pool.mu.Unlock()
 
 	var nilSlot = 0
@@ -939,13 +1006,25 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 }
 
 // addTxsLocked attempts to queue a batch of transactions if they are valid.
-// The transaction pool lock must be held.
-func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error, *accountSet) {
+// If a filter is given, the accepted transactions are counted against the rate
+// limits of their senders. The transaction pool lock must be held.
+func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool, filter *Filter) ([]error, *accountSet) {
 	dirty := newAccountSet(pool.signer)
 	errs := make([]error, len(txs))
 	for i, tx := range txs {
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		if filter != nil {
+			// Earlier transactions of the batch might have exhausted the rate limit
+			if err := filter.Check(from, tx); err != nil {
+				errs[i] = err
+				continue
+			}
+		}
 		replaced, err := pool.add(tx, local)
 		errs[i] = err
+		if err == nil && filter != nil {
+			filter.Admit(from)
+		}
 		if err == nil && !replaced {
 			dirty.addTx(tx)
 		}
@@ -989,8 +1068,9 @@ func (pool *TxPool) Has(hash common.Hash) bool {
 }
 
 // removeTx removes a single transaction from the queue, moving all subsequent
-// transactions back to the future queue.
-func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
+// transactions back to the future queue. If unreserve is set and the account
+// has no transactions left, its reservation is released.
+func (pool *TxPool) removeTx(hash common.Hash, outofbound bool, unreserve bool) {
 	// Fetch the transaction we wish to delete
 	tx := pool.all.Get(hash)
 	if tx == nil {
@@ -998,6 +1078,16 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 	}
 	addr, _ := types.Sender(pool.signer, tx) // already validated during insertion
 
+	// Release the account once it has no transactions left. It's done via a
+	// defer, since it's safer against the many return paths.
+	if unreserve {
+		defer func() {
+			if pool.pending[addr] == nil && pool.queue[addr] == nil {
+				pool.reserveAccount(addr, false)
+			}
+		}()
+	}
+
 	// Remove it from the list of known transactions
 	pool.all.Remove(hash)
 	if outofbound {
@@ -1299,7 +1389,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	core.SenderCacher.Recover(pool.signer, reinject)
-	pool.addTxsLocked(reinject, false)
+	pool.addTxsLocked(reinject, false, nil)
 
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
@@ -1369,6 +1459,9 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 		if list.Empty() {
 			delete(pool.queue, addr)
 			delete(pool.beats, addr)
+			if pool.pending[addr] == nil {
+				pool.reserveAccount(addr, false)
+			}
 		}
 	}
 	return promoted
@@ -1490,7 +1583,7 @@ func (pool *TxPool) truncateQueue() {
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
 			for _, tx := range list.Flatten() {
-				pool.removeTx(tx.Hash(), true)
+				pool.removeTx(tx.Hash(), true, true)
 			}
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
@@ -1499,7 +1592,7 @@ func (pool *TxPool) truncateQueue() {
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
-			pool.removeTx(txs[i].Hash(), true)
+			pool.removeTx(txs[i].Hash(), true, true)
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1560,10 +1653,22 @@ func (pool *TxPool) demoteUnexecutables() {
 		// Delete the entire pending entry if it became empty.
 		if list.Empty() {
 			delete(pool.pending, addr)
+			if pool.queue[addr] == nil {
+				pool.reserveAccount(addr, false)
+			}
 		}
 	}
 }
 
+// reserveAccount claims or releases an account in the reservations shared with
+// the other transaction pools.
+func (pool *TxPool) reserveAccount(addr common.Address, reserve bool) error {
+	if pool.reserve == nil {
+		return nil
+	}
+	return pool.reserve(addr, reserve)
+}
+
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
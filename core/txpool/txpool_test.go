@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
@@ -118,11 +119,15 @@ func setupPool() (*TxPool, *ecdsa.PrivateKey) {
 }
 
 func setupPoolWithConfig(config *params.ChainConfig) (*TxPool, *ecdsa.PrivateKey) {
+	return setupPoolWithFilter(config, nil)
+}
+
+func setupPoolWithFilter(config *params.ChainConfig, filter *Filter) (*TxPool, *ecdsa.PrivateKey) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{10000000, statedb, new(event.Feed)}
 
 	key, _ := crypto.GenerateKey()
-	pool := NewTxPool(testTxPoolConfig, config, blockchain)
+	pool := NewTxPool(testTxPoolConfig, config, blockchain, nil, filter)
 
 	// wait for the pool to initialize
 	<-pool.initDoneCh
@@ -210,7 +215,7 @@ func (c *testChain) State() (*state.StateDB, error) {
 		c.statedb, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 		// simulate that the new head block included tx0 and tx1
 		c.statedb.SetNonce(c.address, 2)
-		c.statedb.SetBalance(c.address, new(big.Int).SetUint64(params.Ether))
+		c.statedb.SetBalance(c.address, new(big.Int).SetUint64(params.Ether), tracing.BalanceChangeUnspecified)
 		*c.trigger = false
 	}
 	return stdb, nil
@@ -230,13 +235,13 @@ func TestStateChangeDuringReset(t *testing.T) {
 	)
 
 	// setup pool with 2 transaction in it
-	statedb.SetBalance(address, new(big.Int).SetUint64(params.Ether))
+	statedb.SetBalance(address, new(big.Int).SetUint64(params.Ether), tracing.BalanceChangeUnspecified)
 	blockchain := &testChain{&testBlockChain{1000000000, statedb, new(event.Feed)}, address, &trigger}
 
 	tx0 := transaction(0, 100000, key)
 	tx1 := transaction(1, 100000, key)
 
-	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	nonce := pool.Nonce(address)
@@ -263,7 +268,7 @@ func TestStateChangeDuringReset(t *testing.T) {
 
 func testAddBalance(pool *TxPool, addr common.Address, amount *big.Int) {
 	pool.mu.Lock()
-	pool.currentState.AddBalance(addr, amount)
+	pool.currentState.AddBalance(addr, amount, tracing.BalanceChangeUnspecified)
 	pool.mu.Unlock()
 }
 
@@ -423,7 +428,7 @@ func TestChainFork(t *testing.T) {
 	addr := crypto.PubkeyToAddress(key.PublicKey)
 	resetState := func() {
 		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
-		statedb.AddBalance(addr, big.NewInt(100000000000000))
+		statedb.AddBalance(addr, big.NewInt(100000000000000), tracing.BalanceChangeUnspecified)
 
 		pool.chain = &testBlockChain{1000000, statedb, new(event.Feed)}
 		<-pool.requestReset(nil, nil)
@@ -434,7 +439,7 @@ func TestChainFork(t *testing.T) {
 	if _, err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
-	pool.removeTx(tx.Hash(), true)
+	pool.removeTx(tx.Hash(), true, true)
 
 	// reset the pool's internal state
 	resetState()
@@ -443,6 +448,70 @@ func TestChainFork(t *testing.T) {
 	}
 }
 
+// Tests that the pool claims the accounts of its transactions in the shared
+// reservations, rejecting accounts owned by another pool and releasing the ones
+// whose transactions all left the pool.
+func TestReservations(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed)}
+
+	reservations := NewReservations()
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain, reservations.Reserver(), nil)
+	defer pool.Stop()
+	<-pool.initDoneCh
+
+	var (
+		other     = reservations.Reserver()
+		key1, _   = crypto.GenerateKey()
+		key2, _   = crypto.GenerateKey()
+		addr1     = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2     = crypto.PubkeyToAddress(key2.PublicKey)
+		tx1, tx2  = transaction(0, 100000, key1), transaction(0, 100000, key2)
+		queuedTx2 = transaction(2, 100000, key2)
+	)
+	testAddBalance(pool, addr1, big.NewInt(1000000000))
+	testAddBalance(pool, addr2, big.NewInt(1000000000))
+
+	// Accounts owned by another pool are rejected
+	if err := other(addr1, true); err != nil {
+		t.Fatalf("failed to reserve account: %v", err)
+	}
+	if err := pool.addRemoteSync(tx1); !errors.Is(err, ErrAlreadyReserved) {
+		t.Fatalf("reserved account error mismatch: have %v, want %v", err, ErrAlreadyReserved)
+	}
+	if err := other(addr1, false); err != nil {
+		t.Fatalf("failed to release account: %v", err)
+	}
+	// Pooled accounts, pending or queued, are owned by the pool
+	for i, tx := range []*types.Transaction{tx1, tx2, queuedTx2} {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	for _, addr := range []common.Address{addr1, addr2} {
+		if err := other(addr, true); !errors.Is(err, ErrAlreadyReserved) {
+			t.Fatalf("pooled account %x error mismatch: have %v, want %v", addr, err, ErrAlreadyReserved)
+		}
+	}
+	// Accounts are released once all their transactions left the pool
+	pool.mu.Lock()
+	pool.removeTx(tx1.Hash(), true, true)
+	pool.removeTx(tx2.Hash(), true, true)
+	pool.mu.Unlock()
+
+	if err := other(addr1, true); err != nil {
+		t.Fatalf("drained account not released: %v", err)
+	}
+	if err := other(addr2, true); !errors.Is(err, ErrAlreadyReserved) {
+		t.Fatalf("queued account error mismatch: have %v, want %v", err, ErrAlreadyReserved)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 func TestDoubleNonce(t *testing.T) {
 	t.Parallel()
 
@@ -452,7 +521,7 @@ func TestDoubleNonce(t *testing.T) {
 	addr := crypto.PubkeyToAddress(key.PublicKey)
 	resetState := func() {
 		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
-		statedb.AddBalance(addr, big.NewInt(100000000000000))
+		statedb.AddBalance(addr, big.NewInt(100000000000000), tracing.BalanceChangeUnspecified)
 
 		pool.chain = &testBlockChain{1000000, statedb, new(event.Feed)}
 		<-pool.requestReset(nil, nil)
@@ -654,7 +723,7 @@ func TestPostponing(t *testing.T) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
 
-	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create two test accounts to produce different gap profiles with
@@ -870,7 +939,7 @@ func testQueueGlobalLimiting(t *testing.T, nolocals bool) {
 	config.NoLocals = nolocals
 	config.GlobalQueue = config.AccountQueue*3 - 1 // reduce the queue limits to shorten test time (-1 to make it non divisible)
 
-	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool := NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create a number of test accounts and fund them (last one will be the local)
@@ -962,7 +1031,7 @@ func testQueueTimeLimiting(t *testing.T, nolocals bool) {
 	config.Lifetime = time.Second
 	config.NoLocals = nolocals
 
-	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool := NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create two test accounts to ensure remotes expire but locals do not
@@ -1146,7 +1215,7 @@ func TestPendingGlobalLimiting(t *testing.T) {
 	config := testTxPoolConfig
 	config.GlobalSlots = config.AccountSlots * 10
 
-	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool := NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create a number of test accounts and fund them
@@ -1250,7 +1319,7 @@ func TestCapClearsFromAll(t *testing.T) {
 	config.AccountQueue = 2
 	config.GlobalSlots = 8
 
-	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool := NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create a number of test accounts and fund them
@@ -1282,7 +1351,7 @@ func TestPendingMinimumAllowance(t *testing.T) {
 	config := testTxPoolConfig
 	config.GlobalSlots = 1
 
-	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool := NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create a number of test accounts and fund them
@@ -1327,7 +1396,7 @@ func TestRepricing(t *testing.T) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
 
-	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Keep track of transaction events to ensure all executables get announced
@@ -1575,7 +1644,7 @@ func TestRepricingKeepsLocals(t *testing.T) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
 
-	pool := NewTxPool(testTxPoolConfig, eip1559Config, blockchain)
+	pool := NewTxPool(testTxPoolConfig, eip1559Config, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create a number of test accounts and fund them
@@ -1652,7 +1721,7 @@ func TestUnderpricing(t *testing.T) {
 	config.GlobalSlots = 2
 	config.GlobalQueue = 2
 
-	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool := NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Keep track of transaction events to ensure all executables get announced
@@ -1758,7 +1827,7 @@ func TestStableUnderpricing(t *testing.T) {
 	config.GlobalSlots = 128
 	config.GlobalQueue = 0
 
-	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool := NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Keep track of transaction events to ensure all executables get announced
@@ -1986,7 +2055,7 @@ func TestDeduplication(t *testing.T) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
 
-	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create a test account to add transactions with
@@ -2052,7 +2121,7 @@ func TestReplacement(t *testing.T) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
 
-	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Keep track of transaction events to ensure all executables get announced
@@ -2262,7 +2331,7 @@ func testJournaling(t *testing.T, nolocals bool) {
 	config.Journal = journal
 	config.Rejournal = time.Second
 
-	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool := NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 
 	// Create two test accounts to ensure remotes expire but locals do not
 	local, _ := crypto.GenerateKey()
@@ -2299,7 +2368,7 @@ func testJournaling(t *testing.T, nolocals bool) {
 	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
 	blockchain = &testBlockChain{1000000, statedb, new(event.Feed)}
 
-	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	pool = NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 
 	pending, queued = pool.Stats()
 	if queued != 0 {
@@ -2325,7 +2394,7 @@ func testJournaling(t *testing.T, nolocals bool) {
 
 	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
 	blockchain = &testBlockChain{1000000, statedb, new(event.Feed)}
-	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	pool = NewTxPool(config, params.TestChainConfig, blockchain, nil, nil)
 
 	pending, queued = pool.Stats()
 	if pending != 0 {
@@ -2355,7 +2424,7 @@ func TestStatusCheck(t *testing.T) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
 
-	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain, nil, nil)
 	defer pool.Stop()
 
 	// Create the test accounts to check various transaction statuses with
@@ -2550,7 +2619,7 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		key, _ := crypto.GenerateKey()
 		account := crypto.PubkeyToAddress(key.PublicKey)
-		pool.currentState.AddBalance(account, big.NewInt(1000000))
+		pool.currentState.AddBalance(account, big.NewInt(1000000), tracing.BalanceChangeUnspecified)
 		tx := transaction(uint64(0), 100000, key)
 		batches[i] = tx
 	}
@@ -2560,3 +2629,169 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 		pool.AddRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// Tests that the admission filters reject transactions with the appropriate
+// errors and can be reconfigured on the fly.
+func TestAdmissionFilter(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilter(FilterConfig{})
+	pool, key := setupPoolWithFilter(params.TestChainConfig, filter)
+	defer pool.Stop()
+
+	var (
+		from     = crypto.PubkeyToAddress(key.PublicKey)
+		other, _ = crypto.GenerateKey()
+		otherTo  = crypto.PubkeyToAddress(other.PublicKey)
+		signer   = types.HomesteadSigner{}
+	)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+	testAddBalance(pool, otherTo, big.NewInt(1000000000))
+
+	create := func(nonce uint64, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewContractCreation(nonce, big.NewInt(0), 100000, big.NewInt(1), nil), signer, key)
+		return tx
+	}
+	send := func(nonce uint64, to common.Address, data []byte, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1), 100000, big.NewInt(1), data), signer, key)
+		return tx
+	}
+	tests := []struct {
+		filter FilterConfig
+		tx     *types.Transaction
+		err    error
+	}{
+		{FilterConfig{Allow: []common.Address{otherTo}}, transaction(0, 100000, key), ErrSenderNotAllowed},
+		{FilterConfig{Deny: []common.Address{from}}, transaction(0, 100000, key), ErrSenderDenied},
+		{FilterConfig{Deny: []common.Address{otherTo}}, send(0, otherTo, nil, key), ErrRecipientDenied},
+		{FilterConfig{NoCreate: true}, create(0, key), ErrCreationNotAllowed},
+		{FilterConfig{NoCreate: true, Creators: []common.Address{from}}, create(0, key), nil},
+		{FilterConfig{MaxCalldata: 4}, send(0, otherTo, make([]byte, 5), key), ErrCalldataTooLarge},
+		{FilterConfig{MaxCalldata: 4}, send(0, otherTo, make([]byte, 4), key), nil},
+		{FilterConfig{Allow: []common.Address{from}, Deny: []common.Address{{0xff}}}, transaction(0, 100000, key), nil},
+	}
+	for i, tt := range tests {
+		filter.SetConfig(tt.filter)
+		if err := pool.addRemoteSync(tt.tx); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		pool.mu.Lock()
+		pool.removeTx(tt.tx.Hash(), true, true)
+		pool.mu.Unlock()
+	}
+	// Ensure the rejection errors carry distinct JSON-RPC error codes
+	codes := make(map[int]bool)
+	for _, err := range []*FilterError{ErrSenderNotAllowed, ErrSenderDenied, ErrRecipientDenied, ErrCreationNotAllowed, ErrSenderRateLimited, ErrCalldataTooLarge} {
+		if codes[err.ErrorCode()] {
+			t.Errorf("duplicate error code %d for %q", err.ErrorCode(), err)
+		}
+		codes[err.ErrorCode()] = true
+	}
+}
+
+// Tests that denying a sender at runtime drops its pooled transactions.
+func TestAdmissionFilterDrop(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilter(FilterConfig{})
+	pool, key := setupPoolWithFilter(params.TestChainConfig, filter)
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+
+	// Add a pending and a queued transaction, then deny the sender
+	if err := pool.addRemoteSync(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add pending transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(2, 100000, key)); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool contents mismatch: have %d/%d pending/queued, want 1/1", pending, queued)
+	}
+	filter.SetConfig(FilterConfig{Deny: []common.Address{from}})
+	if dropped := pool.DropFiltered(); dropped != 2 {
+		t.Fatalf("dropped transaction count mismatch: have %d, want 2", dropped)
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("denied transactions not dropped: have %d/%d pending/queued", pending, queued)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	if have := filter.Config(); len(have.Deny) != 1 || have.Deny[0] != from {
+		t.Fatalf("filter mismatch: have %v", have.Deny)
+	}
+}
+
+// Tests that the per-sender rate limit only admits the configured number of
+// transactions within a rate window.
+func TestAdmissionFilterRateLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		filter   = NewFilter(FilterConfig{SenderRate: 2})
+		key, _   = crypto.GenerateKey()
+		from     = crypto.PubkeyToAddress(key.PublicKey)
+		other, _ = crypto.GenerateKey()
+		now      = time.Now()
+	)
+	for i := 0; i < 2; i++ {
+		if err := filter.check(from, transaction(uint64(i), 100000, key), now); err != nil {
+			t.Fatalf("transaction %d: failed to admit: %v", i, err)
+		}
+		filter.admit(from, now)
+	}
+	if err := filter.check(from, transaction(2, 100000, key), now.Add(rateWindow/2)); err != ErrSenderRateLimited {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSenderRateLimited)
+	}
+	if err := filter.check(crypto.PubkeyToAddress(other.PublicKey), transaction(0, 100000, other), now); err != nil {
+		t.Fatalf("unrelated sender limited: %v", err)
+	}
+	filter.admit(crypto.PubkeyToAddress(other.PublicKey), now)
+
+	// Once the window elapses, the sender should be admitted again and the
+	// counters of the idle senders dropped
+	if err := filter.check(from, transaction(2, 100000, key), now.Add(rateWindow)); err != nil {
+		t.Fatalf("failed to admit after window: %v", err)
+	}
+	filter.admit(from, now.Add(rateWindow))
+	if len(filter.rates) != 1 {
+		t.Fatalf("rate counters not expired: %d left", len(filter.rates))
+	}
+}
+
+// Tests that only the transactions accepted by the pool count against the rate
+// limit of their sender, and that a batch can't exceed it.
+func TestAdmissionFilterRateLimitAccepted(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilter(FilterConfig{SenderRate: 2})
+	pool, key := setupPoolWithFilter(params.TestChainConfig, filter)
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.addRemoteSync(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	// Rejected resubmissions and replacements should not use up the limit
+	if err := pool.addRemoteSync(transaction(0, 100000, key)); err != ErrAlreadyKnown {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 90000, big.NewInt(1), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	// A batch should only be admitted up to the remaining limit
+	errs := pool.AddRemotesSync([]*types.Transaction{transaction(1, 100000, key), transaction(2, 100000, key)})
+	if errs[0] != nil {
+		t.Fatalf("failed to add transaction: %v", errs[0])
+	}
+	if errs[1] != ErrSenderRateLimited {
+		t.Fatalf("error mismatch: have %v, want %v", errs[1], ErrSenderRateLimited)
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatch: have %d, want %d", pending, 2)
+	}
+}
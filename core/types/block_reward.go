@@ -17,10 +17,56 @@ const (
 
 type BlockRewardInsertType uint8
 
+const (
+	ValidatorReward BlockRewardInsertType = iota // reward credited to a validator address
+	CovenantReward                               // reward credited to a covenant nft
+)
+
+// BlockReward is a single reward credited by a block, either to a validator
+// address or to a covenant nft
+type BlockReward struct {
+	Type    BlockRewardInsertType
+	Address common.Address // rewarded validator, set for validator rewards
+	TokenID uint64         // rewarded nft, set for covenant rewards
+	Reward  *big.Int       // newly issued tokens
+	Fee     *big.Int       // transaction fees
+}
+
+// CovenantTier is the rank of a covenant nft, promoted or demoted by one step on
+// every purge depending on the SCREE won since the previous purge
+type CovenantTier uint8
+
+const (
+	Legionnaire CovenantTier = iota
+	Cavalier
+	Templar
+	Paladin
+	Ascendance
+)
+
+func (t CovenantTier) String() string {
+	switch t {
+	case Legionnaire:
+		return "legionnaire"
+	case Cavalier:
+		return "cavalier"
+	case Templar:
+		return "templar"
+	case Paladin:
+		return "paladin"
+	case Ascendance:
+		return "ascendance"
+	default:
+		return fmt.Sprintf("tier(%d)", uint8(t))
+	}
+}
+
 type NodeRewardStorage struct {
-	ValidatorRecords    []ValidatorRewardRecord
-	CovenantRecords     []CovenantNFTRewardRecord
-	moonCalendarCounter int
+	ValidatorRecords   []ValidatorRewardRecord
+	CovenantRecords    []CovenantNFTRewardRecord
+	TotalFeeValidators *big.Int // total fee ever issued to validators
+	TotalFeeCovenants  *big.Int // total fee ever issued to covenant members
+	Day                uint64   // number of days closed since genesis
 }
 
 type RewardRecord struct {
@@ -40,6 +86,7 @@ type ValidatorRewardRecord struct {
 type CovenantNFTRewardRecord struct {
 	*RewardRecord
 	TokenID uint64
+	Tier    CovenantTier // tier the nft was ranked into by the last purge
 }
 
 // rpc type for RewardRecord
@@ -60,6 +107,18 @@ type RPCValidatorRewardRecord struct {
 type RPCCovenantNFTRewardRecord struct {
 	rpcRewardRecord `json:"rewardRecord"`
 	TokenID         hexutil.Uint64 `json:"tokenID"`
+	Tier            hexutil.Uint64 `json:"tier"`
+}
+
+// RPCBlockReward is the rpc type of a reward credited by a canonical block
+type RPCBlockReward struct {
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	Type        hexutil.Uint64  `json:"type"`
+	Address     *common.Address `json:"address,omitempty"`
+	TokenID     *hexutil.Uint64 `json:"tokenID,omitempty"`
+	Reward      *hexutil.Big    `json:"reward"`
+	Fee         *hexutil.Big    `json:"fee"`
 }
 
 func newRewardRecord() *RewardRecord {
@@ -73,6 +132,25 @@ func newRewardRecord() *RewardRecord {
 	}
 }
 
+func toRPCRewardRecord(r *RewardRecord) rpcRewardRecord {
+	rs := rpcRewardRecord{
+		RunningTotal:               (*hexutil.Big)(r.RunningTotal),
+		Daily:                      make([]*hexutil.Big, 0, len(r.Daily)),
+		Monthly:                    make([]*hexutil.Big, 0, len(r.Monthly)),
+		AmountEarnedToday:          (*hexutil.Big)(r.AmountEarnedToday),
+		AmountEarnedSinceLastPurge: (*hexutil.Big)(r.AmountEarnedSinceLastPurge),
+		AmountEarnedThisMoon:       (*hexutil.Big)(r.AmountEarnedThisMoon),
+	}
+	for _, d := range r.Daily {
+		rs.Daily = append(rs.Daily, (*hexutil.Big)(d))
+	}
+	for _, m := range r.Monthly {
+		rs.Monthly = append(rs.Monthly, (*hexutil.Big)(m))
+	}
+
+	return rs
+}
+
 func toRewardRecord(rpcRc rpcRewardRecord) *RewardRecord {
 	rs := newRewardRecord()
 	rs.RunningTotal = (*big.Int)(rpcRc.RunningTotal)
@@ -102,11 +180,125 @@ func (c *RPCCovenantNFTRewardRecord) ToCovenantNFTRewardRecord() *CovenantNFTRew
 	rs := &CovenantNFTRewardRecord{
 		RewardRecord: toRewardRecord(c.rpcRewardRecord),
 		TokenID:      uint64(c.TokenID),
+		Tier:         CovenantTier(c.Tier),
 	}
 
 	return rs
 }
 
+// NewRPCValidatorRewardRecord converts a validator record into its rpc representation
+func NewRPCValidatorRewardRecord(v *ValidatorRewardRecord) *RPCValidatorRewardRecord {
+	record := v.RewardRecord
+	if record == nil {
+		record = newRewardRecord()
+	}
+	return &RPCValidatorRewardRecord{
+		rpcRewardRecord: toRPCRewardRecord(record),
+		Address:         v.Address,
+	}
+}
+
+// NewRPCCovenantNFTRewardRecord converts a covenant nft record into its rpc representation
+func NewRPCCovenantNFTRewardRecord(c *CovenantNFTRewardRecord) *RPCCovenantNFTRewardRecord {
+	record := c.RewardRecord
+	if record == nil {
+		record = newRewardRecord()
+	}
+	return &RPCCovenantNFTRewardRecord{
+		rpcRewardRecord: toRPCRewardRecord(record),
+		TokenID:         hexutil.Uint64(c.TokenID),
+		Tier:            hexutil.Uint64(c.Tier),
+	}
+}
+
+// NewRPCBlockReward converts a reward credited by the given block into its rpc representation
+func NewRPCBlockReward(header *Header, reward *BlockReward) *RPCBlockReward {
+	r := &RPCBlockReward{
+		BlockNumber: hexutil.Uint64(header.Number.Uint64()),
+		BlockHash:   header.Hash(),
+		Type:        hexutil.Uint64(reward.Type),
+		Reward:      (*hexutil.Big)(bigOrZero(reward.Reward)),
+		Fee:         (*hexutil.Big)(bigOrZero(reward.Fee)),
+	}
+	switch reward.Type {
+	case ValidatorReward:
+		address := reward.Address
+		r.Address = &address
+	case CovenantReward:
+		tokenID := hexutil.Uint64(reward.TokenID)
+		r.TokenID = &tokenID
+	}
+	return r
+}
+
+// ToBlockReward converts the rpc representation back into a block reward
+func (r *RPCBlockReward) ToBlockReward() *BlockReward {
+	reward := &BlockReward{
+		Type:   BlockRewardInsertType(r.Type),
+		Reward: (*big.Int)(r.Reward),
+		Fee:    (*big.Int)(r.Fee),
+	}
+	if r.Address != nil {
+		reward.Address = *r.Address
+	}
+	if r.TokenID != nil {
+		reward.TokenID = uint64(*r.TokenID)
+	}
+	return reward
+}
+
+// Copy returns a deep copy of the reward record
+func (r *RewardRecord) Copy() *RewardRecord {
+	cpy := &RewardRecord{
+		RunningTotal:               copyBig(r.RunningTotal),
+		Daily:                      make([]*big.Int, 0, len(r.Daily)),
+		Monthly:                    make([]*big.Int, 0, len(r.Monthly)),
+		AmountEarnedToday:          copyBig(r.AmountEarnedToday),
+		AmountEarnedSinceLastPurge: copyBig(r.AmountEarnedSinceLastPurge),
+		AmountEarnedThisMoon:       copyBig(r.AmountEarnedThisMoon),
+	}
+	for _, d := range r.Daily {
+		cpy.Daily = append(cpy.Daily, copyBig(d))
+	}
+	for _, m := range r.Monthly {
+		cpy.Monthly = append(cpy.Monthly, copyBig(m))
+	}
+	return cpy
+}
+
+// credit adds newly issued tokens to all running amounts of the record
+func (r *RewardRecord) credit(amount *big.Int) {
+	r.RunningTotal = new(big.Int).Add(bigOrZero(r.RunningTotal), amount)
+	r.AmountEarnedToday = new(big.Int).Add(bigOrZero(r.AmountEarnedToday), amount)
+	r.AmountEarnedSinceLastPurge = new(big.Int).Add(bigOrZero(r.AmountEarnedSinceLastPurge), amount)
+	r.AmountEarnedThisMoon = new(big.Int).Add(bigOrZero(r.AmountEarnedThisMoon), amount)
+}
+
+// closeDay moves the amount earned today into the daily history, keeping at
+// most MaxDateInMonth days
+func (r *RewardRecord) closeDay() {
+	r.Daily = append(r.Daily, bigOrZero(r.AmountEarnedToday))
+	if len(r.Daily) > MaxDateInMonth {
+		r.Daily = r.Daily[len(r.Daily)-MaxDateInMonth:]
+	}
+	r.AmountEarnedToday = big.NewInt(0)
+}
+
+// closeMoon moves the amount earned this moon into the monthly history, keeping
+// at most MaxMonthInYear moons
+func (r *RewardRecord) closeMoon() {
+	r.Monthly = append(r.Monthly, bigOrZero(r.AmountEarnedThisMoon))
+	if len(r.Monthly) > MaxMonthInYear {
+		r.Monthly = r.Monthly[len(r.Monthly)-MaxMonthInYear:]
+	}
+	r.AmountEarnedThisMoon = big.NewInt(0)
+}
+
+// closePurge resets the amount earned since the last purge
+func (r *RewardRecord) closePurge() {
+	r.AmountEarnedSinceLastPurge = big.NewInt(0)
+}
+
 func (r *RewardRecord) GetTotalTokensEarnedToday() *big.Int {
 	return r.AmountEarnedToday
 }
@@ -148,9 +340,161 @@ func (r *RewardRecord) GetTotalTokensEarnedSinceLastPurge() *big.Int {
 	return r.AmountEarnedSinceLastPurge
 }
 
+// MoonCalendarCounter returns the number of days closed in the current moon
+func (s *NodeRewardStorage) MoonCalendarCounter() int {
+	return int(s.Day % MaxDateInMonth)
+}
+
+// Credit books a block reward into the record of the rewarded validator or
+// covenant nft, creating the record if it doesn't exist yet
+func (s *NodeRewardStorage) Credit(reward *BlockReward) {
+	var record *RewardRecord
+	switch reward.Type {
+	case ValidatorReward:
+		if v := s.ValidatorRecord(reward.Address); v != nil {
+			record = v.RewardRecord
+		} else {
+			record = newRewardRecord()
+			s.ValidatorRecords = append(s.ValidatorRecords, ValidatorRewardRecord{RewardRecord: record, Address: reward.Address})
+		}
+		if reward.Fee != nil {
+			s.TotalFeeValidators = new(big.Int).Add(bigOrZero(s.TotalFeeValidators), reward.Fee)
+		}
+	case CovenantReward:
+		if c := s.CovenantRecord(reward.TokenID); c != nil {
+			record = c.RewardRecord
+		} else {
+			record = newRewardRecord()
+			s.CovenantRecords = append(s.CovenantRecords, CovenantNFTRewardRecord{RewardRecord: record, TokenID: reward.TokenID})
+		}
+		if reward.Fee != nil {
+			s.TotalFeeCovenants = new(big.Int).Add(bigOrZero(s.TotalFeeCovenants), reward.Fee)
+		}
+	default:
+		return
+	}
+	if reward.Reward != nil {
+		record.credit(reward.Reward)
+	}
+}
+
+// Purges returns the number of purge periods closed since genesis
+func (s *NodeRewardStorage) Purges() uint64 {
+	return s.Day / PurgePeriod
+}
+
+// IsPurgeDay reports whether closing the current day also closes a purge period
+func (s *NodeRewardStorage) IsPurgeDay() bool {
+	return (s.Day+1)%PurgePeriod == 0
+}
+
+// CloseDay moves today's earnings of every record into the daily history and
+// starts a new day. Every MaxDateInMonth days the moon is closed and every
+// PurgePeriod days the purge period is closed as well
+func (s *NodeRewardStorage) CloseDay() {
+	s.Day++
+	closeMoon, closePurge := s.Day%MaxDateInMonth == 0, s.Day%PurgePeriod == 0
+
+	roll := func(r *RewardRecord) {
+		r.closeDay()
+		if closeMoon {
+			r.closeMoon()
+		}
+		if closePurge {
+			r.closePurge()
+		}
+	}
+	for _, v := range s.ValidatorRecords {
+		roll(v.RewardRecord)
+	}
+	for _, c := range s.CovenantRecords {
+		roll(c.RewardRecord)
+	}
+}
+
+// Copy returns a deep copy of the reward storage
+func (s *NodeRewardStorage) Copy() *NodeRewardStorage {
+	cpy := &NodeRewardStorage{
+		ValidatorRecords:   make([]ValidatorRewardRecord, 0, len(s.ValidatorRecords)),
+		CovenantRecords:    make([]CovenantNFTRewardRecord, 0, len(s.CovenantRecords)),
+		TotalFeeValidators: copyBig(s.TotalFeeValidators),
+		TotalFeeCovenants:  copyBig(s.TotalFeeCovenants),
+		Day:                s.Day,
+	}
+	for _, v := range s.ValidatorRecords {
+		cpy.ValidatorRecords = append(cpy.ValidatorRecords, ValidatorRewardRecord{RewardRecord: v.RewardRecord.Copy(), Address: v.Address})
+	}
+	for _, c := range s.CovenantRecords {
+		cpy.CovenantRecords = append(cpy.CovenantRecords, CovenantNFTRewardRecord{RewardRecord: c.RewardRecord.Copy(), TokenID: c.TokenID, Tier: c.Tier})
+	}
+	return cpy
+}
+
+// ValidatorRecord returns the reward record of the given validator, or nil if
+// the validator has never been rewarded
+func (s *NodeRewardStorage) ValidatorRecord(address common.Address) *ValidatorRewardRecord {
+	for i := range s.ValidatorRecords {
+		if s.ValidatorRecords[i].Address == address {
+			return &s.ValidatorRecords[i]
+		}
+	}
+	return nil
+}
+
+// CovenantRecord returns the reward record of the given covenant nft, or nil if
+// the nft has never been rewarded
+func (s *NodeRewardStorage) CovenantRecord(tokenID uint64) *CovenantNFTRewardRecord {
+	for i := range s.CovenantRecords {
+		if s.CovenantRecords[i].TokenID == tokenID {
+			return &s.CovenantRecords[i]
+		}
+	}
+	return nil
+}
+
+// TotalSupplyValidators returns the running total of all tokens issued to validators
+func (s *NodeRewardStorage) TotalSupplyValidators() *big.Int {
+	total := big.NewInt(0)
+	for _, v := range s.ValidatorRecords {
+		if v.RewardRecord != nil && v.RunningTotal != nil {
+			total.Add(total, v.RunningTotal)
+		}
+	}
+	return total
+}
+
+// TotalSupplyCovenant returns the running total of all tokens issued to covenant members
+func (s *NodeRewardStorage) TotalSupplyCovenant() *big.Int {
+	total := big.NewInt(0)
+	for _, c := range s.CovenantRecords {
+		if c.RewardRecord != nil && c.RunningTotal != nil {
+			total.Add(total, c.RunningTotal)
+		}
+	}
+	return total
+}
+
+// TotalSupply returns the running total of all tokens ever issued to validators
+// and covenant members
+func (s *NodeRewardStorage) TotalSupply() *big.Int {
+	return new(big.Int).Add(s.TotalSupplyValidators(), s.TotalSupplyCovenant())
+}
+
+// TotalFee returns the total fee ever issued to validators and covenant members
+func (s *NodeRewardStorage) TotalFee() *big.Int {
+	total := big.NewInt(0)
+	if s.TotalFeeValidators != nil {
+		total.Add(total, s.TotalFeeValidators)
+	}
+	if s.TotalFeeCovenants != nil {
+		total.Add(total, s.TotalFeeCovenants)
+	}
+	return total
+}
+
 func (s *NodeRewardStorage) String() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("MoonCounter: %d\n", s.moonCalendarCounter))
+	sb.WriteString(fmt.Sprintf("MoonCounter: %d\n", s.MoonCalendarCounter()))
 	sb.WriteString(fmt.Sprintf("ValidatorRecords: %v\n", s.ValidatorRecords))
 	sb.WriteString(fmt.Sprintf("CovenantRecords: %v\n", s.CovenantRecords))
 
@@ -173,5 +517,19 @@ func (v *ValidatorRewardRecord) String() string {
 }
 
 func (c *CovenantNFTRewardRecord) String() string {
-	return fmt.Sprintf("[TokenID: %v, record: %s]", c.TokenID, c.RewardRecord.String())
+	return fmt.Sprintf("[TokenID: %v, Tier: %v, record: %s]", c.TokenID, c.Tier, c.RewardRecord.String())
+}
+
+func bigOrZero(b *big.Int) *big.Int {
+	if b == nil {
+		return big.NewInt(0)
+	}
+	return b
+}
+
+func copyBig(b *big.Int) *big.Int {
+	if b == nil {
+		return nil
+	}
+	return new(big.Int).Set(b)
 }
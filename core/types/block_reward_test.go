@@ -0,0 +1,142 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that crediting rewards books them into the right records and totals.
+func TestNodeRewardStorageCredit(t *testing.T) {
+	storage := new(NodeRewardStorage)
+	storage.Credit(&BlockReward{Type: ValidatorReward, Address: common.Address{1}, Reward: big.NewInt(10), Fee: big.NewInt(1)})
+	storage.Credit(&BlockReward{Type: ValidatorReward, Address: common.Address{1}, Reward: big.NewInt(5), Fee: big.NewInt(2)})
+	storage.Credit(&BlockReward{Type: CovenantReward, TokenID: 3, Reward: big.NewInt(7), Fee: big.NewInt(4)})
+
+	if len(storage.ValidatorRecords) != 1 || len(storage.CovenantRecords) != 1 {
+		t.Fatalf("record count mismatch: validators %d, covenants %d", len(storage.ValidatorRecords), len(storage.CovenantRecords))
+	}
+	validator := storage.ValidatorRecord(common.Address{1})
+	for name, have := range map[string]*big.Int{
+		"running":    validator.RunningTotal,
+		"today":      validator.AmountEarnedToday,
+		"purge":      validator.AmountEarnedSinceLastPurge,
+		"moon":       validator.AmountEarnedThisMoon,
+		"supply":     storage.TotalSupplyValidators(),
+		"validators": storage.TotalFeeValidators,
+	} {
+		want := int64(15)
+		if name == "validators" {
+			want = 3
+		}
+		if have.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("validator %s amount mismatch: have %v, want %v", name, have, want)
+		}
+	}
+	if have := storage.TotalSupply(); have.Cmp(big.NewInt(22)) != 0 {
+		t.Errorf("total supply mismatch: have %v, want %v", have, 22)
+	}
+	if have := storage.TotalFee(); have.Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("total fee mismatch: have %v, want %v", have, 7)
+	}
+}
+
+// Tests that closing days rolls the daily, moon and purge amounts over and
+// caps the histories.
+func TestNodeRewardStorageCloseDay(t *testing.T) {
+	storage := new(NodeRewardStorage)
+	for day := 1; day <= MaxDateInMonth*(MaxMonthInYear+1); day++ {
+		storage.Credit(&BlockReward{Type: ValidatorReward, Reward: big.NewInt(int64(day))})
+
+		purge := storage.IsPurgeDay()
+		storage.CloseDay()
+
+		if purge != (storage.Day%PurgePeriod == 0) {
+			t.Fatalf("day %d: purge day mismatch", day)
+		}
+		record := storage.ValidatorRecords[0]
+		if record.AmountEarnedToday.Sign() != 0 {
+			t.Fatalf("day %d: today not reset: %v", day, record.AmountEarnedToday)
+		}
+		if last := record.Daily[len(record.Daily)-1]; last.Cmp(big.NewInt(int64(day))) != 0 {
+			t.Fatalf("day %d: daily history mismatch: have %v", day, last)
+		}
+		if len(record.Daily) > MaxDateInMonth {
+			t.Fatalf("day %d: daily history too long: %d", day, len(record.Daily))
+		}
+		if purge && record.AmountEarnedSinceLastPurge.Sign() != 0 {
+			t.Fatalf("day %d: purge amount not reset: %v", day, record.AmountEarnedSinceLastPurge)
+		}
+		if day%MaxDateInMonth == 0 {
+			if record.AmountEarnedThisMoon.Sign() != 0 {
+				t.Fatalf("day %d: moon amount not reset: %v", day, record.AmountEarnedThisMoon)
+			}
+			// The closed moon earned the sum of its days
+			var (
+				first = int64(day - MaxDateInMonth + 1)
+				last  = int64(day)
+				want  = (first + last) * MaxDateInMonth / 2
+			)
+			if have := record.GetTotalTokensEarnedLastMonth(); have.Cmp(big.NewInt(want)) != 0 {
+				t.Fatalf("day %d: moon history mismatch: have %v, want %v", day, have, want)
+			}
+		}
+	}
+	if have := len(storage.ValidatorRecords[0].Monthly); have != MaxMonthInYear {
+		t.Fatalf("monthly history length mismatch: have %d, want %d", have, MaxMonthInYear)
+	}
+	if storage.MoonCalendarCounter() != 0 {
+		t.Fatalf("moon calendar counter mismatch: have %d, want 0", storage.MoonCalendarCounter())
+	}
+}
+
+// Tests that copies of the reward storage are independent and survive an RLP
+// round trip.
+func TestNodeRewardStorageCopy(t *testing.T) {
+	storage := new(NodeRewardStorage)
+	storage.Credit(&BlockReward{Type: ValidatorReward, Address: common.Address{1}, Reward: big.NewInt(10), Fee: big.NewInt(1)})
+	storage.CloseDay()
+	storage.Credit(&BlockReward{Type: CovenantReward, TokenID: 3, Reward: big.NewInt(7), Fee: big.NewInt(4)})
+
+	want, err := rlp.EncodeToBytes(storage)
+	if err != nil {
+		t.Fatalf("failed to encode storage: %v", err)
+	}
+	cpy := storage.Copy()
+	storage.Credit(&BlockReward{Type: ValidatorReward, Address: common.Address{1}, Reward: big.NewInt(10), Fee: big.NewInt(1)})
+	storage.CloseDay()
+
+	have, err := rlp.EncodeToBytes(cpy)
+	if err != nil {
+		t.Fatalf("failed to encode copy: %v", err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Fatalf("copy modified by original")
+	}
+	var dec NodeRewardStorage
+	if err := rlp.DecodeBytes(have, &dec); err != nil {
+		t.Fatalf("failed to decode copy: %v", err)
+	}
+	if dec.Day != 1 || dec.ValidatorRecord(common.Address{1}).Daily[0].Cmp(big.NewInt(10)) != 0 {
+		t.Fatalf("decoded storage mismatch: %v", &dec)
+	}
+}
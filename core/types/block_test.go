@@ -203,6 +203,56 @@ func TestUncleHash(t *testing.T) {
 	}
 }
 
+func TestWithdrawalsBlockEncoding(t *testing.T) {
+	header := &Header{
+		Difficulty: common.Big0,
+		Number:     big.NewInt(100),
+		GasLimit:   30_000_000,
+		Time:       1234,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+	withdrawals := []*Withdrawal{
+		{Index: 0, Validator: 1, Address: common.Address{0xaa}, Amount: 32_000_000_000},
+		{Index: 1, Validator: 7, Address: common.Address{0xbb}, Amount: 12},
+	}
+	block := NewBlockWithWithdrawals(header, nil, nil, nil, withdrawals, newHasher())
+	if block.Header().WithdrawalsHash == nil {
+		t.Fatal("missing withdrawals hash")
+	}
+	if want := DeriveSha(Withdrawals(withdrawals), newHasher()); *block.Header().WithdrawalsHash != want {
+		t.Fatalf("withdrawals hash mismatch: have %x, want %x", *block.Header().WithdrawalsHash, want)
+	}
+	enc, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var dec Block
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if dec.Hash() != block.Hash() {
+		t.Fatalf("block hash mismatch: have %x, want %x", dec.Hash(), block.Hash())
+	}
+	if !reflect.DeepEqual(dec.Withdrawals(), block.Withdrawals()) {
+		t.Fatalf("withdrawals mismatch: have %v, want %v", dec.Withdrawals(), block.Withdrawals())
+	}
+	// An empty withdrawal list must be distinguishable from a missing one
+	empty := NewBlockWithWithdrawals(header, nil, nil, nil, []*Withdrawal{}, newHasher())
+	if h := empty.Header().WithdrawalsHash; h == nil || *h != EmptyRootHash {
+		t.Fatalf("empty withdrawals hash mismatch: have %v, want %x", h, EmptyRootHash)
+	}
+	if !empty.Header().EmptyBody() {
+		t.Fatal("block with empty withdrawals reported as non-empty")
+	}
+	if block.Header().EmptyBody() {
+		t.Fatal("block with withdrawals reported as empty")
+	}
+	legacy := NewBlockWithWithdrawals(header, nil, nil, nil, nil, newHasher())
+	if legacy.Header().WithdrawalsHash != nil {
+		t.Fatal("unexpected withdrawals hash for nil withdrawals")
+	}
+}
+
 var benchBuffer = bytes.NewBuffer(make([]byte, 0, 32000))
 
 func BenchmarkEncodeBlock(b *testing.B) {
@@ -0,0 +1,35 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// InternalCall is the compact summary of a single call frame executed by a
+// transaction, as persisted by the trace index.
+type InternalCall struct {
+	TxIndex uint64         // index of the transaction within the block
+	Depth   uint64         // call depth of the frame, zero for the transaction itself
+	Type    byte           // opcode which entered the frame (CALL, CREATE, SELFDESTRUCT, ...)
+	From    common.Address // caller of the frame
+	To      common.Address // callee of the frame, the created contract for creations
+	Value   *big.Int       // ether transferred by the frame
+	Error   string         // failure reason of the frame, empty if it succeeded
+}
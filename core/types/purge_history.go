@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"strings"
 )
@@ -17,6 +18,15 @@ type PurgeHistoryResultJSON struct {
 	PromotedLegionnaire []hexutil.Uint64 `json:"promoted_legionnaire"`
 }
 
+// RPCPurgeResult is the rpc type of a purge run on the closing of a purge period
+type RPCPurgeResult struct {
+	Index       hexutil.Uint64          `json:"index"`
+	BlockNumber hexutil.Uint64          `json:"blockNumber"`
+	BlockHash   common.Hash             `json:"blockHash"`
+	Result      *PurgeHistoryResultJSON `json:"result"`
+}
+
+// PurgeHistoryResult lists the covenant nfts moved between tiers by a purge
 type PurgeHistoryResult struct {
 	DemotedAscendance   []uint64
 	PromotedPaladin     []uint64
@@ -28,6 +38,7 @@ type PurgeHistoryResult struct {
 	PromotedLegionnaire []uint64
 }
 
+// ToPurgeHistoryResult converts the rpc representation back into a purge result
 func (p *PurgeHistoryResultJSON) ToPurgeHistoryResult() *PurgeHistoryResult {
 	return &PurgeHistoryResult{
 		DemotedAscendance:   convertUtilUint64ArrayToUint64Array(p.DemotedAscendance),
@@ -41,6 +52,43 @@ func (p *PurgeHistoryResultJSON) ToPurgeHistoryResult() *PurgeHistoryResult {
 	}
 }
 
+// Moved reports whether the given nft was promoted or demoted by the purge
+func (p *PurgeHistoryResult) Moved(tokenID uint64) bool {
+	for _, ids := range [][]uint64{
+		p.DemotedAscendance, p.PromotedPaladin, p.DemotedPaladin, p.PromotedTemplar,
+		p.DemotedTemplar, p.PromotedCavalier, p.DemotedCavalier, p.PromotedLegionnaire,
+	} {
+		for _, id := range ids {
+			if id == tokenID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewPurgeHistoryResultJSON converts a purge result into its rpc representation
+func NewPurgeHistoryResultJSON(p *PurgeHistoryResult) *PurgeHistoryResultJSON {
+	return &PurgeHistoryResultJSON{
+		DemotedAscendance:   convertUint64ArrayToUtilUint64Array(p.DemotedAscendance),
+		PromotedPaladin:     convertUint64ArrayToUtilUint64Array(p.PromotedPaladin),
+		DemotedPaladin:      convertUint64ArrayToUtilUint64Array(p.DemotedPaladin),
+		PromotedTemplar:     convertUint64ArrayToUtilUint64Array(p.PromotedTemplar),
+		DemotedTemplar:      convertUint64ArrayToUtilUint64Array(p.DemotedTemplar),
+		PromotedCavalier:    convertUint64ArrayToUtilUint64Array(p.PromotedCavalier),
+		DemotedCavalier:     convertUint64ArrayToUtilUint64Array(p.DemotedCavalier),
+		PromotedLegionnaire: convertUint64ArrayToUtilUint64Array(p.PromotedLegionnaire),
+	}
+}
+
+func convertUint64ArrayToUtilUint64Array(arr []uint64) []hexutil.Uint64 {
+	rs := make([]hexutil.Uint64, 0)
+	for _, ele := range arr {
+		rs = append(rs, hexutil.Uint64(ele))
+	}
+	return rs
+}
+
 func convertUtilUint64ArrayToUint64Array(arr []hexutil.Uint64) []uint64 {
 	rs := make([]uint64, 0)
 	for _, ele := range arr {
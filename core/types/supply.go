@@ -0,0 +1,104 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SupplyDelta is the change of the ether supply caused by a single block, as
+// persisted by the supply tracker.
+type SupplyDelta struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+
+	Issuance SupplyIssuance // Ether created by the block
+	Burn     SupplyBurn     // Ether destroyed by the block
+
+	// Total supply after the block, nil if the supply of the parent is unknown
+	Total *big.Int `rlp:"optional"`
+}
+
+// SupplyIssuance is the ether created by a block, broken down by its source.
+type SupplyIssuance struct {
+	GenesisAlloc *big.Int // Balances allocated by the genesis block
+	Reward       *big.Int // Block and uncle rewards of the miner
+	Covenant     *big.Int // Rewards credited to covenant members, not part of the state
+	Withdrawals  *big.Int // Withdrawals from the beacon chain
+}
+
+// SupplyBurn is the ether destroyed by a block, broken down by its cause.
+type SupplyBurn struct {
+	BaseFee      *big.Int // Base fees and data fees burnt by the transactions
+	Selfdestruct *big.Int // Balances of accounts destroyed by SELFDESTRUCT
+}
+
+// NewSupplyDelta creates an empty supply delta of the given block.
+func NewSupplyDelta(number uint64, hash, parentHash common.Hash) *SupplyDelta {
+	return &SupplyDelta{
+		Number:     number,
+		Hash:       hash,
+		ParentHash: parentHash,
+		Issuance: SupplyIssuance{
+			GenesisAlloc: new(big.Int),
+			Reward:       new(big.Int),
+			Covenant:     new(big.Int),
+			Withdrawals:  new(big.Int),
+		},
+		Burn: SupplyBurn{
+			BaseFee:      new(big.Int),
+			Selfdestruct: new(big.Int),
+		},
+	}
+}
+
+// Issued returns the total ether created by the block in the state. Covenant
+// rewards are booked in the reward records instead of the balances, so they are
+// reported separately and left out of the supply the state is audited against.
+func (d *SupplyDelta) Issued() *big.Int {
+	issued := new(big.Int).Add(d.Issuance.GenesisAlloc, d.Issuance.Reward)
+	return issued.Add(issued, d.Issuance.Withdrawals)
+}
+
+// Burnt returns the total ether destroyed by the block.
+func (d *SupplyDelta) Burnt() *big.Int {
+	return new(big.Int).Add(d.Burn.BaseFee, d.Burn.Selfdestruct)
+}
+
+// Delta returns the net change of the supply caused by the block.
+func (d *SupplyDelta) Delta() *big.Int {
+	return new(big.Int).Sub(d.Issued(), d.Burnt())
+}
+
+// SupplyAudit is the result of cross-checking the tracked supply against the
+// balances of all the accounts in the state of a block.
+type SupplyAudit struct {
+	Number  uint64
+	Hash    common.Hash
+	Tracked *big.Int // Supply tracked by the supply deltas
+	State   *big.Int // Sum of all the balances in the state
+	Seeded  bool     // Whether the tracker was seeded with the state, as nothing was tracked before
+	Time    uint64   // Unix time of the audit
+}
+
+// Mismatch reports whether the tracked supply disagrees with the state.
+func (a *SupplyAudit) Mismatch() bool {
+	return a.Tracked.Cmp(a.State) != 0
+}
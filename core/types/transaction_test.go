@@ -25,6 +25,7 @@ import (
 	"math/big"
 	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -405,6 +406,113 @@ func TestTransactionTimeSort(t *testing.T) {
 	}
 }
 
+// Tests that transactions are returned in the order they were first seen
+// regardless of price, while honouring account nonces.
+func TestTransactionTimeNonceSort(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 5)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	signer := HomesteadSigner{}
+
+	// Generate transactions with interleaved creation times across the
+	// accounts, priced inversely to their age.
+	groups := map[common.Address]Transactions{}
+	for start, key := range keys[:4] {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		for i := 0; i < 3; i++ {
+			seen := start + i*4
+			tx, _ := SignTx(NewTransaction(uint64(i), common.Address{}, big.NewInt(100), 100, big.NewInt(int64(100-seen)), nil), signer, key)
+			tx.time = time.Unix(0, int64(seen))
+			groups[addr] = append(groups[addr], tx)
+		}
+	}
+	// The earliest transaction can't pay the base fee, dropping its account.
+	underpriced, _ := SignTx(NewTransaction(0, common.Address{}, big.NewInt(100), 100, big.NewInt(1), nil), signer, keys[4])
+	underpriced.time = time.Unix(0, -1)
+	groups[crypto.PubkeyToAddress(keys[4].PublicKey)] = Transactions{underpriced}
+
+	txset := NewTransactionsByTimeAndNonce(signer, groups, big.NewInt(50))
+
+	txs := Transactions{}
+	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
+		txs = append(txs, tx)
+		txset.Shift()
+	}
+	if len(txs) != 12 {
+		t.Fatalf("expected %d transactions, found %d", 12, len(txs))
+	}
+	for i, tx := range txs {
+		if want := time.Unix(0, int64(i)); !tx.time.Equal(want) {
+			t.Errorf("tx #%d: invalid time ordering: have %v, want %v", i, tx.time, want)
+		}
+	}
+}
+
+// Tests that transactions are returned one per account and round, visiting
+// the accounts in address order, while honouring account nonces.
+func TestTransactionRoundRobinSort(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	signer := HomesteadSigner{}
+
+	// Account n has n+1 transactions, with prices increasing with the nonce.
+	makeGroups := func() map[common.Address]Transactions {
+		groups := map[common.Address]Transactions{}
+		for n, key := range keys {
+			addr := crypto.PubkeyToAddress(key.PublicKey)
+			for i := 0; i <= n; i++ {
+				tx, _ := SignTx(NewTransaction(uint64(i), common.Address{}, big.NewInt(100), 100, big.NewInt(int64(10*i+1)), nil), signer, key)
+				groups[addr] = append(groups[addr], tx)
+			}
+		}
+		return groups
+	}
+	groups := makeGroups()
+	addrs := make([]common.Address, 0, len(groups))
+	for addr := range groups {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+
+	var want Transactions
+	for round := 0; round < len(keys); round++ {
+		for _, addr := range addrs {
+			if len(groups[addr]) > round {
+				want = append(want, groups[addr][round])
+			}
+		}
+	}
+	txset := NewTransactionsByRoundRobin(signer, groups, nil)
+
+	var have Transactions
+	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
+		have = append(have, tx)
+		txset.Shift()
+	}
+	if len(have) != len(want) {
+		t.Fatalf("expected %d transactions, found %d", len(want), len(have))
+	}
+	for i := range want {
+		if have[i].Hash() != want[i].Hash() {
+			t.Errorf("tx #%d: invalid ordering: have nonce %d, want nonce %d", i, have[i].Nonce(), want[i].Nonce())
+		}
+	}
+	// Popping the first account's transaction removes the account entirely.
+	groups = makeGroups()
+	txset = NewTransactionsByRoundRobin(signer, groups, nil)
+	txset.Pop()
+
+	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
+		if from, _ := Sender(signer, tx); from == addrs[0] {
+			t.Fatalf("transaction of popped account %x returned", from)
+		}
+		txset.Shift()
+	}
+}
+
 // TestTransactionCoding tests serializing/de-serializing to/from rlp and JSON.
 func TestTransactionCoding(t *testing.T) {
 	key, err := crypto.GenerateKey()
@@ -0,0 +1,88 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// Tests that blob transactions can be encoded both with and without their
+// sidecars, and that the sidecar does not affect the transaction identity.
+func TestBlobTxEncoding(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	sidecar := &BlobTxSidecar{
+		Blobs:       []kzg4844.Blob{{}},
+		Commitments: []kzg4844.Commitment{{0x01}},
+		Proofs:      []kzg4844.Proof{{0x02}},
+	}
+	signer := NewCancunSigner(big.NewInt(1))
+	tx, err := SignNewTx(key, signer, &BlobTx{
+		ChainID:    big.NewInt(1),
+		Nonce:      1,
+		GasTipCap:  big.NewInt(1),
+		GasFeeCap:  big.NewInt(10),
+		Gas:        21000,
+		To:         common.Address{0xaa},
+		Value:      big.NewInt(0),
+		BlobFeeCap: big.NewInt(1),
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign blob transaction: %v", err)
+	}
+	stripped := tx.WithoutBlobTxSidecar()
+	if stripped.BlobTxSidecar() != nil {
+		t.Fatalf("stripped transaction still has sidecar")
+	}
+	if tx.Hash() != stripped.Hash() {
+		t.Fatalf("hash mismatch: full %x, stripped %x", tx.Hash(), stripped.Hash())
+	}
+	// Round trip both encodings and ensure the sidecar is retained only if present
+	for i, want := range []*Transaction{tx, stripped} {
+		blob, err := want.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: failed to encode transaction: %v", i, err)
+		}
+		have := new(Transaction)
+		if err := have.UnmarshalBinary(blob); err != nil {
+			t.Fatalf("test %d: failed to decode transaction: %v", i, err)
+		}
+		if have.Hash() != want.Hash() {
+			t.Errorf("test %d: hash mismatch: have %x, want %x", i, have.Hash(), want.Hash())
+		}
+		if (have.BlobTxSidecar() == nil) != (want.BlobTxSidecar() == nil) {
+			t.Errorf("test %d: sidecar presence mismatch: have %v, want %v", i, have.BlobTxSidecar() != nil, want.BlobTxSidecar() != nil)
+		}
+		from, err := Sender(signer, have)
+		if err != nil {
+			t.Fatalf("test %d: failed to recover sender: %v", i, err)
+		}
+		if from != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("test %d: sender mismatch: have %x, want %x", i, from, crypto.PubkeyToAddress(key.PublicKey))
+		}
+	}
+	if len(stripped.BlobHashes()) != 1 || stripped.BlobGas() == 0 {
+		t.Errorf("stripped transaction lost its blob hashes")
+	}
+}
@@ -33,9 +33,13 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -71,6 +75,75 @@ func (api *EthereumAPI) Mining() bool {
 	return api.e.IsMining()
 }
 
+// BundleAPI provides an API to submit private bundles to the miner. It is
+// served in its own namespace, so operators can restrict it to their partners.
+type BundleAPI struct {
+	e *Ethereum
+}
+
+// NewBundleAPI creates a new private bundle API.
+func NewBundleAPI(e *Ethereum) *BundleAPI {
+	return &BundleAPI{e}
+}
+
+// SendBundleArgs represents the arguments of a private bundle submission.
+type SendBundleArgs struct {
+	Txs         []hexutil.Bytes `json:"txs"`
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+}
+
+// SendBundle submits a bundle of signed transactions which are included into a
+// block atomically and in the given order, ahead of the regular pool
+// transactions. If a block number is given, the bundle is only considered for
+// that block. The hash identifying the bundle is returned.
+func (api *BundleAPI) SendBundle(args SendBundleArgs) (common.Hash, error) {
+	txs := make(types.Transactions, len(args.Txs))
+	for i, encoded := range args.Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(encoded); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid tx %d: %w", i, err)
+		}
+		txs[i] = tx
+	}
+	return api.e.Miner().SendBundle(txs, uint64(args.BlockNumber))
+}
+
+// BundleStatus is the inclusion result of a private bundle.
+type BundleStatus struct {
+	Status      miner.BundleStatus `json:"status"`
+	BlockNumber *hexutil.Uint64    `json:"blockNumber"`
+	BlockHash   *common.Hash       `json:"blockHash"`
+	GasUsed     hexutil.Uint64     `json:"gasUsed"`
+	Attempts    hexutil.Uint64     `json:"attempts"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// GetBundleStatus returns the inclusion result of a previously submitted
+// bundle, or nil if the bundle is unknown. Pending bundles report the block they
+// were last simulated for and the error of the last failed simulation.
+func (api *BundleAPI) GetBundleStatus(hash common.Hash) *BundleStatus {
+	res := api.e.Miner().BundleResult(hash)
+	if res == nil {
+		return nil
+	}
+	status := &BundleStatus{
+		Status:   res.Status,
+		GasUsed:  hexutil.Uint64(res.GasUsed),
+		Attempts: hexutil.Uint64(res.Attempts),
+	}
+	if res.Block != 0 {
+		number := hexutil.Uint64(res.Block)
+		status.BlockNumber = &number
+	}
+	if res.Status == miner.BundleIncluded {
+		status.BlockHash = &res.BlockHash
+	}
+	if res.Err != nil {
+		status.Error = res.Err.Error()
+	}
+	return status
+}
+
 // MinerAPI provides an API to control the miner.
 type MinerAPI struct {
 	e *Ethereum
@@ -114,6 +187,7 @@ func (api *MinerAPI) SetGasPrice(gasPrice hexutil.Big) bool {
 	api.e.lock.Unlock()
 
 	api.e.txPool.SetGasPrice((*big.Int)(&gasPrice))
+	api.e.blobPool.SetGasTip((*big.Int)(&gasPrice))
 	return true
 }
 
@@ -243,6 +317,22 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// TxpoolFilter returns the admission policies enforced by the transaction pools.
+func (api *AdminAPI) TxpoolFilter() txpool.FilterConfig {
+	return api.eth.TxFilter().Config()
+}
+
+// TxpoolSetFilter replaces the admission policies of the transaction pools,
+// dropping the pooled transactions of senders that are no longer permitted.
+func (api *AdminAPI) TxpoolSetFilter(config txpool.FilterConfig) bool {
+	api.eth.TxFilter().SetConfig(config)
+	dropped := api.eth.TxPool().DropFiltered() + api.eth.BlobPool().DropFiltered()
+
+	log.Info("Updated transaction pool admission filter", "allow", len(config.Allow), "deny", len(config.Deny),
+		"nocreate", config.NoCreate, "creators", len(config.Creators), "senderrate", config.SenderRate, "maxcalldata", config.MaxCalldata, "dropped", dropped)
+	return true
+}
+
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
@@ -601,3 +691,88 @@ func (api *DebugAPI) SetTrieFlushInterval(interval string) error {
 	api.eth.blockchain.SetTrieFlushInterval(t)
 	return nil
 }
+
+// RebuildRewards drops the reward records accounted after the given block and
+// recomputes them from the canonical chain up to the current head.
+func (api *DebugAPI) RebuildRewards(from hexutil.Uint64) error {
+	head := api.eth.blockchain.CurrentBlock()
+	if uint64(from) > head.NumberU64() {
+		return fmt.Errorf("block #%d above head #%d", from, head.NumberU64())
+	}
+	return api.eth.blockchain.RewardStore().Rebuild(uint64(from), head.Header())
+}
+
+// balanceChangesReexec is the number of blocks GetBalanceChanges is willing to
+// re-execute to regenerate the state the requested block is built on.
+const balanceChangesReexec = 128
+
+// BalanceChangeResult is a balance change made by a block, as returned by the
+// debug_getBalanceChanges API call.
+type BalanceChangeResult struct {
+	TransactionIndex *hexutil.Uint   `json:"transactionIndex"`
+	TransactionHash  *common.Hash    `json:"transactionHash"`
+	Address          common.Address  `json:"address"`
+	TokenID          *hexutil.Uint64 `json:"tokenID,omitempty"`
+	Delta            *hexutil.Big    `json:"delta"`
+	Reason           string          `json:"reason"`
+}
+
+// GetBalanceChanges re-executes the given block and returns every balance change
+// it made, in execution order, attributed to its reason. Changes made outside
+// of transactions, like block rewards and withdrawals, have no transaction set,
+// and the fees burnt by a transaction are reported against the zero address.
+// Covenant rewards are reported last, against the zero address and the nft the
+// reward records credit them to.
+func (api *DebugAPI) GetBalanceChanges(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*BalanceChangeResult, error) {
+	block, err := api.eth.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	recorder := core.NewBalanceRecorder(api.eth.blockchain.Config(), api.eth.Engine())
+	if block.NumberU64() == 0 {
+		genesis, err := core.ReadGenesis(api.eth.ChainDb())
+		if err != nil {
+			return nil, err
+		}
+		recorder.OnGenesisBlock(block, genesis.Alloc)
+	} else {
+		parent := api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+		if parent == nil {
+			return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+		}
+		statedb, release, err := api.eth.StateAtBlock(parent, balanceChangesReexec, nil, true, false)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		statedb.SetLogger(recorder)
+		recorder.OnBlockStart(block, nil, nil, nil)
+		if _, _, _, err := api.eth.blockchain.Processor().Process(block, statedb, vm.Config{Tracer: recorder}); err != nil {
+			return nil, fmt.Errorf("failed to process block #%d: %v", block.NumberU64(), err)
+		}
+		recorder.OnBlockEnd(nil)
+	}
+	txs := block.Transactions()
+	results := make([]*BalanceChangeResult, 0, len(recorder.Changes()))
+	for _, change := range recorder.Changes() {
+		result := &BalanceChangeResult{
+			Address: change.Address,
+			Delta:   (*hexutil.Big)(change.Delta),
+			Reason:  change.Reason.String(),
+		}
+		if change.Reason == tracing.BalanceIncreaseRewardCovenant {
+			tokenID := hexutil.Uint64(change.TokenID)
+			result.TokenID = &tokenID
+		}
+		if change.TxIndex >= 0 {
+			index, hash := hexutil.Uint(change.TxIndex), txs[change.TxIndex].Hash()
+			result.TransactionIndex, result.TransactionHash = &index, &hash
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -128,11 +129,32 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 	if number == rpc.SafeBlockNumber {
 		return b.eth.blockchain.CurrentSafeBlock(), nil
 	}
-	return b.eth.blockchain.GetBlockByNumber(uint64(number)), nil
+	block := b.eth.blockchain.GetBlockByNumber(uint64(number))
+	if block == nil && uint64(number) < b.HistoryPruningCutoff() {
+		return nil, core.ErrPrunedHistory
+	}
+	return block, nil
 }
 
 func (b *EthAPIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	return b.eth.blockchain.GetBlockByHash(hash), nil
+	block := b.eth.blockchain.GetBlockByHash(hash)
+	if block == nil && b.isPruned(hash) {
+		return nil, core.ErrPrunedHistory
+	}
+	return block, nil
+}
+
+// HistoryPruningCutoff returns the number of the first block whose body and
+// receipts are available locally.
+func (b *EthAPIBackend) HistoryPruningCutoff() uint64 {
+	return b.eth.blockchain.HistoryPruningCutoff()
+}
+
+// isPruned reports whether the history of the block with the given hash has
+// been pruned, i.e. the header is known but older than the pruning cutoff.
+func (b *EthAPIBackend) isPruned(hash common.Hash) bool {
+	header := b.eth.blockchain.GetHeaderByHash(hash)
+	return header != nil && header.Number.Uint64() < b.HistoryPruningCutoff()
 }
 
 func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
@@ -149,6 +171,9 @@ func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash r
 		}
 		block := b.eth.blockchain.GetBlock(hash, header.Number.Uint64())
 		if block == nil {
+			if header.Number.Uint64() < b.HistoryPruningCutoff() {
+				return nil, core.ErrPrunedHistory
+			}
 			return nil, errors.New("header found, but block body is missing")
 		}
 		return block, nil
@@ -200,11 +225,19 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
-	return b.eth.blockchain.GetReceiptsByHash(hash), nil
+	receipts := b.eth.blockchain.GetReceiptsByHash(hash)
+	if receipts == nil && b.isPruned(hash) {
+		return nil, core.ErrPrunedHistory
+	}
+	return receipts, nil
 }
 
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash, number uint64) ([][]*types.Log, error) {
-	return rawdb.ReadLogs(b.eth.chainDb, hash, number, b.ChainConfig()), nil
+	logs := rawdb.ReadLogs(b.eth.chainDb, hash, number, b.ChainConfig())
+	if logs == nil && number < b.HistoryPruningCutoff() {
+		return nil, core.ErrPrunedHistory
+	}
+	return logs, nil
 }
 
 func (b *EthAPIBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
@@ -235,6 +268,14 @@ func (b *EthAPIBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Sub
 	return b.eth.BlockChain().SubscribeChainEvent(ch)
 }
 
+func (b *EthAPIBackend) SubscribeRewardsEvent(ch chan<- rewards.RewardsEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeRewardsEvent(ch)
+}
+
+func (b *EthAPIBackend) SubscribePurgeEvent(ch chan<- rewards.PurgeEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribePurgeEvent(ch)
+}
+
 func (b *EthAPIBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
 	return b.eth.BlockChain().SubscribeChainHeadEvent(ch)
 }
@@ -248,20 +289,28 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if signedTx.Type() == types.BlobTxType {
+		return b.eth.blobPool.Add([]*types.Transaction{signedTx})[0]
+	}
 	return b.eth.txPool.AddLocal(signedTx)
 }
 
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
-	pending := b.eth.txPool.Pending(false)
 	var txs types.Transactions
-	for _, batch := range pending {
+	for _, batch := range b.eth.txPool.Pending(false) {
+		txs = append(txs, batch...)
+	}
+	for _, batch := range b.eth.blobPool.Pending(false) {
 		txs = append(txs, batch...)
 	}
 	return txs, nil
 }
 
 func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
-	return b.eth.txPool.Get(hash)
+	if tx := b.eth.txPool.Get(hash); tx != nil {
+		return tx
+	}
+	return b.eth.blobPool.Get(hash)
 }
 
 func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
@@ -290,7 +339,10 @@ func (b *EthAPIBackend) TxPool() *txpool.TxPool {
 }
 
 func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
-	return b.eth.TxPool().SubscribeNewTxsEvent(ch)
+	return event.JoinSubscriptions(
+		b.eth.txPool.SubscribeNewTxsEvent(ch),
+		b.eth.blobPool.SubscribeNewTxsEvent(ch),
+	)
 }
 
 func (b *EthAPIBackend) SyncProgress() ethereum.SyncProgress {
@@ -352,6 +404,14 @@ func (b *EthAPIBackend) Engine() consensus.Engine {
 	return b.eth.engine
 }
 
+func (b *EthAPIBackend) RewardStorage(ctx context.Context) (*types.NodeRewardStorage, error) {
+	return b.eth.blockchain.RewardStore().Storage(), nil
+}
+
+func (b *EthAPIBackend) PurgeResult(ctx context.Context, index uint64) (*types.PurgeHistoryResult, error) {
+	return b.eth.blockchain.RewardStore().PurgeResult(index), nil
+}
+
 func (b *EthAPIBackend) CurrentHeader() *types.Header {
 	return b.eth.blockchain.CurrentHeader()
 }
@@ -90,7 +90,7 @@ func TestEth2AssembleBlock(t *testing.T) {
 		t.Fatalf("error signing transaction, err=%v", err)
 	}
 	ethservice.TxPool().AddLocal(tx)
-	blockParams := beacon.PayloadAttributesV1{
+	blockParams := beacon.PayloadAttributes{
 		Timestamp: blocks[9].Time() + 5,
 	}
 	// The miner needs to pick up on the txs in the pool, so a few retries might be
@@ -102,7 +102,7 @@ func TestEth2AssembleBlock(t *testing.T) {
 
 // assembleWithTransactions tries to assemble a block, retrying until it has 'want',
 // number of transactions in it, or it has retried three times.
-func assembleWithTransactions(api *ConsensusAPI, parentHash common.Hash, params *beacon.PayloadAttributesV1, want int) (execData *beacon.ExecutableDataV1, err error) {
+func assembleWithTransactions(api *ConsensusAPI, parentHash common.Hash, params *beacon.PayloadAttributes, want int) (execData *beacon.ExecutableData, err error) {
 	for retries := 3; retries > 0; retries-- {
 		execData, err = assembleBlock(api, parentHash, params)
 		if err != nil {
@@ -126,7 +126,7 @@ func TestEth2AssembleBlockWithAnotherBlocksTxs(t *testing.T) {
 
 	// Put the 10th block's tx in the pool and produce a new block
 	api.eth.TxPool().AddRemotesSync(blocks[9].Transactions())
-	blockParams := beacon.PayloadAttributesV1{
+	blockParams := beacon.PayloadAttributes{
 		Timestamp: blocks[8].Time() + 5,
 	}
 	// The miner needs to pick up on the txs in the pool, so a few retries might be
@@ -165,7 +165,7 @@ func TestEth2PrepareAndGetPayload(t *testing.T) {
 
 	// Put the 10th block's tx in the pool and produce a new block
 	ethservice.TxPool().AddLocals(blocks[9].Transactions())
-	blockParams := beacon.PayloadAttributesV1{
+	blockParams := beacon.PayloadAttributes{
 		Timestamp: blocks[8].Time() + 5,
 	}
 	fcState := beacon.ForkchoiceStateV1{
@@ -244,7 +244,7 @@ func TestInvalidPayloadTimestamp(t *testing.T) {
 
 	for i, test := range tests {
 		t.Run(fmt.Sprintf("Timestamp test: %v", i), func(t *testing.T) {
-			params := beacon.PayloadAttributesV1{
+			params := beacon.PayloadAttributes{
 				Timestamp:             test.time,
 				Random:                crypto.Keccak256Hash([]byte{byte(123)}),
 				SuggestedFeeRecipient: parent.Coinbase(),
@@ -288,7 +288,7 @@ func TestEth2NewBlock(t *testing.T) {
 		tx, _ := types.SignTx(types.NewContractCreation(nonce, new(big.Int), 1000000, big.NewInt(2*params.InitialBaseFee), logCode), types.LatestSigner(ethservice.BlockChain().Config()), testKey)
 		ethservice.TxPool().AddLocal(tx)
 
-		execData, err := assembleWithTransactions(api, parent.Hash(), &beacon.PayloadAttributesV1{
+		execData, err := assembleWithTransactions(api, parent.Hash(), &beacon.PayloadAttributes{
 			Timestamp: parent.Time() + 5,
 		}, 1)
 		if err != nil {
@@ -330,7 +330,7 @@ func TestEth2NewBlock(t *testing.T) {
 	)
 	parent = preMergeBlocks[len(preMergeBlocks)-1]
 	for i := 0; i < 10; i++ {
-		execData, err := assembleBlock(api, parent.Hash(), &beacon.PayloadAttributesV1{
+		execData, err := assembleBlock(api, parent.Hash(), &beacon.PayloadAttributes{
 			Timestamp: parent.Time() + 6,
 		})
 		if err != nil {
@@ -577,7 +577,7 @@ func TestNewPayloadOnInvalidChain(t *testing.T) {
 		})
 		ethservice.TxPool().AddRemotesSync([]*types.Transaction{tx})
 		var (
-			params = beacon.PayloadAttributesV1{
+			params = beacon.PayloadAttributes{
 				Timestamp:             parent.Time() + 1,
 				Random:                crypto.Keccak256Hash([]byte{byte(i)}),
 				SuggestedFeeRecipient: parent.Coinbase(),
@@ -587,7 +587,7 @@ func TestNewPayloadOnInvalidChain(t *testing.T) {
 				SafeBlockHash:      common.Hash{},
 				FinalizedBlockHash: common.Hash{},
 			}
-			payload *beacon.ExecutableDataV1
+			payload *beacon.ExecutableData
 			resp    beacon.ForkChoiceResponse
 			err     error
 		)
@@ -634,7 +634,7 @@ func TestNewPayloadOnInvalidChain(t *testing.T) {
 	}
 }
 
-func assembleBlock(api *ConsensusAPI, parentHash common.Hash, params *beacon.PayloadAttributesV1) (*beacon.ExecutableDataV1, error) {
+func assembleBlock(api *ConsensusAPI, parentHash common.Hash, params *beacon.PayloadAttributes) (*beacon.ExecutableData, error) {
 	args := &miner.BuildPayloadArgs{
 		Parent:       parentHash,
 		Timestamp:    params.Timestamp,
@@ -645,7 +645,7 @@ func assembleBlock(api *ConsensusAPI, parentHash common.Hash, params *beacon.Pay
 	if err != nil {
 		return nil, err
 	}
-	return payload.ResolveFull(), nil
+	return payload.ResolveFull().ExecutionPayload, nil
 }
 
 func TestEmptyBlocks(t *testing.T) {
@@ -708,8 +708,8 @@ func TestEmptyBlocks(t *testing.T) {
 	}
 }
 
-func getNewPayload(t *testing.T, api *ConsensusAPI, parent *types.Block) *beacon.ExecutableDataV1 {
-	params := beacon.PayloadAttributesV1{
+func getNewPayload(t *testing.T, api *ConsensusAPI, parent *types.Block) *beacon.ExecutableData {
+	params := beacon.PayloadAttributes{
 		Timestamp:             parent.Time() + 1,
 		Random:                crypto.Keccak256Hash([]byte{byte(1)}),
 		SuggestedFeeRecipient: parent.Coinbase(),
@@ -724,7 +724,7 @@ func getNewPayload(t *testing.T, api *ConsensusAPI, parent *types.Block) *beacon
 
 // setBlockhash sets the blockhash of a modified ExecutableData.
 // Can be used to make modified payloads look valid.
-func setBlockhash(data *beacon.ExecutableDataV1) *beacon.ExecutableDataV1 {
+func setBlockhash(data *beacon.ExecutableData) *beacon.ExecutableData {
 	txs, _ := decodeTransactions(data.Transactions)
 	number := big.NewInt(0)
 	number.SetUint64(data.Number)
@@ -783,7 +783,7 @@ func TestTrickRemoteBlockCache(t *testing.T) {
 	setupBlocks(t, ethserviceA, 10, commonAncestor, func(parent *types.Block) {})
 	commonAncestor = ethserviceA.BlockChain().CurrentBlock()
 
-	var invalidChain []*beacon.ExecutableDataV1
+	var invalidChain []*beacon.ExecutableData
 	// create a valid payload (P1)
 	//payload1 := getNewPayload(t, apiA, commonAncestor)
 	//invalidChain = append(invalidChain, payload1)
@@ -887,7 +887,7 @@ func TestNewPayloadOnInvalidTerminalBlock(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error preparing payload, err=%v", err)
 	}
-	data := *payload.Resolve()
+	data := *payload.Resolve().ExecutionPayload
 	resp2, err := api.NewPayloadV1(data)
 	if err != nil {
 		t.Fatalf("error sending NewPayload, err=%v", err)
@@ -910,7 +910,7 @@ func TestSimultaneousNewBlock(t *testing.T) {
 		parent = preMergeBlocks[len(preMergeBlocks)-1]
 	)
 	for i := 0; i < 10; i++ {
-		execData, err := assembleBlock(api, parent.Hash(), &beacon.PayloadAttributesV1{
+		execData, err := assembleBlock(api, parent.Hash(), &beacon.PayloadAttributes{
 			Timestamp: parent.Time() + 5,
 		})
 		if err != nil {
@@ -984,3 +984,113 @@ func TestSimultaneousNewBlock(t *testing.T) {
 		parent = block
 	}
 }
+
+// TestWithdrawals creates and verifies two post-Shanghai blocks. The first
+// includes zero withdrawals and the second includes two.
+func TestWithdrawals(t *testing.T) {
+	genesis, blocks := generatePreMergeChain(10)
+
+	// Activate Shanghai at the timestamp of the second post-merge block
+	shanghaiTime := blocks[len(blocks)-1].Time() + 10
+	genesis.Config.ShanghaiTime = &shanghaiTime
+
+	n, ethservice := startEthService(t, genesis, blocks)
+	defer n.Close()
+
+	api := NewConsensusAPI(ethservice)
+	parent := ethservice.BlockChain().CurrentHeader()
+
+	// 11: Build a pre-Shanghai block, withdrawals must be rejected
+	fcState := beacon.ForkchoiceStateV1{HeadBlockHash: parent.Hash()}
+	blockParams := beacon.PayloadAttributes{
+		Timestamp:   parent.Time + 5,
+		Withdrawals: make([]*types.Withdrawal, 0),
+	}
+	if _, err := api.ForkchoiceUpdatedV2(fcState, &blockParams); err == nil {
+		t.Fatal("expected error for withdrawals before Shanghai")
+	}
+	blockParams.Withdrawals = nil
+	execData := buildPayloadV2(t, api, fcState, &blockParams)
+	if execData.Withdrawals != nil {
+		t.Fatalf("unexpected withdrawals in pre-Shanghai payload: %v", execData.Withdrawals)
+	}
+	insertPayloadV2(t, api, execData)
+
+	// 12: Build a Shanghai block, withdrawals are mandatory
+	fcState = beacon.ForkchoiceStateV1{HeadBlockHash: execData.BlockHash}
+	blockParams = beacon.PayloadAttributes{Timestamp: execData.Timestamp + 5}
+	if _, err := api.ForkchoiceUpdatedV2(fcState, &blockParams); err == nil {
+		t.Fatal("expected error for missing withdrawals after Shanghai")
+	}
+	blockParams.Withdrawals = []*types.Withdrawal{
+		{Index: 0, Validator: 1, Address: common.Address{0xaa}, Amount: 32},
+		{Index: 1, Validator: 5, Address: common.Address{0xbb}, Amount: 33},
+	}
+	execData = buildPayloadV2(t, api, fcState, &blockParams)
+	if len(execData.Withdrawals) != 2 {
+		t.Fatalf("invalid number of withdrawals: have %d, want 2", len(execData.Withdrawals))
+	}
+	if _, err := api.NewPayloadV1(*execData); err == nil {
+		t.Fatal("expected error for withdrawals in V1 payload")
+	}
+	insertPayloadV2(t, api, execData)
+
+	// Ensure the withdrawals have been credited
+	head := ethservice.BlockChain().CurrentBlock()
+	if head.Hash() != execData.BlockHash {
+		t.Fatalf("chain head mismatch: have %x, want %x", head.Hash(), execData.BlockHash)
+	}
+	if len(head.Withdrawals()) != 2 {
+		t.Fatalf("invalid number of stored withdrawals: have %d, want 2", len(head.Withdrawals()))
+	}
+	statedb, err := ethservice.BlockChain().StateAt(head.Root())
+	if err != nil {
+		t.Fatalf("failed to retrieve state: %v", err)
+	}
+	for _, w := range blockParams.Withdrawals {
+		want := new(big.Int).Mul(new(big.Int).SetUint64(w.Amount), big.NewInt(params.GWei))
+		if have := statedb.GetBalance(w.Address); have.Cmp(want) != 0 {
+			t.Fatalf("balance mismatch for %x: have %v, want %v", w.Address, have, want)
+		}
+	}
+}
+
+// buildPayloadV2 requests a payload to be built on top of the given head and
+// retrieves it through the V2 endpoints.
+func buildPayloadV2(t *testing.T, api *ConsensusAPI, fcState beacon.ForkchoiceStateV1, attrs *beacon.PayloadAttributes) *beacon.ExecutableData {
+	t.Helper()
+
+	resp, err := api.ForkchoiceUpdatedV2(fcState, attrs)
+	if err != nil {
+		t.Fatalf("error preparing payload, err=%v", err)
+	}
+	if resp.PayloadID == nil {
+		t.Fatal("no payload id returned")
+	}
+	envelope, err := api.GetPayloadV2(*resp.PayloadID)
+	if err != nil {
+		t.Fatalf("error getting payload, err=%v", err)
+	}
+	if envelope.BlockValue == nil {
+		t.Fatal("missing block value")
+	}
+	return envelope.ExecutionPayload
+}
+
+// insertPayloadV2 imports the given payload through the V2 endpoints and sets
+// it as the chain head.
+func insertPayloadV2(t *testing.T, api *ConsensusAPI, execData *beacon.ExecutableData) {
+	t.Helper()
+
+	status, err := api.NewPayloadV2(*execData)
+	if err != nil {
+		t.Fatalf("error inserting payload, err=%v", err)
+	}
+	if status.Status != beacon.VALID {
+		t.Fatalf("invalid payload status: %v", status.Status)
+	}
+	fcState := beacon.ForkchoiceStateV1{HeadBlockHash: execData.BlockHash}
+	if _, err := api.ForkchoiceUpdatedV2(fcState, nil); err != nil {
+		t.Fatalf("error setting head, err=%v", err)
+	}
+}
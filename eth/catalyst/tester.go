@@ -76,9 +76,9 @@ func (tester *FullSyncTester) Start() error {
 					return
 				}
 				// Shoot out consensus events in order to trigger syncing.
-				data := beacon.BlockToExecutableData(tester.block)
-				tester.api.NewPayloadV1(*data)
-				tester.api.ForkchoiceUpdatedV1(beacon.ForkchoiceStateV1{
+				data := beacon.BlockToExecutableData(tester.block, nil, nil)
+				tester.api.NewPayloadV2(*data.ExecutionPayload)
+				tester.api.ForkchoiceUpdatedV2(beacon.ForkchoiceStateV1{
 					HeadBlockHash:      tester.block.Hash(),
 					SafeBlockHash:      tester.block.Hash(),
 					FinalizedBlockHash: tester.block.Hash(),
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"sync"
 	"time"
@@ -28,11 +29,20 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+var errPendingReceipts = errors.New("pending block receipts cannot be streamed")
+
+// maxReceiptsHistory is the maximum number of already imported blocks a block
+// receipts subscription may start streaming from.
+const maxReceiptsHistory = 10000
+
 // filter is a helper struct that holds meta information over the filter type
 // and associated subscription in the event system.
 type filter struct {
@@ -55,14 +65,19 @@ type FilterAPI struct {
 	timeout   time.Duration
 }
 
-// NewFilterAPI returns a new FilterAPI instance.
+// NewFilterAPI returns a new FilterAPI instance. Unless running in light mode, the
+// shared event system of the filter system is used.
 func NewFilterAPI(system *FilterSystem, lightMode bool) *FilterAPI {
 	api := &FilterAPI{
 		sys:     system,
-		events:  NewEventSystem(system, lightMode),
 		filters: make(map[rpc.ID]*filter),
 		timeout: system.cfg.Timeout,
 	}
+	if lightMode {
+		api.events = NewEventSystem(system, true)
+	} else {
+		api.events = system.EventSystem()
+	}
 	go api.timeoutLoop(system.cfg.Timeout)
 
 	return api
@@ -278,6 +293,311 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 	return rpcSub, nil
 }
 
+// Rewards creates a subscription that fires for every reward credited by a new
+// canonical block to a validator or covenant nft matching the given criteria.
+func (api *FilterAPI) Rewards(ctx context.Context, crit *RewardCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if crit == nil {
+		crit = new(RewardCriteria)
+	}
+	var (
+		rpcSub     = notifier.CreateSubscription()
+		rewardsCh  = make(chan rewards.RewardsEvent)
+		rewardsSub = api.events.SubscribeRewards(ethereum.RewardQuery(*crit), rewardsCh)
+	)
+	go func() {
+		for {
+			select {
+			case ev := <-rewardsCh:
+				for _, reward := range ev.Rewards {
+					notifier.Notify(rpcSub.ID, types.NewRPCBlockReward(ev.Header, reward))
+				}
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				rewardsSub.Unsubscribe()
+				return
+			case <-notifier.Closed(): // connection dropped
+				rewardsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// Purges creates a subscription that fires for every purge run on the closing of
+// a purge period, which moved a covenant nft matching the given criteria.
+func (api *FilterAPI) Purges(ctx context.Context, crit *RewardCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if crit == nil {
+		crit = new(RewardCriteria)
+	}
+	var (
+		rpcSub   = notifier.CreateSubscription()
+		purgeCh  = make(chan rewards.PurgeEvent)
+		purgeSub = api.events.SubscribePurges(ethereum.RewardQuery(*crit), purgeCh)
+	)
+	go func() {
+		for {
+			select {
+			case ev := <-purgeCh:
+				notifier.Notify(rpcSub.ID, &types.RPCPurgeResult{
+					Index:       hexutil.Uint64(ev.Index),
+					BlockNumber: hexutil.Uint64(ev.Header.Number.Uint64()),
+					BlockHash:   ev.Header.Hash(),
+					Result:      types.NewPurgeHistoryResultJSON(ev.Result),
+				})
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				purgeSub.Unsubscribe()
+				return
+			case <-notifier.Closed(): // connection dropped
+				purgeSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// BlockReceipts creates a subscription streaming the header and the receipts of
+// every canonical block in the given range. The blocks already imported are sent
+// first, followed by the new ones as they get imported if the range is not over
+// yet. A missing start defaults to the current head, a missing end to no end. If
+// the chain is reorganised, the blocks of the new chain are sent again from the
+// fork point.
+func (api *FilterAPI) BlockReceipts(ctx context.Context, crit *BlockReceiptsCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var (
+		head     = api.sys.backend.CurrentHeader().Number.Uint64()
+		from, to = head, uint64(math.MaxUint64)
+	)
+	if crit != nil {
+		if crit.FromBlock != nil {
+			if *crit.FromBlock == rpc.PendingBlockNumber {
+				return nil, errPendingReceipts
+			}
+			from = resolveBlockNum(*crit.FromBlock, head)
+		}
+		if crit.ToBlock != nil {
+			if *crit.ToBlock == rpc.PendingBlockNumber {
+				return nil, errPendingReceipts
+			}
+			to = resolveBlockNum(*crit.ToBlock, head)
+		}
+	}
+	switch {
+	case from > to:
+		return nil, fmt.Errorf("invalid block range: from %d is after to %d", from, to)
+	case from < head && head-from >= maxReceiptsHistory:
+		return nil, fmt.Errorf("block range too large, limit is %d blocks", maxReceiptsHistory)
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		// Stream the blocks already imported
+		var (
+			next = from
+			last *types.Header // Last block sent, to detect reorgs with
+		)
+		for ; next <= to && next <= api.sys.backend.CurrentHeader().Number.Uint64(); next++ {
+			select {
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			default:
+			}
+			header, err := api.notifyBlockReceipts(notifier, rpcSub.ID, next)
+			if err != nil {
+				log.Debug("Block receipts streaming failed", "number", next, "err", err)
+				return
+			}
+			last = header
+		}
+		if next > to {
+			return
+		}
+		// Follow the chain head until the range is over
+		headers := make(chan *types.Header)
+		headersSub := api.events.SubscribeNewHeads(headers)
+		defer headersSub.Unsubscribe()
+
+		for {
+			select {
+			case h := <-headers:
+				// If the new head doesn't build on the last block sent, resend
+				// the new chain from the fork point
+				if last != nil {
+					fork, err := api.commonAncestor(last, h)
+					if err != nil {
+						log.Debug("Block receipts reorg failed", "number", h.Number, "hash", h.Hash(), "err", err)
+						return
+					}
+					if fork < last.Number.Uint64() {
+						next = fork + 1
+						if next < from {
+							next = from
+						}
+					}
+				}
+				for ; next <= to && next <= h.Number.Uint64(); next++ {
+					header, err := api.notifyBlockReceipts(notifier, rpcSub.ID, next)
+					if err != nil {
+						log.Debug("Block receipts streaming failed", "number", next, "err", err)
+						return
+					}
+					last = header
+				}
+				if next > to {
+					return
+				}
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				return
+			case <-notifier.Closed(): // connection dropped
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// commonAncestor returns the number of the most recent block shared by the
+// chains of the two headers.
+func (api *FilterAPI) commonAncestor(a, b *types.Header) (uint64, error) {
+	db := api.sys.backend.ChainDb()
+
+	parent := func(h *types.Header) (*types.Header, error) {
+		number := h.Number.Uint64()
+		if number == 0 {
+			return nil, errors.New("no common ancestor")
+		}
+		if p := rawdb.ReadHeader(db, h.ParentHash, number-1); p != nil {
+			return p, nil
+		}
+		return nil, fmt.Errorf("header #%d [%x..] not found", number-1, h.ParentHash.Bytes()[:4])
+	}
+	var err error
+	for a.Number.Uint64() > b.Number.Uint64() {
+		if a, err = parent(a); err != nil {
+			return 0, err
+		}
+	}
+	for b.Number.Uint64() > a.Number.Uint64() {
+		if b, err = parent(b); err != nil {
+			return 0, err
+		}
+	}
+	for a.Hash() != b.Hash() {
+		if a, err = parent(a); err != nil {
+			return 0, err
+		}
+		if b, err = parent(b); err != nil {
+			return 0, err
+		}
+	}
+	return a.Number.Uint64(), nil
+}
+
+// notifyBlockReceipts sends the header and the receipts of the canonical block
+// with the given number to the subscriber, returning the header sent.
+func (api *FilterAPI) notifyBlockReceipts(notifier *rpc.Notifier, id rpc.ID, number uint64) (*types.Header, error) {
+	var (
+		db     = api.sys.backend.ChainDb()
+		config = api.sys.backend.ChainConfig()
+	)
+	hash := rawdb.ReadCanonicalHash(db, number)
+	block := rawdb.ReadBlock(db, hash, number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	receipts := rawdb.ReadReceipts(db, hash, number, block.Time(), config)
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("receipts of block #%d not found", number)
+	}
+	var (
+		header = block.Header()
+		signer = types.MakeSigner(config, header.Number, header.Time)
+		fields = make([]map[string]interface{}, len(receipts))
+	)
+	for i, receipt := range receipts {
+		fields[i] = ethapi.MarshalReceipt(receipt, header, signer, txs[i], i)
+	}
+	return header, notifier.Notify(id, &rpcBlockReceipts{Header: header, Receipts: fields})
+}
+
+// rpcBlockReceipts is the notification of a block receipts subscription.
+type rpcBlockReceipts struct {
+	Header   *types.Header            `json:"header"`
+	Receipts []map[string]interface{} `json:"receipts"`
+}
+
+// BlockReceiptsCriteria represents a request to subscribe to the receipts of a
+// range of blocks.
+type BlockReceiptsCriteria struct {
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+}
+
+// resolveBlockNum converts a block number of a request into an actual one, the
+// block tags resolving to the given head.
+func resolveBlockNum(number rpc.BlockNumber, head uint64) uint64 {
+	if number < 0 {
+		return head
+	}
+	return uint64(number)
+}
+
+// RewardCriteria represents a request to subscribe to reward or purge events.
+// Same as ethereum.RewardQuery but with UnmarshalJSON() method.
+type RewardCriteria ethereum.RewardQuery
+
+// UnmarshalJSON sets *args fields with given data. Both the addresses and the
+// token ids can either be a single value or an array of values.
+func (args *RewardCriteria) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Addresses json.RawMessage `json:"address"`
+		TokenIDs  json.RawMessage `json:"tokenID"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw.Addresses) > 0 {
+		if err := json.Unmarshal(raw.Addresses, &args.Addresses); err != nil {
+			var addr common.Address
+			if err := json.Unmarshal(raw.Addresses, &addr); err != nil {
+				return fmt.Errorf("invalid address: %v", err)
+			}
+			args.Addresses = []common.Address{addr}
+		}
+	}
+	if len(raw.TokenIDs) > 0 {
+		var ids []hexutil.Uint64
+		if err := json.Unmarshal(raw.TokenIDs, &ids); err != nil {
+			var id hexutil.Uint64
+			if err := json.Unmarshal(raw.TokenIDs, &id); err != nil {
+				return fmt.Errorf("invalid token id: %v", err)
+			}
+			ids = []hexutil.Uint64{id}
+		}
+		for _, id := range ids {
+			args.TokenIDs = append(args.TokenIDs, uint64(id))
+		}
+	}
+	return nil
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
@@ -19,6 +19,7 @@ package filters
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -183,3 +184,40 @@ func TestUnmarshalJSONNewFilterArgs(t *testing.T) {
 		t.Fatalf("expected 0 topics, got %d topics", len(test7.Topics[2]))
 	}
 }
+
+func TestUnmarshalJSONRewardCriteria(t *testing.T) {
+	var (
+		address0 = common.HexToAddress("70c87d191324e6712a591f304b4eedef6ad9bb9d")
+		address1 = common.HexToAddress("9b2055d370f73ec7d8a03e965129118dc8f5bf83")
+	)
+	tests := []struct {
+		input     string
+		addresses []common.Address
+		tokenIDs  []uint64
+		err       bool
+	}{
+		{`{}`, nil, nil, false},
+		{fmt.Sprintf(`{"address":"%s"}`, address0.Hex()), []common.Address{address0}, nil, false},
+		{fmt.Sprintf(`{"address":["%s","%s"],"tokenID":null}`, address0.Hex(), address1.Hex()), []common.Address{address0, address1}, nil, false},
+		{`{"tokenID":"0x7"}`, nil, []uint64{7}, false},
+		{`{"tokenID":["0x7","0x8"]}`, nil, []uint64{7, 8}, false},
+		{`{"address":"0x12"}`, nil, nil, true},
+		{`{"tokenID":7}`, nil, nil, true},
+	}
+	for i, tt := range tests {
+		var crit RewardCriteria
+		err := json.Unmarshal([]byte(tt.input), &crit)
+		if tt.err {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(crit.Addresses, tt.addresses) || !reflect.DeepEqual(crit.TokenIDs, tt.tokenIDs) {
+			t.Errorf("test %d: criteria mismatch: have %v %v, want %v %v", i, crit.Addresses, crit.TokenIDs, tt.addresses, tt.tokenIDs)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -303,9 +304,9 @@ func (f *Filter) pendingLogs() ([]*types.Log, error) {
 	return nil, nil
 }
 
-func includes(addresses []common.Address, a common.Address) bool {
-	for _, addr := range addresses {
-		if addr == a {
+func includes[T comparable](items []T, a T) bool {
+	for _, item := range items {
+		if item == a {
 			return true
 		}
 	}
@@ -349,6 +350,41 @@ Logs:
 	return ret
 }
 
+// filterRewards creates a slice of block rewards matching the given criteria.
+func filterRewards(rewards []*types.BlockReward, crit ethereum.RewardQuery) []*types.BlockReward {
+	if len(crit.Addresses) == 0 && len(crit.TokenIDs) == 0 {
+		return rewards
+	}
+	var ret []*types.BlockReward
+	for _, reward := range rewards {
+		switch reward.Type {
+		case types.ValidatorReward:
+			if includes(crit.Addresses, reward.Address) {
+				ret = append(ret, reward)
+			}
+		case types.CovenantReward:
+			if includes(crit.TokenIDs, reward.TokenID) {
+				ret = append(ret, reward)
+			}
+		}
+	}
+	return ret
+}
+
+// filterPurge reports whether the purge result matches the given criteria. As
+// purges only move covenant nfts, the addresses of the criteria don't match.
+func filterPurge(result *types.PurgeHistoryResult, crit ethereum.RewardQuery) bool {
+	if len(crit.Addresses) == 0 && len(crit.TokenIDs) == 0 {
+		return true
+	}
+	for _, id := range crit.TokenIDs {
+		if result.Moved(id) {
+			return true
+		}
+	}
+	return false
+}
+
 func bloomFilter(bloom types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
 	if len(addresses) > 0 {
 		var included bool
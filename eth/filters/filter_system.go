@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -69,6 +70,8 @@ type Backend interface {
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription
+	SubscribeRewardsEvent(ch chan<- rewards.RewardsEvent) event.Subscription
+	SubscribePurgeEvent(ch chan<- rewards.PurgeEvent) event.Subscription
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
@@ -79,6 +82,9 @@ type FilterSystem struct {
 	backend   Backend
 	logsCache *lru.Cache[common.Hash, [][]*types.Log]
 	cfg       *Config
+
+	events     *EventSystem // Event system shared by the subscription APIs
+	eventsOnce sync.Once
 }
 
 // NewFilterSystem creates a filter system.
@@ -91,6 +97,16 @@ func NewFilterSystem(backend Backend, config Config) *FilterSystem {
 	}
 }
 
+// EventSystem returns the event system shared by all the subscription APIs built
+// on top of the filter system, creating it on first use. It lives as long as the
+// backend, so the APIs don't each need to tear down an event system of their own.
+func (sys *FilterSystem) EventSystem() *EventSystem {
+	sys.eventsOnce.Do(func() {
+		sys.events = NewEventSystem(sys, false)
+	})
+	return sys.events
+}
+
 // cachedGetLogs loads block logs from the backend and caches the result.
 func (sys *FilterSystem) cachedGetLogs(ctx context.Context, blockHash common.Hash, number uint64) ([][]*types.Log, error) {
 	cached, ok := sys.logsCache.Get(blockHash)
@@ -127,6 +143,10 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// RewardsSubscription queries for rewards credited by new canonical blocks
+	RewardsSubscription
+	// PurgesSubscription queries for purges run on the closing of purge periods
+	PurgesSubscription
 	// LastIndexSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -141,18 +161,25 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// rewardsChanSize is the size of channel listening to RewardsEvent.
+	rewardsChanSize = 10
+	// purgeChanSize is the size of channel listening to PurgeEvent.
+	purgeChanSize = 10
 )
 
 type subscription struct {
-	id        rpc.ID
-	typ       Type
-	created   time.Time
-	logsCrit  ethereum.FilterQuery
-	logs      chan []*types.Log
-	txs       chan []*types.Transaction
-	headers   chan *types.Header
-	installed chan struct{} // closed when the filter is installed
-	err       chan error    // closed when the filter is uninstalled
+	id         rpc.ID
+	typ        Type
+	created    time.Time
+	logsCrit   ethereum.FilterQuery
+	logs       chan []*types.Log
+	txs        chan []*types.Transaction
+	headers    chan *types.Header
+	rewardCrit ethereum.RewardQuery
+	rewards    chan rewards.RewardsEvent
+	purges     chan rewards.PurgeEvent
+	installed  chan struct{} // closed when the filter is installed
+	err        chan error    // closed when the filter is uninstalled
 }
 
 // EventSystem creates subscriptions, processes events and broadcasts them to the
@@ -169,6 +196,8 @@ type EventSystem struct {
 	rmLogsSub      event.Subscription // Subscription for removed log event
 	pendingLogsSub event.Subscription // Subscription for pending log event
 	chainSub       event.Subscription // Subscription for new chain event
+	rewardsSub     event.Subscription // Subscription for new rewards event
+	purgeSub       event.Subscription // Subscription for new purge event
 
 	// Channels
 	install       chan *subscription         // install filter for event notification
@@ -178,6 +207,8 @@ type EventSystem struct {
 	pendingLogsCh chan []*types.Log          // Channel to receive new log event
 	rmLogsCh      chan core.RemovedLogsEvent // Channel to receive removed log event
 	chainCh       chan core.ChainEvent       // Channel to receive new chain event
+	rewardsCh     chan rewards.RewardsEvent  // Channel to receive new rewards event
+	purgeCh       chan rewards.PurgeEvent    // Channel to receive new purge event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -198,6 +229,8 @@ func NewEventSystem(sys *FilterSystem, lightMode bool) *EventSystem {
 		rmLogsCh:      make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		pendingLogsCh: make(chan []*types.Log, logsChanSize),
 		chainCh:       make(chan core.ChainEvent, chainEvChanSize),
+		rewardsCh:     make(chan rewards.RewardsEvent, rewardsChanSize),
+		purgeCh:       make(chan rewards.PurgeEvent, purgeChanSize),
 	}
 
 	// Subscribe events
@@ -206,9 +239,11 @@ func NewEventSystem(sys *FilterSystem, lightMode bool) *EventSystem {
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
 	m.pendingLogsSub = m.backend.SubscribePendingLogsEvent(m.pendingLogsCh)
+	m.rewardsSub = m.backend.SubscribeRewardsEvent(m.rewardsCh)
+	m.purgeSub = m.backend.SubscribePurgeEvent(m.purgeCh)
 
 	// Make sure none of the subscriptions are empty
-	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.pendingLogsSub == nil {
+	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.pendingLogsSub == nil || m.rewardsSub == nil || m.purgeSub == nil {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -244,6 +279,8 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.txs:
 			case <-sub.f.headers:
+			case <-sub.f.rewards:
+			case <-sub.f.purges:
 			}
 		}
 
@@ -311,6 +348,8 @@ func (es *EventSystem) subscribeMinedPendingLogs(crit ethereum.FilterQuery, logs
 		logs:      logs,
 		txs:       make(chan []*types.Transaction),
 		headers:   make(chan *types.Header),
+		rewards:   make(chan rewards.RewardsEvent),
+		purges:    make(chan rewards.PurgeEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -328,6 +367,8 @@ func (es *EventSystem) subscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		logs:      logs,
 		txs:       make(chan []*types.Transaction),
 		headers:   make(chan *types.Header),
+		rewards:   make(chan rewards.RewardsEvent),
+		purges:    make(chan rewards.PurgeEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -345,6 +386,8 @@ func (es *EventSystem) subscribePendingLogs(crit ethereum.FilterQuery, logs chan
 		logs:      logs,
 		txs:       make(chan []*types.Transaction),
 		headers:   make(chan *types.Header),
+		rewards:   make(chan rewards.RewardsEvent),
+		purges:    make(chan rewards.PurgeEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -361,6 +404,8 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 		logs:      make(chan []*types.Log),
 		txs:       make(chan []*types.Transaction),
 		headers:   headers,
+		rewards:   make(chan rewards.RewardsEvent),
+		purges:    make(chan rewards.PurgeEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -377,12 +422,52 @@ func (es *EventSystem) SubscribePendingTxs(txs chan []*types.Transaction) *Subsc
 		logs:      make(chan []*types.Log),
 		txs:       txs,
 		headers:   make(chan *types.Header),
+		rewards:   make(chan rewards.RewardsEvent),
+		purges:    make(chan rewards.PurgeEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
 	return es.subscribe(sub)
 }
 
+// SubscribeRewards creates a subscription that writes the rewards credited by
+// new canonical blocks which match the given criteria.
+func (es *EventSystem) SubscribeRewards(crit ethereum.RewardQuery, ch chan rewards.RewardsEvent) *Subscription {
+	sub := &subscription{
+		id:         rpc.NewID(),
+		typ:        RewardsSubscription,
+		created:    time.Now(),
+		logs:       make(chan []*types.Log),
+		txs:        make(chan []*types.Transaction),
+		headers:    make(chan *types.Header),
+		rewardCrit: crit,
+		rewards:    ch,
+		purges:     make(chan rewards.PurgeEvent),
+		installed:  make(chan struct{}),
+		err:        make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribePurges creates a subscription that writes the purges which match the
+// given criteria, run on the closing of purge periods.
+func (es *EventSystem) SubscribePurges(crit ethereum.RewardQuery, ch chan rewards.PurgeEvent) *Subscription {
+	sub := &subscription{
+		id:         rpc.NewID(),
+		typ:        PurgesSubscription,
+		created:    time.Now(),
+		logs:       make(chan []*types.Log),
+		txs:        make(chan []*types.Transaction),
+		headers:    make(chan *types.Header),
+		rewardCrit: crit,
+		rewards:    make(chan rewards.RewardsEvent),
+		purges:     ch,
+		installed:  make(chan struct{}),
+		err:        make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 type filterIndex map[Type]map[rpc.ID]*subscription
 
 func (es *EventSystem) handleLogs(filters filterIndex, ev []*types.Log) {
@@ -439,6 +524,22 @@ func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent)
 	}
 }
 
+func (es *EventSystem) handleRewardsEvent(filters filterIndex, ev rewards.RewardsEvent) {
+	for _, f := range filters[RewardsSubscription] {
+		if matched := filterRewards(ev.Rewards, f.rewardCrit); len(matched) > 0 {
+			f.rewards <- rewards.RewardsEvent{Header: ev.Header, Rewards: matched}
+		}
+	}
+}
+
+func (es *EventSystem) handlePurgeEvent(filters filterIndex, ev rewards.PurgeEvent) {
+	for _, f := range filters[PurgesSubscription] {
+		if filterPurge(ev.Result, f.rewardCrit) {
+			f.purges <- ev
+		}
+	}
+}
+
 func (es *EventSystem) lightFilterNewHead(newHeader *types.Header, callBack func(*types.Header, bool)) {
 	oldh := es.lastHead
 	es.lastHead = newHeader
@@ -521,6 +622,8 @@ func (es *EventSystem) eventLoop() {
 		es.rmLogsSub.Unsubscribe()
 		es.pendingLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.rewardsSub.Unsubscribe()
+		es.purgeSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -540,6 +643,10 @@ func (es *EventSystem) eventLoop() {
 			es.handlePendingLogs(index, ev)
 		case ev := <-es.chainCh:
 			es.handleChainEvent(index, ev)
+		case ev := <-es.rewardsCh:
+			es.handleRewardsEvent(index, ev)
+		case ev := <-es.purgeCh:
+			es.handlePurgeEvent(index, ev)
 
 		case f := <-es.install:
 			if f.typ == MinedAndPendingLogsSubscription {
@@ -570,6 +677,10 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.chainSub.Err():
 			return
+		case <-es.rewardsSub.Err():
+			return
+		case <-es.purgeSub.Err():
+			return
 		}
 	}
 }
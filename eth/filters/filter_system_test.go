@@ -24,6 +24,7 @@ import (
 	"math/rand"
 	"reflect"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,7 +34,9 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
@@ -48,14 +51,16 @@ type testBackend struct {
 	rmLogsFeed      event.Feed
 	pendingLogsFeed event.Feed
 	chainFeed       event.Feed
+	rewardsFeed     event.Feed
+	purgeFeed       event.Feed
 }
 
 func (b *testBackend) ChainConfig() *params.ChainConfig {
-	panic("implement me")
+	return params.TestChainConfig
 }
 
 func (b *testBackend) CurrentHeader() *types.Header {
-	panic("implement me")
+	return rawdb.ReadHeadHeader(b.db)
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
@@ -101,7 +106,9 @@ func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*type
 
 func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
 	if number := rawdb.ReadHeaderNumber(b.db, hash); number != nil {
-		return rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig), nil
+		if header := rawdb.ReadHeader(b.db, hash, *number); header != nil {
+			return rawdb.ReadReceipts(b.db, hash, *number, header.Time, params.TestChainConfig), nil
+		}
 	}
 	return nil, nil
 }
@@ -135,6 +142,14 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeRewardsEvent(ch chan<- rewards.RewardsEvent) event.Subscription {
+	return b.rewardsFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribePurgeEvent(ch chan<- rewards.PurgeEvent) event.Subscription {
+	return b.purgeFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -231,6 +246,272 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestRewardsSubscription tests that reward subscriptions only receive the rewards
+// matching their criteria.
+func TestRewardsSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+
+		validator = &types.BlockReward{Type: types.ValidatorReward, Address: common.Address{0xaa}, Reward: big.NewInt(2), Fee: big.NewInt(1)}
+		covenant  = &types.BlockReward{Type: types.CovenantReward, TokenID: 7, Reward: big.NewInt(1), Fee: new(big.Int)}
+		other     = &types.BlockReward{Type: types.CovenantReward, TokenID: 8, Reward: big.NewInt(1), Fee: new(big.Int)}
+		events    = []rewards.RewardsEvent{
+			{Header: &types.Header{Number: big.NewInt(1)}, Rewards: []*types.BlockReward{validator, covenant}},
+			{Header: &types.Header{Number: big.NewInt(2)}, Rewards: []*types.BlockReward{other}},
+		}
+	)
+	testCases := []struct {
+		crit ethereum.RewardQuery
+		want [][]*types.BlockReward
+	}{
+		{ethereum.RewardQuery{}, [][]*types.BlockReward{{validator, covenant}, {other}}},
+		{ethereum.RewardQuery{Addresses: []common.Address{{0xaa}}}, [][]*types.BlockReward{{validator}}},
+		{ethereum.RewardQuery{TokenIDs: []uint64{7, 8}}, [][]*types.BlockReward{{covenant}, {other}}},
+		{ethereum.RewardQuery{Addresses: []common.Address{{0xbb}}, TokenIDs: []uint64{8}}, [][]*types.BlockReward{{other}}},
+	}
+	var (
+		chans = make([]chan rewards.RewardsEvent, len(testCases))
+		subs  = make([]*Subscription, len(testCases))
+	)
+	for i, tc := range testCases {
+		chans[i] = make(chan rewards.RewardsEvent)
+		subs[i] = api.events.SubscribeRewards(tc.crit, chans[i])
+	}
+	go func() {
+		for _, ev := range events {
+			backend.rewardsFeed.Send(ev)
+		}
+	}()
+	var wg sync.WaitGroup
+	for i, tc := range testCases {
+		wg.Add(1)
+		go func(i int, want [][]*types.BlockReward) { // simulate client
+			defer wg.Done()
+			defer subs[i].Unsubscribe()
+
+			for j := range want {
+				select {
+				case ev := <-chans[i]:
+					if !reflect.DeepEqual(ev.Rewards, want[j]) {
+						t.Errorf("test %d, event %d: rewards mismatch: have %v, want %v", i, j, ev.Rewards, want[j])
+					}
+				case <-time.After(time.Second):
+					t.Errorf("test %d, event %d: timeout waiting for rewards", i, j)
+					return
+				}
+			}
+		}(i, tc.want)
+	}
+	wg.Wait()
+}
+
+// TestPurgesSubscription tests that purge subscriptions only receive the purges
+// moving the nfts of their criteria.
+func TestPurgesSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+
+		events = []rewards.PurgeEvent{
+			{Header: &types.Header{Number: big.NewInt(1)}, Index: 0, Result: &types.PurgeHistoryResult{PromotedLegionnaire: []uint64{7}}},
+			{Header: &types.Header{Number: big.NewInt(2)}, Index: 1, Result: &types.PurgeHistoryResult{DemotedCavalier: []uint64{7}, PromotedLegionnaire: []uint64{8}}},
+		}
+	)
+	testCases := []struct {
+		crit ethereum.RewardQuery
+		want []uint64
+	}{
+		{ethereum.RewardQuery{}, []uint64{0, 1}},
+		{ethereum.RewardQuery{TokenIDs: []uint64{8}}, []uint64{1}},
+		{ethereum.RewardQuery{TokenIDs: []uint64{7, 8}}, []uint64{0, 1}},
+		{ethereum.RewardQuery{Addresses: []common.Address{{0xaa}}, TokenIDs: []uint64{9}}, nil},
+	}
+	var (
+		chans = make([]chan rewards.PurgeEvent, len(testCases))
+		subs  = make([]*Subscription, len(testCases))
+	)
+	for i, tc := range testCases {
+		chans[i] = make(chan rewards.PurgeEvent)
+		subs[i] = api.events.SubscribePurges(tc.crit, chans[i])
+	}
+	go func() {
+		for _, ev := range events {
+			backend.purgeFeed.Send(ev)
+		}
+	}()
+	var wg sync.WaitGroup
+	for i, tc := range testCases {
+		wg.Add(1)
+		go func(i int, want []uint64) { // simulate client
+			defer wg.Done()
+			defer subs[i].Unsubscribe()
+
+			for j := range want {
+				select {
+				case ev := <-chans[i]:
+					if ev.Index != want[j] {
+						t.Errorf("test %d, event %d: purge index mismatch: have %d, want %d", i, j, ev.Index, want[j])
+					}
+				case <-time.After(time.Second):
+					t.Errorf("test %d, event %d: timeout waiting for purge", i, j)
+					return
+				}
+			}
+		}(i, tc.want)
+	}
+	wg.Wait()
+}
+
+// TestBlockReceiptsSubscription tests that block receipt subscriptions stream the
+// blocks already imported, then follow the chain head across reorgs.
+func TestBlockReceiptsSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+		key, _       = crypto.GenerateKey()
+		addr         = crypto.PubkeyToAddress(key.PublicKey)
+		genesis      = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer          = types.LatestSigner(genesis.Config)
+		genDb, chain, _ = core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 4, func(i int, gen *core.BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr), common.Address{0x1}, big.NewInt(1), params.TxGas, gen.BaseFee(), nil), signer, key)
+			gen.AddTx(tx)
+		})
+		fork, _ = core.GenerateChain(genesis.Config, chain[2], ethash.NewFaker(), genDb, 1, func(i int, gen *core.BlockGen) {
+			gen.SetCoinbase(common.Address{0x2})
+		})
+		longFork, _ = core.GenerateChain(genesis.Config, chain[1], ethash.NewFaker(), genDb, 4, func(i int, gen *core.BlockGen) {
+			gen.SetCoinbase(common.Address{0x3})
+		})
+	)
+	genesis.MustCommit(db)
+
+	// import writes a block and its receipts into the database as the head
+	receipts := make(map[common.Hash]types.Receipts)
+	for _, block := range append(append(chain, fork...), longFork...) {
+		r := make(types.Receipts, len(block.Transactions()))
+		for i, tx := range block.Transactions() {
+			r[i] = &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(i+1) * params.TxGas, TxHash: tx.Hash(), Logs: []*types.Log{}}
+		}
+		receipts[block.Hash()] = r
+	}
+	importBlock := func(block *types.Block) {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[block.Hash()])
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadHeaderHash(db, block.Hash())
+	}
+	importBlock(chain[0])
+	importBlock(chain[1])
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	ch := make(chan *types.BlockReceipts)
+	sub, err := client.EthSubscribe(context.Background(), ch, "blockReceipts", map[string]interface{}{"fromBlock": "0x1"})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	expect := func(block *types.Block) {
+		t.Helper()
+		select {
+		case have := <-ch:
+			if have.Header.Hash() != block.Hash() {
+				t.Fatalf("block mismatch: have #%d [%x], want #%d [%x]", have.Header.Number, have.Header.Hash(), block.Number(), block.Hash())
+			}
+			if len(have.Receipts) != len(block.Transactions()) {
+				t.Fatalf("block #%d: receipt count mismatch: have %d, want %d", block.Number(), len(have.Receipts), len(block.Transactions()))
+			}
+			for i, receipt := range have.Receipts {
+				if receipt.TxHash != block.Transactions()[i].Hash() || receipt.BlockHash != block.Hash() || receipt.GasUsed != params.TxGas {
+					t.Fatalf("block #%d: receipt %d mismatch: %+v", block.Number(), i, receipt)
+				}
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block #%d: notification timeout", block.Number())
+		}
+	}
+	// The imported blocks should be sent right away
+	expect(chain[0])
+	expect(chain[1])
+
+	// New heads should be followed, along with the blocks missed in between
+	time.Sleep(100 * time.Millisecond) // wait for the head subscription
+	importBlock(chain[2])
+	importBlock(chain[3])
+	backend.chainFeed.Send(core.ChainEvent{Hash: chain[3].Hash(), Block: chain[3]})
+	expect(chain[2])
+	expect(chain[3])
+
+	// Reorgs should resend the new chain from the fork point
+	importBlock(fork[0])
+	backend.chainFeed.Send(core.ChainEvent{Hash: fork[0].Hash(), Block: fork[0]})
+	expect(fork[0])
+
+	for _, block := range longFork {
+		importBlock(block)
+	}
+	backend.chainFeed.Send(core.ChainEvent{Hash: longFork[3].Hash(), Block: longFork[3]})
+	for _, block := range longFork {
+		expect(block)
+	}
+}
+
+// TestBlockReceiptsHistoryLimit tests that block receipt subscriptions can't
+// start too deep into the chain history.
+func TestBlockReceiptsHistoryLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		_, sys = newTestFilterSystem(t, db, Config{})
+		api    = NewFilterAPI(sys, false)
+		head   = &types.Header{Number: big.NewInt(maxReceiptsHistory + 1)}
+	)
+	rawdb.WriteHeader(db, head)
+	rawdb.WriteHeadHeaderHash(db, head.Hash())
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	ch := make(chan *types.BlockReceipts)
+	if _, err := client.EthSubscribe(context.Background(), ch, "blockReceipts", map[string]interface{}{"fromBlock": "0x1"}); err == nil {
+		t.Fatal("expected error for subscription exceeding the history limit")
+	}
+	sub, err := client.EthSubscribe(context.Background(), ch, "blockReceipts", map[string]interface{}{"fromBlock": "0x2"})
+	if err != nil {
+		t.Fatalf("failed to subscribe within the history limit: %v", err)
+	}
+	sub.Unsubscribe()
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -57,6 +57,9 @@ var noopReleaser = tracers.StateReleaseFunc(func() {})
 //     provided, it would be preferable to start from a fresh state, if we have it
 //     on disk.
 func (eth *Ethereum) StateAtBlock(block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (statedb *state.StateDB, release tracers.StateReleaseFunc, err error) {
+	if eth.blockchain.TrieDB().Scheme().Name() == trie.PathScheme {
+		return eth.pathStateAtBlock(block)
+	}
 	var (
 		current  *types.Block
 		database state.Database
@@ -181,6 +184,18 @@ func (eth *Ethereum) StateAtBlock(block *types.Block, reexec uint64, base *state
 	return statedb, func() { database.TrieDB().Dereference(block.Root()) }, nil
 }
 
+// pathStateAtBlock retrieves the state database associated with a certain block
+// if the path-based state scheme is used. The historical states are overwritten
+// in place by the scheme and can't be regenerated on an ephemeral database, so
+// only the recent states tracked by the live database are available.
+func (eth *Ethereum) pathStateAtBlock(block *types.Block) (*state.StateDB, tracers.StateReleaseFunc, error) {
+	statedb, err := eth.blockchain.StateAt(block.Root())
+	if err != nil {
+		return nil, nil, fmt.Errorf("historical state %x is not available", block.Root())
+	}
+	return statedb, noopReleaser, nil
+}
+
 // stateAtTransaction returns the execution environment of a certain transaction.
 func (eth *Ethereum) stateAtTransaction(block *types.Block, txIndex int, reexec uint64) (core.Message, vm.BlockContext, *state.StateDB, tracers.StateReleaseFunc, error) {
 	// Short circuit if it's genesis block.
@@ -202,7 +217,7 @@ func (eth *Ethereum) stateAtTransaction(block *types.Block, txIndex int, reexec
 		return nil, vm.BlockContext{}, statedb, release, nil
 	}
 	// Recompute transactions up to the target index.
-	signer := types.MakeSigner(eth.blockchain.Config(), block.Number())
+	signer := types.MakeSigner(eth.blockchain.Config(), block.Number(), block.Time())
 	for idx, tx := range block.Transactions() {
 		// Assemble the transaction call message and return if the requested offset
 		msg, _ := tx.AsMessage(signer, block.BaseFee())
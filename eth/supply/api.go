@@ -0,0 +1,150 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package supply
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxBlockRange is the maximum number of blocks a single query may span.
+const maxBlockRange = 10000
+
+// RPCSupplyDelta is the rpc representation of the supply delta of a block.
+type RPCSupplyDelta struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	ParentHash  common.Hash    `json:"parentHash"`
+	Issuance    struct {
+		GenesisAlloc *hexutil.Big `json:"genesisAlloc"`
+		Reward       *hexutil.Big `json:"reward"`
+		Covenant     *hexutil.Big `json:"covenant"`
+		Withdrawals  *hexutil.Big `json:"withdrawals"`
+	} `json:"issuance"`
+	Burn struct {
+		BaseFee      *hexutil.Big `json:"baseFee"`
+		Selfdestruct *hexutil.Big `json:"selfdestruct"`
+	} `json:"burn"`
+	Delta       *hexutil.Big `json:"delta"`
+	TotalSupply *hexutil.Big `json:"totalSupply"` // nil if the supply before the block is unknown
+}
+
+func newRPCSupplyDelta(delta *types.SupplyDelta) *RPCSupplyDelta {
+	result := &RPCSupplyDelta{
+		BlockNumber: hexutil.Uint64(delta.Number),
+		BlockHash:   delta.Hash,
+		ParentHash:  delta.ParentHash,
+		Delta:       (*hexutil.Big)(delta.Delta()),
+		TotalSupply: (*hexutil.Big)(delta.Total),
+	}
+	result.Issuance.GenesisAlloc = (*hexutil.Big)(delta.Issuance.GenesisAlloc)
+	result.Issuance.Reward = (*hexutil.Big)(delta.Issuance.Reward)
+	result.Issuance.Covenant = (*hexutil.Big)(delta.Issuance.Covenant)
+	result.Issuance.Withdrawals = (*hexutil.Big)(delta.Issuance.Withdrawals)
+	result.Burn.BaseFee = (*hexutil.Big)(delta.Burn.BaseFee)
+	result.Burn.Selfdestruct = (*hexutil.Big)(delta.Burn.Selfdestruct)
+	return result
+}
+
+// RPCSupplyAudit is the rpc representation of the last supply audit.
+type RPCSupplyAudit struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Tracked     *hexutil.Big   `json:"tracked"`
+	State       *hexutil.Big   `json:"state"`
+	Difference  *hexutil.Big   `json:"difference"`
+	Mismatch    bool           `json:"mismatch"`
+	Seeded      bool           `json:"seeded"`
+	Timestamp   hexutil.Uint64 `json:"timestamp"`
+}
+
+// API exposes the tracked supply over RPC.
+type API struct {
+	db    ethdb.Database
+	chain *core.BlockChain
+}
+
+// NewAPI creates a new API definition for querying the tracked supply.
+func NewAPI(db ethdb.Database, chain *core.BlockChain) *API {
+	return &API{db: db, chain: chain}
+}
+
+// SupplyDeltas returns the supply deltas of the canonical blocks in the given
+// range. Blocks which weren't tracked are omitted.
+func (api *API) SupplyDeltas(ctx context.Context, from rpc.BlockNumber, to rpc.BlockNumber) ([]*RPCSupplyDelta, error) {
+	head := api.chain.CurrentBlock().NumberU64()
+	first, last := resolveBlock(from, head), resolveBlock(to, head)
+	switch {
+	case first > last:
+		return nil, fmt.Errorf("invalid block range: from %d is after to %d", first, last)
+	case last > head:
+		return nil, fmt.Errorf("block #%d is not imported yet, head is #%d", last, head)
+	case last-first >= maxBlockRange:
+		return nil, fmt.Errorf("block range too large, limit is %d blocks", maxBlockRange)
+	}
+	deltas := []*RPCSupplyDelta{}
+	for number := first; number <= last; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hash := rawdb.ReadCanonicalHash(api.db, number)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		if delta := rawdb.ReadSupplyDelta(api.db, hash, number); delta != nil {
+			deltas = append(deltas, newRPCSupplyDelta(delta))
+		}
+	}
+	return deltas, nil
+}
+
+// SupplyAudit returns the result of the last cross-check of the tracked supply
+// against the state, or nil if none was done yet.
+func (api *API) SupplyAudit(ctx context.Context) (*RPCSupplyAudit, error) {
+	audit := rawdb.ReadSupplyAudit(api.db)
+	if audit == nil {
+		return nil, nil
+	}
+	return &RPCSupplyAudit{
+		BlockNumber: hexutil.Uint64(audit.Number),
+		BlockHash:   audit.Hash,
+		Tracked:     (*hexutil.Big)(audit.Tracked),
+		State:       (*hexutil.Big)(audit.State),
+		Difference:  (*hexutil.Big)(new(big.Int).Sub(audit.Tracked, audit.State)),
+		Mismatch:    audit.Mismatch(),
+		Seeded:      audit.Seeded,
+		Timestamp:   hexutil.Uint64(audit.Time),
+	}, nil
+}
+
+// resolveBlock converts a block number of a query into an actual one, block
+// tags resolving to the head of the chain.
+func resolveBlock(number rpc.BlockNumber, head uint64) uint64 {
+	if number < 0 {
+		return head
+	}
+	return uint64(number)
+}
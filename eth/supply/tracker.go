@@ -0,0 +1,284 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package supply implements an opt-in tracker of the ether supply, recording
+// the ether created and destroyed by every imported block and auditing the
+// result against the balances of the state.
+package supply
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// auditRetry is the time to wait before retrying the startup audit while the
+// snapshot is still being generated.
+const auditRetry = 30 * time.Second
+
+var errTerminated = errors.New("supply tracker terminated")
+
+// Tracker is a blockchain logger recording the supply delta of every block the
+// chain imports. The total supply after a block is tracked as long as the one
+// of its parent is known, either from a tracked genesis or from a state audit.
+type Tracker struct {
+	*core.BalanceRecorder
+
+	db    ethdb.Database
+	block *types.Block // Block being processed currently
+	lock  sync.Mutex   // Lock serialising the writes of the deltas and the audits
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a supply tracker persisting into the given database. It needs to
+// be passed as the tracer of the vm config the blockchain is created with.
+func New(db ethdb.Database) *Tracker {
+	return &Tracker{
+		BalanceRecorder: core.NewBalanceRecorder(nil, nil),
+		db:              db,
+		quit:            make(chan struct{}),
+	}
+}
+
+// Start begins recording the covenant rewards attributed by the consensus engine
+// of the chain, and audits the tracked supply against the head state of the
+// chain in the background, waiting for the snapshot to be generated if needed.
+// It needs to be called before the chain imports any block past its genesis.
+func (t *Tracker) Start(chain *core.BlockChain) {
+	t.BalanceRecorder = core.NewBalanceRecorder(chain.Config(), chain.Engine())
+
+	t.wg.Add(1)
+	go t.loop(chain)
+}
+
+// Stop terminates a running audit.
+func (t *Tracker) Stop() {
+	close(t.quit)
+	t.wg.Wait()
+}
+
+func (t *Tracker) loop(chain *core.BlockChain) {
+	defer t.wg.Done()
+
+	snaps := chain.Snapshots()
+	if snaps == nil {
+		log.Warn("Supply audit skipped, snapshots are disabled")
+		return
+	}
+	for {
+		_, err := t.Audit(snaps, chain.CurrentBlock())
+		switch {
+		case err == nil || errors.Is(err, errTerminated):
+			return
+		case errors.Is(err, snapshot.ErrNotConstructed):
+			log.Info("Supply audit waiting for snapshot generation")
+			select {
+			case <-time.After(auditRetry):
+			case <-t.quit:
+				return
+			}
+		default:
+			log.Error("Supply audit failed", "err", err)
+			return
+		}
+	}
+}
+
+// OnBlockStart implements core.BlockchainLogger, starting the recording of the
+// balance changes of the block.
+func (t *Tracker) OnBlockStart(block *types.Block, td *big.Int, finalized, safe *types.Block) {
+	t.BalanceRecorder.OnBlockStart(block, td, finalized, safe)
+	t.block = block
+}
+
+// OnBlockEnd implements core.BlockchainLogger, persisting the supply delta of
+// the block if it was imported successfully.
+func (t *Tracker) OnBlockEnd(err error) {
+	t.BalanceRecorder.OnBlockEnd(err)
+
+	block := t.block
+	t.block = nil
+	if err != nil || block == nil {
+		return
+	}
+	delta := t.delta(block)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if total := t.total(block.ParentHash(), block.NumberU64()-1); total != nil {
+		delta.Total = total.Add(total, delta.Delta())
+	}
+	rawdb.WriteSupplyDelta(t.db, delta)
+}
+
+// OnGenesisBlock implements core.BlockchainLogger, persisting the genesis
+// allocation as the initial supply.
+func (t *Tracker) OnGenesisBlock(genesis *types.Block, alloc core.GenesisAlloc) {
+	t.BalanceRecorder.OnGenesisBlock(genesis, alloc)
+	delta := t.delta(genesis)
+	delta.Total = delta.Delta()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	rawdb.WriteSupplyDelta(t.db, delta)
+}
+
+// delta aggregates the balance changes recorded for the given block into its
+// supply delta. Ether destroyed beyond the burnt fees can only be the result of
+// SELFDESTRUCT, which is derived from the net change of the balances instead of
+// the individual changes, as the beneficiary and the reverts of the destruction
+// make those ambiguous.
+func (t *Tracker) delta(block *types.Block) *types.SupplyDelta {
+	var (
+		delta = types.NewSupplyDelta(block.NumberU64(), block.Hash(), block.ParentHash())
+		net   = new(big.Int)
+	)
+	for _, change := range t.Changes() {
+		switch change.Reason {
+		case tracing.BalanceChangeBaseFeeBurn:
+			delta.Burn.BaseFee.Add(delta.Burn.BaseFee, change.Delta)
+			continue // not a change of the state
+		case tracing.BalanceIncreaseGenesisBalance:
+			delta.Issuance.GenesisAlloc.Add(delta.Issuance.GenesisAlloc, change.Delta)
+		case tracing.BalanceIncreaseRewardMineBlock, tracing.BalanceIncreaseRewardMineUncle:
+			delta.Issuance.Reward.Add(delta.Issuance.Reward, change.Delta)
+		case tracing.BalanceIncreaseRewardCovenant:
+			delta.Issuance.Covenant.Add(delta.Issuance.Covenant, change.Delta)
+			continue // not a change of the state
+		case tracing.BalanceIncreaseWithdrawal:
+			delta.Issuance.Withdrawals.Add(delta.Issuance.Withdrawals, change.Delta)
+		}
+		net.Add(net, change.Delta)
+	}
+	destructed := new(big.Int).Sub(delta.Issued(), delta.Burn.BaseFee)
+	destructed.Sub(destructed, net)
+	if destructed.Sign() < 0 {
+		log.Warn("Untracked ether issuance", "number", block.Number(), "hash", block.Hash(), "amount", new(big.Int).Neg(destructed))
+	}
+	delta.Burn.Selfdestruct = destructed
+	return delta
+}
+
+// total returns the total supply after the given block, or nil if unknown. The
+// supply of a block is known if it was tracked, or if it was audited.
+func (t *Tracker) total(hash common.Hash, number uint64) *big.Int {
+	if delta := rawdb.ReadSupplyDelta(t.db, hash, number); delta != nil && delta.Total != nil {
+		return delta.Total
+	}
+	if audit := rawdb.ReadSupplyAudit(t.db); audit != nil && audit.Hash == hash {
+		return new(big.Int).Set(audit.State)
+	}
+	return nil
+}
+
+// Audit sums the balances of all the accounts in the state of the given block
+// and cross-checks them against the tracked supply. If the supply of the block
+// isn't known, the tracker is seeded with the audited one instead, along with
+// the canonical descendants imported in the meantime.
+func (t *Tracker) Audit(snaps *snapshot.Tree, block *types.Block) (*types.SupplyAudit, error) {
+	it, err := snaps.AccountIterator(block.Root(), common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	var (
+		balances = new(big.Int)
+		accounts uint64
+		start    = time.Now()
+		logged   = time.Now()
+	)
+	for it.Next() {
+		account, err := snapshot.FullAccount(it.Account())
+		if err != nil {
+			return nil, err
+		}
+		balances.Add(balances, account.Balance)
+		accounts++
+
+		if time.Since(logged) > 8*time.Second {
+			select {
+			case <-t.quit:
+				return nil, errTerminated
+			default:
+			}
+			log.Info("Auditing ether supply", "number", block.Number(), "accounts", accounts, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	audit := &types.SupplyAudit{
+		Number: block.NumberU64(),
+		Hash:   block.Hash(),
+		State:  balances,
+		Time:   uint64(time.Now().Unix()),
+	}
+	if tracked := t.total(block.Hash(), block.NumberU64()); tracked != nil {
+		audit.Tracked = tracked
+	} else {
+		audit.Tracked, audit.Seeded = new(big.Int).Set(balances), true
+	}
+	rawdb.WriteSupplyAudit(t.db, audit)
+
+	switch {
+	case audit.Seeded:
+		seeded := t.propagate(audit)
+		log.Info("Seeded supply tracker from state", "number", block.Number(), "hash", block.Hash(), "supply", balances, "descendants", seeded, "accounts", accounts, "elapsed", common.PrettyDuration(time.Since(start)))
+	case audit.Mismatch():
+		log.Error("Tracked supply mismatches state", "number", block.Number(), "hash", block.Hash(), "tracked", audit.Tracked, "state", balances, "difference", new(big.Int).Sub(audit.Tracked, balances))
+	default:
+		log.Info("Audited tracked supply", "number", block.Number(), "hash", block.Hash(), "supply", balances, "accounts", accounts, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return audit, nil
+}
+
+// propagate fills in the total supply of the canonical descendants of a seeded
+// block imported while the audit was running, returning their number.
+func (t *Tracker) propagate(audit *types.SupplyAudit) int {
+	total := new(big.Int).Set(audit.State)
+	for number := audit.Number + 1; ; number++ {
+		hash := rawdb.ReadCanonicalHash(t.db, number)
+		if hash == (common.Hash{}) {
+			return int(number - audit.Number - 1)
+		}
+		delta := rawdb.ReadSupplyDelta(t.db, hash, number)
+		if delta == nil || delta.Total != nil {
+			return int(number - audit.Number - 1)
+		}
+		delta.Total = total.Add(total, delta.Delta())
+		rawdb.WriteSupplyDelta(t.db, delta)
+		total = new(big.Int).Set(delta.Total)
+	}
+}
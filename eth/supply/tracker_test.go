@@ -0,0 +1,301 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package supply
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr     = crypto.PubkeyToAddress(testKey.PublicKey)
+	destructAddr = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+
+	// Self-destruct with the contract itself as the beneficiary, burning its balance
+	destructCode = []byte{byte(vm.ADDRESS), byte(vm.SELFDESTRUCT)}
+)
+
+// newTestChain generates a chain of the given length, in which the first block
+// destroys a funded contract and all others transfer some ether.
+func newTestChain(n int) (*core.Genesis, []*types.Block, []types.Receipts) {
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			testAddr:     {Balance: big.NewInt(params.Ether)},
+			destructAddr: {Balance: big.NewInt(params.Ether), Code: destructCode},
+		},
+	}
+	signer := types.LatestSigner(gspec.Config)
+
+	_, blocks, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), n, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x1})
+
+		to := common.Address{0x2}
+		if i == 0 {
+			to = destructAddr
+		}
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), to, big.NewInt(10), 100000, new(big.Int).Add(b.BaseFee(), common.Big1), nil), signer, testKey)
+		b.AddTx(tx)
+	})
+	return gspec, blocks, receipts
+}
+
+// newTrackedChain creates a blockchain on top of the database with the supply
+// tracker attached.
+func newTrackedChain(t *testing.T, db ethdb.Database, gspec *core.Genesis) (*core.BlockChain, *Tracker) {
+	tracker := New(db)
+	chain, err := core.NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{Tracer: tracker}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+	return chain, tracker
+}
+
+// checkTotals verifies that the tracked totals of the canonical blocks in the
+// given range follow from each other.
+func checkTotals(t *testing.T, db ethdb.Database, from, to uint64) {
+	t.Helper()
+
+	var prev *types.SupplyDelta
+	for number := from; number <= to; number++ {
+		delta := rawdb.ReadSupplyDelta(db, rawdb.ReadCanonicalHash(db, number), number)
+		if delta == nil || delta.Total == nil {
+			t.Fatalf("block %d: supply not tracked", number)
+		}
+		if prev != nil {
+			if want := new(big.Int).Add(prev.Total, delta.Delta()); delta.Total.Cmp(want) != 0 {
+				t.Errorf("block %d: total supply mismatch: have %v, want %v", number, delta.Total, want)
+			}
+		}
+		prev = delta
+	}
+}
+
+func TestSupplyTracker(t *testing.T) {
+	gspec, blocks, receipts := newTestChain(4)
+
+	db := rawdb.NewMemoryDatabase()
+	chain, tracker := newTrackedChain(t, db, gspec)
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	genesis := rawdb.ReadSupplyDelta(db, chain.Genesis().Hash(), 0)
+	if want := big.NewInt(2 * params.Ether); genesis == nil || genesis.Issuance.GenesisAlloc.Cmp(want) != 0 || genesis.Total.Cmp(want) != 0 {
+		t.Fatalf("genesis supply mismatch: %+v", genesis)
+	}
+	for i, block := range blocks {
+		delta := rawdb.ReadSupplyDelta(db, block.Hash(), block.NumberU64())
+		if delta == nil {
+			t.Fatalf("block %d: supply delta missing", i+1)
+		}
+		if delta.Issuance.Reward.Cmp(ethash.ConstantinopleBlockReward) != 0 {
+			t.Errorf("block %d: reward mismatch: have %v, want %v", i+1, delta.Issuance.Reward, ethash.ConstantinopleBlockReward)
+		}
+		if want := new(big.Int).Mul(new(big.Int).SetUint64(receipts[i][0].GasUsed), block.BaseFee()); delta.Burn.BaseFee.Cmp(want) != 0 {
+			t.Errorf("block %d: burnt base fee mismatch: have %v, want %v", i+1, delta.Burn.BaseFee, want)
+		}
+		want := new(big.Int)
+		if i == 0 {
+			want.SetUint64(params.Ether + 10)
+		}
+		if delta.Burn.Selfdestruct.Cmp(want) != 0 {
+			t.Errorf("block %d: self-destructed ether mismatch: have %v, want %v", i+1, delta.Burn.Selfdestruct, want)
+		}
+	}
+	checkTotals(t, db, 0, 4)
+
+	audit, err := tracker.Audit(chain.Snapshots(), chain.CurrentBlock())
+	if err != nil {
+		t.Fatalf("failed to audit supply: %v", err)
+	}
+	if audit.Seeded || audit.Mismatch() {
+		t.Fatalf("unexpected audit result: seeded %v, tracked %v, state %v", audit.Seeded, audit.Tracked, audit.State)
+	}
+	deltas, err := NewAPI(db, chain).SupplyDeltas(context.Background(), 1, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to query supply deltas: %v", err)
+	}
+	if len(deltas) != 4 {
+		t.Fatalf("supply delta count mismatch: have %d, want %d", len(deltas), 4)
+	}
+	if last := deltas[3]; uint64(last.BlockNumber) != 4 || last.TotalSupply.ToInt().Cmp(audit.State) != 0 {
+		t.Errorf("last supply delta mismatch: block %d, total %v", last.BlockNumber, last.TotalSupply)
+	}
+}
+
+// Tests that the supply of blocks written by the local miner, bypassing the
+// import, is tracked as well.
+func TestSupplyTrackerSealedBlock(t *testing.T) {
+	gspec, blocks, _ := newTestChain(3)
+
+	db := rawdb.NewMemoryDatabase()
+	chain, _ := newTrackedChain(t, db, gspec)
+	if n, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	// Execute the next block the way the miner does and write it directly
+	statedb, err := chain.StateAt(blocks[0].Root())
+	if err != nil {
+		t.Fatalf("failed to open parent state: %v", err)
+	}
+	receipts, logs, _, err := chain.Processor().Process(blocks[1], statedb, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to process block: %v", err)
+	}
+	if _, err := chain.WriteBlockAndSetHead(blocks[1], receipts, logs, statedb, false); err != nil {
+		t.Fatalf("failed to write block: %v", err)
+	}
+	delta := rawdb.ReadSupplyDelta(db, blocks[1].Hash(), 2)
+	if delta == nil || delta.Total == nil {
+		t.Fatalf("supply of sealed block not tracked: %+v", delta)
+	}
+	if delta.Issuance.Reward.Cmp(ethash.ConstantinopleBlockReward) != 0 {
+		t.Errorf("reward mismatch: have %v, want %v", delta.Issuance.Reward, ethash.ConstantinopleBlockReward)
+	}
+	// The descendants should be tracked on top of it
+	if n, err := chain.InsertChain(blocks[2:]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	checkTotals(t, db, 0, 3)
+}
+
+// covenantEngine is a consensus engine crediting the tips of every block to a
+// covenant nft on top of the rewards of the wrapped engine.
+type covenantEngine struct {
+	consensus.Engine
+}
+
+func (e *covenantEngine) BlockRewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header, fees *big.Int) []*types.BlockReward {
+	rewards := e.Engine.(consensus.Rewarder).BlockRewards(config, header, uncles, new(big.Int))
+	return append(rewards, &types.BlockReward{Type: types.CovenantReward, TokenID: 1, Reward: fees, Fee: new(big.Int)})
+}
+
+// Tests that the covenant rewards of the reward records are tracked separately,
+// without being counted towards the supply of the state.
+func TestSupplyTrackerCovenantReward(t *testing.T) {
+	gspec, blocks, receipts := newTestChain(3)
+
+	db := rawdb.NewMemoryDatabase()
+	tracker := New(db)
+	chain, err := core.NewBlockChain(db, nil, gspec, nil, &covenantEngine{ethash.NewFaker()}, vm.Config{Tracer: tracker}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	tracker.Start(chain)
+	defer tracker.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for i, block := range blocks {
+		delta := rawdb.ReadSupplyDelta(db, block.Hash(), block.NumberU64())
+		if delta == nil {
+			t.Fatalf("block %d: supply delta missing", i+1)
+		}
+		// Every transaction pays a tip of 1 wei per gas
+		if want := new(big.Int).SetUint64(receipts[i][0].GasUsed); delta.Issuance.Covenant.Cmp(want) != 0 {
+			t.Errorf("block %d: covenant reward mismatch: have %v, want %v", i+1, delta.Issuance.Covenant, want)
+		}
+		if i > 0 && delta.Burn.Selfdestruct.Sign() != 0 {
+			t.Errorf("block %d: covenant reward taken for self-destructed ether: %v", i+1, delta.Burn.Selfdestruct)
+		}
+	}
+	checkTotals(t, db, 0, 3)
+
+	audit, err := tracker.Audit(chain.Snapshots(), chain.CurrentBlock())
+	if err != nil {
+		t.Fatalf("failed to audit supply: %v", err)
+	}
+	if audit.Mismatch() {
+		t.Fatalf("supply mismatch: tracked %v, state %v", audit.Tracked, audit.State)
+	}
+}
+
+func TestSupplyAudit(t *testing.T) {
+	gspec, blocks, _ := newTestChain(6)
+
+	// Import part of the chain without tracking the supply
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	chain.Stop()
+
+	// Import a block with tracking enabled, its supply is unknown without the parent's
+	chain, tracker := newTrackedChain(t, db, gspec)
+	if n, err := chain.InsertChain(blocks[3:4]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	if delta := rawdb.ReadSupplyDelta(db, blocks[3].Hash(), 4); delta == nil || delta.Total != nil {
+		t.Fatalf("unexpected supply delta of untracked parent: %+v", delta)
+	}
+	// Auditing the parent should seed the tracker and fill in the descendant
+	audit, err := tracker.Audit(chain.Snapshots(), blocks[2])
+	if err != nil {
+		t.Fatalf("failed to audit supply: %v", err)
+	}
+	if !audit.Seeded || audit.Mismatch() {
+		t.Fatalf("unexpected audit result: seeded %v, tracked %v, state %v", audit.Seeded, audit.Tracked, audit.State)
+	}
+	if n, err := chain.InsertChain(blocks[4:]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	checkTotals(t, db, 4, 6)
+
+	if audit, err = tracker.Audit(chain.Snapshots(), chain.CurrentBlock()); err != nil {
+		t.Fatalf("failed to audit supply: %v", err)
+	}
+	if audit.Seeded || audit.Mismatch() {
+		t.Fatalf("unexpected audit result: seeded %v, tracked %v, state %v", audit.Seeded, audit.Tracked, audit.State)
+	}
+	// Corrupt the tracked supply and ensure the audit flags it
+	delta := rawdb.ReadSupplyDelta(db, blocks[5].Hash(), 6)
+	delta.Total.Add(delta.Total, common.Big1)
+	rawdb.WriteSupplyDelta(db, delta)
+
+	if audit, err = tracker.Audit(chain.Snapshots(), chain.CurrentBlock()); err != nil {
+		t.Fatalf("failed to audit supply: %v", err)
+	}
+	if !audit.Mismatch() {
+		t.Fatalf("supply mismatch not detected: tracked %v, state %v", audit.Tracked, audit.State)
+	}
+	result, _ := NewAPI(db, chain).SupplyAudit(context.Background())
+	if result == nil || !result.Mismatch || result.Difference.ToInt().Cmp(common.Big1) != 0 {
+		t.Fatalf("unexpected rpc audit result: %+v", result)
+	}
+}
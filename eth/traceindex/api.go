@@ -0,0 +1,152 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package traceindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxBlockRange is the maximum number of blocks a single query may span.
+const maxBlockRange = 10000
+
+var errNotIndexed = errors.New("no blocks indexed yet")
+
+// CallFilter are the criteria of an internal call query. Missing block bounds
+// default to the range served by the index, and block tags resolve to the last
+// indexed block. Calls are matched if either their sender or recipient is one
+// of the addresses, or unconditionally if no addresses are given.
+type CallFilter struct {
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	Addresses []common.Address `json:"addresses"`
+}
+
+// RPCInternalCall is the rpc representation of an indexed call frame.
+type RPCInternalCall struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	Depth            hexutil.Uint64 `json:"depth"`
+	Type             string         `json:"type"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"`
+	Value            *hexutil.Big   `json:"value"`
+	Error            string         `json:"error,omitempty"`
+}
+
+// API exposes the trace index over RPC.
+type API struct {
+	indexer *Indexer
+}
+
+// NewAPI creates a new API definition for querying the trace index.
+func NewAPI(indexer *Indexer) *API {
+	return &API{indexer: indexer}
+}
+
+// InternalTransactions returns the indexed call frames matching the filter,
+// ordered by block, transaction and execution order.
+func (api *API) InternalTransactions(ctx context.Context, filter CallFilter) ([]*RPCInternalCall, error) {
+	first, last, ok := api.indexer.Range()
+	if !ok {
+		return nil, errNotIndexed
+	}
+	from, to := resolveBlock(filter.FromBlock, first, last), resolveBlock(filter.ToBlock, last, last)
+	switch {
+	case from > to:
+		return nil, fmt.Errorf("invalid block range: from %d is after to %d", from, to)
+	case from < first:
+		return nil, fmt.Errorf("block #%d is pruned from the index, first available is #%d", from, first)
+	case to > last:
+		return nil, fmt.Errorf("block #%d is not indexed yet, last available is #%d", to, last)
+	case to-from >= maxBlockRange:
+		return nil, fmt.Errorf("block range too large, limit is %d blocks", maxBlockRange)
+	}
+	calls := []*RPCInternalCall{}
+	for number := from; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hash, summaries := api.indexer.Calls(number)
+
+		var txs types.Transactions
+		for _, call := range summaries {
+			if !matchAddresses(call, filter.Addresses) {
+				continue
+			}
+			// Transactions are only loaded for blocks with matches
+			if txs == nil {
+				body := rawdb.ReadBody(api.indexer.db, hash, number)
+				if body == nil {
+					return nil, fmt.Errorf("block body #%d [%x..] not found", number, hash.Bytes()[:4])
+				}
+				txs = body.Transactions
+			}
+			if call.TxIndex >= uint64(len(txs)) {
+				return nil, fmt.Errorf("corrupt index entry for block #%d: transaction %d out of range", number, call.TxIndex)
+			}
+			calls = append(calls, &RPCInternalCall{
+				BlockNumber:      hexutil.Uint64(number),
+				BlockHash:        hash,
+				TransactionHash:  txs[call.TxIndex].Hash(),
+				TransactionIndex: hexutil.Uint64(call.TxIndex),
+				Depth:            hexutil.Uint64(call.Depth),
+				Type:             vm.OpCode(call.Type).String(),
+				From:             call.From,
+				To:               call.To,
+				Value:            (*hexutil.Big)(call.Value),
+				Error:            call.Error,
+			})
+		}
+	}
+	return calls, nil
+}
+
+// resolveBlock converts an optional block number of a query into an actual one.
+func resolveBlock(number *rpc.BlockNumber, def uint64, last uint64) uint64 {
+	switch {
+	case number == nil:
+		return def
+	case *number < 0:
+		return last
+	}
+	return uint64(*number)
+}
+
+// matchAddresses reports whether the call was sent from or to any of the given
+// addresses. An empty list matches every call.
+func matchAddresses(call *types.InternalCall, addrs []common.Address) bool {
+	if len(addrs) == 0 {
+		return true
+	}
+	for _, addr := range addrs {
+		if call.From == addr || call.To == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package traceindex
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// callCollector is an EVM logger summarising every call frame executed by the
+// transactions of a block.
+type callCollector struct {
+	calls   []*types.InternalCall // Summaries of all the frames, in execution order
+	stack   []*types.InternalCall // Frames currently being executed
+	txIndex int                   // Index of the transaction being executed
+}
+
+func newCallCollector() *callCollector {
+	return &callCollector{txIndex: -1}
+}
+
+func (c *callCollector) CaptureTxStart(gasLimit uint64) {
+	c.txIndex++
+	c.stack = c.stack[:0]
+}
+
+func (c *callCollector) CaptureTxEnd(restGas uint64) {}
+
+func (c *callCollector) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	c.push(typ, from, to, value)
+}
+
+func (c *callCollector) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	c.pop(err)
+}
+
+func (c *callCollector) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	c.push(typ, from, to, value)
+}
+
+func (c *callCollector) CaptureExit(output []byte, gasUsed uint64, err error) {
+	c.pop(err)
+}
+
+func (c *callCollector) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (c *callCollector) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// push starts tracking a newly entered call frame.
+func (c *callCollector) push(typ vm.OpCode, from, to common.Address, value *big.Int) {
+	call := &types.InternalCall{
+		TxIndex: uint64(c.txIndex),
+		Depth:   uint64(len(c.stack)),
+		Type:    byte(typ),
+		From:    from,
+		To:      to,
+		Value:   new(big.Int),
+	}
+	if value != nil {
+		call.Value.Set(value)
+	}
+	c.calls = append(c.calls, call)
+	c.stack = append(c.stack, call)
+}
+
+// pop finishes the innermost call frame, recording its failure if any.
+func (c *callCollector) pop(err error) {
+	if len(c.stack) == 0 {
+		return
+	}
+	if err != nil {
+		c.stack[len(c.stack)-1].Error = err.Error()
+	}
+	c.stack = c.stack[:len(c.stack)-1]
+}
@@ -0,0 +1,303 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package traceindex implements a background index of the call frames executed
+// by the transactions of the canonical chain, permitting fast lookups of the
+// internal transactions of an address without re-executing historical blocks.
+package traceindex
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// sectionSize is the number of blocks indexed in one go by the chain indexer.
+	sectionSize = 256
+
+	// sectionConfirms is the number of confirmations needed before a section is
+	// indexed, keeping the index clear of shallow reorgs.
+	sectionConfirms = 64
+
+	// throttling is the time to wait between processing two consecutive index
+	// sections, to prevent disk overload while catching up.
+	throttling = 100 * time.Millisecond
+
+	// reexec is the number of blocks the indexer is willing to re-execute to
+	// regenerate the state of the first block it indexes.
+	reexec = uint64(4096)
+
+	// memLimit is the size of the trie database, at which the indexer switches
+	// over to a state available on disk instead of the regenerated one.
+	memLimit = 500 * 1024 * 1024
+)
+
+// Config are the configuration parameters of the trace index.
+type Config struct {
+	Retention uint64 // Number of recent blocks to keep the index for, zero to keep all
+}
+
+// StateAtBlockFunc retrieves the state of the given block, optionally building
+// on top of the state of its parent. See eth.Ethereum.StateAtBlock for details.
+type StateAtBlockFunc func(block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (*state.StateDB, tracers.StateReleaseFunc, error)
+
+// Indexer maintains the index of the internal calls executed by the canonical
+// chain. Sections of blocks are indexed after they are confirmed, re-executing
+// their transactions on top of the historical state.
+type Indexer struct {
+	db      ethdb.Database
+	chain   *core.BlockChain
+	config  Config
+	size    uint64
+	indexer *core.ChainIndexer
+}
+
+// New creates a trace index for the given chain. The index isn't built until
+// Start is called.
+func New(db ethdb.Database, chain *core.BlockChain, stateAt StateAtBlockFunc, config Config) *Indexer {
+	return newIndexer(db, chain, stateAt, config, sectionSize, sectionConfirms)
+}
+
+func newIndexer(db ethdb.Database, chain *core.BlockChain, stateAt StateAtBlockFunc, config Config, size, confirms uint64) *Indexer {
+	backend := &indexerBackend{
+		db:        db,
+		chain:     chain,
+		stateAt:   stateAt,
+		size:      size,
+		retention: config.Retention,
+		pruned:    rawdb.ReadTraceIndexTail(db),
+	}
+	table := rawdb.NewTable(db, string(rawdb.TraceIndexPrefix))
+
+	return &Indexer{
+		db:      db,
+		chain:   chain,
+		config:  config,
+		size:    size,
+		indexer: core.NewChainIndexer(db, table, backend, size, confirms, throttling, "traceindex"),
+	}
+}
+
+// Start begins indexing the chain in the background.
+func (idx *Indexer) Start() {
+	idx.indexer.Start(idx.chain)
+}
+
+// Close tears down the background indexing.
+func (idx *Indexer) Close() error {
+	return idx.indexer.Close()
+}
+
+// Range returns the first and last blocks served by the index, or false if no
+// blocks were indexed yet.
+func (idx *Indexer) Range() (uint64, uint64, bool) {
+	sections, _, _ := idx.indexer.Sections()
+	if sections == 0 {
+		return 0, 0, false
+	}
+	first, last := uint64(0), sections*idx.size-1
+	if retention := idx.config.Retention; retention > 0 {
+		if head := idx.chain.CurrentHeader().Number.Uint64(); head > retention {
+			first = head - retention
+		}
+	}
+	if first > last {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+// Calls retrieves the internal calls executed by the given canonical block.
+func (idx *Indexer) Calls(number uint64) (common.Hash, []*types.InternalCall) {
+	hash := rawdb.ReadCanonicalHash(idx.db, number)
+	if hash == (common.Hash{}) {
+		return hash, nil
+	}
+	return hash, rawdb.ReadInternalCalls(idx.db, hash, number)
+}
+
+// indexerBackend implements core.ChainIndexerBackend, tracing the blocks of a
+// section and storing the summaries of their call frames.
+type indexerBackend struct {
+	db        ethdb.Database
+	chain     *core.BlockChain
+	stateAt   StateAtBlockFunc
+	size      uint64
+	retention uint64
+
+	section uint64      // Section number being processed currently
+	skip    bool        // Whether the current section is outside the retention window
+	batch   ethdb.Batch // Summaries of the current section waiting to be committed
+	pruned  uint64      // First block not yet pruned from the index
+
+	state     *state.StateDB           // State of the last block traced upon
+	stateHash common.Hash              // Hash of the block the state belongs to
+	release   tracers.StateReleaseFunc // Release function of the state
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new index section.
+func (b *indexerBackend) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.section, b.batch = section, b.db.NewBatch()
+
+	// Sections which will be pruned right away are not worth tracing
+	b.skip = false
+	if b.retention > 0 {
+		head := b.chain.CurrentHeader().Number.Uint64()
+		b.skip = (section+1)*b.size+b.retention <= head
+	}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, tracing a block of the section.
+func (b *indexerBackend) Process(ctx context.Context, header *types.Header) error {
+	number := header.Number.Uint64()
+	if b.skip || number == 0 {
+		return nil
+	}
+	block := b.chain.GetBlock(header.Hash(), number)
+	if block == nil {
+		return fmt.Errorf("block #%d [%x..] not found", number, header.Hash().Bytes()[:4])
+	}
+	parent := b.chain.GetBlock(block.ParentHash(), number-1)
+	if parent == nil {
+		return fmt.Errorf("parent #%d [%x..] not found", number-1, block.ParentHash().Bytes()[:4])
+	}
+	statedb, err := b.parentState(parent)
+	if err != nil {
+		return err
+	}
+	// Trace the block right on top of the parent state, even if it's empty, so
+	// the resulting state can be carried forward to its child
+	collector := newCallCollector()
+	if _, _, _, err := b.chain.Processor().Process(block, statedb, vm.Config{Debug: true, Tracer: collector}); err != nil {
+		b.releaseState()
+		return fmt.Errorf("failed to trace block #%d [%x..]: %v", number, header.Hash().Bytes()[:4], err)
+	}
+	if err := b.advanceState(block, statedb); err != nil {
+		return err
+	}
+	if len(block.Transactions()) > 0 {
+		rawdb.WriteInternalCalls(b.batch, block.Hash(), number, collector.calls)
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, persisting the summaries of the
+// section and pruning the ones out of the retention window.
+func (b *indexerBackend) Commit() error {
+	if b.skip {
+		return nil
+	}
+	// Drop any summaries of the section left over by a reorg
+	rawdb.DeleteInternalCallsRange(b.db, b.section*b.size, (b.section+1)*b.size)
+	if err := b.batch.Write(); err != nil {
+		return err
+	}
+	if b.retention > 0 {
+		if head := b.chain.CurrentHeader().Number.Uint64(); head > b.retention {
+			return b.Prune(head - b.retention)
+		}
+	}
+	return nil
+}
+
+// Prune implements core.ChainIndexerBackend, deleting the summaries of all the
+// blocks below the threshold.
+func (b *indexerBackend) Prune(threshold uint64) error {
+	if threshold <= b.pruned {
+		return nil
+	}
+	rawdb.DeleteInternalCallsRange(b.db, b.pruned, threshold)
+	rawdb.WriteTraceIndexTail(b.db, threshold)
+	b.pruned = threshold
+	return nil
+}
+
+// parentState returns the state to trace the child of the given block on top
+// of. The post-state of the previously traced block is reused if possible, the
+// state is only regenerated if the indexer jumps around, e.g. after a reorg or
+// a skipped section.
+func (b *indexerBackend) parentState(parent *types.Block) (*state.StateDB, error) {
+	if b.state != nil && b.stateHash == parent.Hash() {
+		return b.state, nil
+	}
+	b.releaseState()
+
+	statedb, release, err := b.stateAt(parent, reexec, nil, false, false)
+	if err != nil {
+		return nil, err
+	}
+	b.state, b.stateHash, b.release = statedb, parent.Hash(), release
+	return statedb, nil
+}
+
+// advanceState commits the state a block was just traced on, and holds on to it
+// as the parent state of the next block.
+func (b *indexerBackend) advanceState(block *types.Block, statedb *state.StateDB) error {
+	database := statedb.Database()
+
+	// Path based states are served by the live database, which must not be
+	// written to. The state of the block is available there too, drop ours.
+	if database.TrieDB().Scheme().Name() == trie.PathScheme {
+		b.releaseState()
+		return nil
+	}
+	root, err := statedb.Commit(b.chain.Config().IsEIP158(block.Number()))
+	if err != nil {
+		b.releaseState()
+		return fmt.Errorf("failed to commit state of block #%d [%x..]: %v", block.NumberU64(), block.Hash().Bytes()[:4], err)
+	}
+	next, err := state.New(root, database, nil)
+	if err != nil {
+		b.releaseState()
+		return fmt.Errorf("failed to reopen state of block #%d [%x..]: %v", block.NumberU64(), block.Hash().Bytes()[:4], err)
+	}
+	// Hold the new state and drop the parent one to prevent accumulating too
+	// many nodes in memory
+	database.TrieDB().Reference(root, common.Hash{})
+	b.releaseState()
+	b.state, b.stateHash = next, block.Hash()
+	b.release = func() { database.TrieDB().Dereference(root) }
+
+	// Switch over to the state on disk if the regenerated one grew too large
+	if nodes, imgs := database.TrieDB().Size(); nodes+imgs > memLimit {
+		disk := state.NewDatabaseWithConfig(b.db, &trie.Config{Cache: 16})
+		if statedb, err := state.New(root, disk, nil); err == nil {
+			b.releaseState()
+			b.state, b.stateHash = statedb, block.Hash()
+		}
+	}
+	return nil
+}
+
+// releaseState drops the state held for tracing the next block.
+func (b *indexerBackend) releaseState() {
+	if b.release != nil {
+		b.release()
+	}
+	b.state, b.stateHash, b.release = nil, common.Hash{}, nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package traceindex
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	callerAddr  = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	revertsAddr = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+// newTestIndexer creates an archive chain of the given length, in which every
+// block contains a transaction sending ether through a contract to another one
+// which reverts, and starts an indexer over it.
+func newTestIndexer(t *testing.T, blocks int, retention uint64) *Indexer {
+	var (
+		// Call 0xbb with 1 wei and no data, then stop
+		callerCode = []byte{
+			byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+			byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0xbb, byte(vm.GAS),
+			byte(vm.CALL), byte(vm.STOP),
+		}
+		revertsCode = []byte{byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.REVERT)}
+
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				testAddr:    {Balance: big.NewInt(params.Ether)},
+				callerAddr:  {Code: callerCode, Balance: common.Big0},
+				revertsAddr: {Code: revertsCode, Balance: common.Big0},
+			},
+		}
+		engine = ethash.NewFaker()
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, chain, _ := core.GenerateChainWithGenesis(gspec, engine, blocks, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), callerAddr, big.NewInt(10), 100000, b.BaseFee(), nil), signer, testKey)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	blockchain, err := core.NewBlockChain(db, &core.CacheConfig{TrieDirtyDisabled: true}, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if n, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	t.Cleanup(blockchain.Stop)
+
+	stateAt := func(block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (*state.StateDB, tracers.StateReleaseFunc, error) {
+		statedb, err := blockchain.StateAt(block.Root())
+		return statedb, func() {}, err
+	}
+	idx := newIndexer(db, blockchain, stateAt, Config{Retention: retention}, 4, 2)
+	idx.Start()
+	t.Cleanup(func() { idx.Close() })
+	return idx
+}
+
+// waitSections waits until the given number of sections are indexed.
+func waitSections(t *testing.T, idx *Indexer, sections uint64) {
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		if have, _, _ := idx.indexer.Sections(); have >= sections {
+			return
+		}
+	}
+	have, _, _ := idx.indexer.Sections()
+	t.Fatalf("indexing timed out: have %d sections, want %d", have, sections)
+}
+
+func TestInternalTransactions(t *testing.T) {
+	idx := newTestIndexer(t, 20, 0)
+
+	// 21 blocks with 2 confirmations fill 4 sections of 4 blocks
+	waitSections(t, idx, 4)
+	if first, last, ok := idx.Range(); !ok || first != 0 || last != 15 {
+		t.Fatalf("index range mismatch: have %d-%d (%v), want 0-15", first, last, ok)
+	}
+	api := NewAPI(idx)
+
+	calls, err := api.InternalTransactions(context.Background(), CallFilter{Addresses: []common.Address{revertsAddr}})
+	if err != nil {
+		t.Fatalf("failed to query internal calls: %v", err)
+	}
+	if len(calls) != 15 {
+		t.Fatalf("call count mismatch: have %d, want %d", len(calls), 15)
+	}
+	for i, call := range calls {
+		number := uint64(i + 1)
+		block := idx.chain.GetBlockByNumber(number)
+		if uint64(call.BlockNumber) != number || call.BlockHash != block.Hash() || call.TransactionHash != block.Transactions()[0].Hash() {
+			t.Errorf("call %d: position mismatch: block %d [%x], tx %x", i, call.BlockNumber, call.BlockHash, call.TransactionHash)
+		}
+		if call.Type != "CALL" || call.Depth != 1 || call.From != callerAddr || call.To != revertsAddr {
+			t.Errorf("call %d: frame mismatch: %s depth %d from %x to %x", i, call.Type, call.Depth, call.From, call.To)
+		}
+		if call.Value.ToInt().Cmp(big.NewInt(1)) != 0 || call.Error != vm.ErrExecutionReverted.Error() {
+			t.Errorf("call %d: result mismatch: value %v, error %q", i, call.Value, call.Error)
+		}
+	}
+	// Both the transaction and the inner call involve the caller contract
+	from, to := rpc.BlockNumber(2), rpc.BlockNumber(3)
+	calls, err = api.InternalTransactions(context.Background(), CallFilter{FromBlock: &from, ToBlock: &to, Addresses: []common.Address{callerAddr}})
+	if err != nil {
+		t.Fatalf("failed to query internal calls: %v", err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("call count mismatch: have %d, want %d", len(calls), 4)
+	}
+	if calls[0].Depth != 0 || calls[0].From != testAddr || calls[0].Value.ToInt().Cmp(big.NewInt(10)) != 0 || calls[0].Error != "" {
+		t.Errorf("top call mismatch: %+v", calls[0])
+	}
+	// Blocks not yet indexed must be rejected
+	to = rpc.BlockNumber(16)
+	if _, err := api.InternalTransactions(context.Background(), CallFilter{ToBlock: &to}); err == nil {
+		t.Fatal("expected error for unindexed block")
+	}
+}
+
+func TestRetention(t *testing.T) {
+	idx := newTestIndexer(t, 20, 6)
+	waitSections(t, idx, 4)
+
+	// Sections fully out of the window are skipped, the rest pruned below head-6
+	if first, last, ok := idx.Range(); !ok || first != 14 || last != 15 {
+		t.Fatalf("index range mismatch: have %d-%d (%v), want 14-15", first, last, ok)
+	}
+	for number := uint64(1); number <= 15; number++ {
+		hash := rawdb.ReadCanonicalHash(idx.db, number)
+		if have, want := rawdb.HasInternalCalls(idx.db, hash, number), number >= 14; have != want {
+			t.Errorf("block %d: index presence mismatch: have %v, want %v", number, have, want)
+		}
+	}
+	if tail := rawdb.ReadTraceIndexTail(idx.db); tail != 14 {
+		t.Errorf("index tail mismatch: have %d, want %d", tail, 14)
+	}
+	api := NewAPI(idx)
+	from := rpc.BlockNumber(13)
+	if _, err := api.InternalTransactions(context.Background(), CallFilter{FromBlock: &from}); err == nil {
+		t.Fatal("expected error for pruned block")
+	}
+	calls, err := api.InternalTransactions(context.Background(), CallFilter{})
+	if err != nil {
+		t.Fatalf("failed to query internal calls: %v", err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("call count mismatch: have %d, want %d", len(calls), 4)
+	}
+}
+
+func TestReorg(t *testing.T) {
+	idx := newTestIndexer(t, 20, 0)
+	waitSections(t, idx, 4)
+
+	// Replace the chain after block 10 with a longer one without transactions
+	fork, _ := core.GenerateChain(params.TestChainConfig, idx.chain.GetBlockByNumber(10), ethash.NewFaker(), idx.db, 15, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x1})
+	})
+	if n, err := idx.chain.InsertChain(fork); err != nil {
+		t.Fatalf("block %d: failed to insert fork: %v", n, err)
+	}
+	waitSections(t, idx, 6)
+
+	calls, err := NewAPI(idx).InternalTransactions(context.Background(), CallFilter{Addresses: []common.Address{revertsAddr}})
+	if err != nil {
+		t.Fatalf("failed to query internal calls: %v", err)
+	}
+	if len(calls) != 10 {
+		t.Fatalf("call count mismatch: have %d, want %d", len(calls), 10)
+	}
+	for number := uint64(11); number <= 23; number++ {
+		hash := rawdb.ReadCanonicalHash(idx.db, number)
+		if hash != fork[number-11].Hash() {
+			t.Fatalf("block %d: canonical hash mismatch", number)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// flatCallTrace is the subset of a flatCallTracer frame checked by the tests.
+type flatCallTrace struct {
+	Action struct {
+		CallType string          `json:"callType"`
+		From     *common.Address `json:"from"`
+		Address  *common.Address `json:"address"`
+	} `json:"action"`
+	Error        string `json:"error"`
+	Subtraces    int    `json:"subtraces"`
+	TraceAddress []int  `json:"traceAddress"`
+	Type         string `json:"type"`
+}
+
+// flattenCallTrace converts a nested callTracer result into the frames the
+// flatCallTracer is expected to produce for the same transaction.
+func flattenCallTrace(call *callTrace, traceAddress []int) []flatCallTrace {
+	frame := flatCallTrace{
+		Subtraces:    len(call.Calls),
+		TraceAddress: traceAddress,
+	}
+	from := call.From
+	switch call.Type {
+	case "CREATE", "CREATE2":
+		frame.Type = "create"
+		frame.Action.From = &from
+	case "SELFDESTRUCT":
+		frame.Type = "suicide"
+		frame.Action.Address = &from
+	default:
+		frame.Type = "call"
+		frame.Action.CallType = strings.ToLower(call.Type)
+		frame.Action.From = &from
+	}
+	if call.Error != "" {
+		frame.Error = call.Error
+	}
+	frames := []flatCallTrace{frame}
+	for i := range call.Calls {
+		child := append(append([]int{}, traceAddress...), i)
+		frames = append(frames, flattenCallTrace(&call.Calls[i], child)...)
+	}
+	return frames
+}
+
+// Iterates over the callTracer test harness and checks that the flat traces
+// agree with the nested ones.
+func TestFlatCallTracerNative(t *testing.T) {
+	files, err := os.ReadDir(filepath.Join("testdata", "call_tracer"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				test = new(callTracerTest)
+				tx   = new(types.Transaction)
+			)
+			if blob, err := os.ReadFile(filepath.Join("testdata", "call_tracer", file.Name())); err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			} else if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			// Configs of the nested tracer would alter its expected output
+			if len(test.TracerConfig) > 0 {
+				t.Skip("tracer specific configuration")
+			}
+			if err := tx.UnmarshalBinary(common.FromHex(test.Input)); err != nil {
+				t.Fatalf("failed to parse testcase input: %v", err)
+			}
+			var (
+				signer    = types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)), uint64(test.Context.Time))
+				origin, _ = signer.Sender(tx)
+				txContext = vm.TxContext{
+					Origin:   origin,
+					GasPrice: tx.GasPrice(),
+				}
+				context = vm.BlockContext{
+					CanTransfer: core.CanTransfer,
+					Transfer:    core.Transfer,
+					Coinbase:    test.Context.Miner,
+					BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+					Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+					Difficulty:  (*big.Int)(test.Context.Difficulty),
+					GasLimit:    uint64(test.Context.GasLimit),
+					BaseFee:     test.Genesis.BaseFee,
+				}
+				_, statedb = tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+			)
+			txctx := &tracers.Context{
+				BlockNumber: context.BlockNumber,
+				TxHash:      tx.Hash(),
+			}
+			tracer, err := tracers.New("flatCallTracer", txctx, json.RawMessage(`{"includePrecompiles":true}`))
+			if err != nil {
+				t.Fatalf("failed to create flat call tracer: %v", err)
+			}
+			evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+			msg, err := tx.AsMessage(signer, nil)
+			if err != nil {
+				t.Fatalf("failed to prepare transaction for tracing: %v", err)
+			}
+			if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+				t.Fatalf("failed to execute transaction: %v", err)
+			}
+			res, err := tracer.GetResult()
+			if err != nil {
+				t.Fatalf("failed to retrieve trace result: %v", err)
+			}
+			var have []flatCallTrace
+			if err := json.Unmarshal(res, &have); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			want := flattenCallTrace(test.Result, []int{})
+			if len(have) != len(want) {
+				t.Fatalf("frame count mismatch: have %d, want %d", len(have), len(want))
+			}
+			for i := range want {
+				if !reflect.DeepEqual(have[i], want[i]) {
+					t.Errorf("frame %d mismatch\n have: %+v\n want: %+v", i, have[i], want[i])
+				}
+			}
+			// All frames must carry the transaction context
+			var frames []struct {
+				BlockNumber     uint64       `json:"blockNumber"`
+				TransactionHash *common.Hash `json:"transactionHash"`
+			}
+			if err := json.Unmarshal(res, &frames); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			for i, frame := range frames {
+				if frame.BlockNumber != uint64(test.Context.Number) || frame.TransactionHash == nil || *frame.TransactionHash != tx.Hash() {
+					t.Errorf("frame %d: context mismatch: block %d, tx %v", i, frame.BlockNumber, frame.TransactionHash)
+				}
+			}
+		})
+	}
+}
+
+// TestVMTracer checks the instructions and their effects reported by the
+// vmTracer for a contract writing storage, memory and calling another one.
+func TestVMTracer(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		callee = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	)
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	tx, err := types.SignNewTx(privkey, signer, &types.LegacyTx{
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+		To:       &to,
+	})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: big.NewInt(0),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    common.Address{},
+		BlockNumber: new(big.Int).SetUint64(8000000),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+	}
+	var (
+		code = []byte{
+			byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), // slot 0 = 42
+			byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.MSTORE), // mem[0:32] = 1
+			byte(vm.PUSH1), 0x00, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), // in and outs zero
+			byte(vm.DUP1), byte(vm.PUSH1), 0xbb, byte(vm.GAS), // value=0,address=0xbb, gas=GAS
+			byte(vm.CALL),
+			byte(vm.STOP),
+		}
+		calleeCode = []byte{
+			byte(vm.PUSH1), 0x07, byte(vm.POP), byte(vm.STOP),
+		}
+	)
+	alloc := core.GenesisAlloc{
+		to:     core.GenesisAccount{Nonce: 1, Code: code},
+		callee: core.GenesisAccount{Nonce: 1, Code: calleeCode},
+		origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+
+	tracer, err := tracers.New("vmTracer", new(tracers.Context), nil)
+	if err != nil {
+		t.Fatalf("failed to create vm tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	type vmTrace struct {
+		Code hexutil.Bytes `json:"code"`
+		Ops  []struct {
+			Cost uint64 `json:"cost"`
+			Ex   struct {
+				Mem *struct {
+					Data hexutil.Bytes `json:"data"`
+					Off  uint64        `json:"off"`
+				} `json:"mem"`
+				Push  []*hexutil.Big `json:"push"`
+				Store *struct {
+					Key *hexutil.Big `json:"key"`
+					Val *hexutil.Big `json:"val"`
+				} `json:"store"`
+				Used uint64 `json:"used"`
+			} `json:"ex"`
+			PC  uint64          `json:"pc"`
+			Sub json.RawMessage `json:"sub"`
+		} `json:"ops"`
+	}
+	var trace vmTrace
+	if err := json.Unmarshal(res, &trace); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	if !reflect.DeepEqual([]byte(trace.Code), code) {
+		t.Fatalf("code mismatch: have %x, want %x", trace.Code, code)
+	}
+	if len(trace.Ops) != 15 {
+		t.Fatalf("op count mismatch: have %d, want %d", len(trace.Ops), 15)
+	}
+	pushes := func(i int) string {
+		return fmt.Sprint(trace.Ops[i].Ex.Push)
+	}
+	if have := pushes(0); have != "[0x2a]" {
+		t.Errorf("PUSH1 pushes mismatch: have %v", have)
+	}
+	if have := pushes(13); have != "[0x1]" {
+		t.Errorf("CALL pushes mismatch: have %v", have)
+	}
+	if store := trace.Ops[2].Ex.Store; store == nil || store.Key.ToInt().Uint64() != 0 || store.Val.ToInt().Uint64() != 0x2a {
+		t.Errorf("SSTORE store mismatch: have %+v", store)
+	}
+	if mem := trace.Ops[5].Ex.Mem; mem == nil || mem.Off != 0 || !reflect.DeepEqual([]byte(mem.Data), common.LeftPadBytes([]byte{1}, 32)) {
+		t.Errorf("MSTORE memory mismatch: have %+v", mem)
+	}
+	for i, op := range trace.Ops[:14] {
+		if next := trace.Ops[i+1]; op.Ex.Used == 0 || op.Ex.Used < next.Cost {
+			t.Errorf("op %d: invalid gas used %d", i, op.Ex.Used)
+		}
+	}
+	var sub vmTrace
+	if err := json.Unmarshal(trace.Ops[13].Sub, &sub); err != nil {
+		t.Fatalf("failed to unmarshal sub trace: %v", err)
+	}
+	if !reflect.DeepEqual([]byte(sub.Code), calleeCode) || len(sub.Ops) != 3 {
+		t.Errorf("sub trace mismatch: code %x, %d ops", sub.Code, len(sub.Ops))
+	}
+	if string(trace.Ops[0].Sub) != "null" {
+		t.Errorf("unexpected sub trace on PUSH1: %s", trace.Ops[0].Sub)
+	}
+}
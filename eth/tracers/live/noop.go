@@ -0,0 +1,88 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package live is a collection of chain-level tracers, which observe the blocks
+// as they are imported into the chain instead of replaying them on demand.
+//
+// A live tracer implements core.BlockchainLogger and registers itself in the
+// package initialization, using tracers.RegisterLive.
+package live
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.RegisterLive("noop", newNoopTracer)
+}
+
+// noop is a no-op live tracer. It's there to catch changes in the tracing
+// interface, and as a template for new live tracers.
+type noop struct{}
+
+func newNoopTracer(_ json.RawMessage) (core.BlockchainLogger, error) {
+	return &noop{}, nil
+}
+
+func (t *noop) CaptureTxStart(gasLimit uint64) {}
+
+func (t *noop) CaptureTxEnd(restGas uint64) {}
+
+func (t *noop) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+
+func (t *noop) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+
+func (t *noop) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+func (t *noop) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+func (t *noop) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *noop) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *noop) OnBlockStart(block *types.Block, td *big.Int, finalized, safe *types.Block) {}
+
+func (t *noop) OnBlockEnd(err error) {}
+
+func (t *noop) OnGenesisBlock(genesis *types.Block, alloc core.GenesisAlloc) {}
+
+func (t *noop) OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address) {}
+
+func (t *noop) OnTxEnd(receipt *types.Receipt, err error) {}
+
+func (t *noop) OnBalanceChange(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+}
+
+func (t *noop) OnNonceChange(addr common.Address, prev, new uint64) {}
+
+func (t *noop) OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+}
+
+func (t *noop) OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {}
+
+func (t *noop) OnLog(log *types.Log) {}
@@ -0,0 +1,262 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/holiman/uint256"
+)
+
+func init() {
+	register("vmTracer", newVMTracer)
+}
+
+// vmTrace is the trace of the code executed in a single call frame, in the
+// format of the OpenEthereum vmTrace.
+type vmTrace struct {
+	Code hexutil.Bytes `json:"code"`
+	Ops  []*vmTraceOp  `json:"ops"`
+}
+
+// vmTraceOp is a single executed instruction. Sub holds the trace of the call
+// frame entered by the instruction, if any.
+type vmTraceOp struct {
+	Cost uint64     `json:"cost"`
+	Ex   *vmTraceEx `json:"ex"`
+	PC   uint64     `json:"pc"`
+	Sub  *vmTrace   `json:"sub"`
+}
+
+// vmTraceEx are the effects of an executed instruction.
+type vmTraceEx struct {
+	Mem   *vmTraceMem    `json:"mem"`
+	Push  []*hexutil.Big `json:"push"`
+	Store *vmTraceStore  `json:"store"`
+	Used  uint64         `json:"used"`
+}
+
+// vmTraceMem is the memory area written by an instruction.
+type vmTraceMem struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+// vmTraceStore is the storage slot written by an instruction.
+type vmTraceStore struct {
+	Key *hexutil.Big `json:"key"`
+	Val *hexutil.Big `json:"val"`
+}
+
+// vmTraceFrame tracks the instruction last executed in a call frame, whose
+// effects only become visible when the next instruction starts.
+type vmTraceFrame struct {
+	trace *vmTrace
+	typ   vm.OpCode
+
+	pending *vmTraceOp // Last executed instruction, nil if none
+	pushes  int        // Number of stack items pushed by the pending instruction
+	memOff  uint64     // Offset of the memory written by the pending instruction
+	memSize uint64     // Size of the memory written by the pending instruction
+	left    uint64     // Gas left after the pending instruction, if it's the last one
+}
+
+// vmTracer reports every instruction executed by a transaction, along with
+// its effects on the stack, memory and storage. The output is compatible with
+// the vmTrace of the OpenEthereum trace_* namespace.
+type vmTracer struct {
+	noopTracer
+	env       *vm.EVM
+	root      *vmTrace
+	frames    []*vmTraceFrame
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newVMTracer returns a native go tracer which collects the vmTrace of a tx.
+func newVMTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &vmTracer{}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *vmTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+
+	code := input
+	if !create {
+		code = env.StateDB.GetCode(to)
+	}
+	t.root = &vmTrace{Code: common.CopyBytes(code), Ops: []*vmTraceOp{}}
+	t.frames = []*vmTraceFrame{{trace: t.root, typ: vm.CALL}}
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *vmTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if len(t.frames) > 0 {
+		t.frames[0].finalize()
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *vmTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+
+	// The effects of the previous instruction are visible now, record them
+	stack := scope.Stack.Data()
+	if frame.pending != nil {
+		ex := &vmTraceEx{Store: frame.pending.Ex.Store, Push: []*hexutil.Big{}, Used: gas}
+		for i := frame.pushes; i > 0 && i <= len(stack); i-- {
+			ex.Push = append(ex.Push, (*hexutil.Big)(stack[len(stack)-i].ToBig()))
+		}
+		if frame.memSize > 0 && frame.memOff+frame.memSize <= uint64(scope.Memory.Len()) {
+			ex.Mem = &vmTraceMem{
+				Data: scope.Memory.GetCopy(int64(frame.memOff), int64(frame.memSize)),
+				Off:  frame.memOff,
+			}
+		}
+		frame.pending.Ex = ex
+	}
+	// Start tracking the current instruction
+	record := &vmTraceOp{Cost: cost, PC: pc, Ex: new(vmTraceEx)}
+	if op == vm.SSTORE && len(stack) >= 2 {
+		record.Ex.Store = &vmTraceStore{
+			Key: (*hexutil.Big)(stack[len(stack)-1].ToBig()),
+			Val: (*hexutil.Big)(stack[len(stack)-2].ToBig()),
+		}
+	}
+	frame.trace.Ops = append(frame.trace.Ops, record)
+	frame.pending = record
+	frame.pushes = vmTracePushes(op)
+	frame.memOff, frame.memSize = vmTraceMemWrite(op, stack)
+	frame.left = 0
+	if gas > cost {
+		frame.left = gas - cost
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *vmTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.frames) == 0 {
+		return
+	}
+	var code []byte
+	switch typ {
+	case vm.CREATE, vm.CREATE2:
+		code = common.CopyBytes(input)
+	case vm.SELFDESTRUCT:
+	default:
+		code = t.env.StateDB.GetCode(to)
+	}
+	frame := &vmTraceFrame{trace: &vmTrace{Code: code, Ops: []*vmTraceOp{}}, typ: typ}
+
+	// Self destructs don't execute any code, no need to report them
+	if parent := t.frames[len(t.frames)-1]; typ != vm.SELFDESTRUCT && parent.pending != nil {
+		parent.pending.Sub = frame.trace
+	}
+	t.frames = append(t.frames, frame)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *vmTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 || len(t.frames) <= 1 {
+		return
+	}
+	t.frames[len(t.frames)-1].finalize()
+	t.frames = t.frames[:len(t.frames)-1]
+}
+
+// GetResult returns the json-encoded vmTrace of the transaction, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *vmTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.root)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *vmTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// finalize records the effects of the last instruction of a call frame. Since
+// no instruction follows, only the gas left and the storage write are known.
+func (f *vmTraceFrame) finalize() {
+	if f.pending == nil {
+		return
+	}
+	f.pending.Ex = &vmTraceEx{Store: f.pending.Ex.Store, Push: []*hexutil.Big{}, Used: f.left}
+	f.pending = nil
+}
+
+// vmTracePushes returns the number of stack items reported as pushed by the
+// given instruction. Like OpenEthereum, DUP and SWAP report all the stack items
+// they touched.
+func vmTracePushes(op vm.OpCode) int {
+	switch {
+	case op >= vm.PUSH1 && op <= vm.PUSH32:
+		return 1
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0
+	}
+	switch op {
+	case vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.TSTORE, vm.JUMP, vm.JUMPI, vm.JUMPDEST,
+		vm.STOP, vm.RETURN, vm.REVERT, vm.SELFDESTRUCT, vm.INVALID,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY:
+		return 0
+	}
+	return 1
+}
+
+// vmTraceMemWrite returns the memory area written by the given instruction,
+// based on its operands.
+func vmTraceMemWrite(op vm.OpCode, stack []uint256.Int) (uint64, uint64) {
+	arg := func(i int) uint64 {
+		return stack[len(stack)-1-i].Uint64()
+	}
+	switch {
+	case op == vm.MSTORE && len(stack) >= 1:
+		return arg(0), 32
+	case op == vm.MSTORE8 && len(stack) >= 1:
+		return arg(0), 1
+	case (op == vm.CALLDATACOPY || op == vm.CODECOPY || op == vm.RETURNDATACOPY) && len(stack) >= 3:
+		return arg(0), arg(2)
+	case op == vm.EXTCODECOPY && len(stack) >= 4:
+		return arg(1), arg(3)
+	case (op == vm.CALL || op == vm.CALLCODE) && len(stack) >= 7:
+		return arg(5), arg(6)
+	case (op == vm.DELEGATECALL || op == vm.STATICCALL) && len(stack) >= 6:
+		return arg(4), arg(5)
+	}
+	return 0, 0
+}
@@ -0,0 +1,543 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Names of the native tracers the trace namespace is built upon. They are
+// registered by the native package, which needs to be linked in.
+const (
+	flatCallTracerName = "flatCallTracer"
+	callTracerName     = "callTracer"
+	prestateTracerName = "prestateTracer"
+	vmTracerName       = "vmTracer"
+	muxTracerName      = "muxTracer"
+)
+
+// Trace types which can be requested from the replay methods.
+const (
+	traceTypeTrace     = "trace"
+	traceTypeStateDiff = "stateDiff"
+	traceTypeVMTrace   = "vmTrace"
+)
+
+// maxFilterRange is the maximum number of blocks trace_filter may span. Unlike
+// the trace index, the filter re-executes every block of the range.
+const maxFilterRange = 10000
+
+// TraceAPI is the collection of OpenEthereum compatible tracing APIs, exposed
+// over the trace namespace. It's a thin layer over the debug tracing APIs.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the OpenEthereum compatible
+// tracing methods.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// TraceFilterArgs are the arguments of trace_filter.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// TraceResults is the result of replaying a transaction with the requested
+// trace types. The fields of the types not requested are left empty.
+type TraceResults struct {
+	Output          hexutil.Bytes                   `json:"output"`
+	StateDiff       map[common.Address]*AccountDiff `json:"stateDiff"`
+	Trace           []json.RawMessage               `json:"trace"`
+	VMTrace         json.RawMessage                 `json:"vmTrace"`
+	TransactionHash *common.Hash                    `json:"transactionHash,omitempty"`
+}
+
+// AccountDiff is the change of a single account caused by a transaction. Each
+// field is either the string "=" if it was left untouched, or an object with
+// one of the keys "+" (created), "-" (deleted) or "*" (modified).
+type AccountDiff struct {
+	Balance interface{}                 `json:"balance"`
+	Code    interface{}                 `json:"code"`
+	Nonce   interface{}                 `json:"nonce"`
+	Storage map[common.Hash]interface{} `json:"storage"`
+}
+
+// diffChange is the value of a modified field in an account diff.
+type diffChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Block returns the flat call traces of all the transactions in a block.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.api.traceBlock(ctx, block, flatTraceConfig())
+	if err != nil {
+		return nil, err
+	}
+	traces := []json.RawMessage{}
+	for i, res := range results {
+		frames, err := decodeFlatTraces(res)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		traces = append(traces, frames...)
+	}
+	return traces, nil
+}
+
+// Transaction returns the flat call traces of a single transaction.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) (interface{}, error) {
+	return api.api.TraceTransaction(ctx, hash, flatTraceConfig())
+}
+
+// Filter returns the flat call traces within a block range, matching the given
+// sender and recipient addresses.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]json.RawMessage, error) {
+	from, err := api.resolveNumber(ctx, args.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.resolveNumber(ctx, args.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case from > to:
+		return nil, fmt.Errorf("invalid block range: from %d is after to %d", from, to)
+	case to-from >= maxFilterRange:
+		return nil, fmt.Errorf("block range too large, limit is %d blocks", maxFilterRange)
+	}
+	traces := []json.RawMessage{}
+
+	// The genesis block has no transactions, and the range is exclusive of the
+	// start block, begin from its parent.
+	if from == 0 {
+		from = 1
+	}
+	if from > to {
+		return traces, nil
+	}
+	start, err := api.api.blockByNumber(ctx, rpc.BlockNumber(from-1))
+	if err != nil {
+		return nil, err
+	}
+	end, err := api.api.blockByNumber(ctx, rpc.BlockNumber(to))
+	if err != nil {
+		return nil, err
+	}
+	closed := make(chan interface{})
+	resCh := api.api.traceChain(start, end, flatTraceConfig(), closed)
+	defer func() {
+		close(closed)
+		// Drain the remaining results in the background so the tracers shut down
+		go func() {
+			for range resCh {
+			}
+		}()
+	}()
+	var skipped uint64
+	for res := range resCh {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for i, tx := range res.Traces {
+			frames, err := decodeFlatTraces(tx)
+			if err != nil {
+				return nil, fmt.Errorf("block %d transaction %d: %w", res.Block, i, err)
+			}
+			for _, frame := range frames {
+				match, err := filterFlatTrace(frame, args.FromAddress, args.ToAddress)
+				if err != nil {
+					return nil, err
+				}
+				if !match {
+					continue
+				}
+				if args.After != nil && skipped < *args.After {
+					skipped++
+					continue
+				}
+				traces = append(traces, frame)
+				if args.Count != nil && uint64(len(traces)) >= *args.Count {
+					return traces, nil
+				}
+			}
+		}
+	}
+	return traces, nil
+}
+
+// ReplayBlockTransactions replays all the transactions in a block, returning
+// the requested trace types for each.
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, traceTypes []string) ([]*TraceResults, error) {
+	config, err := replayTraceConfig(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.blockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.api.traceBlock(ctx, block, config)
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]*TraceResults, len(results))
+	for i, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("transaction %d: %s", i, res.Error)
+		}
+		if replays[i], err = newTraceResults(res.Result, traceTypes); err != nil {
+			return nil, err
+		}
+		hash := block.Transactions()[i].Hash()
+		replays[i].TransactionHash = &hash
+	}
+	return replays, nil
+}
+
+// ReplayTransaction replays a single transaction, returning the requested
+// trace types.
+func (api *TraceAPI) ReplayTransaction(ctx context.Context, hash common.Hash, traceTypes []string) (*TraceResults, error) {
+	config, err := replayTraceConfig(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	res, err := api.api.TraceTransaction(ctx, hash, config)
+	if err != nil {
+		return nil, err
+	}
+	return newTraceResults(res, traceTypes)
+}
+
+// Call executes a call on top of the given block, by default the latest one,
+// returning the requested trace types.
+func (api *TraceAPI) Call(ctx context.Context, args ethapi.TransactionArgs, traceTypes []string, blockNrOrHash *rpc.BlockNumberOrHash) (*TraceResults, error) {
+	config, err := replayTraceConfig(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	res, err := api.api.TraceCall(ctx, args, *blockNrOrHash, &TraceCallConfig{TraceConfig: *config})
+	if err != nil {
+		return nil, err
+	}
+	return newTraceResults(res, traceTypes)
+}
+
+// resolveNumber converts a block tag into an actual block number, defaulting
+// to the latest block.
+func (api *TraceAPI) resolveNumber(ctx context.Context, number *rpc.BlockNumber) (uint64, error) {
+	if number != nil && *number >= 0 {
+		return uint64(*number), nil
+	}
+	tag := rpc.LatestBlockNumber
+	if number != nil {
+		tag = *number
+	}
+	if tag == rpc.PendingBlockNumber {
+		return 0, errors.New("tracing on top of pending is not supported")
+	}
+	block, err := api.api.blockByNumber(ctx, tag)
+	if err != nil {
+		return 0, err
+	}
+	return block.NumberU64(), nil
+}
+
+// blockByNumberOrHash retrieves the block identified by either its number or hash.
+func (api *TraceAPI) blockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return api.api.blockByHash(ctx, hash)
+	}
+	if number, ok := blockNrOrHash.Number(); ok {
+		return api.api.blockByNumber(ctx, number)
+	}
+	return nil, errors.New("invalid arguments; neither block nor hash specified")
+}
+
+// flatTraceConfig returns the tracing configuration producing flat call traces.
+func flatTraceConfig() *TraceConfig {
+	tracer := flatCallTracerName
+	return &TraceConfig{
+		Tracer:       &tracer,
+		TracerConfig: json.RawMessage(`{"convertParityErrors":true}`),
+	}
+}
+
+// replayTraceConfig returns the tracing configuration producing all the data
+// needed by the requested trace types. The top level call is always traced to
+// retrieve the output of the transaction.
+func replayTraceConfig(traceTypes []string) (*TraceConfig, error) {
+	tracers := map[string]json.RawMessage{
+		callTracerName: json.RawMessage(`{"onlyTopCall":true}`),
+	}
+	for _, typ := range traceTypes {
+		switch typ {
+		case traceTypeTrace:
+			tracers[flatCallTracerName] = json.RawMessage(`{"convertParityErrors":true}`)
+		case traceTypeStateDiff:
+			tracers[prestateTracerName] = json.RawMessage(`{"diffMode":true}`)
+		case traceTypeVMTrace:
+			tracers[vmTracerName] = json.RawMessage(`{}`)
+		default:
+			return nil, fmt.Errorf("invalid trace type %q", typ)
+		}
+	}
+	cfg, err := json.Marshal(tracers)
+	if err != nil {
+		return nil, err
+	}
+	tracer := muxTracerName
+	return &TraceConfig{Tracer: &tracer, TracerConfig: cfg}, nil
+}
+
+// newTraceResults assembles the replay result of a transaction from the output
+// of the tracers configured by replayTraceConfig.
+func newTraceResults(result interface{}, traceTypes []string) (*TraceResults, error) {
+	raw, ok := result.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result type %T", result)
+	}
+	var outputs map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &outputs); err != nil {
+		return nil, err
+	}
+	var call struct {
+		Output hexutil.Bytes `json:"output"`
+	}
+	if err := json.Unmarshal(outputs[callTracerName], &call); err != nil {
+		return nil, err
+	}
+	res := &TraceResults{Output: call.Output}
+	if res.Output == nil {
+		res.Output = hexutil.Bytes{}
+	}
+	for _, typ := range traceTypes {
+		switch typ {
+		case traceTypeTrace:
+			if err := json.Unmarshal(outputs[flatCallTracerName], &res.Trace); err != nil {
+				return nil, err
+			}
+		case traceTypeStateDiff:
+			var diff struct {
+				Pre  map[common.Address]*prestateAccount `json:"pre"`
+				Post map[common.Address]*prestateAccount `json:"post"`
+			}
+			if err := json.Unmarshal(outputs[prestateTracerName], &diff); err != nil {
+				return nil, err
+			}
+			res.StateDiff = newStateDiff(diff.Pre, diff.Post)
+		case traceTypeVMTrace:
+			res.VMTrace = outputs[vmTracerName]
+		}
+	}
+	return res, nil
+}
+
+// decodeFlatTraces splits the result of the flatCallTracer into its frames.
+func decodeFlatTraces(res *txTraceResult) ([]json.RawMessage, error) {
+	if res == nil {
+		return nil, errors.New("missing trace result")
+	}
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	raw, ok := res.Result.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result type %T", res.Result)
+	}
+	var frames []json.RawMessage
+	if err := json.Unmarshal(raw, &frames); err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
+
+// filterFlatTrace reports whether a flat call trace matches the sender and
+// recipient filters of trace_filter. An empty filter matches everything.
+func filterFlatTrace(frame json.RawMessage, fromAddrs, toAddrs []common.Address) (bool, error) {
+	if len(fromAddrs) == 0 && len(toAddrs) == 0 {
+		return true, nil
+	}
+	var trace struct {
+		Action struct {
+			From          *common.Address `json:"from"`
+			To            *common.Address `json:"to"`
+			Address       *common.Address `json:"address"`
+			RefundAddress *common.Address `json:"refundAddress"`
+		} `json:"action"`
+		Result *struct {
+			Address *common.Address `json:"address"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(frame, &trace); err != nil {
+		return false, err
+	}
+	// Self destructs send from the destructed contract to the refund address,
+	// creations send to the address of the new contract.
+	from := trace.Action.From
+	if from == nil {
+		from = trace.Action.Address
+	}
+	to := trace.Action.To
+	if to == nil && trace.Result != nil {
+		to = trace.Result.Address
+	}
+	if to == nil {
+		to = trace.Action.RefundAddress
+	}
+	return containsAddress(fromAddrs, from) && containsAddress(toAddrs, to), nil
+}
+
+// containsAddress reports whether the address is in the filter list, or the
+// filter list is empty.
+func containsAddress(addrs []common.Address, addr *common.Address) bool {
+	if len(addrs) == 0 {
+		return true
+	}
+	if addr == nil {
+		return false
+	}
+	for _, a := range addrs {
+		if a == *addr {
+			return true
+		}
+	}
+	return false
+}
+
+// prestateAccount is an account as reported by the prestateTracer in diff mode.
+// Fields missing from the post state were left unchanged by the transaction.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Nonce   *uint64                     `json:"nonce"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// newStateDiff converts the pre and post states reported by the prestateTracer
+// into the OpenEthereum state diff format.
+func newStateDiff(pre, post map[common.Address]*prestateAccount) map[common.Address]*AccountDiff {
+	diff := make(map[common.Address]*AccountDiff)
+	for addr, account := range post {
+		if prev, ok := pre[addr]; ok {
+			diff[addr] = modifiedAccountDiff(prev, account)
+		} else {
+			diff[addr] = singleAccountDiff("+", account)
+		}
+	}
+	for addr, account := range pre {
+		if _, ok := post[addr]; !ok {
+			diff[addr] = singleAccountDiff("-", account)
+		}
+	}
+	return diff
+}
+
+// singleAccountDiff returns the diff of an account which was either created or
+// deleted by the transaction, as denoted by the marker.
+func singleAccountDiff(marker string, account *prestateAccount) *AccountDiff {
+	diff := &AccountDiff{
+		Balance: map[string]interface{}{marker: diffBalance(account.Balance)},
+		Code:    map[string]interface{}{marker: diffCode(account.Code)},
+		Nonce:   map[string]interface{}{marker: diffNonce(account.Nonce)},
+		Storage: make(map[common.Hash]interface{}),
+	}
+	for key, val := range account.Storage {
+		diff.Storage[key] = map[string]interface{}{marker: val}
+	}
+	return diff
+}
+
+// modifiedAccountDiff returns the diff of an account which existed both before
+// and after the transaction.
+func modifiedAccountDiff(pre, post *prestateAccount) *AccountDiff {
+	diff := &AccountDiff{
+		Balance: "=",
+		Code:    "=",
+		Nonce:   "=",
+		Storage: make(map[common.Hash]interface{}),
+	}
+	if post.Balance != nil && diffBalance(pre.Balance).ToInt().Cmp(post.Balance.ToInt()) != 0 {
+		diff.Balance = map[string]interface{}{"*": diffChange{From: diffBalance(pre.Balance), To: post.Balance}}
+	}
+	if post.Code != nil {
+		diff.Code = map[string]interface{}{"*": diffChange{From: diffCode(pre.Code), To: post.Code}}
+	}
+	if post.Nonce != nil && diffNonce(pre.Nonce) != diffNonce(post.Nonce) {
+		diff.Nonce = map[string]interface{}{"*": diffChange{From: diffNonce(pre.Nonce), To: diffNonce(post.Nonce)}}
+	}
+	// Slots cleared by the transaction are missing from the post state, slots
+	// set from zero are missing from the pre state.
+	for key, prev := range pre.Storage {
+		if val := post.Storage[key]; val != prev {
+			diff.Storage[key] = map[string]interface{}{"*": diffChange{From: prev, To: val}}
+		}
+	}
+	for key, val := range post.Storage {
+		if _, ok := pre.Storage[key]; !ok && val != (common.Hash{}) {
+			diff.Storage[key] = map[string]interface{}{"*": diffChange{From: common.Hash{}, To: val}}
+		}
+	}
+	return diff
+}
+
+func diffBalance(balance *hexutil.Big) *hexutil.Big {
+	if balance == nil {
+		return (*hexutil.Big)(new(big.Int))
+	}
+	return balance
+}
+
+func diffCode(code hexutil.Bytes) hexutil.Bytes {
+	if code == nil {
+		return hexutil.Bytes{}
+	}
+	return code
+}
+
+func diffNonce(nonce *uint64) hexutil.Uint64 {
+	if nonce == nil {
+		return 0
+	}
+	return hexutil.Uint64(*nonce)
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestStateDiff(t *testing.T) {
+	var (
+		pre = `{
+			"0x0000000000000000000000000000000000000001": {"balance": "0x10", "nonce": 1, "storage": {
+				"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000001",
+				"0x0000000000000000000000000000000000000000000000000000000000000002": "0x0000000000000000000000000000000000000000000000000000000000000002"
+			}},
+			"0x0000000000000000000000000000000000000002": {"balance": "0x5", "code": "0x6000"}
+		}`
+		post = `{
+			"0x0000000000000000000000000000000000000001": {"balance": "0x8", "nonce": 2, "storage": {
+				"0x0000000000000000000000000000000000000000000000000000000000000002": "0x0000000000000000000000000000000000000000000000000000000000000003",
+				"0x0000000000000000000000000000000000000000000000000000000000000003": "0x0000000000000000000000000000000000000000000000000000000000000004"
+			}},
+			"0x0000000000000000000000000000000000000003": {"balance": "0x8", "code": "0x6001"}
+		}`
+		want = `{
+			"0x0000000000000000000000000000000000000001": {
+				"balance": {"*": {"from": "0x10", "to": "0x8"}},
+				"code": "=",
+				"nonce": {"*": {"from": "0x1", "to": "0x2"}},
+				"storage": {
+					"0x0000000000000000000000000000000000000000000000000000000000000001": {"*": {
+						"from": "0x0000000000000000000000000000000000000000000000000000000000000001",
+						"to": "0x0000000000000000000000000000000000000000000000000000000000000000"}},
+					"0x0000000000000000000000000000000000000000000000000000000000000002": {"*": {
+						"from": "0x0000000000000000000000000000000000000000000000000000000000000002",
+						"to": "0x0000000000000000000000000000000000000000000000000000000000000003"}},
+					"0x0000000000000000000000000000000000000000000000000000000000000003": {"*": {
+						"from": "0x0000000000000000000000000000000000000000000000000000000000000000",
+						"to": "0x0000000000000000000000000000000000000000000000000000000000000004"}}
+				}
+			},
+			"0x0000000000000000000000000000000000000002": {
+				"balance": {"-": "0x5"},
+				"code": {"-": "0x6000"},
+				"nonce": {"-": "0x0"},
+				"storage": {}
+			},
+			"0x0000000000000000000000000000000000000003": {
+				"balance": {"+": "0x8"},
+				"code": {"+": "0x6001"},
+				"nonce": {"+": "0x0"},
+				"storage": {}
+			}
+		}`
+	)
+	var preState, postState map[common.Address]*prestateAccount
+	if err := json.Unmarshal([]byte(pre), &preState); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(post), &postState); err != nil {
+		t.Fatal(err)
+	}
+	have, err := json.Marshal(newStateDiff(preState, postState))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wantDiff map[common.Address]*AccountDiff
+	if err := json.Unmarshal([]byte(want), &wantDiff); err != nil {
+		t.Fatal(err)
+	}
+	wantJSON, _ := json.Marshal(wantDiff)
+	if string(have) != string(wantJSON) {
+		t.Fatalf("state diff mismatch\n have: %s\n want: %s", have, wantJSON)
+	}
+}
+
+func TestFilterFlatTrace(t *testing.T) {
+	var (
+		a = common.HexToAddress("0xa")
+		b = common.HexToAddress("0xb")
+		c = common.HexToAddress("0xc")
+
+		call    = json.RawMessage(`{"action":{"from":"0x000000000000000000000000000000000000000a","to":"0x000000000000000000000000000000000000000b","callType":"call"},"type":"call"}`)
+		create  = json.RawMessage(`{"action":{"from":"0x000000000000000000000000000000000000000a"},"result":{"address":"0x000000000000000000000000000000000000000c"},"type":"create"}`)
+		failed  = json.RawMessage(`{"action":{"from":"0x000000000000000000000000000000000000000a"},"error":"Reverted","type":"create"}`)
+		suicide = json.RawMessage(`{"action":{"address":"0x000000000000000000000000000000000000000c","refundAddress":"0x000000000000000000000000000000000000000b"},"type":"suicide"}`)
+		tests   = []struct {
+			frame    json.RawMessage
+			from, to []common.Address
+			want     bool
+		}{
+			{call, nil, nil, true},
+			{call, []common.Address{a}, nil, true},
+			{call, []common.Address{b}, nil, false},
+			{call, nil, []common.Address{b}, true},
+			{call, []common.Address{a}, []common.Address{c}, false},
+			{call, []common.Address{c, a}, []common.Address{c, b}, true},
+			{create, nil, []common.Address{c}, true},
+			{failed, nil, []common.Address{c}, false},
+			{failed, []common.Address{a}, nil, true},
+			{suicide, []common.Address{c}, []common.Address{b}, true},
+			{suicide, []common.Address{a}, nil, false},
+		}
+	)
+	for i, tt := range tests {
+		have, err := filterFlatTrace(tt.frame, tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if have != tt.want {
+			t.Errorf("test %d: match mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestFilterRange(t *testing.T) {
+	api := NewTraceAPI(nil)
+	for _, tt := range []struct {
+		from, to rpc.BlockNumber
+		err      string
+	}{
+		{2, 1, "invalid block range: from 2 is after to 1"},
+		{0, maxFilterRange, "block range too large, limit is 10000 blocks"},
+		{1, maxFilterRange + 5, "block range too large, limit is 10000 blocks"},
+	} {
+		from, to := tt.from, tt.to
+		_, err := api.Filter(context.Background(), TraceFilterArgs{FromBlock: &from, ToBlock: &to})
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("range %d-%d: error mismatch: have %v, want %q", from, to, err, tt.err)
+		}
+	}
+}
+
+func TestReplayTraceConfig(t *testing.T) {
+	config, err := replayTraceConfig([]string{"trace", "stateDiff", "vmTrace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *config.Tracer != muxTracerName {
+		t.Fatalf("tracer mismatch: have %s, want %s", *config.Tracer, muxTracerName)
+	}
+	var tracers map[string]json.RawMessage
+	if err := json.Unmarshal(config.TracerConfig, &tracers); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{callTracerName, flatCallTracerName, prestateTracerName, vmTracerName} {
+		if _, ok := tracers[name]; !ok {
+			t.Errorf("missing tracer %s", name)
+		}
+	}
+	if _, err := replayTraceConfig([]string{"storage"}); err == nil {
+		t.Fatal("expected error for unknown trace type")
+	}
+}
@@ -20,17 +20,21 @@ package tracers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/vm"
 )
 
 // Context contains some contextual infos for a transaction execution that is not
 // available from within the EVM object.
 type Context struct {
-	BlockHash common.Hash // Hash of the block the tx is contained within (zero if dangling tx or call)
-	TxIndex   int         // Index of the transaction within a block (zero if dangling tx or call)
-	TxHash    common.Hash // Hash of the transaction being traced (zero if dangling call)
+	BlockHash   common.Hash // Hash of the block the tx is contained within (zero if dangling tx or call)
+	BlockNumber *big.Int    // Number of the block the tx is contained within (nil if dangling tx or call)
+	TxIndex     int         // Index of the transaction within a block (zero if dangling tx or call)
+	TxHash      common.Hash // Hash of the transaction being traced (zero if dangling call)
 }
 
 // Tracer interface extends vm.EVMLogger and additionally
@@ -70,3 +74,23 @@ func New(code string, ctx *Context, cfg json.RawMessage) (Tracer, error) {
 	}
 	return nil, errors.New("tracer not found")
 }
+
+// LiveConstructor creates a chain-level tracer from its json configuration.
+type LiveConstructor func(cfg json.RawMessage) (core.BlockchainLogger, error)
+
+var liveTracers = make(map[string]LiveConstructor)
+
+// RegisterLive registers a chain-level tracer, meaning that users can select it
+// by name to trace the blocks imported into the chain.
+func RegisterLive(name string, ctor LiveConstructor) {
+	liveTracers[name] = ctor
+}
+
+// NewLive returns a new instance of the named chain-level tracer.
+func NewLive(name string, cfg json.RawMessage) (core.BlockchainLogger, error) {
+	ctor, ok := liveTracers[name]
+	if !ok {
+		return nil, fmt.Errorf("live tracer %q not found", name)
+	}
+	return ctor(cfg)
+}
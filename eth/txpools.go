@@ -0,0 +1,97 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// txPools joins the legacy transaction pool and the blob pool into a single
+// txPool for the protocol handler, routing transactions by their type.
+type txPools struct {
+	legacy *txpool.TxPool
+	blob   *blobpool.BlobPool
+}
+
+// Has returns an indicator whether any of the pools has a transaction cached
+// with the given hash.
+func (p *txPools) Has(hash common.Hash) bool {
+	return p.legacy.Has(hash) || p.blob.Has(hash)
+}
+
+// Get retrieves the transaction from the pool tracking it. Blob transactions
+// are returned with their sidecars.
+func (p *txPools) Get(hash common.Hash) *types.Transaction {
+	if tx := p.legacy.Get(hash); tx != nil {
+		return tx
+	}
+	return p.blob.Get(hash)
+}
+
+// AddRemotes splits the given transactions by type and adds them to the pool
+// responsible for them, returning the errors in the original order.
+func (p *txPools) AddRemotes(txs []*types.Transaction) []error {
+	var (
+		legacyTxs, blobTxs []*types.Transaction
+		legacyIdx, blobIdx []int
+	)
+	for i, tx := range txs {
+		if tx.Type() == types.BlobTxType {
+			blobTxs, blobIdx = append(blobTxs, tx), append(blobIdx, i)
+		} else {
+			legacyTxs, legacyIdx = append(legacyTxs, tx), append(legacyIdx, i)
+		}
+	}
+	errs := make([]error, len(txs))
+	if len(legacyTxs) > 0 {
+		for i, err := range p.legacy.AddRemotes(legacyTxs) {
+			errs[legacyIdx[i]] = err
+		}
+	}
+	if len(blobTxs) > 0 {
+		for i, err := range p.blob.Add(blobTxs) {
+			errs[blobIdx[i]] = err
+		}
+	}
+	return errs
+}
+
+// Pending returns the pending transactions of both pools. Blob transactions
+// are returned without their sidecars. The pools never track the same account,
+// so the account lists are simply merged.
+func (p *txPools) Pending(enforceTips bool) map[common.Address]types.Transactions {
+	pending := p.legacy.Pending(enforceTips)
+	for addr, txs := range p.blob.Pending(enforceTips) {
+		if _, ok := pending[addr]; !ok {
+			pending[addr] = txs
+		}
+	}
+	return pending
+}
+
+// SubscribeNewTxsEvent subscribes to the new transaction events of both pools.
+func (p *txPools) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
+	return event.JoinSubscriptions(
+		p.legacy.SubscribeNewTxsEvent(ch),
+		p.blob.SubscribeNewTxsEvent(ch),
+	)
+}
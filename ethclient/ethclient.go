@@ -102,9 +102,10 @@ func (ec *Client) PeerCount(ctx context.Context) (uint64, error) {
 }
 
 type rpcBlock struct {
-	Hash         common.Hash      `json:"hash"`
-	Transactions []rpcTransaction `json:"transactions"`
-	UncleHashes  []common.Hash    `json:"uncles"`
+	Hash         common.Hash         `json:"hash"`
+	Transactions []rpcTransaction    `json:"transactions"`
+	UncleHashes  []common.Hash       `json:"uncles"`
+	Withdrawals  []*types.Withdrawal `json:"withdrawals,omitempty"`
 }
 
 func (ec *Client) getBlock(ctx context.Context, method string, args ...interface{}) (*types.Block, error) {
@@ -169,7 +170,7 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 		}
 		txs[i] = tx.tx
 	}
-	return types.NewBlockWithHeader(head).WithBody(txs, uncles), nil
+	return types.NewBlockWithHeader(head).WithBody(txs, uncles).WithWithdrawals(body.Withdrawals), nil
 }
 
 // HeaderByHash returns the block header with the given hash.
@@ -398,6 +399,29 @@ func (ec *Client) GetPurgeResults(ctx context.Context, index uint64) (*types.Pur
 	return raw.ToPurgeHistoryResult(), nil
 }
 
+// SubscribeRewards subscribes to notifications about the rewards credited by new
+// canonical blocks to the validators and covenant nfts matching the given query.
+func (ec *Client) SubscribeRewards(ctx context.Context, q ethereum.RewardQuery, ch chan<- *types.RPCBlockReward) (ethereum.Subscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "rewards", toRewardArg(q))
+}
+
+// SubscribePurges subscribes to notifications about the purges run on the closing
+// of purge periods, which moved a covenant nft matching the given query.
+func (ec *Client) SubscribePurges(ctx context.Context, q ethereum.RewardQuery, ch chan<- *types.RPCPurgeResult) (ethereum.Subscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "purges", toRewardArg(q))
+}
+
+func toRewardArg(q ethereum.RewardQuery) interface{} {
+	ids := make([]hexutil.Uint64, len(q.TokenIDs))
+	for i, id := range q.TokenIDs {
+		ids[i] = hexutil.Uint64(id)
+	}
+	return map[string]interface{}{
+		"address": q.Addresses,
+		"tokenID": ids,
+	}
+}
+
 // TransactionReceipt returns the receipt of a transaction by transaction hash.
 // Note that the receipt is not available for pending transactions.
 func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
@@ -411,6 +435,30 @@ func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*
 	return r, err
 }
 
+// BlockReceipts returns the receipts of all the transactions in the given block.
+func (ec *Client) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	var r []*types.Receipt
+	err := ec.c.CallContext(ctx, &r, "eth_getBlockReceipts", blockNrOrHash)
+	if err == nil && r == nil {
+		return nil, ethereum.NotFound
+	}
+	return r, err
+}
+
+// SubscribeBlockReceipts subscribes to the headers and the receipts of the
+// canonical blocks in the range of the given query, streaming the ones already
+// imported first.
+func (ec *Client) SubscribeBlockReceipts(ctx context.Context, q ethereum.BlockReceiptsQuery, ch chan<- *types.BlockReceipts) (ethereum.Subscription, error) {
+	arg := make(map[string]interface{})
+	if q.FromBlock != nil {
+		arg["fromBlock"] = toBlockNumArg(q.FromBlock)
+	}
+	if q.ToBlock != nil {
+		arg["toBlock"] = toBlockNumArg(q.ToBlock)
+	}
+	return ec.c.EthSubscribe(ctx, ch, "blockReceipts", arg)
+}
+
 // SyncProgress retrieves the current progress of the sync algorithm. If there's
 // no sync currently running, it returns nil.
 func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
@@ -30,10 +30,12 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rewards"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -210,10 +212,23 @@ var testTx2 = types.MustSignNewTx(testKey, types.LatestSigner(genesis.Config), &
 	To:       &common.Address{2},
 })
 
+var testValidator = common.Address{0xaa}
+
 func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
 	// Generate test chain.
 	blocks := generateTestChain()
 
+	// Import the test chain.
+	n, ethservice := newTestNode(t)
+	if _, err := ethservice.BlockChain().InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("can't import test blocks: %v", err)
+	}
+	waitRewards(t, ethservice.BlockChain())
+	return n, blocks
+}
+
+// newTestNode creates and starts a node serving the genesis block only.
+func newTestNode(t *testing.T) (*node.Node, *eth.Ethereum) {
 	// Create node
 	n, err := node.New(&node.Config{})
 	if err != nil {
@@ -226,21 +241,36 @@ func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
 	if err != nil {
 		t.Fatalf("can't create new ethereum service: %v", err)
 	}
-	// Import the test chain.
+	filterSystem := filters.NewFilterSystem(ethservice.APIBackend, filters.Config{})
+	n.RegisterAPIs([]rpc.API{{
+		Namespace: "eth",
+		Service:   filters.NewFilterAPI(filterSystem, false),
+	}})
 	if err := n.Start(); err != nil {
 		t.Fatalf("can't start test node: %v", err)
 	}
-	if _, err := ethservice.BlockChain().InsertChain(blocks[1:]); err != nil {
-		t.Fatalf("can't import test blocks: %v", err)
+	return n, ethservice
+}
+
+// waitRewards waits until the reward records caught up with the chain head.
+func waitRewards(t *testing.T, chain *core.BlockChain) {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		if chain.RewardStore().Head().Hash() == chain.CurrentBlock().Hash() {
+			return
+		}
 	}
-	return n, blocks
+	t.Fatalf("reward records not synced with the chain head")
 }
 
 func generateTestChain() []*types.Block {
 	generate := func(i int, g *core.BlockGen) {
 		g.OffsetTime(5)
 		g.SetExtra([]byte("test"))
+		g.SetCoinbase(testValidator)
 		if i == 1 {
+			// Block #2 closes the first purge period.
+			g.OffsetTime(types.PurgePeriod * rewards.DayLength)
+
 			// Test transactions are included in block #2.
 			g.AddTx(testTx1)
 			g.AddTx(testTx2)
@@ -289,6 +319,12 @@ func TestEthClient(t *testing.T) {
 		"TransactionSender": {
 			func(t *testing.T) { testTransactionSender(t, client) },
 		},
+		"RewardFunctions": {
+			func(t *testing.T) { testRewardFunctions(t, client) },
+		},
+		"BlockReceipts": {
+			func(t *testing.T) { testBlockReceipts(t, chain, client) },
+		},
 	}
 
 	t.Parallel()
@@ -687,6 +723,212 @@ func testTransactionSender(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func testRewardFunctions(t *testing.T, client *rpc.Client) {
+	ec := NewClient(client)
+	ctx := context.Background()
+
+	// Validator records, the test chain credits two block rewards to the validator,
+	// one on the first day and one after the first purge period
+	reward := new(big.Int).Mul(big.NewInt(2), ethash.ConstantinopleBlockReward)
+
+	validator, err := ec.GetRunningRewardsByAddress(ctx, testValidator)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if validator.Address != testValidator {
+		t.Fatalf("unexpected validator: %v", validator.Address)
+	}
+	if validator.RunningTotal.Cmp(reward) != 0 || validator.AmountEarnedToday.Cmp(ethash.ConstantinopleBlockReward) != 0 {
+		t.Fatalf("unexpected validator rewards: %v", validator)
+	}
+	if validator.AmountEarnedSinceLastPurge.Cmp(ethash.ConstantinopleBlockReward) != 0 {
+		t.Fatalf("unexpected validator purge rewards: %v", validator)
+	}
+	if len(validator.Daily) != types.PurgePeriod || validator.Daily[0].Cmp(ethash.ConstantinopleBlockReward) != 0 || len(validator.Monthly) != 0 {
+		t.Fatalf("unexpected validator history: %v", validator)
+	}
+	if _, err := ec.GetRunningRewardsByAddress(ctx, common.Address{0xbb}); err != ethereum.NotFound {
+		t.Fatalf("unexpected error for unknown validator: %v", err)
+	}
+	// Covenant records
+	if _, err := ec.GetRunningRewardsByNFT(ctx, 7); err != ethereum.NotFound {
+		t.Fatalf("unexpected error for unknown nft: %v", err)
+	}
+	// Totals, the validator balance consists of the rewards and the fees
+	balance, err := ec.BalanceAt(ctx, testValidator, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fees := new(big.Int).Sub(balance, reward)
+	if fees.Sign() <= 0 {
+		t.Fatalf("no fees paid to validator: %v", fees)
+	}
+	for _, tt := range []struct {
+		name  string
+		fetch func(context.Context) (*big.Int, error)
+		want  *big.Int
+	}{
+		{"TotalSupply", ec.GetTotalSupply, reward},
+		{"OutStanding", ec.GetOutStanding, reward},
+		{"TotalSupplyCovenant", ec.GetTotalSupplyCovenant, new(big.Int)},
+		{"TotalFee", ec.GetTotalFee, fees},
+		{"TotalFeeValidators", ec.GetTotalFeeValidators, fees},
+		{"TotalFeeCovenants", ec.GetTotalFeeCovenants, new(big.Int)},
+	} {
+		have, err := tt.fetch(ctx)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if have.Cmp(tt.want) != 0 {
+			t.Fatalf("%s: have %v, want %v", tt.name, have, tt.want)
+		}
+	}
+	// Purge history, the first purge ran without any covenant nfts to move
+	purge, err := ec.GetPurgeResults(ctx, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := rewards.Purge(new(types.NodeRewardStorage)); !reflect.DeepEqual(purge, want) {
+		t.Fatalf("purge result mismatch: have %v, want %v", purge, want)
+	}
+	if _, err := ec.GetPurgeResults(ctx, 1); err != ethereum.NotFound {
+		t.Fatalf("unexpected error for unknown purge index: %v", err)
+	}
+}
+
+func testBlockReceipts(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+	ctx := context.Background()
+
+	// Both test transactions are in block #2, retrieve them by number and hash
+	for _, query := range []rpc.BlockNumberOrHash{
+		rpc.BlockNumberOrHashWithNumber(2),
+		rpc.BlockNumberOrHashWithHash(chain[2].Hash(), false),
+	} {
+		receipts, err := ec.BlockReceipts(ctx, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(receipts) != 2 {
+			t.Fatalf("receipt count mismatch: have %d, want %d", len(receipts), 2)
+		}
+		for i, tx := range []*types.Transaction{testTx1, testTx2} {
+			receipt := receipts[i]
+			if receipt.TxHash != tx.Hash() || receipt.BlockHash != chain[2].Hash() || receipt.TransactionIndex != uint(i) {
+				t.Errorf("receipt %d: inclusion mismatch: tx %x, block %x, index %d", i, receipt.TxHash, receipt.BlockHash, receipt.TransactionIndex)
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful || receipt.GasUsed != params.TxGas {
+				t.Errorf("receipt %d: result mismatch: status %d, gas used %d", i, receipt.Status, receipt.GasUsed)
+			}
+		}
+	}
+	if _, err := ec.BlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(10)); err != ethereum.NotFound {
+		t.Fatalf("unexpected error for unknown block: %v", err)
+	}
+	// Stream the entire chain
+	ch := make(chan *types.BlockReceipts)
+	sub, err := ec.SubscribeBlockReceipts(ctx, ethereum.BlockReceiptsQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(2)}, ch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for i, block := range chain {
+		select {
+		case have := <-ch:
+			if have.Header.Hash() != block.Hash() {
+				t.Fatalf("block %d: header mismatch: have %x, want %x", i, have.Header.Hash(), block.Hash())
+			}
+			if len(have.Receipts) != len(block.Transactions()) {
+				t.Fatalf("block %d: receipt count mismatch: have %d, want %d", i, len(have.Receipts), len(block.Transactions()))
+			}
+			for j, receipt := range have.Receipts {
+				if receipt.TxHash != block.Transactions()[j].Hash() {
+					t.Errorf("block %d, receipt %d: transaction mismatch", i, j)
+				}
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block %d: notification timeout", i)
+		}
+	}
+}
+
+func TestRewardSubscriptions(t *testing.T) {
+	blocks := generateTestChain()
+	backend, ethservice := newTestNode(t)
+	client, _ := backend.Attach()
+	defer backend.Close()
+	defer client.Close()
+
+	ec := NewClient(client)
+	ctx := context.Background()
+
+	rewardsCh := make(chan *types.RPCBlockReward)
+	rewardsSub, err := ec.SubscribeRewards(ctx, ethereum.RewardQuery{Addresses: []common.Address{testValidator}}, rewardsCh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rewardsSub.Unsubscribe()
+
+	purgeCh := make(chan *types.RPCPurgeResult)
+	purgeSub, err := ec.SubscribePurges(ctx, ethereum.RewardQuery{}, purgeCh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer purgeSub.Unsubscribe()
+
+	// Rewards of a different validator are filtered out
+	otherCh := make(chan *types.RPCBlockReward)
+	otherSub, err := ec.SubscribeRewards(ctx, ethereum.RewardQuery{Addresses: []common.Address{{0xbb}}}, otherCh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer otherSub.Unsubscribe()
+
+	if _, err := ethservice.BlockChain().InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("can't import test blocks: %v", err)
+	}
+	// Every block credits the validator, block #2 closes the first purge period
+	for _, block := range blocks[1:] {
+		select {
+		case reward := <-rewardsCh:
+			if reward.BlockHash != block.Hash() || uint64(reward.BlockNumber) != block.NumberU64() {
+				t.Fatalf("block %d: reward block mismatch: have #%d [%x]", block.NumberU64(), reward.BlockNumber, reward.BlockHash)
+			}
+			if reward.Address == nil || *reward.Address != testValidator || reward.TokenID != nil {
+				t.Fatalf("block %d: reward recipient mismatch: %v", block.NumberU64(), reward.Address)
+			}
+			if reward.Reward.ToInt().Cmp(ethash.ConstantinopleBlockReward) != 0 {
+				t.Fatalf("block %d: reward mismatch: have %v, want %v", block.NumberU64(), reward.Reward, ethash.ConstantinopleBlockReward)
+			}
+		case err := <-rewardsSub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block %d: reward notification timeout", block.NumberU64())
+		}
+	}
+	select {
+	case purge := <-purgeCh:
+		if purge.Index != 0 || purge.BlockHash != blocks[2].Hash() {
+			t.Fatalf("purge mismatch: have index %d at [%x], want index 0 at [%x]", purge.Index, purge.BlockHash, blocks[2].Hash())
+		}
+		if want := rewards.Purge(new(types.NodeRewardStorage)); !reflect.DeepEqual(purge.Result.ToPurgeHistoryResult(), want) {
+			t.Fatalf("purge result mismatch: have %v, want %v", purge.Result.ToPurgeHistoryResult(), want)
+		}
+	case err := <-purgeSub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("purge notification timeout")
+	}
+	select {
+	case reward := <-otherCh:
+		t.Fatalf("unexpected reward for filtered validator: %v", reward)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func sendTransaction(ec *Client) error {
 	chainID, err := ec.ChainID(context.Background())
 	if err != nil {
@@ -0,0 +1,696 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	cmath "github.com/ethereum/go-ethereum/common/math"
+	"github.com/graph-gophers/graphql-go"
+	gqlErrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/trace"
+)
+
+// maxParseDepth is the maximum nesting of selection sets and values accepted by
+// the cost estimator, bounding the recursion of its parser.
+const maxParseDepth = 256
+
+// fieldCost is the cost of resolving a field of the schema.
+type fieldCost struct {
+	weight uint64 // Cost of resolving the field itself
+	items  uint64 // Expected number of items of a list field, scaling the estimated cost of its selection
+}
+
+// fieldCosts contains the fields costlier than the default weight of one, keyed
+// by type and field name. The weights roughly follow the number of database
+// accesses needed to resolve a field, the item counts are conservative averages
+// of the lists returned. The block range queries are charged for every block of
+// the requested range.
+var fieldCosts = map[string]fieldCost{
+	"Query.block":                   {weight: 5},
+	"Query.blocks":                  {weight: 5},
+	"Query.transaction":             {weight: 5},
+	"Query.logs":                    {weight: 20, items: 10},
+	"Query.gasPrice":                {weight: 5},
+	"Query.maxPriorityFeePerGas":    {weight: 5},
+	"Block.parent":                  {weight: 5},
+	"Block.totalDifficulty":         {weight: 5},
+	"Block.ommers":                  {weight: 5, items: 2},
+	"Block.ommerAt":                 {weight: 5},
+	"Block.transactions":            {weight: 5, items: 200},
+	"Block.transactionAt":           {weight: 5},
+	"Block.logs":                    {weight: 20, items: 100},
+	"Block.account":                 {weight: 5},
+	"Block.call":                    {weight: 1000},
+	"Block.estimateGas":             {weight: 1000},
+	"Block.rawReceipts":             {weight: 20},
+	"Transaction.block":             {weight: 5},
+	"Transaction.status":            {weight: 20},
+	"Transaction.gasUsed":           {weight: 20},
+	"Transaction.cumulativeGasUsed": {weight: 20},
+	"Transaction.effectiveGasPrice": {weight: 20},
+	"Transaction.createdContract":   {weight: 20},
+	"Transaction.logs":              {weight: 20, items: 10},
+	"Transaction.rawReceipt":        {weight: 20},
+	"Account.balance":               {weight: 5},
+	"Account.transactionCount":      {weight: 5},
+	"Account.code":                  {weight: 5},
+	"Account.storage":               {weight: 5},
+	"Pending.transactions":          {weight: 5, items: 1000},
+	"Pending.account":               {weight: 5},
+	"Pending.call":                  {weight: 1000},
+	"Pending.estimateGas":           {weight: 1000},
+}
+
+// fieldWeight returns the cost of resolving a field once.
+func fieldWeight(typeName, fieldName string) uint64 {
+	if cost, ok := fieldCosts[typeName+"."+fieldName]; ok {
+		return cost.weight
+	}
+	return 1
+}
+
+// fieldBlocks returns the number of blocks a field is resolved for. It is the
+// length of the requested range for the block range queries, one otherwise.
+func fieldBlocks(typeName, fieldName string, args map[string]interface{}, head func() uint64) uint64 {
+	switch typeName + "." + fieldName {
+	case "Query.blocks":
+		return blockRange(args["from"], args["to"], 0, head())
+	case "Query.logs":
+		filter, _ := args["filter"].(map[string]interface{})
+		number := head()
+		return blockRange(filter["fromBlock"], filter["toBlock"], number, number)
+	}
+	return 1
+}
+
+// blockRange returns the number of blocks in the range given by the arguments
+// of a field. A missing start defaults to the given block, a missing end to the
+// head block.
+func blockRange(fromArg, toArg interface{}, from, head uint64) uint64 {
+	if n, ok := argNumber(fromArg); ok {
+		from = n
+	}
+	to, ok := argNumber(toArg)
+	if !ok {
+		to = head
+	}
+	if to < from {
+		return 0
+	}
+	return addCost(to-from, 1)
+}
+
+// argNumber converts a block number argument, either given as a literal or as a
+// variable, into an actual number. Block tags are not accepted.
+func argNumber(arg interface{}) (uint64, bool) {
+	switch arg := arg.(type) {
+	case int32:
+		return uint64(arg), arg >= 0
+	case float64:
+		return uint64(arg), arg >= 0 && arg <= math.MaxUint64
+	case string:
+		return cmath.ParseUint64(arg)
+	}
+	return 0, false
+}
+
+// costError returns the error to respond with to an operation exceeding the
+// cost limit.
+func costError(cost, limit uint64) *gqlErrors.QueryError {
+	return &gqlErrors.QueryError{
+		Message:    fmt.Sprintf("query cost %d exceeds the limit of %d", cost, limit),
+		Extensions: map[string]interface{}{"code": "COST_LIMIT_EXCEEDED", "cost": cost, "limit": limit},
+	}
+}
+
+// costEstimator statically estimates the cost of GraphQL operations, so that the
+// ones exceeding the limit are rejected before they are executed. Lists are
+// assumed to hold their expected number of items.
+type costEstimator struct {
+	fields map[string]map[string]string // Named result types of the fields of every type
+	head   func() uint64                // Number of the current head block
+}
+
+// newCostEstimator creates a cost estimator for the operations of the given
+// schema.
+func newCostEstimator(schema *graphql.Schema, head func() uint64) *costEstimator {
+	fields := make(map[string]map[string]string)
+	for _, typ := range schema.Inspect().Types() {
+		defs := typ.Fields(&struct{ IncludeDeprecated bool }{true})
+		if typ.Name() == nil || defs == nil {
+			continue
+		}
+		types := make(map[string]string)
+		for _, field := range *defs {
+			t := field.Type()
+			for t.OfType() != nil {
+				t = t.OfType()
+			}
+			if t.Name() != nil {
+				types[field.Name()] = *t.Name()
+			}
+		}
+		fields[*typ.Name()] = types
+	}
+	return &costEstimator{fields: fields, head: head}
+}
+
+// estimate returns the estimated cost of the costliest of the requested
+// operations. Queries failing to parse are not estimated, the schema reports
+// the syntax errors when executing them.
+func (e *costEstimator) estimate(query string, operationName string, variables map[string]interface{}) (uint64, bool) {
+	doc, ok := parseCostDocument(query, variables)
+	if !ok {
+		return 0, false
+	}
+	var (
+		cost      uint64
+		fragments = make(map[string]uint64)
+	)
+	for _, op := range doc.operations {
+		if operationName != "" && op.name != operationName {
+			continue
+		}
+		root := strings.ToUpper(op.kind[:1]) + op.kind[1:]
+		if c := e.selectionCost(doc, root, op.selection, fragments); c > cost {
+			cost = c
+		}
+	}
+	return cost, true
+}
+
+// selectionCost estimates the cost of resolving a selection set on an object of
+// the given type. The costs of the named fragments are cached.
+func (e *costEstimator) selectionCost(doc *costDocument, typ string, selection []*costSelection, fragments map[string]uint64) uint64 {
+	var cost uint64
+	for _, sel := range selection {
+		switch {
+		case sel.fragment != "":
+			c, ok := fragments[sel.fragment]
+			if frag := doc.fragments[sel.fragment]; !ok && frag != nil {
+				fragments[sel.fragment] = 0 // Cut cycles, those fail validation anyway
+				c = e.selectionCost(doc, frag.typ, frag.selection, fragments)
+				fragments[sel.fragment] = c
+			}
+			cost = addCost(cost, c)
+
+		case sel.name == "":
+			cond := typ
+			if sel.typ != "" {
+				cond = sel.typ
+			}
+			cost = addCost(cost, e.selectionCost(doc, cond, sel.selection, fragments))
+
+		default:
+			c := e.selectionCost(doc, e.fields[typ][sel.name], sel.selection, fragments)
+			if items := fieldCosts[typ+"."+sel.name].items; items > 0 {
+				c = mulCost(items, c)
+			}
+			c = addCost(fieldWeight(typ, sel.name), c)
+			cost = addCost(cost, mulCost(fieldBlocks(typ, sel.name, sel.args, e.head), c))
+		}
+	}
+	return cost
+}
+
+// costTracer is the tracer of the schemas, charging the cost of every field to
+// the meter of its operation right before the field is resolved. Operations
+// without a meter are not limited.
+type costTracer struct {
+	head func() uint64 // Number of the current head block
+}
+
+// TraceQuery implements trace.Tracer.
+func (t *costTracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, trace.TraceQueryFinishFunc) {
+	return ctx, func([]*gqlErrors.QueryError) {}
+}
+
+// TraceField implements trace.Tracer. The fields of a subscription event are
+// charged to the meter of the event, which is handed down to their children.
+func (t *costTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, trace.TraceFieldFinishFunc) {
+	meter, ok := ctx.Value(costMeterKey{}).(*costMeter)
+	if !ok {
+		events, ok := ctx.Value(eventMetersKey{}).(*eventMeters)
+		if !ok {
+			return ctx, func(*gqlErrors.QueryError) {}
+		}
+		ctx, meter = events.meter(ctx)
+	}
+	meter.charge(t.fieldCost(typeName, fieldName, args))
+	return ctx, func(*gqlErrors.QueryError) {}
+}
+
+// fieldCost returns the cost of resolving a field with the given arguments.
+func (t *costTracer) fieldCost(typeName, fieldName string, args map[string]interface{}) uint64 {
+	return mulCost(fieldWeight(typeName, fieldName), fieldBlocks(typeName, fieldName, args, t.head))
+}
+
+// costMeterKey is the context key of the cost meter of an operation.
+type costMeterKey struct{}
+
+// costMeter accumulates the cost of the fields resolved by an operation, and
+// cancels the operation once the limit is exceeded.
+type costMeter struct {
+	cost   uint64 // Cost charged so far, accessed atomically
+	limit  uint64
+	cancel context.CancelFunc
+}
+
+// withCostMeter attaches a cost meter with the given limit to the context of an
+// operation. The meter must be cancelled once the operation finishes.
+func withCostMeter(ctx context.Context, limit uint64) (context.Context, *costMeter) {
+	ctx, cancel := context.WithCancel(ctx)
+	meter := &costMeter{limit: limit, cancel: cancel}
+	return &costContext{Context: ctx, meter: meter}, meter
+}
+
+// charge adds the cost of a field to the meter, cancelling the operation if it
+// exceeds the limit. The resolver of the field is skipped in that case.
+func (m *costMeter) charge(cost uint64) {
+	for {
+		old := atomic.LoadUint64(&m.cost)
+		if atomic.CompareAndSwapUint64(&m.cost, old, addCost(old, cost)) {
+			break
+		}
+	}
+	if atomic.LoadUint64(&m.cost) > m.limit {
+		m.cancel()
+	}
+}
+
+// err returns the error of the operation if it exceeded the limit.
+func (m *costMeter) err() *gqlErrors.QueryError {
+	if cost := atomic.LoadUint64(&m.cost); cost > m.limit {
+		return costError(cost, m.limit)
+	}
+	return nil
+}
+
+// errors returns the errors to respond with if the operation exceeded the limit.
+func (m *costMeter) errors() []*gqlErrors.QueryError {
+	if err := m.err(); err != nil {
+		return []*gqlErrors.QueryError{err}
+	}
+	return nil
+}
+
+// costContext is the context of a metered operation. It carries the meter, and
+// reports the cost error as the reason of its cancellation, so that the fields
+// skipped over the limit fail with it.
+type costContext struct {
+	context.Context
+	meter *costMeter
+}
+
+// Err implements context.Context.
+func (c *costContext) Err() error {
+	err := c.Context.Err()
+	if err != nil {
+		if cerr := c.meter.err(); cerr != nil {
+			return cerr
+		}
+	}
+	return err
+}
+
+// Value implements context.Context.
+func (c *costContext) Value(key interface{}) interface{} {
+	if key == (costMeterKey{}) {
+		return c.meter
+	}
+	return c.Context.Value(key)
+}
+
+// eventMetersKey is the context key of the event meters of a subscription.
+type eventMetersKey struct{}
+
+// eventMeters hands out a fresh cost meter to every event of a subscription.
+// The schema resolves the events one after the other, each in its own context
+// shared by the top-level fields of the event.
+type eventMeters struct {
+	limit uint64
+
+	event context.Context // Context of the event being resolved
+	ctx   context.Context // Metered context of the event being resolved
+	cost  *costMeter      // Meter of the event being resolved
+	lock  sync.Mutex
+}
+
+// withEventMeters attaches the event meters with the given limit to the context
+// of a subscription.
+func withEventMeters(ctx context.Context, limit uint64) context.Context {
+	return context.WithValue(ctx, eventMetersKey{}, &eventMeters{limit: limit})
+}
+
+// meter returns the metered context of the event resolved in the given context,
+// releasing the meter of the previous event.
+func (m *eventMeters) meter(event context.Context) (context.Context, *costMeter) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.event != event {
+		if m.cost != nil {
+			m.cost.cancel()
+		}
+		m.event = event
+		m.ctx, m.cost = withCostMeter(event, m.limit)
+	}
+	return m.ctx, m.cost
+}
+
+// addCost adds two costs, saturating on overflow.
+func addCost(a, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+	return a + b
+}
+
+// mulCost multiplies two costs, saturating on overflow.
+func mulCost(a, b uint64) uint64 {
+	if a != 0 && b > math.MaxUint64/a {
+		return math.MaxUint64
+	}
+	return a * b
+}
+
+// costDocument is the skeleton of a GraphQL document, retaining just enough of
+// it to estimate the cost of its operations.
+type costDocument struct {
+	operations []*costOperation
+	fragments  map[string]*costFragment
+}
+
+// costOperation is an operation defined in a GraphQL document.
+type costOperation struct {
+	name      string
+	kind      string
+	selection []*costSelection
+}
+
+// costFragment is a named fragment defined in a GraphQL document.
+type costFragment struct {
+	typ       string
+	selection []*costSelection
+}
+
+// costSelection is a field, fragment spread or inline fragment of a selection set.
+type costSelection struct {
+	name      string                 // Name of the selected field, empty for fragments
+	args      map[string]interface{} // Scalar and object arguments of the field, with the variables resolved
+	fragment  string                 // Name of the spread fragment
+	typ       string                 // Type condition of an inline fragment
+	selection []*costSelection
+}
+
+// costToken is a lexical token of a GraphQL document. Punctuators and names are
+// kept verbatim, numbers and strings as the text of their value.
+type costToken struct {
+	text  string
+	value bool
+}
+
+// lexCostTokens splits a GraphQL document into tokens. It reports false for
+// documents that aren't lexically valid.
+func lexCostTokens(src string) ([]costToken, bool) {
+	var tokens []costToken
+	for i := 0; i < len(src); {
+		switch c := src[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, costToken{text: "..."})
+			i += 3
+		case strings.IndexByte("!$&()=:@[]{}|", c) >= 0:
+			tokens = append(tokens, costToken{text: src[i : i+1]})
+			i++
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return nil, false
+			}
+			tokens = append(tokens, costToken{text: src[i+3 : i+3+end], value: true})
+			i += end + 6
+		case c == '"':
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\n' || src[j] == '\r' {
+					return nil, false
+				}
+				if src[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(src) {
+				return nil, false
+			}
+			tokens = append(tokens, costToken{text: src[i+1 : j], value: true})
+			i = j + 1
+		case c == '-' || isNameChar(c):
+			j := i + 1
+			for j < len(src) && (isNameChar(src[j]) || src[j] == '.' || ((src[j] == '-' || src[j] == '+') && (src[j-1] == 'e' || src[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, costToken{text: src[i:j], value: c == '-' || ('0' <= c && c <= '9')})
+			i = j
+		default:
+			return nil, false
+		}
+	}
+	return tokens, true
+}
+
+// costParser is a minimal parser of GraphQL executable documents. It stops at
+// the first syntax error, leaving the reporting of the error to the schema.
+type costParser struct {
+	tokens    []costToken
+	pos       int
+	depth     int
+	failed    bool
+	variables map[string]interface{}
+}
+
+// parseCostDocument parses a GraphQL executable document, substituting the
+// values of the variables used as arguments. It reports false if the document
+// isn't valid.
+func parseCostDocument(src string, variables map[string]interface{}) (*costDocument, bool) {
+	tokens, ok := lexCostTokens(strings.TrimPrefix(src, "\ufeff"))
+	if !ok {
+		return nil, false
+	}
+	p := &costParser{tokens: tokens, variables: variables}
+	doc := &costDocument{fragments: make(map[string]*costFragment)}
+	for !p.failed && p.pos < len(p.tokens) {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, &costOperation{kind: "query", selection: p.selectionSet()})
+
+		case p.take("fragment"):
+			name := p.name()
+			p.expect("on")
+			frag := &costFragment{typ: p.name()}
+			p.directives()
+			frag.selection = p.selectionSet()
+			doc.fragments[name] = frag
+
+		default:
+			op := &costOperation{kind: p.name()}
+			if op.kind != "query" && op.kind != "mutation" && op.kind != "subscription" {
+				return nil, false
+			}
+			if !p.peek("(") && !p.peek("@") && !p.peek("{") {
+				op.name = p.name()
+			}
+			if p.take("(") {
+				// Skip the variable definitions, the values come with the request
+				for depth := 1; !p.failed && depth > 0; {
+					switch {
+					case p.take("("):
+						depth++
+					case p.take(")"):
+						depth--
+					default:
+						p.next()
+					}
+				}
+			}
+			p.directives()
+			op.selection = p.selectionSet()
+			doc.operations = append(doc.operations, op)
+		}
+	}
+	return doc, !p.failed && len(doc.operations) > 0
+}
+
+// selectionSet parses a selection set enclosed in braces.
+func (p *costParser) selectionSet() []*costSelection {
+	p.enter()
+	defer p.leave()
+
+	var selection []*costSelection
+	for p.expect("{"); !p.failed && !p.take("}"); {
+		sel := new(costSelection)
+		switch {
+		case p.take("..."):
+			if p.peek("on") || p.peek("@") || p.peek("{") {
+				if p.take("on") {
+					sel.typ = p.name()
+				}
+				p.directives()
+				sel.selection = p.selectionSet()
+			} else {
+				sel.fragment = p.name()
+				p.directives()
+			}
+		default:
+			if sel.name = p.name(); p.take(":") {
+				sel.name = p.name()
+			}
+			if p.peek("(") {
+				sel.args = p.arguments()
+			}
+			p.directives()
+			if p.peek("{") {
+				sel.selection = p.selectionSet()
+			}
+		}
+		selection = append(selection, sel)
+	}
+	return selection
+}
+
+// arguments parses the arguments of a field or directive.
+func (p *costParser) arguments() map[string]interface{} {
+	args := make(map[string]interface{})
+	for p.expect("("); !p.failed && !p.take(")"); {
+		name := p.name()
+		p.expect(":")
+		args[name] = p.value()
+	}
+	return args
+}
+
+// directives skips the directives applied to a definition or selection.
+func (p *costParser) directives() {
+	for !p.failed && p.take("@") {
+		p.name()
+		if p.peek("(") {
+			p.arguments()
+		}
+	}
+}
+
+// value parses an argument value. Numbers and strings are returned as their
+// text, objects as maps, while lists and other values are dropped.
+func (p *costParser) value() interface{} {
+	switch {
+	case p.take("$"):
+		return p.variables[p.name()]
+
+	case p.take("["):
+		p.enter()
+		defer p.leave()
+		for !p.failed && !p.take("]") {
+			p.value()
+		}
+		return nil
+
+	case p.take("{"):
+		p.enter()
+		defer p.leave()
+		obj := make(map[string]interface{})
+		for !p.failed && !p.take("}") {
+			name := p.name()
+			p.expect(":")
+			obj[name] = p.value()
+		}
+		return obj
+	}
+	if tok := p.next(); tok.value {
+		return tok.text
+	} else if tok.text == "" || !isNameChar(tok.text[0]) {
+		p.failed = true
+	}
+	return nil
+}
+
+// enter descends into a nested selection set or value, failing the parsing if
+// it's nested too deep.
+func (p *costParser) enter() {
+	if p.depth++; p.depth > maxParseDepth {
+		p.failed = true
+	}
+}
+
+// leave ascends from a nested selection set or value.
+func (p *costParser) leave() {
+	p.depth--
+}
+
+// next consumes the current token, failing the parsing at the end of the
+// document.
+func (p *costParser) next() costToken {
+	if p.failed || p.pos >= len(p.tokens) {
+		p.failed = true
+		return costToken{}
+	}
+	p.pos++
+	return p.tokens[p.pos-1]
+}
+
+// peek reports whether the current token is the given punctuator or name.
+func (p *costParser) peek(text string) bool {
+	return !p.failed && p.pos < len(p.tokens) && !p.tokens[p.pos].value && p.tokens[p.pos].text == text
+}
+
+// take consumes the current token if it's the given punctuator or name.
+func (p *costParser) take(text string) bool {
+	if p.peek(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes the given punctuator or name, failing the parsing if it's not
+// the current token.
+func (p *costParser) expect(text string) {
+	if !p.take(text) {
+		p.failed = true
+	}
+}
+
+// name consumes a name, failing the parsing if the current token isn't one.
+func (p *costParser) name() string {
+	tok := p.next()
+	if tok.value || tok.text == "" || !isNameChar(tok.text[0]) {
+		p.failed = true
+		return ""
+	}
+	return tok.text
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlErrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+func TestFieldCost(t *testing.T) {
+	tracer := &costTracer{head: func() uint64 { return 9 }}
+
+	tests := []struct {
+		typ, field string
+		args       map[string]interface{}
+		cost       uint64
+	}{
+		{"Query", "chainID", nil, 1},
+		{"Block", "call", nil, 1000},
+		// Block ranges are taken from literals, variables and the head block
+		{"Query", "blocks", map[string]interface{}{"from": int32(0), "to": int32(9)}, 50},
+		{"Query", "blocks", map[string]interface{}{"from": 3.0, "to": "0x4"}, 10},
+		{"Query", "blocks", map[string]interface{}{"from": int32(5)}, 25},
+		{"Query", "blocks", map[string]interface{}{"from": int32(9), "to": int32(5)}, 0},
+		{"Query", "logs", map[string]interface{}{"filter": map[string]interface{}{}}, 20},
+		{"Query", "logs", map[string]interface{}{"filter": map[string]interface{}{"fromBlock": int32(1), "toBlock": int32(-1)}}, 180},
+		{"Query", "logs", map[string]interface{}{"filter": map[string]interface{}{"fromBlock": "0x0", "toBlock": 4.0}}, 100},
+	}
+	for i, tt := range tests {
+		if cost := tracer.fieldCost(tt.typ, tt.field, tt.args); cost != tt.cost {
+			t.Errorf("test %d: cost mismatch: have %d, want %d", i, cost, tt.cost)
+		}
+	}
+}
+
+func TestCostEstimate(t *testing.T) {
+	subs, err := graphql.ParseSchema(schema+subscriptionSchema, new(subscriptionResolver))
+	if err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+	estimator := newCostEstimator(subs, func() uint64 { return 9 })
+
+	tests := []struct {
+		query     string
+		operation string
+		variables map[string]interface{}
+		cost      uint64
+	}{
+		{query: `{ chainID }`, cost: 1},
+		{query: `{ block { transactions { hash } } }`, cost: 210},
+		{query: `subscription { newBlock { transactions { logs { data } } } }`, cost: 6006},
+		// Aliases, directives, comments and strings don't get in the way
+		{query: "# comment\n{ a: block(hash: \"0x\\\"\") @include(if: true) { ... on Block { number } } }", cost: 6},
+		// Block ranges are taken from literals, variables and the head block
+		{query: `{ blocks(from: 0, to: 9) { number } }`, cost: 60},
+		{query: `query($from: Long) { blocks(from: $from) { number } }`, variables: map[string]interface{}{"from": 5.0}, cost: 30},
+		{query: `{ logs(filter: {fromBlock: 5, addresses: []}) { data } }`, cost: 150},
+		// Fragments are estimated once, cycles are cut
+		{query: `{ block { ...f } } fragment f on Block { number parent { ...f } }`, cost: 11},
+		// The costliest of the requested operations counts
+		{query: `query A { chainID } query B { block { number } }`, cost: 6},
+		{query: `query A { chainID } query B { block { number } }`, operation: "A", cost: 1},
+	}
+	for i, tt := range tests {
+		cost, ok := estimator.estimate(tt.query, tt.operation, tt.variables)
+		if !ok {
+			t.Errorf("test %d: failed to estimate", i)
+		} else if cost != tt.cost {
+			t.Errorf("test %d: cost mismatch: have %d, want %d", i, cost, tt.cost)
+		}
+	}
+	// Invalid queries are left to the schema to reject
+	for i, query := range []string{`{ block { number }`, `{ block(number: ) { number } }`, `{ block { number } } }`, `"unterminated`, strings.Repeat("{ block ", 300) + strings.Repeat("}", 300)} {
+		if _, ok := estimator.estimate(query, "", nil); ok {
+			t.Errorf("invalid query %d estimated", i)
+		}
+	}
+}
+
+func TestCostLimits(t *testing.T) {
+	tracer := &costTracer{head: func() uint64 { return 9 }}
+	s, err := graphql.ParseSchema(schema, new(Resolver), graphql.MaxDepth(3), graphql.Tracer(tracer))
+	if err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+	// Operations are rejected by the estimate, and metered if not estimated
+	handlers := []handler{
+		{Schema: s, estimator: newCostEstimator(s, tracer.head), limits: Limits{MaxCost: 20, MaxDepth: 3}},
+		{Schema: s, limits: Limits{MaxCost: 20, MaxDepth: 3}},
+	}
+	tests := []struct {
+		query string
+		code  string
+	}{
+		// The resolvers are skipped once over the limit, so the nil backend
+		// is never touched
+		{query: `{ pending { call(data: {}) { status } } }`, code: "COST_LIMIT_EXCEEDED"},
+		{query: `{ blocks(from: 0) { number } }`, code: "COST_LIMIT_EXCEEDED"},
+		{query: `{ logs(filter: {fromBlock: 0}) { data } }`, code: "COST_LIMIT_EXCEEDED"},
+		{query: `{ block { parent { parent { number } } } }`, code: "MaxDepthExceeded"},
+	}
+	for j, h := range handlers {
+		for i, tt := range tests {
+			res := h.exec(context.Background(), tt.query, "", nil)
+			switch {
+			case len(res.Errors) == 0:
+				t.Errorf("handler %d, test %d: expected rejection with %s", j, i, tt.code)
+			case tt.code == "MaxDepthExceeded" && res.Errors[0].Rule != tt.code:
+				t.Errorf("handler %d, test %d: error rule mismatch: have %v, want %s", j, i, res.Errors[0].Rule, tt.code)
+			case tt.code != "MaxDepthExceeded" && res.Errors[0].Extensions["code"] != tt.code:
+				t.Errorf("handler %d, test %d: error code mismatch: have %v, want %s", j, i, res.Errors[0].Extensions["code"], tt.code)
+			}
+		}
+	}
+}
+
+func TestSubscriptionCostLimits(t *testing.T) {
+	tracer := &costTracer{head: func() uint64 { return 9 }}
+	subs, err := graphql.ParseSchema(schema+subscriptionSchema, new(subscriptionResolver), graphql.Tracer(tracer))
+	if err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+	h := handler{subscriptions: subs, estimator: newCostEstimator(subs, tracer.head), limits: Limits{MaxCost: 1500}}
+
+	// Subscriptions with costly events are rejected before subscribing
+	results, err := h.subscribe(context.Background(), `subscription { newBlock { call(data: {}) { status } estimateGas(data: {}) } }`, "", nil)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if res := (<-results).(*graphql.Response); len(res.Errors) == 0 || res.Errors[0].Extensions["code"] != "COST_LIMIT_EXCEEDED" {
+		t.Fatalf("costly subscription not rejected: %v", res.Errors)
+	}
+	if _, ok := <-results; ok {
+		t.Fatalf("rejected subscription not closed")
+	}
+	// The fields of every event are charged to a meter of their own
+	ctx := withEventMeters(context.Background(), 1500)
+	for i := 0; i < 2; i++ {
+		event, cancel := context.WithCancel(ctx)
+		call, _ := tracer.TraceField(event, "", "Block", "call", false, nil)
+		if err := call.Err(); err != nil {
+			t.Fatalf("event %d: aborted under the limit: %v", i, err)
+		}
+		if _, meter := call.Value(costMeterKey{}).(*costMeter); !meter {
+			t.Fatalf("event %d: meter not handed down to the children", i)
+		}
+		estimate, _ := tracer.TraceField(event, "", "Block", "estimateGas", false, nil)
+		err, _ := estimate.Err().(*gqlErrors.QueryError)
+		if err == nil || err.Extensions["code"] != "COST_LIMIT_EXCEEDED" {
+			t.Fatalf("event %d: costly event not aborted: %v", i, estimate.Err())
+		}
+		cancel()
+	}
+}
+
+func TestResponseSizeLimit(t *testing.T) {
+	h := handler{limits: Limits{MaxResponseSize: 32}}
+
+	blob, failed, err := h.encode(&graphql.Response{Data: []byte(`{"chainID":"0x1"}`)})
+	if err != nil || failed || string(blob) != `{"data":{"chainID":"0x1"}}` {
+		t.Fatalf("small response mangled: %s (failed %v, err %v)", blob, failed, err)
+	}
+	blob, failed, err = h.encode(&graphql.Response{Data: []byte(`{"block":{"hash":"0x0000000000000000000000000000000000000000000000000000000000000000"}}`)})
+	if err != nil || !failed || !strings.Contains(string(blob), `"code":"RESPONSE_TOO_LARGE"`) {
+		t.Fatalf("large response not rejected: %s (failed %v, err %v)", blob, failed, err)
+	}
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/gorilla/websocket"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -53,7 +54,7 @@ func TestBuildSchema(t *testing.T) {
 	}
 	defer stack.Close()
 	// Make sure the schema can be parsed and matched up to the object model.
-	if _, err := newHandler(stack, nil, nil, []string{}, []string{}); err != nil {
+	if _, err := newHandler(stack, nil, nil, []string{}, []string{}, Limits{}); err != nil {
 		t.Errorf("Could not construct GraphQL handler: %v", err)
 	}
 }
@@ -233,6 +234,11 @@ func TestGraphQLBlockSerializationEIP2718(t *testing.T) {
 			want: `{"data":{"block":{"number":1,"transactions":[{"from":{"address":"0x71562b71999873db5b286df957af199ec94617f7"},"to":{"address":"0x0000000000000000000000000000000000000dad"},"value":"0x64","hash":"0xd864c9d7d37fade6b70164740540c06dd58bb9c3f6b46101908d6339db6a6a7b","type":0,"accessList":[],"index":0},{"from":{"address":"0x71562b71999873db5b286df957af199ec94617f7"},"to":{"address":"0x0000000000000000000000000000000000000dad"},"value":"0x32","hash":"0x19b35f8187b4e15fb59a9af469dca5dfa3cd363c11d372058c12f6482477b474","type":1,"accessList":[{"address":"0x0000000000000000000000000000000000000dad","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000000"]}],"index":1}]}}}`,
 			code: 200,
 		},
+		{
+			body: `{"query": "{block {rawReceipts transactions { rawReceipt }}}"}`,
+			want: `{"data":{"block":{"rawReceipts":["0xf9010801826274b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000c0","0x01f901080182cde4b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000c0"],"transactions":[{"rawReceipt":"0xf9010801826274b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000c0"},{"rawReceipt":"0x01f901080182cde4b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000c0"}]}}}`,
+			code: 200,
+		},
 	} {
 		resp, err := http.Post(fmt.Sprintf("%s/graphql", stack.HTTPEndpoint()), "application/json", strings.NewReader(tt.body))
 		if err != nil {
@@ -292,7 +298,7 @@ func TestGraphQLTransactionLogs(t *testing.T) {
 	)
 	defer stack.Close()
 
-	handler := newGQLService(t, stack, genesis, 1, func(i int, gen *core.BlockGen) {
+	handler, _ := newGQLService(t, stack, genesis, 1, func(i int, gen *core.BlockGen) {
 		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{To: &dad, Gas: 100000, GasPrice: big.NewInt(params.InitialBaseFee)})
 		gen.AddTx(tx)
 		tx, _ = types.SignNewTx(key, signer, &types.LegacyTx{To: &dad, Nonce: 1, Gas: 100000, GasPrice: big.NewInt(params.InitialBaseFee)})
@@ -319,6 +325,185 @@ func TestGraphQLTransactionLogs(t *testing.T) {
 	}
 }
 
+func TestGraphQLSubscriptions(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		dadStr  = "0x0000000000000000000000000000000000000dad"
+		dad     = common.HexToAddress(dadStr)
+		genesis = &core.Genesis{
+			Config:     params.AllEthashProtocolChanges,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+			Alloc: core.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				dad: {
+					// LOG0(0, 0), RETURN(0, 0)
+					Code:    common.Hex2Bytes("60006000a060006000f3"),
+					Nonce:   0,
+					Balance: big.NewInt(0),
+				},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		stack  = createNode(t)
+	)
+	defer stack.Close()
+
+	_, backend := newGQLService(t, stack, genesis, 0, func(i int, gen *core.BlockGen) {})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	send, read := dialWebsocket(t, stack)
+
+	// Queries are served too, completing right after their only result
+	send(`{"id":"query","type":"start","payload":{"query":"{ chainID }"}}`)
+	if msg := read(); msg.ID != "query" || msg.Type != gqlData || string(msg.Payload) != `{"data":{"chainID":"0x539"}}` {
+		t.Fatalf("unexpected query result: %s %s %s", msg.ID, msg.Type, msg.Payload)
+	}
+	if msg := read(); msg.ID != "query" || msg.Type != gqlComplete {
+		t.Fatalf("unexpected query completion: %s %s", msg.ID, msg.Type)
+	}
+	send(`{"id":"blocks","type":"start","payload":{"query":"subscription { newBlock { number } }"}}`)
+	send(fmt.Sprintf(`{"id":"logs","type":"start","payload":{"query":"subscription { logs(filter: {addresses: [\"%s\"]}) { account { address } transaction { hash } removed } }"}}`, dadStr))
+	send(`{"id":"txs","type":"start","payload":{"query":"subscription { pendingTransactions { hash } }"}}`)
+
+	// Wait for the subscriptions to be installed, then add a transaction to the
+	// pool and import it in a block
+	time.Sleep(100 * time.Millisecond)
+
+	tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{To: &dad, Gas: 100000, GasPrice: big.NewInt(params.InitialBaseFee)})
+	if err := backend.TxPool().AddLocal(tx); err != nil {
+		t.Fatalf("failed to add transaction to the pool: %v", err)
+	}
+	chain, _ := core.GenerateChain(params.AllEthashProtocolChanges, backend.BlockChain().Genesis(), ethash.NewFaker(), backend.ChainDb(), 1, func(i int, gen *core.BlockGen) {
+		gen.AddTx(tx)
+	})
+	if _, err := backend.BlockChain().InsertChain(chain); err != nil {
+		t.Fatalf("failed to import block: %v", err)
+	}
+	want := map[string]string{
+		"blocks": `{"data":{"newBlock":{"number":1}}}`,
+		"logs":   fmt.Sprintf(`{"data":{"logs":{"account":{"address":"%s"},"transaction":{"hash":"%s"},"removed":false}}}`, dadStr, tx.Hash().Hex()),
+		"txs":    fmt.Sprintf(`{"data":{"pendingTransactions":{"hash":"%s"}}}`, tx.Hash().Hex()),
+	}
+	for len(want) > 0 {
+		msg := read()
+		if msg.Type != gqlData {
+			t.Fatalf("unexpected message type for %s: have %s, want %s", msg.ID, msg.Type, gqlData)
+		}
+		if string(msg.Payload) != want[msg.ID] {
+			t.Fatalf("unexpected result for %s: have %s, want %s", msg.ID, msg.Payload, want[msg.ID])
+		}
+		delete(want, msg.ID)
+	}
+	// Subscriptions with invalid operations are rejected
+	send(`{"id":"invalid","type":"start","payload":{"query":"subscription { newBlock { unknown } }"}}`)
+	if msg := read(); msg.ID != "invalid" || msg.Type != gqlData || !strings.Contains(string(msg.Payload), "errors") {
+		t.Fatalf("unexpected result for invalid subscription: %s %s %s", msg.ID, msg.Type, msg.Payload)
+	}
+}
+
+// Tests that a long running query doesn't hold up the other operations of its
+// websocket connection, and that it can be stopped.
+func TestGraphQLWebsocketSlowQuery(t *testing.T) {
+	var (
+		loopStr = "0x0000000000000000000000000000000000001007"
+		genesis = &core.Genesis{
+			Config:     params.AllEthashProtocolChanges,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+			Alloc: core.GenesisAlloc{
+				// JUMPDEST, JUMP(0)
+				common.HexToAddress(loopStr): {Code: common.Hex2Bytes("5b600056"), Balance: big.NewInt(0)},
+			},
+		}
+		stack = createNode(t)
+	)
+	defer stack.Close()
+
+	newGQLService(t, stack, genesis, 0, func(i int, gen *core.BlockGen) {})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	send, read := dialWebsocket(t, stack)
+
+	// Start a call looping until it runs out of a practically unlimited gas
+	send(fmt.Sprintf(`{"id":"slow","type":"start","payload":{"query":"{ block { call(data: {to: \"%s\", gas: \"0xe8d4a51000\"}) { status } } }"}}`, loopStr))
+	send(`{"id":"fast","type":"start","payload":{"query":"{ chainID }"}}`)
+	if msg := read(); msg.ID != "fast" || msg.Type != gqlData {
+		t.Fatalf("unexpected message while slow query runs: %s %s %s", msg.ID, msg.Type, msg.Payload)
+	}
+	if msg := read(); msg.ID != "fast" || msg.Type != gqlComplete {
+		t.Fatalf("unexpected query completion: %s %s", msg.ID, msg.Type)
+	}
+	// Stopping the slow query aborts it without any further message
+	send(`{"id":"slow","type":"stop"}`)
+	send(`{"id":"after","type":"start","payload":{"query":"{ chainID }"}}`)
+	if msg := read(); msg.ID != "after" || msg.Type != gqlData {
+		t.Fatalf("unexpected message after stopping slow query: %s %s %s", msg.ID, msg.Type, msg.Payload)
+	}
+}
+
+// dialWebsocket connects to the GraphQL websocket endpoint of the node and
+// initialises the graphql-ws session, returning functions to send and read
+// messages over it. Keep-alive messages are skipped.
+func dialWebsocket(t *testing.T, stack *node.Node) (func(string), func() wsMessage) {
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(stack.HTTPEndpoint(), "http")+"/graphql", nil)
+	if err != nil {
+		t.Fatalf("failed to dial graphql websocket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	send := func(msg string) {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("failed to send message: %v", err)
+		}
+	}
+	read := func() wsMessage {
+		for {
+			var msg wsMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("failed to read message: %v", err)
+			}
+			if msg.Type != gqlConnectionKeepAlive {
+				return msg
+			}
+		}
+	}
+	send(`{"type":"connection_init"}`)
+	if msg := read(); msg.Type != gqlConnectionAck {
+		t.Fatalf("unexpected message type: have %s, want %s", msg.Type, gqlConnectionAck)
+	}
+	return send, read
+}
+
+func TestIsSubscription(t *testing.T) {
+	const doc = `query q { chainID } subscription s { newBlock { number } }`
+	tests := []struct {
+		query, operation string
+		want             bool
+	}{
+		{`{ chainID }`, "", false},
+		{`subscription { newBlock { number } }`, "", true},
+		{`# subscription
+		query { chainID }`, "", false},
+		{doc, "q", false},
+		{doc, "s", true},
+		{doc, "unknown", false},
+		{`subscription {`, "", false},
+		{`fragment F on Block { number } subscription($a: Long = 1) @dir { newBlock { ...F } }`, "", true},
+		{`query q { block(hash: "}") { number } } subscription s { newBlock { number } }`, "s", true},
+	}
+	for i, tt := range tests {
+		if have := isSubscription(tt.query, tt.operation); have != tt.want {
+			t.Errorf("test %d: subscription mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
 func createNode(t *testing.T) *node.Node {
 	stack, err := node.New(&node.Config{
 		HTTPHost:     "127.0.0.1",
@@ -333,7 +518,7 @@ func createNode(t *testing.T) *node.Node {
 	return stack
 }
 
-func newGQLService(t *testing.T, stack *node.Node, gspec *core.Genesis, genBlocks int, genfunc func(i int, gen *core.BlockGen)) *handler {
+func newGQLService(t *testing.T, stack *node.Node, gspec *core.Genesis, genBlocks int, genfunc func(i int, gen *core.BlockGen)) (*handler, *eth.Ethereum) {
 	ethConf := &ethconfig.Config{
 		Genesis: gspec,
 		Ethash: ethash.Config{
@@ -360,9 +545,9 @@ func newGQLService(t *testing.T, stack *node.Node, gspec *core.Genesis, genBlock
 	}
 	// Set up handler
 	filterSystem := filters.NewFilterSystem(ethBackend.APIBackend, filters.Config{})
-	handler, err := newHandler(stack, ethBackend.APIBackend, filterSystem, []string{}, []string{})
+	handler, err := newHandler(stack, ethBackend.APIBackend, filterSystem, []string{}, []string{}, Limits{})
 	if err != nil {
 		t.Fatalf("could not create graphql service: %v", err)
 	}
-	return handler
+	return handler, ethBackend
 }
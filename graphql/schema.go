@@ -31,11 +31,6 @@ const schema string = `
     # Long is a 64 bit unsigned integer.
     scalar Long
 
-    schema {
-        query: Query
-        mutation: Mutation
-    }
-
     # Account is an Ethereum account at a particular block.
     type Account {
         # Address is the address owning the account.
@@ -67,6 +62,9 @@ const schema string = `
         data: Bytes!
         # Transaction is the transaction that generated this log entry.
         transaction: Transaction!
+        # Removed is true if the log was reverted by a chain reorganisation. It
+        # is only ever set on logs delivered by the logs subscription.
+        removed: Boolean!
     }
 
     #EIP-2718
@@ -246,6 +244,9 @@ const schema string = `
         rawHeader: Bytes!
         # Raw is the RLP encoding of the block.
         raw: Bytes!
+        # RawReceipts is the canonical encoding of the receipts of all the
+        # transactions in the block, in transaction order.
+        rawReceipts: [Bytes!]!
     }
 
     # CallData represents the data associated with a local contract call.
@@ -359,3 +360,31 @@ const schema string = `
         sendRawTransaction(data: Bytes!): Bytes32!
     }
 `
+
+// subscriptionSchema extends the schema with the subscriptions served over
+// websocket connections. It is parsed into a standalone schema, as the logs
+// subscription would otherwise clash with the logs query on the root resolver.
+const subscriptionSchema string = `
+    schema {
+        query: SubscriptionQuery
+        subscription: Subscription
+    }
+
+    # SubscriptionQuery is the query root of the subscription schema. Queries
+    # sent over websocket connections are executed against the main schema.
+    type SubscriptionQuery {
+        # ChainID returns the current chain ID for transaction replay protection.
+        chainID: BigInt!
+    }
+
+    type Subscription {
+        # NewBlock delivers every block added to the canonical chain, including
+        # the ones replacing earlier blocks during a reorg.
+        newBlock: Block!
+        # Logs delivers the log entries matching the provided filter, as they are
+        # added to or removed from the canonical chain.
+        logs(filter: BlockFilterCriteria): Log!
+        # PendingTransactions delivers the transactions entering the pool.
+        pendingTransactions: Transaction!
+    }
+`
@@ -19,6 +19,7 @@ package graphql
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
@@ -28,15 +29,42 @@ import (
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
 	"github.com/graph-gophers/graphql-go"
 	gqlErrors "github.com/graph-gophers/graphql-go/errors"
 )
 
+// Limits are the restrictions on the operations served by the GraphQL service,
+// protecting the node from queries too expensive to execute. Zero values disable
+// the corresponding limit.
+//
+// The cost of an operation is estimated before it's executed, assuming lists of
+// their expected length, and operations over the limit are rejected right away.
+// The cost of the fields actually resolved is metered during execution too,
+// aborting the operations with longer lists than expected once over the limit.
+// For subscriptions, both apply to every event separately, and the timeout bounds
+// the resolution of every event, defaulting to one second.
+type Limits struct {
+	MaxCost         uint64        // Maximum cost of an operation, or of a subscription event
+	MaxDepth        int           // Maximum nesting depth of the fields selected by an operation
+	Timeout         time.Duration // Maximum execution time of an operation, or of a subscription event
+	MaxResponseSize int           // Maximum size of a response in bytes
+}
+
 type handler struct {
 	Schema *graphql.Schema
+
+	subscriptions *graphql.Schema     // Schema of the subscriptions served over websocket
+	estimator     *costEstimator      // Static cost estimator of the operations
+	upgrader      *websocket.Upgrader // Upgrader of the websocket connections
+	limits        Limits              // Restrictions on the operations served
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isWebsocket(r) {
+		h.serveWebsocket(w, r)
+		return
+	}
 	var params struct {
 		Query         string                 `json:"query"`
 		OperationName string                 `json:"operationName"`
@@ -53,7 +81,11 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		timer     *time.Timer
 		cancel    context.CancelFunc
 	)
-	ctx, cancel = context.WithCancel(ctx)
+	if h.limits.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.limits.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
 	defer cancel()
 
 	if timeout, ok := rpc.ContextRequestTimeout(ctx); ok {
@@ -64,7 +96,10 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 				// Create the timeout response.
 				response := &graphql.Response{
-					Errors: []*gqlErrors.QueryError{{Message: "request timed out"}},
+					Errors: []*gqlErrors.QueryError{{
+						Message:    "request timed out",
+						Extensions: map[string]interface{}{"code": "TIMEOUT"},
+					}},
 				}
 				responseJSON, err := json.Marshal(response)
 				if err != nil {
@@ -87,15 +122,15 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	response := h.Schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
+	response := h.exec(ctx, params.Query, params.OperationName, params.Variables)
 	timer.Stop()
 	responded.Do(func() {
-		responseJSON, err := json.Marshal(response)
+		responseJSON, failed, err := h.encode(response)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if len(response.Errors) > 0 {
+		if failed {
 			w.WriteHeader(http.StatusBadRequest)
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -103,22 +138,107 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// exec runs a query or mutation, unless its estimated cost exceeds the limit.
+// It's aborted once the cost of the fields resolved exceeds the limit too.
+// Subscription operations are rejected by the schema.
+func (h handler) exec(ctx context.Context, query string, operationName string, variables map[string]interface{}) *graphql.Response {
+	if h.limits.MaxCost == 0 {
+		return h.Schema.Exec(ctx, query, operationName, variables)
+	}
+	if err := h.check(query, operationName, variables); err != nil {
+		return &graphql.Response{Errors: []*gqlErrors.QueryError{err}}
+	}
+	ctx, meter := withCostMeter(ctx, h.limits.MaxCost)
+	defer meter.cancel()
+
+	response := h.Schema.Exec(ctx, query, operationName, variables)
+	if errs := meter.errors(); errs != nil {
+		return &graphql.Response{Errors: errs}
+	}
+	return response
+}
+
+// subscribe opens a subscription, unless the estimated cost of its events
+// exceeds the limit. Every event is metered separately, the fields of an event
+// exceeding the limit are left unresolved, failing with the cost error.
+func (h handler) subscribe(ctx context.Context, query string, operationName string, variables map[string]interface{}) (<-chan interface{}, error) {
+	if h.limits.MaxCost > 0 {
+		if err := h.check(query, operationName, variables); err != nil {
+			results := make(chan interface{}, 1)
+			results <- &graphql.Response{Errors: []*gqlErrors.QueryError{err}}
+			close(results)
+			return results, nil
+		}
+		ctx = withEventMeters(ctx, h.limits.MaxCost)
+	}
+	return h.subscriptions.Subscribe(ctx, query, operationName, variables)
+}
+
+// check returns the error to reject an operation with if its estimated cost
+// exceeds the limit. Operations which can't be parsed are left to the schema
+// to reject.
+func (h handler) check(query string, operationName string, variables map[string]interface{}) *gqlErrors.QueryError {
+	if h.estimator == nil {
+		return nil
+	}
+	if cost, ok := h.estimator.estimate(query, operationName, variables); ok && cost > h.limits.MaxCost {
+		return costError(cost, h.limits.MaxCost)
+	}
+	return nil
+}
+
+// encode marshals a response, replacing it with an error if it exceeds the size
+// limit. It also reports whether the response carries errors.
+func (h handler) encode(response *graphql.Response) ([]byte, bool, error) {
+	blob, err := json.Marshal(response)
+	if err != nil {
+		return nil, false, err
+	}
+	if limit := h.limits.MaxResponseSize; limit > 0 && len(blob) > limit {
+		response = &graphql.Response{Errors: []*gqlErrors.QueryError{{
+			Message:    fmt.Sprintf("response size %d exceeds the limit of %d", len(blob), limit),
+			Extensions: map[string]interface{}{"code": "RESPONSE_TOO_LARGE", "size": len(blob), "limit": limit},
+		}}}
+		if blob, err = json.Marshal(response); err != nil {
+			return nil, false, err
+		}
+	}
+	return blob, len(response.Errors) > 0, nil
+}
+
 // New constructs a new GraphQL service instance.
-func New(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cors, vhosts []string) error {
-	_, err := newHandler(stack, backend, filterSystem, cors, vhosts)
+func New(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cors, vhosts []string, limits Limits) error {
+	_, err := newHandler(stack, backend, filterSystem, cors, vhosts, limits)
 	return err
 }
 
-// newHandler returns a new `http.Handler` that will answer GraphQL queries.
-// It additionally exports an interactive query browser on the / endpoint.
-func newHandler(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cors, vhosts []string) (*handler, error) {
+// newHandler returns a new `http.Handler` that will answer GraphQL queries,
+// and subscriptions over websocket connections. It additionally exports an
+// interactive query browser on the / endpoint.
+func newHandler(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cors, vhosts []string, limits Limits) (*handler, error) {
 	q := Resolver{backend, filterSystem}
 
-	s, err := graphql.ParseSchema(schema, &q)
+	tracer := &costTracer{head: func() uint64 {
+		return backend.CurrentHeader().Number.Uint64()
+	}}
+	opts := []graphql.SchemaOpt{graphql.MaxDepth(limits.MaxDepth), graphql.Tracer(tracer)}
+
+	s, err := graphql.ParseSchema(schema, &q, opts...)
 	if err != nil {
 		return nil, err
 	}
-	h := handler{Schema: s}
+	opts = append(opts, graphql.SubscribeResolverTimeout(limits.Timeout))
+	subs, err := graphql.ParseSchema(schema+subscriptionSchema, &subscriptionResolver{r: &q}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	h := handler{
+		Schema:        s,
+		subscriptions: subs,
+		estimator:     newCostEstimator(subs, tracer.head),
+		upgrader:      newUpgrader(cors),
+		limits:        limits,
+	}
 	handler := node.NewHTTPHandlerStack(h, cors, vhosts, nil)
 
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui", GraphiQL{})
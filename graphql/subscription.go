@@ -0,0 +1,125 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// subscriptionResolver is the top-level object of the subscription schema. The
+// subscriptions are fed by the event system shared with the JSON-RPC filter API.
+type subscriptionResolver struct {
+	r *Resolver
+}
+
+// eventSystem returns the event system feeding the subscriptions.
+func (s *subscriptionResolver) eventSystem() *filters.EventSystem {
+	return s.r.filterSystem.EventSystem()
+}
+
+func (s *subscriptionResolver) ChainID(ctx context.Context) (hexutil.Big, error) {
+	return s.r.ChainID(ctx)
+}
+
+func (s *subscriptionResolver) NewBlock(ctx context.Context) (<-chan *Block, error) {
+	headers := make(chan *types.Header)
+	sub := s.eventSystem().SubscribeNewHeads(headers)
+
+	return forward(ctx, sub, headers, func(header *types.Header) []*Block {
+		numberOrHash := rpc.BlockNumberOrHashWithHash(header.Hash(), false)
+		return []*Block{{
+			r:            s.r,
+			numberOrHash: &numberOrHash,
+			hash:         header.Hash(),
+			header:       header,
+		}}
+	}), nil
+}
+
+func (s *subscriptionResolver) Logs(ctx context.Context, args struct{ Filter *BlockFilterCriteria }) (<-chan *Log, error) {
+	var crit ethereum.FilterQuery
+	if args.Filter != nil {
+		if args.Filter.Addresses != nil {
+			crit.Addresses = *args.Filter.Addresses
+		}
+		if args.Filter.Topics != nil {
+			crit.Topics = *args.Filter.Topics
+		}
+	}
+	logs := make(chan []*types.Log)
+	sub, err := s.eventSystem().SubscribeLogs(crit, logs)
+	if err != nil {
+		return nil, err
+	}
+	return forward(ctx, sub, logs, func(logs []*types.Log) []*Log {
+		ret := make([]*Log, 0, len(logs))
+		for _, log := range logs {
+			ret = append(ret, &Log{
+				r:           s.r,
+				transaction: &Transaction{r: s.r, hash: log.TxHash},
+				log:         log,
+			})
+		}
+		return ret
+	}), nil
+}
+
+func (s *subscriptionResolver) PendingTransactions(ctx context.Context) (<-chan *Transaction, error) {
+	txs := make(chan []*types.Transaction)
+	sub := s.eventSystem().SubscribePendingTxs(txs)
+
+	return forward(ctx, sub, txs, func(txs []*types.Transaction) []*Transaction {
+		ret := make([]*Transaction, 0, len(txs))
+		for _, tx := range txs {
+			ret = append(ret, &Transaction{r: s.r, hash: tx.Hash(), tx: tx})
+		}
+		return ret
+	}), nil
+}
+
+// forward relays the events of a filter subscription as the results of a GraphQL
+// subscription. The filter subscription is torn down and the result channel is
+// closed when the context is cancelled.
+func forward[E any, R any](ctx context.Context, sub *filters.Subscription, events <-chan E, convert func(E) []R) <-chan R {
+	results := make(chan R)
+	go func() {
+		defer close(results)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				for _, res := range convert(ev) {
+					select {
+					case results <- res:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
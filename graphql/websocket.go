@@ -0,0 +1,391 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	gqlErrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// Message types of the graphql-ws protocol, as specified in
+// https://github.com/apollographql/subscriptions-transport-ws/blob/master/PROTOCOL.md
+const (
+	gqlConnectionInit      = "connection_init"      // Client -> Server
+	gqlConnectionTerminate = "connection_terminate" // Client -> Server
+	gqlStart               = "start"                // Client -> Server
+	gqlStop                = "stop"                 // Client -> Server
+	gqlConnectionAck       = "connection_ack"       // Server -> Client
+	gqlConnectionError     = "connection_error"     // Server -> Client
+	gqlConnectionKeepAlive = "ka"                   // Server -> Client
+	gqlData                = "data"                 // Server -> Client
+	gqlError               = "error"                // Server -> Client
+	gqlComplete            = "complete"             // Server -> Client
+)
+
+const (
+	wsProtocol         = "graphql-ws"
+	wsKeepAlive        = 30 * time.Second
+	wsWriteTimeout     = 10 * time.Second
+	wsMessageSizeLimit = 1024 * 1024
+)
+
+// wsMessage is the envelope of all messages of the graphql-ws protocol.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsRequest is the payload of a start message.
+type wsRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// newUpgrader creates the websocket upgrader of the GraphQL endpoint. Connections
+// from browsers are only accepted from the same host or from the CORS origins.
+func newUpgrader(cors []string) *websocket.Upgrader {
+	origins := make(map[string]bool)
+	for _, origin := range cors {
+		origins[strings.ToLower(origin)] = true
+	}
+	return &websocket.Upgrader{
+		Subprotocols: []string{wsProtocol},
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" || origins["*"] || origins[strings.ToLower(origin)] {
+				return true
+			}
+			host := strings.TrimPrefix(strings.TrimPrefix(origin, "http://"), "https://")
+			if strings.EqualFold(host, r.Host) {
+				return true
+			}
+			log.Warn("Rejected GraphQL websocket connection", "origin", origin)
+			return false
+		},
+	}
+}
+
+// isSubscription reports whether the operation selected out of the query is a
+// subscription, which is served by the subscription schema. Only the headers of
+// the top level definitions are scanned, the selections are left to the schemas
+// to validate, along with any syntax errors.
+func isSubscription(query string, operationName string) bool {
+	var (
+		depth  int
+		kind   string // Keyword of the current definition, empty until seen
+		name   string // Name of the current definition, if any
+		header = true // Whether the definition's name can still follow
+	)
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			if strings.HasPrefix(query[i:], `"""`) {
+				end := strings.Index(query[i+3:], `"""`)
+				if end < 0 {
+					return false
+				}
+				i += end + 5
+				continue
+			}
+			for i++; i < len(query) && query[i] != '"' && query[i] != '\n'; i++ {
+				if query[i] == '\\' {
+					i++
+				}
+			}
+		case c == '(' || c == '[' || (c == '{' && depth > 0):
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == '{':
+			if kind == "" {
+				kind = "query" // anonymous query shorthand
+			}
+			depth, header = 1, false
+		case c == '}':
+			if depth--; depth == 0 {
+				if kind != "fragment" && (operationName == "" || name == operationName) {
+					return kind == "subscription"
+				}
+				kind, name, header = "", "", true
+			}
+		case c == '@' && depth == 0:
+			header = false
+		case c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+			start := i
+			for i+1 < len(query) && isNameChar(query[i+1]) {
+				i++
+			}
+			if depth != 0 || !header {
+				continue
+			}
+			if kind == "" {
+				kind = query[start : i+1]
+			} else if name == "" {
+				name, header = query[start:i+1], false
+			}
+		}
+	}
+	return false
+}
+
+// isNameChar reports whether c may appear within a GraphQL name.
+func isNameChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// isWebsocket checks the header of an http request for a websocket upgrade request.
+func isWebsocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// wsConn serves the GraphQL operations of a single websocket connection.
+type wsConn struct {
+	conn    *websocket.Conn
+	handler handler
+
+	ops   map[string]*context.CancelFunc // Cancel functions of the running operations
+	opsMu sync.Mutex
+	wg    sync.WaitGroup
+
+	writeMu sync.Mutex
+}
+
+// serveWebsocket upgrades the request to a websocket connection and serves the
+// operations sent over it until the connection is closed.
+func (h handler) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("GraphQL websocket upgrade failed", "err", err)
+		return
+	}
+	c := &wsConn{
+		conn:    conn,
+		handler: h,
+		ops:     make(map[string]*context.CancelFunc),
+	}
+	c.run()
+}
+
+// run reads the messages of the client until the connection is terminated, and
+// tears down all the operations still running afterwards.
+func (c *wsConn) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		c.conn.Close()
+		c.wg.Wait()
+	}()
+	c.conn.SetReadLimit(wsMessageSizeLimit)
+
+	var initialised bool
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch {
+		case msg.Type == gqlConnectionInit:
+			if !initialised {
+				initialised = true
+				c.send("", gqlConnectionAck, nil)
+				c.wg.Add(1)
+				go c.keepAlive(ctx)
+			}
+		case msg.Type == gqlConnectionTerminate:
+			return
+		case !initialised:
+			c.send("", gqlConnectionError, &gqlErrors.QueryError{Message: "connection not initialised"})
+			return
+		case msg.Type == gqlStart:
+			c.start(ctx, msg)
+		case msg.Type == gqlStop:
+			c.stop(msg.ID)
+		default:
+			c.send(msg.ID, gqlError, []*gqlErrors.QueryError{{Message: fmt.Sprintf("unknown message type %q", msg.Type)}})
+		}
+	}
+}
+
+// start runs the operation of a start message in the background. Subscriptions
+// are executed against the subscription schema, everything else against the
+// main one.
+func (c *wsConn) start(ctx context.Context, msg wsMessage) {
+	var req wsRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		c.send(msg.ID, gqlError, []*gqlErrors.QueryError{{Message: err.Error()}})
+		return
+	}
+	c.opsMu.Lock()
+	if _, ok := c.ops[msg.ID]; ok {
+		c.opsMu.Unlock()
+		c.send(msg.ID, gqlError, []*gqlErrors.QueryError{{Message: fmt.Sprintf("operation %q already running", msg.ID)}})
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	op := &cancel
+	c.ops[msg.ID] = op
+	c.opsMu.Unlock()
+
+	// Subscriptions run until stopped, queries and mutations are bounded by the
+	// execution timeout. Both are served in the background, leaving the read
+	// loop free to handle the messages of other operations.
+	if !isSubscription(req.Query, req.OperationName) {
+		c.wg.Add(1)
+		go c.exec(ctx, msg.ID, op, req)
+		return
+	}
+	results, err := c.handler.subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		c.finish(msg.ID, op)
+		c.send(msg.ID, gqlError, []*gqlErrors.QueryError{{Message: err.Error()}})
+		return
+	}
+	c.wg.Add(1)
+	go c.forward(ctx, msg.ID, op, results)
+}
+
+// exec runs a query or mutation and sends its response, unless the operation was
+// stopped in the meantime.
+func (c *wsConn) exec(ctx context.Context, id string, op *context.CancelFunc, req wsRequest) {
+	defer c.wg.Done()
+	defer c.finish(id, op)
+
+	execCtx, cancelExec := ctx, context.CancelFunc(func() {})
+	if c.handler.limits.Timeout > 0 {
+		execCtx, cancelExec = context.WithTimeout(ctx, c.handler.limits.Timeout)
+	}
+	response := c.handler.exec(execCtx, req.Query, req.OperationName, req.Variables)
+	cancelExec()
+
+	if ctx.Err() != nil {
+		return
+	}
+	c.sendResponse(id, response)
+	c.send(id, gqlComplete, nil)
+}
+
+// forward sends the events of a subscription until it ends or is stopped.
+func (c *wsConn) forward(ctx context.Context, id string, op *context.CancelFunc, results <-chan interface{}) {
+	defer c.wg.Done()
+	defer c.finish(id, op)
+
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				c.send(id, gqlComplete, nil)
+				return
+			}
+			c.sendResponse(id, result.(*graphql.Response))
+		case <-ctx.Done():
+			// Wait for the executor to notice the cancellation too
+			for range results {
+			}
+			return
+		}
+	}
+}
+
+// sendResponse sends the response of an operation as a data message.
+func (c *wsConn) sendResponse(id string, response *graphql.Response) {
+	blob, _, err := c.handler.encode(response)
+	if err != nil {
+		c.send(id, gqlError, []*gqlErrors.QueryError{{Message: err.Error()}})
+		return
+	}
+	c.send(id, gqlData, json.RawMessage(blob))
+}
+
+// stop cancels a running operation.
+func (c *wsConn) stop(id string) {
+	c.opsMu.Lock()
+	defer c.opsMu.Unlock()
+
+	if cancel, ok := c.ops[id]; ok {
+		(*cancel)()
+		delete(c.ops, id)
+	}
+}
+
+// finish releases the resources of a terminated operation, unless its id was
+// already reused by a newer one.
+func (c *wsConn) finish(id string, op *context.CancelFunc) {
+	c.opsMu.Lock()
+	defer c.opsMu.Unlock()
+
+	(*op)()
+	if c.ops[id] == op {
+		delete(c.ops, id)
+	}
+}
+
+// keepAlive periodically sends keep-alive messages to the client, until the
+// connection is closed.
+func (c *wsConn) keepAlive(ctx context.Context) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(wsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		c.send("", gqlConnectionKeepAlive, nil)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// send writes a message to the client. Failing writes close the connection,
+// which terminates the read loop and with it all running operations.
+func (c *wsConn) send(id string, typ string, payload interface{}) {
+	msg := wsMessage{ID: id, Type: typ}
+	if payload != nil {
+		blob, err := json.Marshal(payload)
+		if err != nil {
+			log.Warn("Failed to encode GraphQL websocket message", "type", typ, "err", err)
+			return
+		}
+		msg.Payload = blob
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		log.Debug("GraphQL websocket write failed", "err", err)
+		c.conn.Close()
+	}
+}
@@ -183,6 +183,21 @@ type LogFilterer interface {
 	SubscribeFilterLogs(ctx context.Context, q FilterQuery, ch chan<- types.Log) (Subscription, error)
 }
 
+// RewardQuery contains options for reward and purge event filtering. An empty query
+// matches all events. Otherwise rewards match if they were credited to one of the
+// validators or covenant nfts, and purges match if they moved one of the nfts.
+type RewardQuery struct {
+	Addresses []common.Address // restricts matches to rewards of specific validators
+	TokenIDs  []uint64         // restricts matches to specific covenant nfts
+}
+
+// BlockReceiptsQuery contains options for streaming the receipts of a range of
+// blocks.
+type BlockReceiptsQuery struct {
+	FromBlock *big.Int // beginning of the range, nil means the current head
+	ToBlock   *big.Int // end of the range, nil means no end
+}
+
 // TransactionSender wraps transaction sending. The SendTransaction method injects a
 // signed transaction into the pending transaction pool for execution. If the transaction
 // was a contract creation, the TransactionReceipt method can be used to retrieve the
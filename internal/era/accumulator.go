@@ -0,0 +1,121 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// accumulatorDepth is the depth of the merkle tree backing the accumulator
+// list, i.e. log2(MaxEra1Size).
+const accumulatorDepth = 13
+
+// zeroHashes holds the roots of empty subtrees of each height, used to pad
+// the accumulator tree up to its fixed capacity.
+var zeroHashes [accumulatorDepth + 1][32]byte
+
+func init() {
+	for i := 1; i <= accumulatorDepth; i++ {
+		zeroHashes[i] = hashPair(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+// ComputeAccumulator calculates the SSZ hash tree root of the Era1
+// accumulator of header records, defined as
+//
+//	HeaderRecord = { block_hash: Bytes32, total_difficulty: Uint256 }
+//	Accumulator  = List[HeaderRecord, MaxEra1Size]
+func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, error) {
+	if len(hashes) != len(tds) {
+		return common.Hash{}, errors.New("must have equal number hashes as td values")
+	}
+	if len(hashes) > MaxEra1Size {
+		return common.Hash{}, fmt.Errorf("too many records: have %d, max %d", len(hashes), MaxEra1Size)
+	}
+	leaves := make([][32]byte, len(hashes))
+	for i := range hashes {
+		td, err := encodeTD(tds[i])
+		if err != nil {
+			return common.Hash{}, err
+		}
+		leaves[i] = hashPair(hashes[i], td)
+	}
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(hashes)))
+	return hashPair(merkleize(leaves), length), nil
+}
+
+// merkleize computes the root of the fixed-capacity binary merkle tree over
+// the given leaves, padding the missing ones with zero hashes.
+func merkleize(layer [][32]byte) [32]byte {
+	for depth := 0; depth < accumulatorDepth; depth++ {
+		if len(layer) == 0 {
+			return zeroHashes[accumulatorDepth]
+		}
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[depth])
+		}
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+	}
+	if len(layer) == 0 {
+		return zeroHashes[accumulatorDepth]
+	}
+	return layer[0]
+}
+
+// hashPair returns the sha256 hash of the concatenation of two chunks.
+func hashPair(a, b [32]byte) [32]byte {
+	h := sha256.New()
+	h.Write(a[:])
+	h.Write(b[:])
+
+	var out [32]byte
+	h.Sum(out[:0])
+	return out
+}
+
+// encodeTD encodes a total difficulty as a 32 byte little-endian uint256.
+func encodeTD(td *big.Int) ([32]byte, error) {
+	var out [32]byte
+	if td == nil || td.Sign() < 0 || td.BitLen() > 256 {
+		return out, fmt.Errorf("invalid total difficulty: %v", td)
+	}
+	be := td.FillBytes(make([]byte, 32))
+	for i := range be {
+		out[i] = be[31-i]
+	}
+	return out, nil
+}
+
+// decodeTD decodes a 32 byte little-endian uint256 total difficulty.
+func decodeTD(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[i] = b[len(b)-1-i]
+	}
+	return new(big.Int).SetBytes(be)
+}
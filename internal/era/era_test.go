@@ -0,0 +1,295 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// makeChain creates a linked chain of n blocks starting at number start, each
+// carrying a single transaction and receipt.
+func makeChain(start uint64, n int) ([]*types.Block, []types.Receipts, []*big.Int) {
+	var (
+		blocks   []*types.Block
+		receipts []types.Receipts
+		tds      []*big.Int
+		parent   common.Hash
+		td       = big.NewInt(int64(start))
+	)
+	for i := 0; i < n; i++ {
+		num := start + uint64(i)
+		tx := types.NewTransaction(num, common.Address{byte(i)}, big.NewInt(int64(i)), 21000, big.NewInt(1), nil)
+		receipt := types.NewReceipt(nil, i%2 == 0, 21000)
+		receipt.Logs = []*types.Log{{Address: common.Address{0x01}, Topics: []common.Hash{{byte(i)}}}}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     new(big.Int).SetUint64(num),
+			Difficulty: big.NewInt(int64(i) + 1),
+			GasLimit:   30000000,
+		}
+		block := types.NewBlock(header, []*types.Transaction{tx}, nil, []*types.Receipt{receipt}, trie.NewStackTrie(nil))
+		td = new(big.Int).Add(td, header.Difficulty)
+
+		blocks = append(blocks, block)
+		receipts = append(receipts, types.Receipts{receipt})
+		tds = append(tds, td)
+		parent = block.Hash()
+	}
+	return blocks, receipts, tds
+}
+
+func TestEra1Builder(t *testing.T) {
+	var (
+		f                     = new(bytes.Buffer)
+		builder               = NewBuilder(f)
+		blocks, receipts, tds = makeChain(100, 128)
+	)
+	for i, block := range blocks {
+		if err := builder.Add(block, receipts[i], tds[i]); err != nil {
+			t.Fatalf("error adding entry: %v", err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("error finalizing era1: %v", err)
+	}
+	e, err := From(nopCloser{bytes.NewReader(f.Bytes())})
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	if e.Start() != 100 || e.Count() != 128 {
+		t.Fatalf("wrong range: start %d count %d", e.Start(), e.Count())
+	}
+	if have, err := e.Accumulator(); err != nil || have != root {
+		t.Fatalf("accumulator mismatch: have %x (%v), want %x", have, err, root)
+	}
+	if td, err := e.InitialTD(); err != nil || td.Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("initial td mismatch: have %v (%v), want 100", td, err)
+	}
+	// Check random access.
+	for i, want := range blocks {
+		num := want.NumberU64()
+		block, err := e.GetBlockByNumber(num)
+		if err != nil {
+			t.Fatalf("error reading block %d: %v", num, err)
+		}
+		if block.Hash() != want.Hash() || block.Transactions()[0].Hash() != want.Transactions()[0].Hash() {
+			t.Fatalf("block %d mismatch", num)
+		}
+		body, err := e.GetRawBodyByNumber(num)
+		if err != nil {
+			t.Fatalf("error reading body %d: %v", num, err)
+		}
+		if wantBody, _ := rlp.EncodeToBytes(want.Body()); !bytes.Equal(body, wantBody) {
+			t.Fatalf("body %d mismatch", num)
+		}
+		rawReceipts, err := e.GetRawReceiptsByNumber(num)
+		if err != nil {
+			t.Fatalf("error reading receipts %d: %v", num, err)
+		}
+		if wantReceipts, _ := rlp.EncodeToBytes(receipts[i]); !bytes.Equal(rawReceipts, wantReceipts) {
+			t.Fatalf("receipts %d mismatch", num)
+		}
+	}
+	if _, err := e.GetBlockByNumber(99); err == nil {
+		t.Fatalf("expected error reading block before start")
+	}
+	if _, err := e.GetBlockByNumber(228); err == nil {
+		t.Fatalf("expected error reading block past end")
+	}
+	// Check sequential access.
+	it, err := NewIterator(e)
+	if err != nil {
+		t.Fatalf("error creating iterator: %v", err)
+	}
+	var i int
+	for it.Next() {
+		if err := it.Error(); err != nil {
+			t.Fatalf("iterator error: %v", err)
+		}
+		block, rs, err := it.BlockAndReceipts()
+		if err != nil {
+			t.Fatalf("error reading block %d: %v", it.Number(), err)
+		}
+		if block.Hash() != blocks[i].Hash() {
+			t.Fatalf("block %d mismatch", it.Number())
+		}
+		if types.DeriveSha(rs, trie.NewStackTrie(nil)) != block.ReceiptHash() {
+			t.Fatalf("receipts %d mismatch", it.Number())
+		}
+		td, err := it.TotalDifficulty()
+		if err != nil || td.Cmp(tds[i]) != 0 {
+			t.Fatalf("td %d mismatch: have %v (%v), want %v", it.Number(), td, err, tds[i])
+		}
+		i++
+	}
+	if i != len(blocks) {
+		t.Fatalf("iterated %d blocks, want %d", i, len(blocks))
+	}
+	// Check verification.
+	if err := Verify(e, root); err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	if err := Verify(e, common.Hash{0x01}); err == nil {
+		t.Fatalf("verification passed with wrong root")
+	}
+}
+
+func TestEra1BuilderNonContiguous(t *testing.T) {
+	var (
+		builder               = NewBuilder(io.Discard)
+		blocks, receipts, tds = makeChain(0, 3)
+	)
+	if err := builder.Add(blocks[0], receipts[0], tds[0]); err != nil {
+		t.Fatalf("error adding entry: %v", err)
+	}
+	if err := builder.Add(blocks[2], receipts[2], tds[2]); err == nil {
+		t.Fatalf("expected error adding non-contiguous block")
+	}
+}
+
+func TestEra1Tampered(t *testing.T) {
+	var (
+		f                     = new(bytes.Buffer)
+		builder               = NewBuilder(f)
+		blocks, receipts, tds = makeChain(0, 4)
+	)
+	for i, block := range blocks {
+		// Swap the receipts of the last block with those of the first one.
+		rs := receipts[i]
+		if i == len(blocks)-1 {
+			rs = receipts[0]
+		}
+		if err := builder.Add(block, rs, tds[i]); err != nil {
+			t.Fatalf("error adding entry: %v", err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("error finalizing era1: %v", err)
+	}
+	e, err := From(nopCloser{bytes.NewReader(f.Bytes())})
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	if err := Verify(e, root); err == nil {
+		t.Fatalf("verification passed with mismatching receipts")
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		Filename("mainnet", 0, common.Hash{0x01}),
+		Filename("mainnet", 1, common.Hash{0x02}),
+		Filename("mainnet", 2, common.Hash{0x04}),
+		Filename("sepolia", 0, common.Hash{0x03}),
+		"checksums.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := ReadDir(dir, "mainnet")
+	if err != nil {
+		t.Fatalf("error reading dir: %v", err)
+	}
+	if len(files) != 3 || files[0] != "mainnet-00000-01000000.era1" || files[1] != "mainnet-00001-02000000.era1" {
+		t.Fatalf("wrong files: %v", files)
+	}
+	// Archives need not start at the first epoch.
+	os.Remove(filepath.Join(dir, files[0]))
+	if files, err := ReadDir(dir, "mainnet"); err != nil || len(files) != 2 {
+		t.Fatalf("wrong files: %v (%v)", files, err)
+	}
+	os.Remove(filepath.Join(dir, "mainnet-00001-02000000.era1"))
+	os.WriteFile(filepath.Join(dir, Filename("mainnet", 4, common.Hash{0x05})), nil, 0644)
+	if _, err := ReadDir(dir, "mainnet"); err == nil {
+		t.Fatalf("expected error for missing epoch")
+	}
+}
+
+// TestAccumulator checks the padded merkleization against a naive computation
+// over the full fixed-size tree.
+func TestAccumulator(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 100, MaxEra1Size} {
+		var (
+			hashes = make([]common.Hash, n)
+			tds    = make([]*big.Int, n)
+		)
+		for i := 0; i < n; i++ {
+			hashes[i] = common.BigToHash(big.NewInt(int64(i + 1)))
+			tds[i] = big.NewInt(int64(i * 1000))
+		}
+		have, err := ComputeAccumulator(hashes, tds)
+		if err != nil {
+			t.Fatalf("n=%d: error computing accumulator: %v", n, err)
+		}
+		if want := naiveAccumulator(hashes, tds); have != want {
+			t.Fatalf("n=%d: accumulator mismatch: have %x, want %x", n, have, want)
+		}
+	}
+	if _, err := ComputeAccumulator(make([]common.Hash, MaxEra1Size+1), make([]*big.Int, MaxEra1Size+1)); err == nil {
+		t.Fatalf("expected error for oversized accumulator")
+	}
+}
+
+func naiveAccumulator(hashes []common.Hash, tds []*big.Int) common.Hash {
+	layer := make([][]byte, MaxEra1Size)
+	for i := range layer {
+		layer[i] = make([]byte, 32)
+		if i < len(hashes) {
+			td := make([]byte, 32)
+			be := tds[i].FillBytes(make([]byte, 32))
+			for j := range be {
+				td[j] = be[31-j]
+			}
+			sum := sha256.Sum256(append(hashes[i].Bytes(), td...))
+			layer[i] = sum[:]
+		}
+	}
+	for len(layer) > 1 {
+		next := make([][]byte, len(layer)/2)
+		for i := range next {
+			sum := sha256.Sum256(append(append([]byte{}, layer[2*i]...), layer[2*i+1]...))
+			next[i] = sum[:]
+		}
+		layer = next
+	}
+	length := make([]byte, 32)
+	length[0], length[1] = byte(len(hashes)), byte(len(hashes)>>8)
+	return sha256.Sum256(append(layer[0], length...))
+}
+
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,115 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Verify checks the integrity of an Era1 archive. Every block's body and
+// receipts must match the roots committed to in its header, blocks must link
+// to their parents, total difficulties must accumulate the header difficulties
+// and the accumulator computed over the block hashes and total difficulties
+// must equal both the one stored in the archive and want.
+func Verify(e *Era, want common.Hash) error {
+	stored, err := e.Accumulator()
+	if err != nil {
+		return fmt.Errorf("error reading accumulator: %w", err)
+	}
+	if stored != want {
+		return fmt.Errorf("accumulator mismatch: have %x, want %x", stored, want)
+	}
+	td, err := e.InitialTD()
+	if err != nil {
+		return fmt.Errorf("error reading initial total difficulty: %w", err)
+	}
+	it, err := NewIterator(e)
+	if err != nil {
+		return err
+	}
+	var (
+		hashes = make([]common.Hash, 0, e.Count())
+		tds    = make([]*big.Int, 0, e.Count())
+		parent common.Hash
+	)
+	for it.Next() {
+		if err := it.Error(); err != nil {
+			return err
+		}
+		block, receipts, err := it.BlockAndReceipts()
+		if err != nil {
+			return fmt.Errorf("error reading block %d: %w", it.Number(), err)
+		}
+		if err := verifyBlock(block, receipts); err != nil {
+			return err
+		}
+		if len(hashes) > 0 && block.ParentHash() != parent {
+			return fmt.Errorf("block %d: parent hash mismatch: have %x, want %x", block.NumberU64(), block.ParentHash(), parent)
+		}
+		blockTd, err := it.TotalDifficulty()
+		if err != nil {
+			return fmt.Errorf("error reading total difficulty of block %d: %w", block.NumberU64(), err)
+		}
+		td = new(big.Int).Add(td, block.Difficulty())
+		if blockTd.Cmp(td) != 0 {
+			return fmt.Errorf("block %d: total difficulty mismatch: have %v, want %v", block.NumberU64(), blockTd, td)
+		}
+		parent = block.Hash()
+		hashes = append(hashes, parent)
+		tds = append(tds, blockTd)
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if uint64(len(hashes)) != e.Count() {
+		return fmt.Errorf("block count mismatch: have %d, want %d", len(hashes), e.Count())
+	}
+	root, err := ComputeAccumulator(hashes, tds)
+	if err != nil {
+		return err
+	}
+	if root != want {
+		return fmt.Errorf("computed accumulator mismatch: have %x, want %x", root, want)
+	}
+	return nil
+}
+
+// verifyBlock checks that the body and receipts of a block match the roots in
+// its header.
+func verifyBlock(block *types.Block, receipts types.Receipts) error {
+	header := block.Header()
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+		return fmt.Errorf("block %d: transaction root mismatch: have %x, want %x", block.NumberU64(), hash, header.TxHash)
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("block %d: uncle root mismatch: have %x, want %x", block.NumberU64(), hash, header.UncleHash)
+	}
+	if header.WithdrawalsHash != nil {
+		if hash := types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *header.WithdrawalsHash {
+			return fmt.Errorf("block %d: withdrawals root mismatch: have %x, want %x", block.NumberU64(), hash, *header.WithdrawalsHash)
+		}
+	}
+	if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != header.ReceiptHash {
+		return fmt.Errorf("block %d: receipt root mismatch: have %x, want %x", block.NumberU64(), hash, header.ReceiptHash)
+	}
+	return nil
+}
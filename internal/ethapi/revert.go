@@ -0,0 +1,257 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// revertErrorData is the decoded revert of a call, returned by debug_callRevert
+// and as the error data of eth_call if requested.
+// Besides the raw revert data it carries the decoded reason, which is either an
+// Error(string) message, a Panic(uint256) code or a custom error declared in a
+// registered ABI, and the call frame in which the revert originated.
+type revertErrorData struct {
+	Data      hexutil.Bytes          `json:"data"`
+	Reason    string                 `json:"reason,omitempty"`
+	PanicCode *hexutil.Big           `json:"panicCode,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+	Address   *common.Address        `json:"address,omitempty"`
+	Depth     int                    `json:"depth,omitempty"`
+}
+
+// newRevertErrorData decodes the revert data of a reverted call. The origin is
+// the frame reported by the call's revertTracer, its ABI is preferred when
+// looking up custom errors.
+func newRevertErrorData(result *core.ExecutionResult, origin *revertOrigin, abis *abiRegistry) *revertErrorData {
+	data := &revertErrorData{Data: result.Revert()}
+	if origin != nil {
+		data.Address = &origin.address
+		data.Depth = origin.depth
+	}
+	if reason, errUnpack := abi.UnpackRevert(data.Data); errUnpack == nil {
+		data.Reason = reason
+	} else if code, errUnpack := abi.UnpackPanic(data.Data); errUnpack == nil {
+		data.PanicCode = (*hexutil.Big)(code)
+		data.Reason = abi.PanicReason(code)
+	} else if e, args := abis.unpackError(data.Address, data.Data); e != nil {
+		data.Error = e.Sig
+		data.Args = make(map[string]interface{}, len(args))
+		for name, arg := range args {
+			data.Args[name] = formatErrorArg(arg)
+		}
+	}
+	return data
+}
+
+// formatErrorArg converts an unpacked error argument into a form that is
+// marshalled the same way as other RPC values, e.g. big integers and byte
+// blobs as hex strings.
+func formatErrorArg(arg interface{}) interface{} {
+	switch v := arg.(type) {
+	case *big.Int:
+		return (*hexutil.Big)(v)
+	case []byte:
+		return hexutil.Bytes(v)
+	}
+	if val := reflect.ValueOf(arg); val.Kind() == reflect.Array && val.Type().Elem().Kind() == reflect.Uint8 {
+		blob := make([]byte, val.Len())
+		reflect.Copy(reflect.ValueOf(blob), val)
+		return hexutil.Bytes(blob)
+	}
+	return arg
+}
+
+// abiRegistry is an in-memory set of contract ABIs used to decode custom
+// errors of reverted calls.
+type abiRegistry struct {
+	abis map[common.Address]*abi.ABI
+	lock sync.RWMutex
+}
+
+func newABIRegistry() *abiRegistry {
+	return &abiRegistry{abis: make(map[common.Address]*abi.ABI)}
+}
+
+// unpackError decodes revert data as a custom error. The ABI of the contract
+// the revert originated in is tried first, falling back to every registered
+// ABI to cover libraries and proxies whose errors share declarations.
+func (r *abiRegistry) unpackError(addr *common.Address, data []byte) (*abi.Error, map[string]interface{}) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if addr != nil {
+		if contract, ok := r.abis[*addr]; ok {
+			if e, args, err := contract.UnpackError(data); err == nil {
+				return e, args
+			}
+		}
+	}
+	for _, contract := range r.abis {
+		if e, args, err := contract.UnpackError(data); err == nil {
+			return e, args
+		}
+	}
+	return nil, nil
+}
+
+// RevertAPI decodes the reverts of calls, using the registered contract ABIs to
+// decode custom errors.
+type RevertAPI struct {
+	b    Backend
+	abis *abiRegistry
+}
+
+// NewRevertAPI creates a new revert decoding API, registering the ABIs into the
+// given registry.
+func NewRevertAPI(b Backend, abis *abiRegistry) *RevertAPI {
+	return &RevertAPI{b: b, abis: abis}
+}
+
+// CallRevert executes the given call like eth_call, and decodes its revert. Any
+// revert reason, panic code or custom error is returned along with the call frame
+// the revert originated in. Nil is returned if the call didn't revert.
+func (api *RevertAPI) CallRevert(ctx context.Context, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride) (*revertErrorData, error) {
+	tracer := new(revertTracer)
+	result, err := doCall(ctx, api.b, args, blockNrOrHash, overrides, api.b.RPCEVMTimeout(), api.b.RPCGasCap(), tracer)
+	if err != nil {
+		return nil, err
+	}
+	if !errors.Is(result.Err, vm.ErrExecutionReverted) {
+		return nil, nil
+	}
+	return newRevertErrorData(result, tracer.origin, api.abis), nil
+}
+
+// RegisterABI registers the JSON ABI of the contract at the given address,
+// replacing any previously registered one. Registrations are not persisted
+// across restarts.
+func (api *RevertAPI) RegisterABI(address common.Address, abiJSON string) error {
+	contract, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return err
+	}
+	api.abis.lock.Lock()
+	defer api.abis.lock.Unlock()
+
+	api.abis.abis[address] = &contract
+	return nil
+}
+
+// UnregisterABI removes the ABI of the contract at the given address and
+// reports whether one was registered.
+func (api *RevertAPI) UnregisterABI(address common.Address) bool {
+	api.abis.lock.Lock()
+	defer api.abis.lock.Unlock()
+
+	_, ok := api.abis.abis[address]
+	delete(api.abis.abis, address)
+	return ok
+}
+
+// RegisteredABIs returns the addresses of the contracts with a registered ABI.
+func (api *RevertAPI) RegisteredABIs() []common.Address {
+	api.abis.lock.RLock()
+	defer api.abis.lock.RUnlock()
+
+	addrs := make([]common.Address, 0, len(api.abis.abis))
+	for addr := range api.abis.abis {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
+
+// revertOrigin is the call frame a revert originated in.
+type revertOrigin struct {
+	address common.Address
+	depth   int // call depth of the frame, 1 being the top level call
+	data    []byte
+}
+
+// revertTracer is an EVM logger which tracks the call frame a revert
+// originated in. Revert data which is propagated unchanged by the callers of
+// a reverted frame is attributed to the innermost frame.
+type revertTracer struct {
+	frames   []common.Address // addresses of the executing call frames
+	origin   *revertOrigin
+	bubbling bool // whether the origin's revert data is being propagated
+}
+
+func (t *revertTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (t *revertTracer) CaptureTxEnd(restGas uint64) {}
+
+func (t *revertTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames[:0], to)
+}
+
+func (t *revertTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.captureExit(output, err)
+}
+
+func (t *revertTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, to)
+}
+
+func (t *revertTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.captureExit(output, err)
+}
+
+func (t *revertTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *revertTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// captureExit pops the current call frame, recording it as the revert origin
+// unless it merely propagates the revert data of the previous origin.
+func (t *revertTracer) captureExit(output []byte, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	depth := len(t.frames)
+	addr := t.frames[depth-1]
+	t.frames = t.frames[:depth-1]
+
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		t.bubbling = false
+		return
+	}
+	if t.bubbling && t.origin.depth > depth && bytes.Equal(output, t.origin.data) {
+		return
+	}
+	t.origin = &revertOrigin{address: addr, depth: depth, data: common.CopyBytes(output)}
+	t.bubbling = true
+}
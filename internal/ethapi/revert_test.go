@@ -0,0 +1,196 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func (b *simBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	blockContext := core.NewEVMBlockContext(header, nil, &header.Coinbase)
+	return vm.NewEVM(blockContext, core.NewEVMTxContext(msg), state, b.config, *vmConfig), state.Error, nil
+}
+
+var (
+	revFailing = common.Address{0x02, 0x01} // reverts with Failure(7)
+	revProxy   = common.Address{0x02, 0x02} // calls revFailing and propagates its revert data
+	revPanic   = common.Address{0x02, 0x03} // reverts with Panic(0x11)
+	revCatcher = common.Address{0x02, 0x04} // calls revFailing, then reverts with Panic(0x01)
+)
+
+// revertCode returns code which reverts with the given selector and a single
+// word argument.
+func revertCode(sig string, arg byte) string {
+	return fmt.Sprintf("0x63%x60e01b60005260%02x60045260246000fd", crypto.Keccak256([]byte(sig))[:4], arg)
+}
+
+// callCode returns code which calls the given address, discarding the result.
+func callCode(addr common.Address) string {
+	return fmt.Sprintf("0x60006000600060006000%x5af150", append([]byte{0x73}, addr.Bytes()...))
+}
+
+// revertOverrides deploys the reverting test contracts.
+var revertOverrides = &StateOverride{
+	revFailing: {Code: simCode(revertCode("Failure(uint256)", 7))},
+	revProxy:   {Code: simCode(callCode(revFailing) + "3d600060003e3d6000fd")},
+	revPanic:   {Code: simCode(revertCode("Panic(uint256)", 0x11))},
+	revCatcher: {Code: simCode(callCode(revFailing) + revertCode("Panic(uint256)", 0x01)[2:])},
+}
+
+func revertCall(t *testing.T, api *RevertAPI, to common.Address) *revertErrorData {
+	t.Helper()
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	data, err := api.CallRevert(context.Background(), TransactionArgs{From: &simAccountA, To: &to}, latest, revertOverrides)
+	if err != nil {
+		t.Fatalf("call to %x: unexpected error: %v", to, err)
+	}
+	if data == nil {
+		t.Fatalf("call to %x: revert not reported", to)
+	}
+	return data
+}
+
+// Tests that eth_call reports reverts with the hex revert data, decoding the
+// reason into the error message.
+func TestCallRevertError(t *testing.T) {
+	api := NewBlockChainAPI(newSimBackend(t), newABIRegistry())
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	tests := []struct {
+		to      common.Address
+		message string
+	}{
+		{revProxy, "execution reverted"},
+		{revPanic, "execution reverted: panic: arithmetic underflow or overflow (0x11)"},
+	}
+	for _, tt := range tests {
+		_, err := api.Call(context.Background(), TransactionArgs{From: &simAccountA, To: &tt.to}, latest, revertOverrides, nil)
+
+		var revErr *revertError
+		if !errors.As(err, &revErr) {
+			t.Fatalf("call to %x: unexpected error: %v", tt.to, err)
+		}
+		if revErr.Error() != tt.message {
+			t.Errorf("call to %x: error message mismatch: have %q, want %q", tt.to, revErr.Error(), tt.message)
+		}
+		if data, ok := revErr.ErrorData().(string); !ok || len(data) != 2+2*36 {
+			t.Errorf("call to %x: error data mismatch: have %v", tt.to, revErr.ErrorData())
+		}
+	}
+}
+
+// Tests that debug_callRevert reports the decoded revert reason along with the
+// call frame the revert originated in.
+func TestCallRevertErrorData(t *testing.T) {
+	api := NewRevertAPI(newSimBackend(t), newABIRegistry())
+
+	// Successful calls report no revert
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if data, err := api.CallRevert(context.Background(), TransactionArgs{From: &simAccountA, To: &simAccountB}, latest, nil); err != nil || data != nil {
+		t.Fatalf("successful call reported revert: %v, %v", data, err)
+	}
+	// Custom errors can't be decoded without an ABI
+	data := revertCall(t, api, revProxy)
+	if data.Address == nil || *data.Address != revFailing || data.Depth != 2 {
+		t.Errorf("origin mismatch: have %v at depth %d, want %x at depth 2", data.Address, data.Depth, revFailing)
+	}
+	if data.Error != "" || len(data.Data) != 36 {
+		t.Errorf("revert data mismatch: error %q, data %x", data.Error, data.Data)
+	}
+	// Register the ABI of the failing contract and decode the custom error
+	abiJSON := `[{"type":"error","name":"Failure","inputs":[{"name":"code","type":"uint256"}]}]`
+	if err := api.RegisterABI(revFailing, abiJSON); err != nil {
+		t.Fatalf("failed to register ABI: %v", err)
+	}
+	data = revertCall(t, api, revProxy)
+	if data.Error != "Failure(uint256)" {
+		t.Errorf("custom error mismatch: have %q", data.Error)
+	}
+	if code, ok := data.Args["code"].(*hexutil.Big); !ok || code.ToInt().Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("custom error args mismatch: have %v", data.Args)
+	}
+	// Panics are decoded regardless of any ABI
+	data = revertCall(t, api, revPanic)
+	if data.PanicCode == nil || data.PanicCode.ToInt().Uint64() != 0x11 || data.Reason != "arithmetic underflow or overflow" {
+		t.Errorf("panic mismatch: have code %v, reason %q", data.PanicCode, data.Reason)
+	}
+	if data.Address == nil || *data.Address != revPanic || data.Depth != 1 {
+		t.Errorf("origin mismatch: have %v at depth %d, want %x at depth 1", data.Address, data.Depth, revPanic)
+	}
+	// Reverts caught by the caller are not reported as the origin
+	data = revertCall(t, api, revCatcher)
+	if data.Address == nil || *data.Address != revCatcher || data.Depth != 1 {
+		t.Errorf("origin mismatch: have %v at depth %d, want %x at depth 1", data.Address, data.Depth, revCatcher)
+	}
+	if data.PanicCode == nil || data.PanicCode.ToInt().Uint64() != 0x01 {
+		t.Errorf("panic code mismatch: have %v", data.PanicCode)
+	}
+}
+
+// Tests that eth_call returns the decoded revert as its error data if requested,
+// using the ABIs registered through debug_registerABI.
+func TestCallDecodeRevert(t *testing.T) {
+	var (
+		backend = newSimBackend(t)
+		abis    = newABIRegistry()
+		api     = NewBlockChainAPI(backend, abis)
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		opts    = &callOpts{DecodeRevert: true}
+	)
+	abiJSON := `[{"type":"error","name":"Failure","inputs":[{"name":"code","type":"uint256"}]}]`
+	if err := NewRevertAPI(backend, abis).RegisterABI(revFailing, abiJSON); err != nil {
+		t.Fatalf("failed to register ABI: %v", err)
+	}
+	_, err := api.Call(context.Background(), TransactionArgs{From: &simAccountA, To: &revProxy}, latest, revertOverrides, opts)
+
+	var revErr *revertError
+	if !errors.As(err, &revErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, ok := revErr.ErrorData().(*revertErrorData)
+	if !ok {
+		t.Fatalf("error data not decoded: %v", revErr.ErrorData())
+	}
+	if data.Error != "Failure(uint256)" || len(data.Data) != 36 {
+		t.Errorf("custom error mismatch: have %q, data %x", data.Error, data.Data)
+	}
+	if data.Address == nil || *data.Address != revFailing || data.Depth != 2 {
+		t.Errorf("origin mismatch: have %v at depth %d, want %x at depth 2", data.Address, data.Depth, revFailing)
+	}
+	// The message is the same as without decoding
+	if revErr.Error() != "execution reverted" {
+		t.Errorf("error message mismatch: have %q", revErr.Error())
+	}
+	// Successful calls are unaffected
+	if _, err := api.Call(context.Background(), TransactionArgs{From: &simAccountA, To: &simAccountB}, latest, nil, opts); err != nil {
+		t.Errorf("successful call failed: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RewardAPI provides an API to access the validator and covenant reward
+// records, the issuance totals and the purge history.
+type RewardAPI struct {
+	b Backend
+}
+
+// NewRewardAPI creates a new reward API.
+func NewRewardAPI(b Backend) *RewardAPI {
+	return &RewardAPI{b}
+}
+
+// rewardStorage returns the current reward records, or an empty storage if
+// nothing has been rewarded yet.
+func (api *RewardAPI) rewardStorage(ctx context.Context) (*types.NodeRewardStorage, error) {
+	storage, err := api.b.RewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if storage == nil {
+		storage = new(types.NodeRewardStorage)
+	}
+	return storage, nil
+}
+
+// GetRunningRewardsByAddress returns the reward record of the given validator
+// address for the last 28 days and 12 months.
+func (api *RewardAPI) GetRunningRewardsByAddress(ctx context.Context, address common.Address) (*types.RPCValidatorRewardRecord, error) {
+	storage, err := api.rewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := storage.ValidatorRecord(address)
+	if record == nil {
+		return nil, nil
+	}
+	return types.NewRPCValidatorRewardRecord(record), nil
+}
+
+// GetRunningRewardsByNFT returns the reward record of the given covenant nft
+// for the last 28 days and 12 months.
+func (api *RewardAPI) GetRunningRewardsByNFT(ctx context.Context, tokenID uint64) (*types.RPCCovenantNFTRewardRecord, error) {
+	storage, err := api.rewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := storage.CovenantRecord(tokenID)
+	if record == nil {
+		return nil, nil
+	}
+	return types.NewRPCCovenantNFTRewardRecord(record), nil
+}
+
+// TotalSupply returns the total amount of tokens ever issued to validators
+// and covenant members.
+func (api *RewardAPI) TotalSupply(ctx context.Context) (*hexutil.Big, error) {
+	storage, err := api.rewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(storage.TotalSupply()), nil
+}
+
+// OutStanding returns the total amount of tokens ever issued, minus the tokens
+// burned by sending them to the zero address.
+func (api *RewardAPI) OutStanding(ctx context.Context) (*hexutil.Big, error) {
+	storage, err := api.rewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state, _, err := api.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	outstanding := new(big.Int).Sub(storage.TotalSupply(), state.GetBalance(common.Address{}))
+	if outstanding.Sign() < 0 {
+		outstanding.SetUint64(0)
+	}
+	return (*hexutil.Big)(outstanding), state.Error()
+}
+
+// TotalSupplyCovenant returns the total amount of tokens ever issued to
+// covenant members.
+func (api *RewardAPI) TotalSupplyCovenant(ctx context.Context) (*hexutil.Big, error) {
+	storage, err := api.rewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(storage.TotalSupplyCovenant()), nil
+}
+
+// TotalFee returns the total fee ever issued to validators and covenant members.
+func (api *RewardAPI) TotalFee(ctx context.Context) (*hexutil.Big, error) {
+	storage, err := api.rewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(storage.TotalFee()), nil
+}
+
+// TotalFeeValidators returns the total fee ever issued to validators.
+func (api *RewardAPI) TotalFeeValidators(ctx context.Context) (*hexutil.Big, error) {
+	storage, err := api.rewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	if storage.TotalFeeValidators != nil {
+		total.Set(storage.TotalFeeValidators)
+	}
+	return (*hexutil.Big)(total), nil
+}
+
+// TotalFeeCovenants returns the total fee ever issued to covenant members.
+func (api *RewardAPI) TotalFeeCovenants(ctx context.Context) (*hexutil.Big, error) {
+	storage, err := api.rewardStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	if storage.TotalFeeCovenants != nil {
+		total.Set(storage.TotalFeeCovenants)
+	}
+	return (*hexutil.Big)(total), nil
+}
+
+// GetPurgeResults returns the promotions and demotions of the purge cycle with
+// the given index.
+func (api *RewardAPI) GetPurgeResults(ctx context.Context, index uint64) (*types.PurgeHistoryResultJSON, error) {
+	result, err := api.b.PurgeResult(ctx, index)
+	if result == nil || err != nil {
+		return nil, err
+	}
+	return types.NewPurgeHistoryResultJSON(result), nil
+}
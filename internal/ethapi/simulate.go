@@ -0,0 +1,458 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks that can be simulated
+	// in a single request, including the empty blocks filling number gaps.
+	maxSimulateBlocks = 256
+
+	// maxSimulateCalls is the maximum number of calls that can be simulated
+	// in a single request, summed up over all blocks.
+	maxSimulateCalls = 1000
+
+	// timestampIncrement is the default increment between block timestamps.
+	timestampIncrement = 12
+
+	// errCodeVMError is the error code of calls that failed for a reason other
+	// than a revert.
+	errCodeVMError = -32015
+)
+
+var (
+	errSimulateNoBlocks     = errors.New("no blocks to simulate")
+	errSimulateTooManyCalls = fmt.Errorf("too many calls, maximum is %d", maxSimulateCalls)
+)
+
+// simBlock is a batch of calls to be simulated sequentially, on top of the
+// state left behind by the previous block.
+type simBlock struct {
+	BlockOverrides *BlockOverrides   `json:"blockOverrides"`
+	StateOverrides *StateOverride    `json:"stateOverrides"`
+	Calls          []TransactionArgs `json:"calls"`
+}
+
+// simCallError is the error reported for a single call which failed during
+// execution. It doesn't abort the simulation.
+type simCallError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	Data    string `json:"data,omitempty"`
+}
+
+// simCallResult is the result of a simulated call.
+type simCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Logs        []*types.Log   `json:"logs"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Error       *simCallError  `json:"error,omitempty"`
+}
+
+// simOpts are the inputs to eth_simulateV1.
+type simOpts struct {
+	BlockStateCalls []simBlock `json:"blockStateCalls"`
+
+	// TraceTransfers adds an ERC20 style Transfer log for every ether transfer,
+	// emitted from the 0xeeee...eeee pseudo address.
+	TraceTransfers bool `json:"traceTransfers"`
+
+	// Validation executes the calls like real transactions. Nonces, balances
+	// and the base fee are checked and fees are paid. Without it, the sender
+	// of a call is taken at face value and none of the checks are done.
+	Validation bool `json:"validation"`
+
+	// ReturnFullTransactions returns the transaction objects in the blocks
+	// instead of only their hashes.
+	ReturnFullTransactions bool `json:"returnFullTransactions"`
+}
+
+// simulator is a stateful object that simulates a series of blocks. It is not
+// safe for concurrent use.
+type simulator struct {
+	b              Backend
+	state          *state.StateDB
+	base           *types.Header
+	chainConfig    *params.ChainConfig
+	gp             *core.GasPool
+	traceTransfers bool
+	validate       bool
+	fullTx         bool
+}
+
+// execute runs the given blocks on top of the base state and returns the
+// resulting blocks, each extended with the results of its calls.
+func (sim *simulator) execute(ctx context.Context, blocks []simBlock) ([]map[string]interface{}, error) {
+	if len(blocks) == 0 {
+		return nil, errSimulateNoBlocks
+	}
+	var calls int
+	for _, block := range blocks {
+		calls += len(block.Calls)
+	}
+	if calls > maxSimulateCalls {
+		return nil, errSimulateTooManyCalls
+	}
+	// Setup context so it may be cancelled the simulation has completed
+	// or, in case of unmetered gas, setup a context with a timeout.
+	var cancel context.CancelFunc
+	if timeout := sim.b.RPCEVMTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	// Make sure the context is cancelled when the simulation has completed
+	// this makes sure resources are cleaned up.
+	defer cancel()
+
+	blocks, err := sim.sanitizeChain(blocks)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		results = make([]map[string]interface{}, len(blocks))
+		headers = make([]*types.Header, 0, len(blocks))
+		parent  = sim.base
+	)
+	for i, block := range blocks {
+		result, callResults, senders, err := sim.processBlock(ctx, &block, parent, headers)
+		if err != nil {
+			return nil, err
+		}
+		enc, err := RPCMarshalBlock(result, true, sim.fullTx, sim.chainConfig)
+		if err != nil {
+			return nil, err
+		}
+		// The calls are unsigned, so the sender can't be derived from the
+		// transaction itself. Fill it in from the call instead.
+		if sim.fullTx {
+			for j, tx := range enc["transactions"].([]interface{}) {
+				tx.(*RPCTransaction).From = senders[j]
+			}
+		}
+		enc["calls"] = callResults
+		results[i] = enc
+
+		parent = result.Header()
+		headers = append(headers, parent)
+	}
+	return results, nil
+}
+
+// processBlock executes the calls of a single block on top of the simulator
+// state and assembles the resulting block.
+func (sim *simulator) processBlock(ctx context.Context, block *simBlock, parent *types.Header, headers []*types.Header) (*types.Block, []simCallResult, []common.Address, error) {
+	header := sim.makeHeader(block.BlockOverrides, parent)
+	if err := block.StateOverrides.Apply(sim.state); err != nil {
+		return nil, nil, nil, err
+	}
+	var (
+		gasUsed     uint64
+		logIndex    uint
+		txs         = make([]*types.Transaction, len(block.Calls))
+		receipts    = make(types.Receipts, len(block.Calls))
+		senders     = make([]common.Address, len(block.Calls))
+		callResults = make([]simCallResult, len(block.Calls))
+		vmConfig    = vm.Config{NoBaseFee: !sim.validate}
+	)
+	if sim.traceTransfers {
+		vmConfig.Debug = true
+		vmConfig.Tracer = new(transferTracer)
+	}
+	chain := &simChainContext{ctx: ctx, b: sim.b, headers: headers}
+	blockContext := core.NewEVMBlockContext(header, chain, &header.Coinbase)
+	evm := vm.NewEVM(blockContext, vm.TxContext{}, sim.state, sim.chainConfig, vmConfig)
+
+	// Wait for the context to be done and cancel the evm. Even if the
+	// EVM has finished, cancelling may be done (repeatedly)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	for i, call := range block.Calls {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := sim.sanitizeCall(&call, header, gasUsed); err != nil {
+			return nil, nil, nil, fmt.Errorf("block %d, call %d: %w", header.Number, i, err)
+		}
+		tx := call.ToTransaction()
+		txMsg, err := sim.toMessage(&call, header)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("block %d, call %d: %w", header.Number, i, err)
+		}
+		txs[i], senders[i] = tx, txMsg.From()
+
+		sim.state.SetTxContext(tx.Hash(), i)
+		prevLogs := len(sim.state.GetLogs(tx.Hash(), header.Number.Uint64(), common.Hash{}))
+
+		evm.Reset(core.NewEVMTxContext(txMsg), sim.state)
+		result, err := core.ApplyMessage(evm, txMsg, sim.gp)
+		if err := sim.state.Error(); err != nil {
+			return nil, nil, nil, err
+		}
+		if evm.Cancelled() {
+			return nil, nil, nil, fmt.Errorf("execution aborted (timeout = %v)", sim.b.RPCEVMTimeout())
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("block %d, call %d: err: %w (supplied gas %d)", header.Number, i, err, txMsg.Gas())
+		}
+		// Update the state with pending changes.
+		var root []byte
+		if sim.chainConfig.IsByzantium(header.Number) {
+			sim.state.Finalise(true)
+		} else {
+			root = sim.state.IntermediateRoot(sim.chainConfig.IsEIP158(header.Number)).Bytes()
+		}
+		gasUsed += result.UsedGas
+
+		// Collect the logs of the call, numbering them within the block
+		logs := sim.state.GetLogs(tx.Hash(), header.Number.Uint64(), common.Hash{})[prevLogs:]
+		for _, log := range logs {
+			log.Index = logIndex
+			logIndex++
+		}
+		receipt := &types.Receipt{
+			Type:              tx.Type(),
+			PostState:         root,
+			CumulativeGasUsed: gasUsed,
+			TxHash:            tx.Hash(),
+			GasUsed:           result.UsedGas,
+			Logs:              logs,
+			BlockNumber:       header.Number,
+			TransactionIndex:  uint(i),
+		}
+		if txMsg.To() == nil {
+			receipt.ContractAddress = crypto.CreateAddress(txMsg.From(), tx.Nonce())
+		}
+		callResult := simCallResult{
+			ReturnValue: result.Return(),
+			Logs:        logs,
+			GasUsed:     hexutil.Uint64(result.UsedGas),
+			Status:      hexutil.Uint64(types.ReceiptStatusSuccessful),
+		}
+		if result.Failed() {
+			receipt.Status = types.ReceiptStatusFailed
+			callResult.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+			if errors.Is(result.Err, vm.ErrExecutionReverted) {
+				revertErr := newRevertError(result)
+				callResult.Error = &simCallError{Message: revertErr.Error(), Code: revertErr.ErrorCode(), Data: revertErr.reason}
+			} else {
+				callResult.Error = &simCallError{Message: result.Err.Error(), Code: errCodeVMError}
+			}
+		} else {
+			receipt.Status = types.ReceiptStatusSuccessful
+		}
+		if callResult.Logs == nil {
+			callResult.Logs = []*types.Log{}
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts[i] = receipt
+		callResults[i] = callResult
+	}
+	header.GasUsed = gasUsed
+	if sim.chainConfig.IsCancun(header.Time) {
+		header.DataGasUsed = new(uint64)
+	}
+	header.Root = sim.state.IntermediateRoot(sim.chainConfig.IsEIP158(header.Number))
+
+	var withdrawals []*types.Withdrawal
+	if sim.chainConfig.IsShanghai(header.Time) {
+		withdrawals = make([]*types.Withdrawal, 0)
+	}
+	result := types.NewBlockWithWithdrawals(header, txs, nil, receipts, withdrawals, trie.NewStackTrie(nil))
+
+	// The block hash is only known now, annotate the logs with it
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			log.BlockHash = result.Hash()
+		}
+	}
+	return result, callResults, senders, nil
+}
+
+// sanitizeChain checks the ordering of the requested blocks and fills in the
+// block numbers and timestamps which were not overridden. Gaps in the block
+// numbers are filled with empty blocks.
+func (sim *simulator) sanitizeChain(blocks []simBlock) ([]simBlock, error) {
+	var (
+		res           = make([]simBlock, 0, len(blocks))
+		base          = sim.base
+		prevNumber    = new(big.Int).Set(base.Number)
+		prevTimestamp = base.Time
+	)
+	for _, block := range blocks {
+		if block.BlockOverrides == nil {
+			block.BlockOverrides = new(BlockOverrides)
+		}
+		if block.BlockOverrides.Number == nil {
+			block.BlockOverrides.Number = (*hexutil.Big)(new(big.Int).Add(prevNumber, common.Big1))
+		}
+		number := block.BlockOverrides.Number.ToInt()
+		if number.Cmp(prevNumber) <= 0 {
+			return nil, fmt.Errorf("block numbers must be in order: %d <= %d", number, prevNumber)
+		}
+		if span := new(big.Int).Sub(number, base.Number); span.Cmp(big.NewInt(maxSimulateBlocks)) > 0 {
+			return nil, fmt.Errorf("too many blocks, maximum is %d", maxSimulateBlocks)
+		}
+		// Fill the gap to the previous block with empty ones
+		for n := new(big.Int).Add(prevNumber, common.Big1); n.Cmp(number) < 0; n = new(big.Int).Add(n, common.Big1) {
+			prevTimestamp += timestampIncrement
+			res = append(res, simBlock{BlockOverrides: &BlockOverrides{
+				Number: (*hexutil.Big)(n),
+				Time:   (*hexutil.Big)(new(big.Int).SetUint64(prevTimestamp)),
+			}})
+		}
+		prevNumber = number
+
+		if block.BlockOverrides.Time == nil {
+			block.BlockOverrides.Time = (*hexutil.Big)(new(big.Int).SetUint64(prevTimestamp + timestampIncrement))
+		}
+		timestamp := block.BlockOverrides.Time.ToInt()
+		if !timestamp.IsUint64() || timestamp.Uint64() <= prevTimestamp {
+			return nil, fmt.Errorf("block timestamps must be in order: %d <= %d", timestamp, prevTimestamp)
+		}
+		prevTimestamp = timestamp.Uint64()
+
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+// makeHeader creates the header of a simulated block on top of the given
+// parent, applying the requested overrides.
+func (sim *simulator) makeHeader(overrides *BlockOverrides, parent *types.Header) *types.Header {
+	header := overrides.MakeHeader(&types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		GasLimit:   parent.GasLimit,
+		MixDigest:  parent.MixDigest,
+	})
+	if sim.chainConfig.IsLondon(header.Number) && (overrides == nil || overrides.BaseFee == nil) {
+		// Without validation the fee fields of calls may be left empty, in
+		// which case a zero base fee is the only one the calls can pay.
+		if sim.validate {
+			header.BaseFee = misc.CalcBaseFee(sim.chainConfig, parent)
+		} else {
+			header.BaseFee = new(big.Int)
+		}
+	}
+	if sim.chainConfig.IsCancun(header.Time) {
+		var excessDataGas uint64
+		if parent.ExcessDataGas != nil && parent.DataGasUsed != nil {
+			excessDataGas = misc.CalcExcessDataGas(*parent.ExcessDataGas, *parent.DataGasUsed)
+		}
+		header.ExcessDataGas = &excessDataGas
+	}
+	return header
+}
+
+// sanitizeCall fills in the defaults of a call: the nonce is taken from the
+// simulated state and the gas defaults to what is left in the block.
+func (sim *simulator) sanitizeCall(call *TransactionArgs, header *types.Header, gasUsed uint64) error {
+	if call.Nonce == nil {
+		nonce := sim.state.GetNonce(call.from())
+		call.Nonce = (*hexutil.Uint64)(&nonce)
+	}
+	remaining := header.GasLimit - gasUsed
+	if call.Gas == nil {
+		if budget := sim.gp.Gas(); budget < remaining {
+			remaining = budget
+		}
+		call.Gas = (*hexutil.Uint64)(&remaining)
+	}
+	if uint64(*call.Gas) > remaining {
+		return fmt.Errorf("block gas limit reached: %d > %d", uint64(*call.Gas), remaining)
+	}
+	if call.GasPrice != nil && (call.MaxFeePerGas != nil || call.MaxPriorityFeePerGas != nil) {
+		return errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	if call.ChainID == nil {
+		call.ChainID = (*hexutil.Big)(sim.chainConfig.ChainID)
+	}
+	if call.Value == nil {
+		call.Value = new(hexutil.Big)
+	}
+	// Default to the cheapest fees the block accepts
+	if call.GasPrice == nil && call.MaxFeePerGas == nil {
+		if header.BaseFee != nil {
+			call.MaxFeePerGas = (*hexutil.Big)(new(big.Int).Set(header.BaseFee))
+			if call.MaxPriorityFeePerGas == nil {
+				call.MaxPriorityFeePerGas = new(hexutil.Big)
+			}
+		} else {
+			call.GasPrice = new(hexutil.Big)
+		}
+	}
+	return nil
+}
+
+// toMessage converts a sanitized call into the message to execute. Without
+// validation the message skips the nonce and sender checks.
+func (sim *simulator) toMessage(call *TransactionArgs, header *types.Header) (types.Message, error) {
+	msg, err := call.ToMessage(0, header.BaseFee)
+	if err != nil {
+		return types.Message{}, err
+	}
+	return types.NewMessage(msg.From(), msg.To(), uint64(*call.Nonce), msg.Value(), msg.Gas(), msg.GasPrice(), msg.GasFeeCap(), msg.GasTipCap(), msg.Data(), msg.AccessList(), !sim.validate), nil
+}
+
+// simChainContext resolves the headers of the simulated blocks in addition to
+// the ones of the canonical chain, so BLOCKHASH works across simulated blocks.
+type simChainContext struct {
+	ctx     context.Context
+	b       Backend
+	headers []*types.Header
+}
+
+func (c *simChainContext) Engine() consensus.Engine {
+	return c.b.Engine()
+}
+
+func (c *simChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	for _, header := range c.headers {
+		if header.Number.Uint64() == number && header.Hash() == hash {
+			return header
+		}
+	}
+	header, err := c.b.HeaderByHash(c.ctx, hash)
+	if err != nil || header == nil || header.Number.Uint64() != number {
+		return nil
+	}
+	return header
+}
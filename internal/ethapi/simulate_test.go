@@ -0,0 +1,313 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// simBackend is a backend mock serving a single in-memory state for the
+// simulation tests.
+type simBackend struct {
+	*backendMock
+	state *state.StateDB
+}
+
+func newSimBackend(t *testing.T) *simBackend {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	return &simBackend{backendMock: newBackendMock(), state: statedb}
+}
+
+func (b *simBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	return b.state.Copy(), b.current, nil
+}
+
+func (b *simBackend) simulate(t *testing.T, opts simOpts) []map[string]interface{} {
+	t.Helper()
+
+	results, err := NewBlockChainAPI(b, newABIRegistry()).SimulateV1(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	return results
+}
+
+var (
+	simAccountA = common.Address{0xaa}
+	simAccountB = common.Address{0xbb}
+	simAccountC = common.Address{0xcc}
+
+	simLogger   = common.Address{0x01, 0x01} // LOG1(0, 0, 0x01)
+	simReverter = common.Address{0x01, 0x02} // REVERT(0, 0)
+	simHasher   = common.Address{0x01, 0x03} // RETURN(BLOCKHASH(NUMBER - 1))
+)
+
+func simCode(hex string) *hexutil.Bytes {
+	code := hexutil.Bytes(common.FromHex(hex))
+	return &code
+}
+
+func simBalance(v int64) **hexutil.Big {
+	b := (*hexutil.Big)(big.NewInt(v))
+	return &b
+}
+
+func simValue(v int64) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(v))
+}
+
+func simCalls(t *testing.T, result map[string]interface{}) []simCallResult {
+	t.Helper()
+
+	calls, ok := result["calls"].([]simCallResult)
+	if !ok {
+		t.Fatalf("block result has no calls")
+	}
+	return calls
+}
+
+// Tests that the state changes of a simulated block carry over to the next one
+// and that ether transfers are reported as logs if requested.
+func TestSimulateChainedTransfers(t *testing.T) {
+	b := newSimBackend(t)
+	results := b.simulate(t, simOpts{
+		TraceTransfers: true,
+		BlockStateCalls: []simBlock{
+			{
+				StateOverrides: &StateOverride{simAccountA: {Balance: simBalance(1000)}},
+				Calls: []TransactionArgs{{
+					From:  &simAccountA,
+					To:    &simAccountB,
+					Value: simValue(600),
+				}},
+			},
+			{
+				Calls: []TransactionArgs{{
+					From:  &simAccountB,
+					To:    &simAccountC,
+					Value: simValue(500),
+				}, {
+					// Only 100 left in B after the previous call
+					From:  &simAccountB,
+					To:    &simAccountC,
+					Value: simValue(100),
+				}},
+			},
+		},
+	})
+	if len(results) != 2 {
+		t.Fatalf("block count mismatch: have %d, want %d", len(results), 2)
+	}
+	for i, result := range results {
+		if have, want := result["number"].(*hexutil.Big).ToInt().Uint64(), b.current.Number.Uint64()+uint64(i)+1; have != want {
+			t.Errorf("block %d: number mismatch: have %d, want %d", i, have, want)
+		}
+		if have, want := uint64(result["timestamp"].(hexutil.Uint64)), b.current.Time+uint64(i+1)*timestampIncrement; have != want {
+			t.Errorf("block %d: timestamp mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	if parent := results[1]["parentHash"].(common.Hash); parent != results[0]["hash"].(common.Hash) {
+		t.Errorf("parent hash mismatch: have %x, want %x", parent, results[0]["hash"])
+	}
+	transfers := []struct {
+		block, call int
+		from, to    common.Address
+		value       int64
+	}{
+		{0, 0, simAccountA, simAccountB, 600},
+		{1, 0, simAccountB, simAccountC, 500},
+		{1, 1, simAccountB, simAccountC, 100},
+	}
+	for _, tt := range transfers {
+		call := simCalls(t, results[tt.block])[tt.call]
+		if call.Status != hexutil.Uint64(types.ReceiptStatusSuccessful) {
+			t.Fatalf("block %d, call %d: call failed: %v", tt.block, tt.call, call.Error)
+		}
+		if len(call.Logs) != 1 {
+			t.Fatalf("block %d, call %d: log count mismatch: have %d, want %d", tt.block, tt.call, len(call.Logs), 1)
+		}
+		log := call.Logs[0]
+		if log.Address != transferAddress {
+			t.Errorf("block %d, call %d: log address mismatch: have %x, want %x", tt.block, tt.call, log.Address, transferAddress)
+		}
+		if log.Topics[1] != common.BytesToHash(tt.from.Bytes()) || log.Topics[2] != common.BytesToHash(tt.to.Bytes()) {
+			t.Errorf("block %d, call %d: log topics mismatch: have %v", tt.block, tt.call, log.Topics)
+		}
+		if have := new(big.Int).SetBytes(log.Data); have.Int64() != tt.value {
+			t.Errorf("block %d, call %d: transferred value mismatch: have %d, want %d", tt.block, tt.call, have, tt.value)
+		}
+		if log.Index != uint(tt.call) {
+			t.Errorf("block %d, call %d: log index mismatch: have %d, want %d", tt.block, tt.call, log.Index, tt.call)
+		}
+		if log.BlockHash != results[tt.block]["hash"].(common.Hash) {
+			t.Errorf("block %d, call %d: log block hash mismatch", tt.block, tt.call)
+		}
+	}
+	// Overdrawing B must fail the whole simulation
+	_, err := NewBlockChainAPI(b, newABIRegistry()).SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{{
+			Calls: []TransactionArgs{{From: &simAccountB, To: &simAccountC, Value: simValue(1)}},
+		}},
+	}, nil)
+	if err == nil {
+		t.Fatalf("transfer without funds succeeded")
+	}
+}
+
+// Tests that reverting calls are reported with their reason and don't abort
+// the simulation, and that logs are numbered within their block.
+func TestSimulateCallResults(t *testing.T) {
+	b := newSimBackend(t)
+	results := b.simulate(t, simOpts{
+		BlockStateCalls: []simBlock{{
+			StateOverrides: &StateOverride{
+				simLogger:   {Code: simCode("0x600160006000a100")},
+				simReverter: {Code: simCode("0x60006000fd")},
+			},
+			Calls: []TransactionArgs{
+				{From: &simAccountA, To: &simLogger},
+				{From: &simAccountA, To: &simReverter},
+				{From: &simAccountA, To: &simLogger},
+			},
+		}},
+	})
+	calls := simCalls(t, results[0])
+	if len(calls) != 3 {
+		t.Fatalf("call count mismatch: have %d, want %d", len(calls), 3)
+	}
+	for i, call := range []int{0, 2} {
+		result := calls[call]
+		if result.Status != hexutil.Uint64(types.ReceiptStatusSuccessful) || result.Error != nil {
+			t.Fatalf("call %d: failed: %v", call, result.Error)
+		}
+		if len(result.Logs) != 1 {
+			t.Fatalf("call %d: log count mismatch: have %d, want %d", call, len(result.Logs), 1)
+		}
+		if log := result.Logs[0]; log.Address != simLogger || log.Index != uint(i) || log.TxIndex != uint(call) {
+			t.Errorf("call %d: log mismatch: address %x, index %d, tx index %d", call, log.Address, log.Index, log.TxIndex)
+		}
+	}
+	reverted := calls[1]
+	if reverted.Status != hexutil.Uint64(types.ReceiptStatusFailed) {
+		t.Errorf("reverting call succeeded")
+	}
+	if reverted.Error == nil || reverted.Error.Code != 3 {
+		t.Errorf("revert error mismatch: have %+v, want code 3", reverted.Error)
+	}
+	if len(reverted.Logs) != 0 {
+		t.Errorf("reverting call has logs: %v", reverted.Logs)
+	}
+	if reverted.GasUsed == 0 {
+		t.Errorf("reverting call used no gas")
+	}
+	if have, want := uint64(results[0]["gasUsed"].(hexutil.Uint64)), uint64(calls[0].GasUsed+calls[1].GasUsed+calls[2].GasUsed); have != want {
+		t.Errorf("block gas used mismatch: have %d, want %d", have, want)
+	}
+}
+
+// Tests that gaps between the requested block numbers are filled with empty
+// blocks and that BLOCKHASH resolves the simulated ancestors.
+func TestSimulateBlockGaps(t *testing.T) {
+	b := newSimBackend(t)
+	head := b.current.Number.Uint64()
+	results := b.simulate(t, simOpts{
+		BlockStateCalls: []simBlock{
+			{},
+			{
+				BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(new(big.Int).SetUint64(head + 4))},
+				StateOverrides: &StateOverride{simHasher: {Code: simCode("0x43600190034060005260206000f3")}},
+				Calls:          []TransactionArgs{{From: &simAccountA, To: &simHasher}},
+			},
+		},
+	})
+	if len(results) != 4 {
+		t.Fatalf("block count mismatch: have %d, want %d", len(results), 4)
+	}
+	for i, result := range results {
+		if have, want := result["number"].(*hexutil.Big).ToInt().Uint64(), head+uint64(i)+1; have != want {
+			t.Errorf("block %d: number mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	call := simCalls(t, results[3])[0]
+	if have, want := common.BytesToHash(call.ReturnValue), results[2]["hash"].(common.Hash); have != want {
+		t.Errorf("block hash mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that invalid simulation requests are rejected.
+func TestSimulateInvalid(t *testing.T) {
+	b := newSimBackend(t)
+	head := b.current.Number.Uint64()
+
+	number := func(n uint64) *BlockOverrides {
+		return &BlockOverrides{Number: (*hexutil.Big)(new(big.Int).SetUint64(n))}
+	}
+	tooManyCalls := make([]TransactionArgs, maxSimulateCalls+1)
+	for i := range tooManyCalls {
+		tooManyCalls[i] = TransactionArgs{From: &simAccountA, To: &simAccountB}
+	}
+	nonce := hexutil.Uint64(5)
+
+	tests := []struct {
+		name string
+		opts simOpts
+	}{
+		{"no blocks", simOpts{}},
+		{"unordered numbers", simOpts{BlockStateCalls: []simBlock{{BlockOverrides: number(head + 2)}, {BlockOverrides: number(head + 1)}}}},
+		{"number at base", simOpts{BlockStateCalls: []simBlock{{BlockOverrides: number(head)}}}},
+		{"too many blocks", simOpts{BlockStateCalls: []simBlock{{BlockOverrides: number(head + maxSimulateBlocks + 1)}}}},
+		{"unordered timestamps", simOpts{BlockStateCalls: []simBlock{{BlockOverrides: &BlockOverrides{Time: (*hexutil.Big)(new(big.Int).SetUint64(b.current.Time))}}}}},
+		{"too many calls", simOpts{BlockStateCalls: []simBlock{{Calls: tooManyCalls}}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewBlockChainAPI(b, newABIRegistry()).SimulateV1(context.Background(), tt.opts, nil); err == nil {
+			t.Errorf("%s: simulation succeeded", tt.name)
+		}
+	}
+	// Nonces are only checked if validation is requested
+	funded := &StateOverride{simAccountA: {Balance: simBalance(1_000_000_000_000)}}
+	for _, validate := range []bool{false, true} {
+		opts := simOpts{
+			Validation: validate,
+			BlockStateCalls: []simBlock{{
+				StateOverrides: funded,
+				Calls:          []TransactionArgs{{From: &simAccountA, To: &simAccountB, Nonce: &nonce}},
+			}},
+		}
+		_, err := NewBlockChainAPI(b, newABIRegistry()).SimulateV1(context.Background(), opts, nil)
+		if validate && !errors.Is(err, core.ErrNonceTooHigh) {
+			t.Errorf("nonce error mismatch: have %v, want %v", err, core.ErrNonceTooHigh)
+		}
+		if !validate && err != nil {
+			t.Errorf("simulation without validation failed: %v", err)
+		}
+	}
+}
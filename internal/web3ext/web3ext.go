@@ -30,6 +30,7 @@ var Modules = map[string]string{
 	"txpool":   TxpoolJs,
 	"les":      LESJs,
 	"vflux":    VfluxJs,
+	"bundle":   BundleJs,
 }
 
 const CliqueJs = `
@@ -189,6 +190,15 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'txpoolFilter',
+			call: 'admin_txpoolFilter'
+		}),
+		new web3._extend.Method({
+			name: 'txpoolSetFilter',
+			call: 'admin_txpoolSetFilter',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -495,6 +505,52 @@ web3._extend({
 			call: 'debug_setTrieFlushInterval',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'rebuildRewards',
+			call: 'debug_rebuildRewards',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getBalanceChanges',
+			call: 'debug_getBalanceChanges',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'supplyDeltas',
+			call: 'debug_supplyDeltas',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'supplyAudit',
+			call: 'debug_supplyAudit',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'registerABI',
+			call: 'debug_registerABI',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'unregisterABI',
+			call: 'debug_unregisterABI',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'registeredABIs',
+			call: 'debug_registeredABIs',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'callRevert',
+			call: 'debug_callRevert',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null]
+		}),
 	],
 	properties: []
 });
@@ -569,6 +625,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, function (val) { return !!val; }]
 		}),
+		new web3._extend.Method({
+			name: 'getBlockReceipts',
+			call: 'eth_getBlockReceipts',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransaction',
 			call: 'eth_getRawTransactionByHash',
@@ -611,8 +673,60 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null],
 		}),
+		new web3._extend.Method({
+			name: 'simulateV1',
+			call: 'eth_simulateV1',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter],
+		}),
+		new web3._extend.Method({
+			name: 'getRunningRewardsByAddress',
+			call: 'eth_getRunningRewardsByAddress',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getRunningRewardsByNFT',
+			call: 'eth_getRunningRewardsByNFT',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getPurgeResults',
+			call: 'eth_getPurgeResults',
+			params: 1
+		}),
 	],
 	properties: [
+		new web3._extend.Property({
+			name: 'totalSupply',
+			getter: 'eth_totalSupply',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Property({
+			name: 'outStanding',
+			getter: 'eth_outStanding',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Property({
+			name: 'totalSupplyCovenant',
+			getter: 'eth_totalSupplyCovenant',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Property({
+			name: 'totalFee',
+			getter: 'eth_totalFee',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Property({
+			name: 'totalFeeValidators',
+			getter: 'eth_totalFeeValidators',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Property({
+			name: 'totalFeeCovenants',
+			getter: 'eth_totalFeeCovenants',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
 		new web3._extend.Property({
 			name: 'pendingTransactions',
 			getter: 'eth_pendingTransactions',
@@ -883,3 +997,22 @@ web3._extend({
 	]
 });
 `
+
+const BundleJs = `
+web3._extend({
+	property: 'bundle',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'sendBundle',
+			call: 'bundle_sendBundle',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getBundleStatus',
+			call: 'bundle_getBundleStatus',
+			params: 1
+		}),
+	]
+});
+`
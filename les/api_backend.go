@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/rewards"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -252,6 +253,20 @@ func (b *LesApiBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.
 	})
 }
 
+func (b *LesApiBackend) SubscribeRewardsEvent(ch chan<- rewards.RewardsEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+func (b *LesApiBackend) SubscribePurgeEvent(ch chan<- rewards.PurgeEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeRemovedLogsEvent(ch)
 }
@@ -318,6 +333,14 @@ func (b *LesApiBackend) Engine() consensus.Engine {
 	return b.eth.engine
 }
 
+func (b *LesApiBackend) RewardStorage(ctx context.Context) (*types.NodeRewardStorage, error) {
+	return nil, errors.New("reward records are not available in light mode")
+}
+
+func (b *LesApiBackend) PurgeResult(ctx context.Context, index uint64) (*types.PurgeHistoryResult, error) {
+	return nil, errors.New("purge history is not available in light mode")
+}
+
 func (b *LesApiBackend) CurrentHeader() *types.Header {
 	return b.eth.blockchain.CurrentHeader()
 }
@@ -134,6 +134,7 @@ type ChtIndexerBackend struct {
 	section, sectionSize uint64
 	lastHash             common.Hash
 	trie                 *trie.Trie
+	originRoot           common.Hash
 }
 
 // NewChtIndexer creates a Cht chain indexer
@@ -191,6 +192,7 @@ func (c *ChtIndexerBackend) Reset(ctx context.Context, section uint64, lastSecti
 		}
 	}
 	c.section = section
+	c.originRoot = root
 	return err
 }
 
@@ -218,12 +220,13 @@ func (c *ChtIndexerBackend) Commit() error {
 	}
 	// Commit trie changes into trie database in case it's not nil.
 	if nodes != nil {
-		if err := c.triedb.Update(trie.NewWithNodeSet(nodes)); err != nil {
+		if err := c.triedb.Update(root, c.originRoot, trie.NewWithNodeSet(nodes)); err != nil {
 			return err
 		}
 		if err := c.triedb.Commit(root, false, nil); err != nil {
 			return err
 		}
+		c.originRoot = root
 	}
 	// Re-create trie with newly generated root and updated database.
 	c.trie, err = trie.New(trie.TrieID(root), c.triedb)
@@ -335,6 +338,7 @@ type BloomTrieIndexerBackend struct {
 	size              uint64
 	bloomTrieRatio    uint64
 	trie              *trie.Trie
+	originRoot        common.Hash
 	sectionHeads      []common.Hash
 }
 
@@ -416,6 +420,7 @@ func (b *BloomTrieIndexerBackend) Reset(ctx context.Context, section uint64, las
 		}
 	}
 	b.section = section
+	b.originRoot = root
 	return err
 }
 
@@ -464,12 +469,13 @@ func (b *BloomTrieIndexerBackend) Commit() error {
 	}
 	// Commit trie changes into trie database in case it's not nil.
 	if nodes != nil {
-		if err := b.triedb.Update(trie.NewWithNodeSet(nodes)); err != nil {
+		if err := b.triedb.Update(root, b.originRoot, trie.NewWithNodeSet(nodes)); err != nil {
 			return err
 		}
 		if err := b.triedb.Commit(root, false, nil); err != nil {
 			return err
 		}
+		b.originRoot = root
 	}
 	// Re-create trie with newly generated root and updated database.
 	b.trie, err = trie.New(trie.TrieID(root), b.triedb)
@@ -0,0 +1,291 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// maxBundles is the maximum number of bundles waiting for inclusion.
+	maxBundles = 1024
+
+	// maxBundleTxs is the maximum number of transactions in a single bundle.
+	maxBundleTxs = 64
+
+	// maxBundleResults is the number of finished (included or expired) bundle
+	// results retained for status queries.
+	maxBundleResults = 4096
+)
+
+var (
+	errBundleEmpty        = errors.New("bundle contains no transactions")
+	errBundleTooLarge     = fmt.Errorf("bundle exceeds %d transactions", maxBundleTxs)
+	errBundleBlobTx       = errors.New("blob transactions are not supported in bundles")
+	errBundleKnown        = errors.New("bundle already known")
+	errBundlePoolFull     = errors.New("bundle pool is full")
+	errBundleTargetPassed = errors.New("bundle targets a past block")
+	errBundleTxReverted   = errors.New("bundle transaction reverted")
+
+	bundleIncludedMeter = metrics.NewRegisteredMeter("miner/bundles/included", nil)
+	bundleExpiredMeter  = metrics.NewRegisteredMeter("miner/bundles/expired", nil)
+	bundleFailedMeter   = metrics.NewRegisteredMeter("miner/bundles/failed", nil)
+	bundlePendingGauge  = metrics.NewRegisteredGauge("miner/bundles/pending", nil)
+)
+
+// Bundle is an ordered list of transactions which is included into a block
+// atomically: either all of them execute successfully, back to back and in the
+// given order, ahead of the regular pool transactions, or none of them is
+// included at all.
+type Bundle struct {
+	Txs         types.Transactions
+	BlockNumber uint64 // Block the bundle is restricted to, zero for any block until it expires
+
+	hash     common.Hash
+	received time.Time
+}
+
+// NewBundle creates a bundle from the given transactions, optionally restricted
+// to the block with the given number.
+func NewBundle(txs types.Transactions, blockNumber uint64) *Bundle {
+	b := &Bundle{
+		Txs:         txs,
+		BlockNumber: blockNumber,
+		received:    time.Now(),
+	}
+	hashes := make([]byte, 0, len(txs)*common.HashLength+8)
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	var number [8]byte
+	binary.BigEndian.PutUint64(number[:], blockNumber)
+	b.hash = crypto.Keccak256Hash(hashes, number[:])
+	return b
+}
+
+// Hash returns the identifier of the bundle, the keccak256 hash of the
+// concatenated transaction hashes and the target block number.
+func (b *Bundle) Hash() common.Hash {
+	return b.hash
+}
+
+// BundleStatus is the inclusion status of a bundle.
+type BundleStatus string
+
+const (
+	BundlePending  BundleStatus = "pending"  // Waiting for inclusion
+	BundleIncluded BundleStatus = "included" // Included in a canonical block
+	BundleExpired  BundleStatus = "expired"  // Dropped without being included
+)
+
+// BundleResult reports the outcome of a submitted bundle.
+type BundleResult struct {
+	Status    BundleStatus
+	Block     uint64      // Number of the including block, or the last block the bundle was simulated for
+	BlockHash common.Hash // Hash of the including block, only set once included
+	GasUsed   uint64      // Gas used by the bundle in its last successful simulation
+	Attempts  int         // Number of sealing blocks the bundle was simulated for
+	Err       error       // Reason of the last failed simulation, if any
+}
+
+// bundlePool keeps track of the bundles waiting for inclusion along with the
+// results of the recently finished ones.
+type bundlePool struct {
+	lifetime time.Duration // Maximum time a bundle is kept waiting for inclusion
+
+	pending []*Bundle                     // Bundles waiting for inclusion, in arrival order
+	results map[common.Hash]*BundleResult // Results of both pending and finished bundles
+	done    []common.Hash                 // Finished bundles, in order of finishing, for eviction
+	mu      sync.RWMutex
+}
+
+// newBundlePool creates a bundle pool dropping bundles after the given lifetime.
+func newBundlePool(lifetime time.Duration) *bundlePool {
+	if lifetime <= 0 {
+		lifetime = DefaultConfig.BundleLifetime
+	}
+	return &bundlePool{
+		lifetime: lifetime,
+		results:  make(map[common.Hash]*BundleResult),
+	}
+}
+
+// add validates and inserts a new bundle, head being the number of the current
+// chain head.
+func (p *bundlePool) add(bundle *Bundle, head uint64) error {
+	switch {
+	case len(bundle.Txs) == 0:
+		return errBundleEmpty
+	case len(bundle.Txs) > maxBundleTxs:
+		return errBundleTooLarge
+	case bundle.BlockNumber != 0 && bundle.BlockNumber <= head:
+		return errBundleTargetPassed
+	}
+	for _, tx := range bundle.Txs {
+		if tx.Type() == types.BlobTxType {
+			return errBundleBlobTx
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.results[bundle.hash]; ok {
+		return errBundleKnown
+	}
+	p.expire(head, time.Now())
+	if len(p.pending) >= maxBundles {
+		return errBundlePoolFull
+	}
+	p.pending = append(p.pending, bundle)
+	p.results[bundle.hash] = &BundleResult{Status: BundlePending}
+	bundlePendingGauge.Update(int64(len(p.pending)))
+	return nil
+}
+
+// eligible returns the bundles which may be included in the block with the
+// given number, in arrival order.
+func (p *bundlePool) eligible(number uint64) []*Bundle {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var (
+		now     = time.Now()
+		bundles []*Bundle
+	)
+	for _, bundle := range p.pending {
+		if bundle.BlockNumber != 0 && bundle.BlockNumber != number {
+			continue
+		}
+		if now.Sub(bundle.received) > p.lifetime {
+			continue
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles
+}
+
+// report records the outcome of simulating a bundle for the given block.
+func (p *bundlePool) report(hash common.Hash, number uint64, gasUsed uint64, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res, ok := p.results[hash]
+	if !ok || res.Status != BundlePending {
+		return
+	}
+	res.Block = number
+	res.Attempts++
+	if err != nil {
+		res.Err = err
+		bundleFailedMeter.Mark(1)
+		return
+	}
+	res.GasUsed, res.Err = gasUsed, nil
+}
+
+// reset updates the pool after a new chain head: the bundles included in the
+// head block are marked as such and the ones which can no longer be included
+// are expired.
+func (p *bundlePool) reset(head *types.Block) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	included := make(map[common.Hash]struct{}, len(head.Transactions()))
+	for _, tx := range head.Transactions() {
+		included[tx.Hash()] = struct{}{}
+	}
+	pending := p.pending[:0]
+	for _, bundle := range p.pending {
+		if bundleIncluded(bundle, included) {
+			res := p.results[bundle.hash]
+			res.Status, res.Block, res.BlockHash, res.Err = BundleIncluded, head.NumberU64(), head.Hash(), nil
+			p.finish(bundle.hash)
+
+			log.Debug("Bundle included", "hash", bundle.hash, "number", head.NumberU64(), "txs", len(bundle.Txs))
+			bundleIncludedMeter.Mark(1)
+			continue
+		}
+		pending = append(pending, bundle)
+	}
+	p.pending = pending
+	p.expire(head.NumberU64(), time.Now())
+}
+
+// expire drops the bundles which passed their lifetime or target block. The
+// lock must be held by the caller.
+func (p *bundlePool) expire(head uint64, now time.Time) {
+	pending := p.pending[:0]
+	for _, bundle := range p.pending {
+		if (bundle.BlockNumber != 0 && bundle.BlockNumber <= head) || now.Sub(bundle.received) > p.lifetime {
+			p.results[bundle.hash].Status = BundleExpired
+			p.finish(bundle.hash)
+
+			log.Debug("Bundle expired", "hash", bundle.hash, "target", bundle.BlockNumber, "err", p.results[bundle.hash].Err)
+			bundleExpiredMeter.Mark(1)
+			continue
+		}
+		pending = append(pending, bundle)
+	}
+	for i := len(pending); i < len(p.pending); i++ {
+		p.pending[i] = nil
+	}
+	p.pending = pending
+	bundlePendingGauge.Update(int64(len(p.pending)))
+}
+
+// finish moves a bundle result into the finished set, evicting the oldest
+// results over the retention limit. The lock must be held by the caller.
+func (p *bundlePool) finish(hash common.Hash) {
+	p.done = append(p.done, hash)
+	for len(p.done) > maxBundleResults {
+		delete(p.results, p.done[0])
+		p.done = p.done[1:]
+	}
+}
+
+// result returns a copy of the result of the given bundle, or nil if unknown.
+func (p *bundlePool) result(hash common.Hash) *BundleResult {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	res, ok := p.results[hash]
+	if !ok {
+		return nil
+	}
+	cpy := *res
+	return &cpy
+}
+
+// bundleIncluded reports whether all transactions of the bundle are in the set.
+func bundleIncluded(bundle *Bundle, txs map[common.Hash]struct{}) bool {
+	for _, tx := range bundle.Txs {
+		if _, ok := txs[tx.Hash()]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,264 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newBundleTx creates a signed legacy transaction for use in test bundles.
+func newBundleTx(key *ecdsa.PrivateKey, nonce uint64, to *common.Address, value *big.Int, gas uint64, data []byte) *types.Transaction {
+	return types.MustSignNewTx(key, types.LatestSigner(ethashChainConfig), &types.LegacyTx{
+		Nonce:    nonce,
+		To:       to,
+		Value:    value,
+		Gas:      gas,
+		GasPrice: big.NewInt(2 * params.InitialBaseFee),
+		Data:     data,
+	})
+}
+
+func TestBundleInclusion(t *testing.T) {
+	w, b := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// The second transaction spends the funds received in the first one, so
+	// it can only succeed if the bundle is executed in order.
+	var (
+		tx0    = newBundleTx(testBankKey, 0, &testUserAddress, big.NewInt(params.Ether/1000), params.TxGas, nil)
+		tx1    = newBundleTx(testUserKey, 0, &testBankAddress, big.NewInt(1), params.TxGas, nil)
+		bundle = NewBundle(types.Transactions{tx0, tx1}, 0)
+	)
+	if err := w.bundles.add(bundle, 0); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	r := w.getSealingBlock(b.chain.CurrentBlock().Hash(), uint64(time.Now().Unix()), testUserAddress, common.Hash{}, nil, false)
+	if r.err != nil {
+		t.Fatalf("failed to build block: %v", r.err)
+	}
+	txs := r.block.Transactions()
+	if len(txs) < 2 || txs[0].Hash() != tx0.Hash() || txs[1].Hash() != tx1.Hash() {
+		t.Fatalf("bundle not included at the top of the block: %v", txs)
+	}
+	res := w.bundles.result(bundle.Hash())
+	if res.Status != BundlePending || res.Attempts != 1 || res.Block != 1 || res.GasUsed != 2*params.TxGas || res.Err != nil {
+		t.Fatalf("unexpected result after simulation: %+v", res)
+	}
+	// Once the block becomes the chain head, the bundle is reported as included.
+	w.bundles.reset(r.block)
+
+	res = w.bundles.result(bundle.Hash())
+	if res.Status != BundleIncluded || res.Block != 1 || res.BlockHash != r.block.Hash() {
+		t.Fatalf("unexpected result after inclusion: %+v", res)
+	}
+	if bundles := w.bundles.eligible(2); len(bundles) != 0 {
+		t.Fatalf("included bundle still pending")
+	}
+}
+
+func TestBundleRevert(t *testing.T) {
+	w, b := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// The second transaction deploys a contract whose constructor reverts,
+	// which must keep the first one out of the block as well.
+	var (
+		tx0    = newBundleTx(testBankKey, 0, &testUserAddress, big.NewInt(1000), params.TxGas, nil)
+		tx1    = newBundleTx(testBankKey, 1, nil, big.NewInt(0), 100000, common.FromHex("0x60006000fd"))
+		bundle = NewBundle(types.Transactions{tx0, tx1}, 0)
+	)
+	if err := w.bundles.add(bundle, 0); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	r := w.getSealingBlock(b.chain.CurrentBlock().Hash(), uint64(time.Now().Unix()), testUserAddress, common.Hash{}, nil, false)
+	if r.err != nil {
+		t.Fatalf("failed to build block: %v", r.err)
+	}
+	for _, tx := range r.block.Transactions() {
+		if tx.Hash() == tx0.Hash() || tx.Hash() == tx1.Hash() {
+			t.Fatalf("transaction %x of failed bundle included", tx.Hash())
+		}
+	}
+	res := w.bundles.result(bundle.Hash())
+	if res.Status != BundlePending || res.Attempts != 1 || !errors.Is(res.Err, errBundleTxReverted) {
+		t.Fatalf("unexpected result after failed simulation: %+v", res)
+	}
+}
+
+func TestBundleTargetBlock(t *testing.T) {
+	w, b := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	var (
+		tx     = newBundleTx(testBankKey, 0, &testUserAddress, big.NewInt(1000), params.TxGas, nil)
+		bundle = NewBundle(types.Transactions{tx}, 2)
+	)
+	if err := w.bundles.add(bundle, 0); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	r := w.getSealingBlock(b.chain.CurrentBlock().Hash(), uint64(time.Now().Unix()), testUserAddress, common.Hash{}, nil, false)
+	if r.err != nil {
+		t.Fatalf("failed to build block: %v", r.err)
+	}
+	for _, included := range r.block.Transactions() {
+		if included.Hash() == tx.Hash() {
+			t.Fatalf("bundle included before its target block")
+		}
+	}
+	if res := w.bundles.result(bundle.Hash()); res.Attempts != 0 {
+		t.Fatalf("bundle simulated before its target block: %+v", res)
+	}
+	if bundles := w.bundles.eligible(2); len(bundles) != 1 {
+		t.Fatalf("bundle not eligible for its target block")
+	}
+	// A target block without the bundle expires it.
+	w.bundles.reset(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2)}))
+	if res := w.bundles.result(bundle.Hash()); res.Status != BundleExpired {
+		t.Fatalf("bundle not expired after its target block: %+v", res)
+	}
+}
+
+// Tests that bundles are subject to the admission filter of the pools.
+func TestBundleFilter(t *testing.T) {
+	w, b := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	b.txFilter = txpool.NewFilter(txpool.FilterConfig{Deny: []common.Address{testUserAddress}})
+	miner := &Miner{eth: b, worker: w}
+
+	var (
+		tx0 = newBundleTx(testBankKey, 0, &testUserAddress, big.NewInt(1000), params.TxGas, nil)
+		tx1 = newBundleTx(testUserKey, 0, &testBankAddress, big.NewInt(1), params.TxGas, nil)
+	)
+	if _, err := miner.SendBundle(types.Transactions{tx0}, 0); !errors.Is(err, txpool.ErrRecipientDenied) {
+		t.Fatalf("error mismatch: have %v, want %v", err, txpool.ErrRecipientDenied)
+	}
+	if _, err := miner.SendBundle(types.Transactions{tx1}, 0); !errors.Is(err, txpool.ErrSenderDenied) {
+		t.Fatalf("error mismatch: have %v, want %v", err, txpool.ErrSenderDenied)
+	}
+	if bundles := w.bundles.eligible(1); len(bundles) != 0 {
+		t.Fatalf("filtered bundles accepted: %d", len(bundles))
+	}
+	b.txFilter.SetConfig(txpool.FilterConfig{})
+	if _, err := miner.SendBundle(types.Transactions{tx0, tx1}, 0); err != nil {
+		t.Fatalf("failed to send bundle: %v", err)
+	}
+}
+
+// Tests that bundles are only included into the blocks being sealed, never into
+// the pending block.
+func TestBundleNotPending(t *testing.T) {
+	w, _ := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	tx := newBundleTx(testBankKey, 0, &testUserAddress, big.NewInt(1000), params.TxGas, nil)
+	if err := w.bundles.add(NewBundle(types.Transactions{tx}, 0), 0); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	taskCh := make(chan *types.Block, 4)
+	w.newTaskHook = func(task *task) {
+		if len(task.block.Transactions()) > 0 {
+			select {
+			case taskCh <- task.block:
+			default:
+			}
+		}
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+	w.start()
+
+	select {
+	case block := <-taskCh:
+		if txs := block.Transactions(); txs[0].Hash() != tx.Hash() {
+			t.Fatalf("bundle not included in the sealing block: %v", txs)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("sealing task timeout")
+	}
+	// The pending block should be built from the pool transactions alone
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if block := w.pendingBlock(); block != nil && len(block.Transactions()) > 0 {
+			for _, included := range block.Transactions() {
+				if included.Hash() == tx.Hash() {
+					t.Fatalf("bundle transaction leaked into the pending block")
+				}
+			}
+			if included := block.Transactions()[0]; included.Hash() != pendingTxs[0].Hash() {
+				t.Fatalf("pending block transaction mismatch: have %x, want %x", included.Hash(), pendingTxs[0].Hash())
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("pending block not updated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBundlePool(t *testing.T) {
+	var (
+		pool = newBundlePool(50 * time.Millisecond)
+		tx   = newBundleTx(testBankKey, 0, &testUserAddress, big.NewInt(1000), params.TxGas, nil)
+		blob = types.NewTx(&types.BlobTx{})
+	)
+	if err := pool.add(NewBundle(nil, 0), 0); err != errBundleEmpty {
+		t.Fatalf("empty bundle: have %v, want %v", err, errBundleEmpty)
+	}
+	if err := pool.add(NewBundle(types.Transactions{tx}, 5), 5); err != errBundleTargetPassed {
+		t.Fatalf("past target: have %v, want %v", err, errBundleTargetPassed)
+	}
+	if err := pool.add(NewBundle(types.Transactions{blob}, 0), 0); err != errBundleBlobTx {
+		t.Fatalf("blob bundle: have %v, want %v", err, errBundleBlobTx)
+	}
+	bundle := NewBundle(types.Transactions{tx}, 0)
+	if err := pool.add(bundle, 0); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	if err := pool.add(NewBundle(types.Transactions{tx}, 0), 0); err != errBundleKnown {
+		t.Fatalf("duplicate bundle: have %v, want %v", err, errBundleKnown)
+	}
+	if NewBundle(types.Transactions{tx}, 1).Hash() == bundle.Hash() {
+		t.Fatalf("bundles with different targets share a hash")
+	}
+	if bundles := pool.eligible(1); len(bundles) != 1 {
+		t.Fatalf("bundle not eligible")
+	}
+	// Bundles are dropped after their lifetime.
+	time.Sleep(100 * time.Millisecond)
+	if bundles := pool.eligible(1); len(bundles) != 0 {
+		t.Fatalf("expired bundle eligible")
+	}
+	pool.reset(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}))
+	if res := pool.result(bundle.Hash()); res == nil || res.Status != BundleExpired {
+		t.Fatalf("bundle not expired: %+v", res)
+	}
+	if res := pool.result(common.Hash{}); res != nil {
+		t.Fatalf("unknown bundle reported: %+v", res)
+	}
+}
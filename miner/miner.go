@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -29,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/event"
@@ -41,6 +43,8 @@ import (
 type Backend interface {
 	BlockChain() *core.BlockChain
 	TxPool() *txpool.TxPool
+	BlobPool() *blobpool.BlobPool
+	TxFilter() *txpool.Filter
 }
 
 // Config is the configuration parameters of mining.
@@ -56,6 +60,8 @@ type Config struct {
 	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
 
 	NewPayloadTimeout time.Duration // The maximum time allowance for creating a new payload
+	BundleLifetime    time.Duration // Maximum time a private bundle waits for inclusion
+	Ordering          string        // Transaction ordering strategy used for block building (price, fifo, roundrobin)
 }
 
 // DefaultConfig contains default settings for miner.
@@ -69,6 +75,8 @@ var DefaultConfig = Config{
 	// run 3 rounds.
 	Recommit:          2 * time.Second,
 	NewPayloadTimeout: 2 * time.Second,
+	BundleLifetime:    5 * time.Minute,
+	Ordering:          OrderingPrice,
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -256,6 +264,50 @@ func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscript
 	return miner.worker.pendingLogsFeed.Subscribe(ch)
 }
 
+// SendBundle submits a bundle of transactions to be included atomically, ahead
+// of the regular pool transactions, in the block with the given number or, if
+// zero, in any block until the bundle expires. The hash identifying the bundle
+// is returned.
+func (miner *Miner) SendBundle(txs types.Transactions, blockNumber uint64) (common.Hash, error) {
+	head := miner.eth.BlockChain().CurrentBlock()
+	var (
+		signer  = types.MakeSigner(miner.eth.BlockChain().Config(), new(big.Int).Add(head.Number(), common.Big1), head.Time())
+		filter  = miner.eth.TxFilter()
+		senders = make([]common.Address, len(txs))
+	)
+	for i, tx := range txs {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("invalid sender of tx %d: %w", i, err)
+		}
+		// Bundles bypass the pools, so apply their admission policies here
+		if filter != nil {
+			if err := filter.Check(from, tx); err != nil {
+				return common.Hash{}, fmt.Errorf("tx %d rejected: %w", i, err)
+			}
+		}
+		senders[i] = from
+	}
+	bundle := NewBundle(txs, blockNumber)
+	if err := miner.worker.bundles.add(bundle, head.NumberU64()); err != nil {
+		return common.Hash{}, err
+	}
+	if filter != nil {
+		for _, from := range senders {
+			filter.Admit(from)
+		}
+	}
+	// Make sure a sealing miner picks up the bundle on its next recommit.
+	atomic.AddInt32(&miner.worker.newTxs, int32(len(txs)))
+	return bundle.Hash(), nil
+}
+
+// BundleResult returns the inclusion result of a previously submitted bundle,
+// or nil if the bundle is unknown.
+func (miner *Miner) BundleResult(hash common.Hash) *BundleResult {
+	return miner.worker.bundles.result(hash)
+}
+
 // BuildPayload builds the payload according to the provided parameters.
 func (miner *Miner) BuildPayload(args *BuildPayloadArgs) (*Payload, error) {
 	return miner.worker.buildPayload(args)
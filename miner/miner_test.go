@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/downloader"
@@ -36,14 +37,16 @@ import (
 )
 
 type mockBackend struct {
-	bc     *core.BlockChain
-	txPool *txpool.TxPool
+	bc       *core.BlockChain
+	txPool   *txpool.TxPool
+	blobPool *blobpool.BlobPool
 }
 
-func NewMockBackend(bc *core.BlockChain, txPool *txpool.TxPool) *mockBackend {
+func NewMockBackend(bc *core.BlockChain, txPool *txpool.TxPool, blobPool *blobpool.BlobPool) *mockBackend {
 	return &mockBackend{
-		bc:     bc,
-		txPool: txPool,
+		bc:       bc,
+		txPool:   txPool,
+		blobPool: blobPool,
 	}
 }
 
@@ -55,6 +58,14 @@ func (m *mockBackend) TxPool() *txpool.TxPool {
 	return m.txPool
 }
 
+func (m *mockBackend) BlobPool() *blobpool.BlobPool {
+	return m.blobPool
+}
+
+func (m *mockBackend) TxFilter() *txpool.Filter {
+	return nil
+}
+
 func (m *mockBackend) StateAtBlock(block *types.Block, reexec uint64, base *state.StateDB, checkLive bool, preferDisk bool) (statedb *state.StateDB, err error) {
 	return nil, errors.New("not supported")
 }
@@ -262,8 +273,12 @@ func createMiner(t *testing.T) (*Miner, *event.TypeMux, func(skipMiner bool)) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(chainDB), nil)
 	blockchain := &testBlockChain{statedb, 10000000, new(event.Feed)}
 
-	pool := txpool.NewTxPool(testTxPoolConfig, chainConfig, blockchain)
-	backend := NewMockBackend(bc, pool)
+	pool := txpool.NewTxPool(testTxPoolConfig, chainConfig, blockchain, nil, nil)
+	blobPool, err := blobpool.New(blobpool.Config{}, chainConfig, bc, nil, nil)
+	if err != nil {
+		t.Fatalf("can't create blob pool: %v", err)
+	}
+	backend := NewMockBackend(bc, pool, blobPool)
 	// Create event Mux
 	mux := new(event.TypeMux)
 	// Create Miner
@@ -272,6 +287,7 @@ func createMiner(t *testing.T) (*Miner, *event.TypeMux, func(skipMiner bool)) {
 		bc.Stop()
 		engine.Close()
 		pool.Stop()
+		blobPool.Stop()
 		if !skipMiner {
 			miner.Close()
 		}
@@ -0,0 +1,104 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Transaction ordering strategies supported by the block builder.
+const (
+	OrderingPrice      = "price"      // Greedily by effective miner tip
+	OrderingFIFO       = "fifo"       // By the time transactions were first seen
+	OrderingRoundRobin = "roundrobin" // One transaction per sender and round, senders in address order
+)
+
+// TransactionSet yields pending transactions in block inclusion order while
+// honouring the nonce order within every account.
+type TransactionSet interface {
+	// Peek returns the next transaction to include, or nil if none are left.
+	Peek() *types.Transaction
+
+	// Shift replaces the current transaction with the next one from the same account.
+	Shift()
+
+	// Pop removes the current transaction along with all subsequent ones from
+	// the same account.
+	Pop()
+}
+
+// OrderingStrategy decides the order in which the pending transactions are
+// committed into a block.
+type OrderingStrategy interface {
+	// Order returns a set iterating over the given nonce-sorted transactions
+	// of every account. The map is reowned by the returned set.
+	Order(signer types.Signer, txs map[common.Address]types.Transactions, baseFee *big.Int) TransactionSet
+
+	// PrioritizeLocals reports whether the transactions of local accounts are
+	// committed ahead of the remote ones, or ordered together with them.
+	PrioritizeLocals() bool
+}
+
+// NewOrderingStrategy returns the built-in ordering strategy with the given
+// name, defaulting to ordering by price if empty.
+func NewOrderingStrategy(name string) (OrderingStrategy, error) {
+	switch name {
+	case OrderingPrice, "":
+		return priceOrdering{}, nil
+	case OrderingFIFO:
+		return fifoOrdering{}, nil
+	case OrderingRoundRobin:
+		return roundRobinOrdering{}, nil
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering %q, want one of %s, %s, %s", name, OrderingPrice, OrderingFIFO, OrderingRoundRobin)
+	}
+}
+
+// priceOrdering orders transactions greedily by their effective miner tip,
+// maximizing the fees collected by the block.
+type priceOrdering struct{}
+
+func (priceOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions, baseFee *big.Int) TransactionSet {
+	return types.NewTransactionsByPriceAndNonce(signer, txs, baseFee)
+}
+
+func (priceOrdering) PrioritizeLocals() bool { return true }
+
+// fifoOrdering orders transactions by the time they were first seen, serving
+// senders on a first-come-first-served basis regardless of price. Local
+// transactions get no precedence, they queue up with the remote ones.
+type fifoOrdering struct{}
+
+func (fifoOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions, baseFee *big.Int) TransactionSet {
+	return types.NewTransactionsByTimeAndNonce(signer, txs, baseFee)
+}
+
+func (fifoOrdering) PrioritizeLocals() bool { return false }
+
+// roundRobinOrdering includes one transaction per sender and round, visiting
+// the senders in address order, which makes the ordering fully deterministic.
+type roundRobinOrdering struct{}
+
+func (roundRobinOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions, baseFee *big.Int) TransactionSet {
+	return types.NewTransactionsByRoundRobin(signer, txs, baseFee)
+}
+
+func (roundRobinOrdering) PrioritizeLocals() bool { return true }
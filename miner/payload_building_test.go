@@ -47,7 +47,7 @@ func TestBuildPayload(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to build payload %v", err)
 	}
-	verify := func(data *beacon.ExecutableDataV1, txs int) {
+	verify := func(data *beacon.ExecutableData, txs int) {
 		if data.ParentHash != b.chain.CurrentBlock().Hash() {
 			t.Fatal("Unexpect parent hash")
 		}
@@ -65,10 +65,10 @@ func TestBuildPayload(t *testing.T) {
 		}
 	}
 	empty := payload.ResolveEmpty()
-	verify(empty, 0)
+	verify(empty.ExecutionPayload, 0)
 
 	full := payload.ResolveFull()
-	verify(full, len(pendingTxs))
+	verify(full.ExecutionPayload, len(pendingTxs))
 
 	// Ensure resolve can be called multiple times and the
 	// result should be unchanged
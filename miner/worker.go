@@ -81,6 +81,8 @@ var (
 	errBlockInterruptedByNewHead  = errors.New("new head arrived while building block")
 	errBlockInterruptedByRecommit = errors.New("recommit interrupt while building block")
 	errBlockInterruptedByTimeout  = errors.New("timeout while building block")
+	errMaxBlobsReached            = errors.New("max data blobs reached")
+	errMissingBlobs               = errors.New("blob transaction missing from blob pool")
 )
 
 // environment is the worker's current environment and holds all
@@ -99,6 +101,9 @@ type environment struct {
 	txs      []*types.Transaction
 	receipts []*types.Receipt
 	uncles   map[common.Hash]*types.Header
+	sidecars []*types.BlobTxSidecar
+	blobs    int
+	bundles  int // Number of private bundles included ahead of the pool transactions
 }
 
 // copy creates a deep copy of environment.
@@ -112,6 +117,8 @@ func (env *environment) copy() *environment {
 		coinbase:  env.coinbase,
 		header:    types.CopyHeader(env.header),
 		receipts:  copyReceipts(env.receipts),
+		blobs:     env.blobs,
+		bundles:   env.bundles,
 	}
 	if env.gasPool != nil {
 		gasPool := *env.gasPool
@@ -121,6 +128,8 @@ func (env *environment) copy() *environment {
 	// to do the expensive deep copy for them.
 	cpy.txs = make([]*types.Transaction, len(env.txs))
 	copy(cpy.txs, env.txs)
+	cpy.sidecars = make([]*types.BlobTxSidecar, len(env.sidecars))
+	copy(cpy.sidecars, env.sidecars)
 	cpy.uncles = make(map[common.Hash]*types.Header)
 	for hash, uncle := range env.uncles {
 		cpy.uncles[hash] = uncle
@@ -171,9 +180,10 @@ type newWorkReq struct {
 
 // newPayloadResult represents a result struct corresponds to payload generation.
 type newPayloadResult struct {
-	err   error
-	block *types.Block
-	fees  *big.Int
+	err      error
+	block    *types.Block
+	fees     *big.Int
+	sidecars []*types.BlobTxSidecar
 }
 
 // getWorkReq represents a request for getting a new sealing work with provided parameters.
@@ -233,6 +243,9 @@ type worker struct {
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
 
+	bundles  *bundlePool      // Private bundles to include ahead of the pool transactions
+	ordering OrderingStrategy // Strategy ordering the pool transactions within blocks
+
 	snapshotMu       sync.RWMutex // The lock used to protect the snapshots below
 	snapshotBlock    *types.Block
 	snapshotReceipts types.Receipts
@@ -283,6 +296,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		remoteUncles:       make(map[common.Hash]*types.Block),
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), sealingLogAtDepth),
 		pendingTasks:       make(map[common.Hash]*task),
+		bundles:            newBundlePool(config.BundleLifetime),
 		txsCh:              make(chan core.NewTxsEvent, txChanSize),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:        make(chan core.ChainSideEvent, chainSideChanSize),
@@ -320,6 +334,14 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	}
 	worker.newpayloadTimeout = newpayloadTimeout
 
+	// Sanitize the transaction ordering strategy.
+	ordering, err := NewOrderingStrategy(worker.config.Ordering)
+	if err != nil {
+		log.Warn("Sanitizing miner transaction ordering", "provided", worker.config.Ordering, "updated", OrderingPrice, "err", err)
+		ordering = priceOrdering{}
+	}
+	worker.ordering = ordering
+
 	worker.wg.Add(4)
 	go worker.mainLoop()
 	go worker.newWorkLoop(recommit)
@@ -491,6 +513,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 
 		case head := <-w.chainHeadCh:
 			clearPending(head.Block.NumberU64())
+			w.bundles.reset(head.Block)
 			timestamp = time.Now().Unix()
 			commit(false, commitInterruptNewHead)
 
@@ -565,12 +588,8 @@ func (w *worker) mainLoop() {
 			w.commitWork(req.interrupt, req.noempty, req.timestamp)
 
 		case req := <-w.getWorkCh:
-			block, fees, err := w.generateWork(req.params)
-			req.result <- &newPayloadResult{
-				err:   err,
-				block: block,
-				fees:  fees,
-			}
+			req.result <- w.generateWork(req.params)
+
 		case ev := <-w.chainSideCh:
 			// Short circuit for duplicate side blocks
 			if _, exist := w.localUncles[ev.Block.Hash()]; exist {
@@ -624,7 +643,7 @@ func (w *worker) mainLoop() {
 					acc, _ := types.Sender(w.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs, w.current.header.BaseFee)
+				txset := w.ordering.Order(w.current.signer, txs, w.current.header.BaseFee)
 				tcount := w.current.tcount
 				w.commitTransactions(w.current, txset, nil)
 
@@ -792,7 +811,7 @@ func (w *worker) makeEnv(parent *types.Block, header *types.Header, coinbase com
 
 	// Note the passed coinbase may be different with header.Coinbase.
 	env := &environment{
-		signer:    types.MakeSigner(w.chainConfig, header.Number),
+		signer:    types.MakeSigner(w.chainConfig, header.Number, header.Time),
 		state:     state,
 		coinbase:  coinbase,
 		ancestors: mapset.NewSet[common.Hash](),
@@ -852,20 +871,69 @@ func (w *worker) updateSnapshot(env *environment) {
 }
 
 func (w *worker) commitTransaction(env *environment, tx *types.Transaction) ([]*types.Log, error) {
-	snap := env.state.Snapshot()
+	if tx.Type() == types.BlobTxType {
+		return w.commitBlobTransaction(env, tx)
+	}
+	receipt, err := w.applyTransaction(env, tx)
+	if err != nil {
+		return nil, err
+	}
+	env.txs = append(env.txs, tx)
+	env.receipts = append(env.receipts, receipt)
 
-	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, *w.chain.GetVMConfig())
+	return receipt.Logs, nil
+}
+
+// commitBlobTransaction includes a blob transaction into the sealing block,
+// tracking its sidecar separately since blocks only carry the stripped version.
+func (w *worker) commitBlobTransaction(env *environment, tx *types.Transaction) ([]*types.Log, error) {
+	if env.header.DataGasUsed == nil {
+		return nil, types.ErrTxTypeNotSupported
+	}
+	// Pending blob transactions are announced without their blobs, retrieve
+	// them from the pool if needed.
+	sc := tx.BlobTxSidecar()
+	if sc == nil {
+		full := w.eth.BlobPool().Get(tx.Hash())
+		if full == nil {
+			return nil, errMissingBlobs
+		}
+		sc = full.BlobTxSidecar()
+	}
+	// The data gas limit is only checked during block validation, not during
+	// execution, so explicitly ensure the blobs still fit into the block.
+	if (env.blobs+len(sc.Blobs))*params.BlobTxDataGasPerBlob > params.BlobTxMaxDataGasPerBlock {
+		return nil, errMaxBlobsReached
+	}
+	tx = tx.WithoutBlobTxSidecar()
+
+	receipt, err := w.applyTransaction(env, tx)
 	if err != nil {
-		env.state.RevertToSnapshot(snap)
 		return nil, err
 	}
 	env.txs = append(env.txs, tx)
 	env.receipts = append(env.receipts, receipt)
+	env.sidecars = append(env.sidecars, sc)
+	env.blobs += len(sc.Blobs)
+	*env.header.DataGasUsed += tx.BlobGas()
 
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByPriceAndNonce, interrupt *int32) error {
+// applyTransaction runs the transaction on the sealing state, reverting any
+// changes if it fails.
+func (w *worker) applyTransaction(env *environment, tx *types.Transaction) (*types.Receipt, error) {
+	snap := env.state.Snapshot()
+
+	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, *w.chain.GetVMConfig())
+	if err != nil {
+		env.state.RevertToSnapshot(snap)
+		return nil, err
+	}
+	return receipt, nil
+}
+
+func (w *worker) commitTransactions(env *environment, txs TransactionSet, interrupt *int32) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(gasLimit)
@@ -932,6 +1000,11 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 			log.Trace("Skipping unsupported transaction type", "sender", from, "type", tx.Type())
 			txs.Pop()
 
+		case errors.Is(err, errMaxBlobsReached):
+			// Pop the blob transaction that doesn't fit without shifting in the next from the account
+			log.Trace("Data blob limit exceeded for current block", "sender", from)
+			txs.Pop()
+
 		default:
 			// Strange error, discard the transaction and get the next in line (note, the
 			// nonce-too-high clause will prevent us from executing in vain).
@@ -939,6 +1012,13 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 			txs.Shift()
 		}
 	}
+	w.sendPendingLogs(coalescedLogs)
+	return nil
+}
+
+// sendPendingLogs notifies the pending log subscribers of the logs produced by
+// the transactions added to the pending block.
+func (w *worker) sendPendingLogs(coalescedLogs []*types.Log) {
 	if !w.isRunning() && len(coalescedLogs) > 0 {
 		// We don't push the pendingLogsEvent while we are sealing. The reason is that
 		// when we are sealing, the worker will regenerate a sealing block every 3 seconds.
@@ -954,19 +1034,81 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 		}
 		w.pendingLogsFeed.Send(cpy)
 	}
+}
+
+// commitBundles includes the pending bundles eligible for the sealing block in
+// arrival order. Every bundle is simulated on a copy of the environment which
+// is only adopted if all of its transactions succeed, so a bundle failing
+// halfway through leaves the block untouched. Bundles are private, so their
+// logs are never announced to the pending log subscribers.
+func (w *worker) commitBundles(env *environment, interrupt *int32) error {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	number := env.header.Number.Uint64()
+	for _, bundle := range w.bundles.eligible(number) {
+		// Check interruption signal and abort building if it's fired.
+		if interrupt != nil {
+			if signal := atomic.LoadInt32(interrupt); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		sim := env.copy()
+		if err := w.commitBundle(sim, bundle); err != nil {
+			log.Debug("Bundle simulation failed", "hash", bundle.Hash(), "number", number, "err", err)
+			w.bundles.report(bundle.Hash(), number, 0, err)
+			sim.discard()
+			continue
+		}
+		w.bundles.report(bundle.Hash(), number, sim.header.GasUsed-env.header.GasUsed, nil)
+		log.Debug("Committed bundle", "hash", bundle.Hash(), "number", number, "txs", len(bundle.Txs), "gas", sim.header.GasUsed-env.header.GasUsed)
+
+		// Swap in the simulated environment, restarting the prefetcher which
+		// isn't carried over by the state copy.
+		env.discard()
+		*env = *sim
+		env.state.StartPrefetcher("miner")
+		env.bundles++
+	}
+	return nil
+}
+
+// commitBundle executes all transactions of a bundle in order on the given
+// environment, failing if any of them is invalid or reverts. The environment
+// is left in an inconsistent state on failure and must be discarded.
+func (w *worker) commitBundle(env *environment, bundle *Bundle) error {
+	for i, tx := range bundle.Txs {
+		if tx.Protected() && !w.chainConfig.IsEIP155(env.header.Number) {
+			return fmt.Errorf("tx %d [%x]: %w", i, tx.Hash(), types.ErrInvalidChainId)
+		}
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+
+		receipt, err := w.applyTransaction(env, tx)
+		if err != nil {
+			return fmt.Errorf("tx %d [%x]: %w", i, tx.Hash(), err)
+		}
+		if receipt.Status == types.ReceiptStatusFailed {
+			return fmt.Errorf("tx %d [%x]: %w", i, tx.Hash(), errBundleTxReverted)
+		}
+		env.txs = append(env.txs, tx)
+		env.receipts = append(env.receipts, receipt)
+		env.tcount++
+	}
 	return nil
 }
 
 // generateParams wraps various of settings for generating sealing task.
 type generateParams struct {
-	timestamp  uint64         // The timstamp for sealing task
-	forceTime  bool           // Flag whether the given timestamp is immutable or not
-	parentHash common.Hash    // Parent block hash, empty means the latest chain head
-	coinbase   common.Address // The fee recipient address for including transaction
-	random     common.Hash    // The randomness generated by beacon chain, empty before the merge
-	noUncle    bool           // Flag whether the uncle block inclusion is allowed
-	noExtra    bool           // Flag whether the extra field assignment is allowed
-	noTxs      bool           // Flag whether an empty block without any transaction is expected
+	timestamp   uint64            // The timstamp for sealing task
+	forceTime   bool              // Flag whether the given timestamp is immutable or not
+	parentHash  common.Hash       // Parent block hash, empty means the latest chain head
+	coinbase    common.Address    // The fee recipient address for including transaction
+	random      common.Hash       // The randomness generated by beacon chain, empty before the merge
+	withdrawals types.Withdrawals // List of withdrawals to include in block.
+	noUncle     bool              // Flag whether the uncle block inclusion is allowed
+	noExtra     bool              // Flag whether the extra field assignment is allowed
+	noTxs       bool              // Flag whether an empty block without any transaction is expected
+	bundles     bool              // Flag whether the private bundles are included ahead of the pool transactions
 }
 
 // prepareWork constructs the sealing task according to the given parameters,
@@ -1017,6 +1159,17 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
 		}
 	}
+	// Apply the EIP-4844 data gas accounting if Cancun is active
+	if w.chainConfig.IsCancun(header.Time) {
+		var parentExcessDataGas, parentDataGasUsed uint64
+		if parent.ExcessDataGas() != nil {
+			parentExcessDataGas = *parent.ExcessDataGas()
+			parentDataGasUsed = *parent.DataGasUsed()
+		}
+		excessDataGas := misc.CalcExcessDataGas(parentExcessDataGas, parentDataGasUsed)
+		header.ExcessDataGas = &excessDataGas
+		header.DataGasUsed = new(uint64)
+	}
 	// Run the consensus preparation with the default or customized consensus engine.
 	if err := w.engine.Prepare(w.chain, header); err != nil {
 		log.Error("Failed to prepare header for sealing", "err", err)
@@ -1052,27 +1205,44 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 }
 
 // fillTransactions retrieves the pending transactions from the txpool and fills them
-// into the given sealing block. The transaction selection and ordering strategy can
-// be customized with the plugin in the future.
-func (w *worker) fillTransactions(interrupt *int32, env *environment) error {
+// into the given sealing block, preceded by any eligible private bundles if
+// requested. The pool transactions are ordered by the configured ordering
+// strategy, local ones ahead of the remote ones if the strategy prioritizes them.
+func (w *worker) fillTransactions(interrupt *int32, env *environment, bundles bool) error {
+	if bundles {
+		if err := w.commitBundles(env, interrupt); err != nil {
+			return err
+		}
+	}
 	// Split the pending transactions into locals and remotes
 	// Fill the block with all available pending transactions.
 	pending := w.eth.TxPool().Pending(true)
+
+	// Merge in the blob transactions. The pools never track the same account,
+	// unless it moved between them in between the two snapshots, in which case
+	// the legacy transactions are kept.
+	for addr, txs := range w.eth.BlobPool().Pending(true) {
+		if _, ok := pending[addr]; !ok {
+			pending[addr] = txs
+		}
+	}
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
-	for _, account := range w.eth.TxPool().Locals() {
-		if txs := remoteTxs[account]; len(txs) > 0 {
-			delete(remoteTxs, account)
-			localTxs[account] = txs
+	if w.ordering.PrioritizeLocals() {
+		for _, account := range w.eth.TxPool().Locals() {
+			if txs := remoteTxs[account]; len(txs) > 0 {
+				delete(remoteTxs, account)
+				localTxs[account] = txs
+			}
 		}
 	}
 	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
+		txs := w.ordering.Order(env.signer, localTxs, env.header.BaseFee)
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
+		txs := w.ordering.Order(env.signer, remoteTxs, env.header.BaseFee)
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
@@ -1081,10 +1251,10 @@ func (w *worker) fillTransactions(interrupt *int32, env *environment) error {
 }
 
 // generateWork generates a sealing block based on the given parameters.
-func (w *worker) generateWork(params *generateParams) (*types.Block, *big.Int, error) {
+func (w *worker) generateWork(params *generateParams) *newPayloadResult {
 	work, err := w.prepareWork(params)
 	if err != nil {
-		return nil, nil, err
+		return &newPayloadResult{err: err}
 	}
 	defer work.discard()
 
@@ -1095,16 +1265,20 @@ func (w *worker) generateWork(params *generateParams) (*types.Block, *big.Int, e
 		})
 		defer timer.Stop()
 
-		err := w.fillTransactions(interrupt, work)
+		err := w.fillTransactions(interrupt, work, params.bundles)
 		if errors.Is(err, errBlockInterruptedByTimeout) {
 			log.Warn("Block building is interrupted", "allowance", common.PrettyDuration(w.newpayloadTimeout))
 		}
 	}
-	block, err := w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, work.txs, work.unclelist(), work.receipts)
+	block, err := w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, work.txs, work.unclelist(), work.receipts, params.withdrawals)
 	if err != nil {
-		return nil, nil, err
+		return &newPayloadResult{err: err}
+	}
+	return &newPayloadResult{
+		block:    block,
+		fees:     totalFees(block, work.receipts),
+		sidecars: work.sidecars,
 	}
-	return block, totalFees(block, work.receipts), nil
 }
 
 // commitWork generates several new sealing tasks based on the parent block
@@ -1121,10 +1295,12 @@ func (w *worker) commitWork(interrupt *int32, noempty bool, timestamp int64) {
 		}
 		coinbase = w.coinbase // Use the preset address as the fee recipient
 	}
-	work, err := w.prepareWork(&generateParams{
+	genParams := &generateParams{
 		timestamp: uint64(timestamp),
 		coinbase:  coinbase,
-	})
+		bundles:   w.isRunning(),
+	}
+	work, err := w.prepareWork(genParams)
 	if err != nil {
 		return
 	}
@@ -1133,8 +1309,14 @@ func (w *worker) commitWork(interrupt *int32, noempty bool, timestamp int64) {
 	if !noempty && atomic.LoadUint32(&w.noempty) == 0 {
 		w.commit(work.copy(), nil, false, start)
 	}
+	// Private bundles must not leak through the pending block. If any may be
+	// included, keep a copy of the empty block to build the pending one from.
+	var pending *environment
+	if genParams.bundles && len(w.bundles.eligible(work.header.Number.Uint64())) > 0 {
+		pending = work.copy()
+	}
 	// Fill pending transactions from the txpool into the block.
-	err = w.fillTransactions(interrupt, work)
+	err = w.fillTransactions(interrupt, work, genParams.bundles)
 	switch {
 	case err == nil:
 		// The entire block is filled, decrease resubmit interval in case
@@ -1160,11 +1342,26 @@ func (w *worker) commitWork(interrupt *int32, noempty bool, timestamp int64) {
 		// delay, and possibly causes miner to mine on the previous head,
 		// which could result in higher uncle rate.
 		work.discard()
+		if pending != nil {
+			pending.discard()
+		}
 		return
 	}
-	// Submit the generated block for consensus sealing.
-	w.commit(work.copy(), w.fullTaskHook, true, start)
-
+	// Submit the generated block for consensus sealing, updating the pending
+	// block only if it doesn't carry any private bundles.
+	w.commit(work.copy(), w.fullTaskHook, work.bundles == 0, start)
+
+	if pending != nil {
+		if work.bundles == 0 {
+			pending.discard()
+		} else {
+			if err := w.fillTransactions(interrupt, pending, false); !errors.Is(err, errBlockInterruptedByNewHead) {
+				w.updateSnapshot(pending)
+			}
+			work.discard()
+			work = pending
+		}
+	}
 	// Swap out the old work with the new one, terminating any leftover
 	// prefetcher processes in the mean time and starting a new one.
 	if w.current != nil {
@@ -1185,7 +1382,8 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		// Create a local environment copy, avoid the data race with snapshot state.
 		// https://github.com/ethereum/go-ethereum/issues/24299
 		env := env.copy()
-		block, err := w.engine.FinalizeAndAssemble(w.chain, env.header, env.state, env.txs, env.unclelist(), env.receipts)
+		// Withdrawals are set to nil here, because this is only called in PoW.
+		block, err := w.engine.FinalizeAndAssemble(w.chain, env.header, env.state, env.txs, env.unclelist(), env.receipts, nil)
 		if err != nil {
 			return err
 		}
@@ -1216,29 +1414,27 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 // getSealingBlock generates the sealing block based on the given parameters.
 // The generation result will be passed back via the given channel no matter
 // the generation itself succeeds or not.
-func (w *worker) getSealingBlock(parent common.Hash, timestamp uint64, coinbase common.Address, random common.Hash, noTxs bool) (*types.Block, *big.Int, error) {
+func (w *worker) getSealingBlock(parent common.Hash, timestamp uint64, coinbase common.Address, random common.Hash, withdrawals types.Withdrawals, noTxs bool) *newPayloadResult {
 	req := &getWorkReq{
 		params: &generateParams{
-			timestamp:  timestamp,
-			forceTime:  true,
-			parentHash: parent,
-			coinbase:   coinbase,
-			random:     random,
-			noUncle:    true,
-			noExtra:    true,
-			noTxs:      noTxs,
+			timestamp:   timestamp,
+			forceTime:   true,
+			parentHash:  parent,
+			coinbase:    coinbase,
+			random:      random,
+			withdrawals: withdrawals,
+			noUncle:     true,
+			noExtra:     true,
+			noTxs:       noTxs,
+			bundles:     true,
 		},
 		result: make(chan *newPayloadResult, 1),
 	}
 	select {
 	case w.getWorkCh <- req:
-		result := <-req.result
-		if result.err != nil {
-			return nil, nil, result.err
-		}
-		return result.block, result.fees, nil
+		return <-req.result
 	case <-w.exitCh:
-		return nil, nil, errors.New("miner closed")
+		return &newPayloadResult{err: errors.New("miner closed")}
 	}
 }
 
@@ -17,9 +17,12 @@
 package miner
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"errors"
 	"math/big"
 	"math/rand"
+	"sort"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -32,7 +35,9 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -113,6 +118,8 @@ func init() {
 type testWorkerBackend struct {
 	db         ethdb.Database
 	txPool     *txpool.TxPool
+	blobPool   *blobpool.BlobPool
+	txFilter   *txpool.Filter
 	chain      *core.BlockChain
 	genesis    *core.Genesis
 	uncleBlock *types.Block
@@ -138,7 +145,11 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 	if err != nil {
 		t.Fatalf("core.NewBlockChain failed: %v", err)
 	}
-	txpool := txpool.NewTxPool(testTxPoolConfig, chainConfig, chain)
+	txpool := txpool.NewTxPool(testTxPoolConfig, chainConfig, chain, nil, nil)
+	blobpool, err := blobpool.New(blobpool.Config{}, chainConfig, chain, nil, nil)
+	if err != nil {
+		t.Fatalf("blobpool.New failed: %v", err)
+	}
 
 	// Generate a small n-block chain and an uncle block for it
 	var uncle *types.Block
@@ -164,6 +175,7 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 		db:         db,
 		chain:      chain,
 		txPool:     txpool,
+		blobPool:   blobpool,
 		genesis:    gspec,
 		uncleBlock: uncle,
 	}
@@ -171,6 +183,8 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 
 func (b *testWorkerBackend) BlockChain() *core.BlockChain { return b.chain }
 func (b *testWorkerBackend) TxPool() *txpool.TxPool       { return b.txPool }
+func (b *testWorkerBackend) BlobPool() *blobpool.BlobPool { return b.blobPool }
+func (b *testWorkerBackend) TxFilter() *txpool.Filter     { return b.txFilter }
 func (b *testWorkerBackend) StateAtBlock(block *types.Block, reexec uint64, base *state.StateDB, checkLive bool, preferDisk bool) (statedb *state.StateDB, err error) {
 	return nil, errors.New("not supported")
 }
@@ -637,7 +651,8 @@ func testGetSealingWork(t *testing.T, chainConfig *params.ChainConfig, engine co
 
 	// This API should work even when the automatic sealing is not enabled
 	for _, c := range cases {
-		block, _, err := w.getSealingBlock(c.parent, timestamp, c.coinbase, c.random, false)
+		r := w.getSealingBlock(c.parent, timestamp, c.coinbase, c.random, nil, false)
+		block, err := r.block, r.err
 		if c.expectErr {
 			if err == nil {
 				t.Error("Expect error but get nil")
@@ -653,7 +668,8 @@ func testGetSealingWork(t *testing.T, chainConfig *params.ChainConfig, engine co
 	// This API should work even when the automatic sealing is enabled
 	w.start()
 	for _, c := range cases {
-		block, _, err := w.getSealingBlock(c.parent, timestamp, c.coinbase, c.random, false)
+		r := w.getSealingBlock(c.parent, timestamp, c.coinbase, c.random, nil, false)
+		block, err := r.block, r.err
 		if c.expectErr {
 			if err == nil {
 				t.Error("Expect error but get nil")
@@ -666,3 +682,197 @@ func testGetSealingWork(t *testing.T, chainConfig *params.ChainConfig, engine co
 		}
 	}
 }
+
+func TestTransactionOrdering(t *testing.T) {
+	w, b := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// Create three senders, with transactions arriving interleaved across
+	// them and tips unrelated to both arrival time and sender address.
+	var (
+		keys   = make([]*ecdsa.PrivateKey, 3)
+		addrs  = make([]common.Address, 3)
+		signer = types.LatestSigner(ethashChainConfig)
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	newTx := func(sender int, nonce uint64, tip int64) *types.Transaction {
+		defer time.Sleep(time.Millisecond) // Ensure distinct arrival times
+		return types.MustSignNewTx(keys[sender], signer, &types.DynamicFeeTx{
+			ChainID:   ethashChainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(tip * params.GWei),
+			GasFeeCap: big.NewInt(100 * params.GWei),
+			Gas:       params.TxGas,
+			To:        &testUserAddress,
+			Value:     big.NewInt(1),
+		})
+	}
+	var (
+		a0 = newTx(0, 0, 3)
+		b0 = newTx(1, 0, 5)
+		a1 = newTx(0, 1, 10)
+		c0 = newTx(2, 0, 4)
+		c1 = newTx(2, 1, 2)
+		b1 = newTx(1, 1, 1)
+
+		pending = map[common.Address]types.Transactions{
+			addrs[0]: {a0, a1},
+			addrs[1]: {b0, b1},
+			addrs[2]: {c0, c1},
+		}
+	)
+	// The round robin ordering visits the senders in address order.
+	byAddr := []int{0, 1, 2}
+	sort.Slice(byAddr, func(i, j int) bool {
+		return bytes.Compare(addrs[byAddr[i]][:], addrs[byAddr[j]][:]) < 0
+	})
+	var rounds types.Transactions
+	for nonce := 0; nonce < 2; nonce++ {
+		for _, sender := range byAddr {
+			rounds = append(rounds, pending[addrs[sender]][nonce])
+		}
+	}
+	tests := []struct {
+		ordering string
+		want     types.Transactions
+	}{
+		{OrderingPrice, types.Transactions{b0, c0, a0, a1, c1, b1}},
+		{OrderingFIFO, types.Transactions{a0, b0, a1, c0, c1, b1}},
+		{OrderingRoundRobin, rounds},
+	}
+	for _, tt := range tests {
+		ordering, err := NewOrderingStrategy(tt.ordering)
+		if err != nil {
+			t.Fatalf("%s: failed to create ordering: %v", tt.ordering, err)
+		}
+		env, err := w.prepareWork(&generateParams{
+			timestamp:  uint64(time.Now().Unix()),
+			parentHash: b.chain.CurrentBlock().Hash(),
+			coinbase:   testBankAddress,
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to prepare work: %v", tt.ordering, err)
+		}
+		for _, addr := range addrs {
+			env.state.AddBalance(addr, big.NewInt(params.Ether), tracing.BalanceChangeUnspecified)
+		}
+		txs := make(map[common.Address]types.Transactions)
+		for addr, list := range pending {
+			txs[addr] = list
+		}
+		if err := w.commitTransactions(env, ordering.Order(env.signer, txs, env.header.BaseFee), nil); err != nil {
+			t.Fatalf("%s: failed to commit transactions: %v", tt.ordering, err)
+		}
+		env.discard()
+
+		if len(env.txs) != len(tt.want) {
+			t.Fatalf("%s: included transaction count mismatch: have %d, want %d", tt.ordering, len(env.txs), len(tt.want))
+		}
+		for i, tx := range env.txs {
+			if tx.Hash() != tt.want[i].Hash() {
+				t.Errorf("%s: transaction %d mismatch: have %x, want %x", tt.ordering, i, tx.Hash(), tt.want[i].Hash())
+			}
+		}
+	}
+}
+
+// Tests that the fifo ordering serves local transactions in arrival order along
+// with the remote ones, whereas the price ordering commits them first.
+func TestTransactionOrderingLocals(t *testing.T) {
+	b := newTestWorkerBackend(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	signer := types.LatestSigner(ethashChainConfig)
+
+	// Fund the user account, so it can submit local transactions
+	fund := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+		Nonce:    0,
+		To:       &testUserAddress,
+		Value:    big.NewInt(params.Ether / 10),
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(params.InitialBaseFee),
+	})
+	blocks, _ := core.GenerateChain(ethashChainConfig, b.chain.Genesis(), b.chain.Engine(), b.db, 1, func(i int, gen *core.BlockGen) {
+		gen.AddTx(fund)
+	})
+	if _, err := b.chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert funding block: %v", err)
+	}
+	for b.txPool.Nonce(testBankAddress) != 1 {
+		time.Sleep(time.Millisecond) // Wait for the pool to reset to the new head
+	}
+	// Submit a cheap remote transaction, followed by a pricier local one
+	remote := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+		Nonce:    1,
+		To:       &testUserAddress,
+		Value:    big.NewInt(1),
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(2 * params.InitialBaseFee),
+	})
+	time.Sleep(time.Millisecond) // Ensure distinct arrival times
+	local := types.MustSignNewTx(testUserKey, signer, &types.LegacyTx{
+		Nonce:    0,
+		To:       &testBankAddress,
+		Value:    big.NewInt(1),
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(3 * params.InitialBaseFee),
+	})
+	if errs := b.txPool.AddRemotesSync([]*types.Transaction{remote}); errs[0] != nil {
+		t.Fatalf("failed to add remote transaction: %v", errs[0])
+	}
+	if err := b.txPool.AddLocal(local); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	tests := []struct {
+		ordering string
+		want     types.Transactions
+	}{
+		{OrderingPrice, types.Transactions{local, remote}},
+		{OrderingFIFO, types.Transactions{remote, local}},
+	}
+	for _, tt := range tests {
+		config := *testConfig
+		config.Ordering = tt.ordering
+
+		w := newWorker(&config, ethashChainConfig, ethash.NewFaker(), b, new(event.TypeMux), nil, false)
+		env, err := w.prepareWork(&generateParams{
+			timestamp:  uint64(time.Now().Unix()),
+			parentHash: b.chain.CurrentBlock().Hash(),
+			coinbase:   testBankAddress,
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to prepare work: %v", tt.ordering, err)
+		}
+		if err := w.fillTransactions(nil, env, false); err != nil {
+			t.Fatalf("%s: failed to fill transactions: %v", tt.ordering, err)
+		}
+		env.discard()
+		w.close()
+
+		if len(env.txs) != len(tt.want) {
+			t.Fatalf("%s: included transaction count mismatch: have %d, want %d", tt.ordering, len(env.txs), len(tt.want))
+		}
+		for i, tx := range env.txs {
+			if tx.Hash() != tt.want[i].Hash() {
+				t.Errorf("%s: transaction %d mismatch: have %x, want %x", tt.ordering, i, tx.Hash(), tt.want[i].Hash())
+			}
+		}
+	}
+}
+
+func TestOrderingFallback(t *testing.T) {
+	if _, err := NewOrderingStrategy("random"); err == nil {
+		t.Fatal("expected error for unknown ordering")
+	}
+	config := *testConfig
+	config.Ordering = "random"
+
+	backend := newTestWorkerBackend(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	w := newWorker(&config, ethashChainConfig, ethash.NewFaker(), backend, new(event.TypeMux), nil, false)
+	defer w.close()
+
+	if _, ok := w.ordering.(priceOrdering); !ok {
+		t.Fatalf("unknown ordering not sanitized: have %T, want priceOrdering", w.ordering)
+	}
+}
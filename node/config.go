@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -189,6 +190,24 @@ type Config struct {
 	// Requests using ip address directly are not affected
 	GraphQLVirtualHosts []string `toml:",omitempty"`
 
+	// GraphQLMaxCost is the maximum cost of a GraphQL query, or of a subscription
+	// event. Queries estimated costlier are rejected before execution, the others
+	// are aborted once the fields resolved exceed the limit. Zero means no limit.
+	GraphQLMaxCost uint64 `toml:",omitempty"`
+
+	// GraphQLMaxDepth is the maximum nesting depth of the fields selected by a
+	// GraphQL operation. Zero means no limit.
+	GraphQLMaxDepth int `toml:",omitempty"`
+
+	// GraphQLTimeout is the maximum time a GraphQL query, or the resolution of a
+	// subscription event, may execute for. Zero means queries are only bounded by
+	// the HTTP timeouts, and subscription events by a second.
+	GraphQLTimeout time.Duration `toml:",omitempty"`
+
+	// GraphQLMaxResponseSize is the maximum size of a GraphQL response in bytes.
+	// Zero means no limit.
+	GraphQLMaxResponseSize int `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
@@ -199,6 +218,11 @@ type Config struct {
 
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
+
+	// DBEngine is the key-value database engine backing the node's databases,
+	// "leveldb" or "pebble". If empty, the engine of an existing database is
+	// used, falling back to leveldb for new ones.
+	DBEngine string `toml:",omitempty"`
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
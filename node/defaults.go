@@ -21,6 +21,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/nat"
@@ -48,17 +49,21 @@ var (
 
 // DefaultConfig contains reasonable default settings.
 var DefaultConfig = Config{
-	DataDir:             DefaultDataDir(),
-	HTTPPort:            DefaultHTTPPort,
-	AuthAddr:            DefaultAuthHost,
-	AuthPort:            DefaultAuthPort,
-	AuthVirtualHosts:    DefaultAuthVhosts,
-	HTTPModules:         []string{"net", "web3"},
-	HTTPVirtualHosts:    []string{"localhost"},
-	HTTPTimeouts:        rpc.DefaultHTTPTimeouts,
-	WSPort:              DefaultWSPort,
-	WSModules:           []string{"net", "web3"},
-	GraphQLVirtualHosts: []string{"localhost"},
+	DataDir:                DefaultDataDir(),
+	HTTPPort:               DefaultHTTPPort,
+	AuthAddr:               DefaultAuthHost,
+	AuthPort:               DefaultAuthPort,
+	AuthVirtualHosts:       DefaultAuthVhosts,
+	HTTPModules:            []string{"net", "web3"},
+	HTTPVirtualHosts:       []string{"localhost"},
+	HTTPTimeouts:           rpc.DefaultHTTPTimeouts,
+	WSPort:                 DefaultWSPort,
+	WSModules:              []string{"net", "web3"},
+	GraphQLVirtualHosts:    []string{"localhost"},
+	GraphQLMaxCost:         100000,
+	GraphQLMaxDepth:        20,
+	GraphQLTimeout:         20 * time.Second,
+	GraphQLMaxResponseSize: 16 * 1024 * 1024,
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
 		MaxPeers:   50,
@@ -86,6 +86,7 @@ type Database struct {
 	dirtiesSize  common.StorageSize // Storage size of the dirty node cache (exc. metadata)
 	childrenSize common.StorageSize // Storage size of the external children tracking
 	preimages    *preimageStore     // The store for caching preimages
+	pathdb       *pathDatabase      // Path-based node store, nil if the hash scheme is used
 
 	lock sync.RWMutex
 }
@@ -265,9 +266,11 @@ func expandNode(hash hashNode, n node) node {
 
 // Config defines all necessary options for database.
 type Config struct {
-	Cache     int    // Memory allowance (MB) to use for caching trie nodes in memory
-	Journal   string // Journal of clean cache to survive node restarts
-	Preimages bool   // Flag whether the preimage of trie key is recorded
+	Cache        int    // Memory allowance (MB) to use for caching trie nodes in memory
+	Journal      string // Journal of clean cache to survive node restarts
+	Preimages    bool   // Flag whether the preimage of trie key is recorded
+	Scheme       string // Node scheme used for storing trie nodes, hash scheme if empty
+	StateHistory uint64 // Number of recent state histories retained in path scheme, zero to retain all
 }
 
 // NewDatabase creates a new trie database to store ephemeral trie content before
@@ -301,6 +304,9 @@ func NewDatabaseWithConfig(diskdb ethdb.Database, config *Config) *Database {
 		}},
 		preimages: preimage,
 	}
+	if config != nil && config.Scheme == PathScheme {
+		db.pathdb = newPathDatabase(diskdb, cleans, config.StateHistory)
+	}
 	return db
 }
 
@@ -384,6 +390,10 @@ func (db *Database) Node(hash common.Hash) ([]byte, error) {
 	if hash == (common.Hash{}) {
 		return nil, errors.New("not found")
 	}
+	// Nodes are not addressable by hash in the path scheme
+	if db.pathdb != nil {
+		return nil, errors.New("not supported")
+	}
 	// Retrieve the node from the clean cache if available
 	if db.cleans != nil {
 		if enc := db.cleans.Get(nil, hash[:]); enc != nil {
@@ -438,6 +448,11 @@ func (db *Database) Nodes() []common.Hash {
 // and external node(e.g. storage trie root), all internal trie nodes
 // are referenced together by database itself.
 func (db *Database) Reference(child common.Hash, parent common.Hash) {
+	// The path scheme doesn't track references, the states are retained
+	// as diff layers instead
+	if db.pathdb != nil {
+		return
+	}
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
@@ -467,6 +482,10 @@ func (db *Database) reference(child common.Hash, parent common.Hash) {
 
 // Dereference removes an existing reference from a root node.
 func (db *Database) Dereference(root common.Hash) {
+	// The stale states are overwritten in place in the path scheme
+	if db.pathdb != nil {
+		return
+	}
 	// Sanity check to ensure that the meta-root is not removed
 	if root == (common.Hash{}) {
 		log.Error("Attempted to dereference the trie cache meta root")
@@ -546,6 +565,10 @@ func (db *Database) dereference(child common.Hash, parent common.Hash) {
 // Note, this method is a non-synchronized mutator. It is unsafe to call this
 // concurrently with other mutators.
 func (db *Database) Cap(limit common.StorageSize) error {
+	// The path scheme flushes the diff layers on its own
+	if db.pathdb != nil {
+		return nil
+	}
 	// Create a database batch to flush persistent data out. It is important that
 	// outside code doesn't see an inconsistent state (referenced data removed from
 	// memory cache during commit but not yet in persistent storage). This is ensured
@@ -633,6 +656,14 @@ func (db *Database) Cap(limit common.StorageSize) error {
 // Note, this method is a non-synchronized mutator. It is unsafe to call this
 // concurrently with other mutators.
 func (db *Database) Commit(node common.Hash, report bool, callback func(common.Hash)) error {
+	if db.pathdb != nil {
+		if db.preimages != nil {
+			if err := db.preimages.commit(true); err != nil {
+				return err
+			}
+		}
+		return db.pathdb.commit(node, report)
+	}
 	// Create a database batch to flush persistent data out. It is important that
 	// outside code doesn't see an inconsistent state (referenced data removed from
 	// memory cache during commit but not yet in persistent storage). This is ensured
@@ -772,8 +803,13 @@ func (c *cleaner) Delete(key []byte) error {
 }
 
 // Update inserts the dirty nodes in provided nodeset into database and
-// link the account trie with multiple storage tries if necessary.
-func (db *Database) Update(nodes *MergedNodeSet) error {
+// link the account trie with multiple storage tries if necessary. The root
+// and parent are the state roots after and before the transition, which are
+// only needed by the path scheme to stack the changes as a new diff layer.
+func (db *Database) Update(root common.Hash, parent common.Hash, nodes *MergedNodeSet) error {
+	if db.pathdb != nil {
+		return db.pathdb.update(root, parent, nodes)
+	}
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
@@ -822,6 +858,13 @@ func (db *Database) Update(nodes *MergedNodeSet) error {
 // Size returns the current storage size of the memory cache in front of the
 // persistent database layer.
 func (db *Database) Size() (common.StorageSize, common.StorageSize) {
+	var preimageSize common.StorageSize
+	if db.preimages != nil {
+		preimageSize = db.preimages.size()
+	}
+	if db.pathdb != nil {
+		return db.pathdb.size(), preimageSize
+	}
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
@@ -830,15 +873,14 @@ func (db *Database) Size() (common.StorageSize, common.StorageSize) {
 	// counted.
 	var metadataSize = common.StorageSize((len(db.dirties) - 1) * cachedNodeSize)
 	var metarootRefs = common.StorageSize(len(db.dirties[common.Hash{}].children) * (common.HashLength + 2))
-	var preimageSize common.StorageSize
-	if db.preimages != nil {
-		preimageSize = db.preimages.size()
-	}
 	return db.dirtiesSize + db.childrenSize + metadataSize - metarootRefs, preimageSize
 }
 
 // GetReader retrieves a node reader belonging to the given state root.
 func (db *Database) GetReader(root common.Hash) Reader {
+	if db.pathdb != nil {
+		return db.pathdb.reader(root)
+	}
 	return newHashReader(db)
 }
 
@@ -920,5 +962,38 @@ func (db *Database) CommitPreimages() error {
 
 // Scheme returns the node scheme used in the database.
 func (db *Database) Scheme() NodeScheme {
+	if db.pathdb != nil {
+		return &pathScheme{}
+	}
 	return &hashScheme{}
 }
+
+// Recoverable reports whether the persisted state can be rolled back to the
+// one with the given root. It's always false in the hash scheme, which has no
+// notion of reverting the state.
+func (db *Database) Recoverable(root common.Hash) bool {
+	if db.pathdb == nil {
+		return false
+	}
+	return db.pathdb.recoverable(root)
+}
+
+// Recover rolls the persisted state back to the one with the given root,
+// discarding all the states built on top of it. It's only supported in the
+// path scheme.
+func (db *Database) Recover(root common.Hash) error {
+	if db.pathdb == nil {
+		return errors.New("not supported")
+	}
+	return db.pathdb.recover(root)
+}
+
+// Enable activates the state written directly into the database by snap sync,
+// dropping all the states tracked before. It's only needed in the path scheme,
+// where the persisted state has to be reloaded.
+func (db *Database) Enable(root common.Hash) error {
+	if db.pathdb == nil {
+		return nil
+	}
+	return db.pathdb.enable(root)
+}
@@ -0,0 +1,513 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// maxDiffLayers is the number of in-memory diff layers retained on top of
+	// the disk layer. Chain reorgs within this depth are resolved in memory,
+	// deeper ones need to roll the disk layer back using the state histories.
+	maxDiffLayers = 128
+
+	// DefaultStateHistory is the default number of reverse diffs of recent
+	// persisted states retained in the database.
+	DefaultStateHistory = 90000
+)
+
+var (
+	pathdbCleanHitMeter   = metrics.NewRegisteredMeter("trie/pathdb/clean/hit", nil)
+	pathdbCleanMissMeter  = metrics.NewRegisteredMeter("trie/pathdb/clean/miss", nil)
+	pathdbCleanFalseMeter = metrics.NewRegisteredMeter("trie/pathdb/clean/false", nil)
+	pathdbCleanReadMeter  = metrics.NewRegisteredMeter("trie/pathdb/clean/read", nil)
+	pathdbCleanWriteMeter = metrics.NewRegisteredMeter("trie/pathdb/clean/write", nil)
+
+	pathdbDirtyHitMeter     = metrics.NewRegisteredMeter("trie/pathdb/dirty/hit", nil)
+	pathdbDirtyMissMeter    = metrics.NewRegisteredMeter("trie/pathdb/dirty/miss", nil)
+	pathdbDirtyReadMeter    = metrics.NewRegisteredMeter("trie/pathdb/dirty/read", nil)
+	pathdbDirtyHitDepthHist = metrics.NewRegisteredHistogram("trie/pathdb/dirty/depth", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+	pathdbCommitTimeTimer  = metrics.NewRegisteredResettingTimer("trie/pathdb/commit/time", nil)
+	pathdbCommitNodesMeter = metrics.NewRegisteredMeter("trie/pathdb/commit/nodes", nil)
+	pathdbCommitSizeMeter  = metrics.NewRegisteredMeter("trie/pathdb/commit/size", nil)
+
+	pathdbHistorySizeMeter = metrics.NewRegisteredMeter("trie/pathdb/history/size", nil)
+)
+
+var (
+	// errStateUnrecoverable is returned if the persisted state can't be rolled
+	// back to the requested one, as it's unknown or its history was pruned.
+	errStateUnrecoverable = errors.New("state is unrecoverable")
+)
+
+// pathDatabase is the backend of the trie database for the path-based node
+// scheme. A single state is persisted on disk with its trie nodes keyed by
+// owner and path, so that persisting a state transition overwrites the stale
+// nodes in place and the size of the state on disk stays bounded. The recent
+// states are kept as in-memory diff layers on top of it, and the reverse diffs
+// of the persisted transitions are retained to roll the disk layer back on
+// deep reorgs.
+type pathDatabase struct {
+	diskdb  ethdb.Database   // Persistent storage for the disk layer and state histories
+	cleans  *fastcache.Cache // GC friendly memory cache of clean node RLPs, keyed by owner and path
+	history uint64           // Number of recent state histories retained, zero to retain all
+
+	layers map[common.Hash]layer // Disk layer and diff layers, keyed by state root
+	lock   sync.RWMutex
+}
+
+// newPathDatabase opens the path-based trie database on top of the state
+// persisted in the given key-value store.
+func newPathDatabase(diskdb ethdb.Database, cleans *fastcache.Cache, history uint64) *pathDatabase {
+	db := &pathDatabase{
+		diskdb:  diskdb,
+		cleans:  cleans,
+		history: history,
+	}
+	disk := db.loadDiskLayer()
+	db.layers = map[common.Hash]layer{disk.root: disk}
+	return db
+}
+
+// loadDiskLayer constructs the disk layer from the state persisted in the
+// database. The state root is the hash of the stored account trie root node.
+func (db *pathDatabase) loadDiskLayer() *diskLayer {
+	root := emptyRoot
+	if blob, _ := rawdb.ReadAccountTrieNode(db.diskdb, nil); len(blob) > 0 {
+		root = crypto.Keccak256Hash(blob)
+	}
+	return &diskLayer{
+		root:   root,
+		id:     rawdb.ReadPersistentStateID(db.diskdb),
+		diskdb: db.diskdb,
+		cleans: db.cleans,
+	}
+}
+
+// disk returns the current disk layer.
+func (db *pathDatabase) disk() *diskLayer {
+	for _, l := range db.layers {
+		for {
+			if disk, ok := l.(*diskLayer); ok {
+				return disk
+			}
+			l = l.parentLayer()
+		}
+	}
+	return nil
+}
+
+// reader returns a reader for the trie nodes of the given state, or nil if
+// the state is neither persisted nor kept in memory.
+func (db *pathDatabase) reader(root common.Hash) Reader {
+	if root == (common.Hash{}) {
+		root = emptyRoot
+	}
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	l, ok := db.layers[root]
+	if !ok {
+		return nil
+	}
+	return &pathReader{layer: l}
+}
+
+// update adds the dirty nodes of a state transition as a new diff layer on
+// top of the parent state, persisting the bottom-most layers exceeding the
+// number of in-memory diff layers retained.
+func (db *pathDatabase) update(root common.Hash, parent common.Hash, nodes *MergedNodeSet) error {
+	if parent == (common.Hash{}) {
+		parent = emptyRoot
+	}
+	// Reject noop updates to avoid self-loops. This can only happen for empty
+	// blocks which don't modify the state.
+	if root == parent {
+		return nil
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// The same state might be reached from different forks, it's enough to
+	// track one of them.
+	if _, ok := db.layers[root]; ok {
+		return nil
+	}
+	base, ok := db.layers[parent]
+	if !ok {
+		return fmt.Errorf("triedb parent [%#x] layer missing", parent)
+	}
+	db.layers[root] = newDiffLayer(base, root, nodes)
+
+	// Retain the configured number of diff layers below the new one, the
+	// persistent layer being the (maxDiffLayers + 1)th.
+	var (
+		diff  = db.layers[root].(*diffLayer)
+		depth = 1
+	)
+	for depth < maxDiffLayers {
+		parent, ok := diff.parentLayer().(*diffLayer)
+		if !ok {
+			return nil
+		}
+		diff, depth = parent, depth+1
+	}
+	bottom, ok := diff.parentLayer().(*diffLayer)
+	if !ok {
+		return nil
+	}
+	return db.flatten(bottom, false)
+}
+
+// commit persists the state with the given root along with all the diff
+// layers below it, discarding the diff layers not built on top of it.
+func (db *pathDatabase) commit(root common.Hash, report bool) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	l, ok := db.layers[root]
+	if !ok {
+		return fmt.Errorf("triedb layer [%#x] missing", root)
+	}
+	diff, ok := l.(*diffLayer)
+	if !ok {
+		return nil // State already persisted
+	}
+	return db.flatten(diff, report)
+}
+
+// flatten persists the given diff layer along with all the diff layers below
+// it, moving the layers built on top of it onto the new disk layer and dropping
+// the ones built on other forks.
+//
+// The caller must hold the write lock.
+func (db *pathDatabase) flatten(top *diffLayer, report bool) error {
+	// Collect the diff layers to persist, bottom-most first
+	var (
+		diffs []*diffLayer
+		l     layer = top
+	)
+	for {
+		diff, ok := l.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append([]*diffLayer{diff}, diffs...)
+		l = diff.parentLayer()
+	}
+	var (
+		disk  = l.(*diskLayer)
+		start = time.Now()
+		nodes int
+		size  common.StorageSize
+	)
+	for _, diff := range diffs {
+		n, s, err := db.persist(disk, diff)
+		if err != nil {
+			return err
+		}
+		nodes, size = nodes+n, size+s
+		disk = &diskLayer{
+			root:   diff.root,
+			id:     diff.id,
+			diskdb: db.diskdb,
+			cleans: db.cleans,
+		}
+	}
+	// Move the layers built on the persisted state onto the new disk layer and
+	// drop everything which is not a descendant of it anymore
+	for _, l := range db.layers {
+		if diff, ok := l.(*diffLayer); ok && diff.parentLayer() == top {
+			diff.setParent(disk)
+		}
+	}
+	layers := map[common.Hash]layer{disk.root: disk}
+	for root, l := range db.layers {
+		for base := l; base != nil; base = base.parentLayer() {
+			if base == disk {
+				layers[root] = l
+				break
+			}
+		}
+	}
+	db.layers = layers
+
+	pathdbCommitTimeTimer.Update(time.Since(start))
+	pathdbCommitNodesMeter.Mark(int64(nodes))
+	pathdbCommitSizeMeter.Mark(int64(size))
+
+	logger := log.Debug
+	if report {
+		logger = log.Info
+	}
+	logger("Persisted trie from memory database", "layers", len(diffs), "nodes", nodes, "size", size, "time", common.PrettyDuration(time.Since(start)), "id", disk.id, "root", disk.root)
+	return nil
+}
+
+// persist writes the nodes of a diff layer directly on top of the disk layer,
+// along with the reverse diff to roll it back. The disk layer turns stale.
+func (db *pathDatabase) persist(disk *diskLayer, diff *diffLayer) (int, common.StorageSize, error) {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+
+	if disk.stale {
+		return 0, 0, errLayerStale
+	}
+	batch := db.diskdb.NewBatch()
+
+	// Store the reverse diff of the transition, pruning the ones falling out
+	// of the retained range together with their state ids
+	blob, err := newStateHistory(disk.root, diff).encode()
+	if err != nil {
+		return 0, 0, err
+	}
+	rawdb.WriteStateHistory(batch, diff.id, blob)
+	rawdb.WriteStateID(batch, diff.root, diff.id)
+	pathdbHistorySizeMeter.Mark(int64(len(blob)))
+
+	if db.history > 0 && diff.id > db.history {
+		if old := rawdb.ReadStateHistory(db.diskdb, diff.id-db.history); len(old) > 0 {
+			if h, err := decodeStateHistory(old); err == nil {
+				rawdb.DeleteStateID(batch, h.Parent)
+			}
+			rawdb.DeleteStateHistory(batch, diff.id-db.history)
+		}
+	}
+	// Overwrite the stale nodes in place
+	var (
+		nodes int
+		size  common.StorageSize
+	)
+	for owner, subset := range diff.nodes {
+		for path, n := range subset {
+			if n.hash == (common.Hash{}) {
+				if owner == (common.Hash{}) {
+					rawdb.DeleteAccountTrieNode(batch, []byte(path))
+				} else {
+					rawdb.DeleteStorageTrieNode(batch, owner, []byte(path))
+				}
+				if db.cleans != nil {
+					db.cleans.Del(cacheKey(owner, []byte(path)))
+				}
+			} else {
+				blob := n.rlp()
+				if owner == (common.Hash{}) {
+					rawdb.WriteAccountTrieNode(batch, []byte(path), blob)
+				} else {
+					rawdb.WriteStorageTrieNode(batch, owner, []byte(path), blob)
+				}
+				if db.cleans != nil {
+					db.cleans.Set(cacheKey(owner, []byte(path)), blob)
+				}
+				size += common.StorageSize(len(path) + len(blob))
+			}
+			nodes++
+		}
+	}
+	rawdb.WritePersistentStateID(batch, diff.id)
+	if err := batch.Write(); err != nil {
+		return 0, 0, err
+	}
+	disk.stale = true
+	return nodes, size, nil
+}
+
+// recoverable reports whether the disk layer can be rolled back to the state
+// with the given root.
+func (db *pathDatabase) recoverable(root common.Hash) bool {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil {
+		return false
+	}
+	disk := db.disk()
+	if *id >= disk.id {
+		return false
+	}
+	for i := disk.id; i > *id; i-- {
+		if len(rawdb.ReadStateHistory(db.diskdb, i)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// recover rolls the disk layer back to the state with the given root, applying
+// the reverse diffs of all the transitions persisted after it. All the diff
+// layers are discarded.
+func (db *pathDatabase) recover(root common.Hash) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	disk := db.disk()
+	if disk.root == root {
+		db.layers = map[common.Hash]layer{disk.root: disk}
+		return nil
+	}
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil || *id >= disk.id {
+		return errStateUnrecoverable
+	}
+	for i := disk.id; i > *id; i-- {
+		if len(rawdb.ReadStateHistory(db.diskdb, i)) == 0 {
+			return errStateUnrecoverable
+		}
+	}
+	start := time.Now()
+	for disk.id > *id {
+		h, err := decodeStateHistory(rawdb.ReadStateHistory(db.diskdb, disk.id))
+		if err != nil {
+			return err
+		}
+		if h.Root != disk.root {
+			return fmt.Errorf("state history mismatch: have %#x, want %#x", h.Root, disk.root)
+		}
+		batch := db.diskdb.NewBatch()
+		for _, trie := range h.Tries {
+			for _, n := range trie.Nodes {
+				if len(n.Blob) == 0 {
+					if trie.Owner == (common.Hash{}) {
+						rawdb.DeleteAccountTrieNode(batch, n.Path)
+					} else {
+						rawdb.DeleteStorageTrieNode(batch, trie.Owner, n.Path)
+					}
+				} else {
+					if trie.Owner == (common.Hash{}) {
+						rawdb.WriteAccountTrieNode(batch, n.Path, n.Blob)
+					} else {
+						rawdb.WriteStorageTrieNode(batch, trie.Owner, n.Path, n.Blob)
+					}
+				}
+				if db.cleans != nil {
+					db.cleans.Del(cacheKey(trie.Owner, n.Path))
+				}
+			}
+		}
+		rawdb.DeleteStateHistory(batch, disk.id)
+		rawdb.DeleteStateID(batch, h.Root)
+		rawdb.WritePersistentStateID(batch, disk.id-1)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		disk.markStale()
+		disk = &diskLayer{
+			root:   h.Parent,
+			id:     disk.id - 1,
+			diskdb: db.diskdb,
+			cleans: db.cleans,
+		}
+	}
+	db.layers = map[common.Hash]layer{disk.root: disk}
+	log.Info("Rolled back persistent trie state", "root", disk.root, "id", disk.id, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// enable resets the database onto the state written into the disk by an
+// external process (e.g. snap sync), verifying that its root is the given
+// one. The diff layers and the histories of the previous state are dropped.
+func (db *pathDatabase) enable(root common.Hash) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	stored := emptyRoot
+	if blob, _ := rawdb.ReadAccountTrieNode(db.diskdb, nil); len(blob) > 0 {
+		stored = crypto.Keccak256Hash(blob)
+	}
+	if stored != root {
+		return fmt.Errorf("state root mismatch: stored %#x, synced %#x", stored, root)
+	}
+	batch := db.diskdb.NewBatch()
+	for id := rawdb.ReadPersistentStateID(db.diskdb); id > 0; id-- {
+		blob := rawdb.ReadStateHistory(db.diskdb, id)
+		if len(blob) == 0 {
+			break
+		}
+		if h, err := decodeStateHistory(blob); err == nil {
+			rawdb.DeleteStateID(batch, h.Root)
+		}
+		rawdb.DeleteStateHistory(batch, id)
+	}
+	rawdb.WritePersistentStateID(batch, 0)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	if db.cleans != nil {
+		db.cleans.Reset()
+	}
+	disk := db.disk()
+	disk.lock.Lock()
+	disk.stale = true
+	disk.lock.Unlock()
+
+	disk = db.loadDiskLayer()
+	db.layers = map[common.Hash]layer{disk.root: disk}
+	log.Info("Enabled persistent trie state", "root", root)
+	return nil
+}
+
+// size returns the memory used by the in-memory diff layers.
+func (db *pathDatabase) size() common.StorageSize {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var size common.StorageSize
+	for _, l := range db.layers {
+		if diff, ok := l.(*diffLayer); ok {
+			size += diff.size
+		}
+	}
+	return size
+}
+
+// pathReader is the reader of the path-based trie database, resolving the
+// nodes of a single state.
+type pathReader struct {
+	layer layer
+}
+
+// Node retrieves the trie node with the given node info.
+func (r *pathReader) Node(owner common.Hash, path []byte, hash common.Hash) (node, error) {
+	n, err := r.layer.node(owner, path, hash, 0)
+	if err != nil {
+		return nil, err
+	}
+	return n.obj(), nil
+}
+
+// NodeBlob retrieves the RLP-encoded trie node blob with the given node info.
+func (r *pathReader) NodeBlob(owner common.Hash, path []byte, hash common.Hash) ([]byte, error) {
+	n, err := r.layer.node(owner, path, hash, 0)
+	if err != nil {
+		return nil, err
+	}
+	return n.rlp(), nil
+}
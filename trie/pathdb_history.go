@@ -0,0 +1,89 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// stateHistory is the reverse diff of a persisted state transition, holding
+// the previous values of all the trie nodes modified by it. It's used to roll
+// the disk layer back to the parent state, as the path-based scheme overwrites
+// the nodes of the parent in place.
+type stateHistory struct {
+	Parent common.Hash   // Root hash of the state before the transition
+	Root   common.Hash   // Root hash of the state after the transition
+	Tries  []historyTrie // Previous node values, grouped by trie
+}
+
+// historyTrie contains the previous node values of a single trie.
+type historyTrie struct {
+	Owner common.Hash   // Identifier of the trie, zero for the account trie
+	Nodes []historyNode // Previous node values, sorted by path
+}
+
+// historyNode is the previous value of a trie node, empty if the node didn't
+// exist before the transition.
+type historyNode struct {
+	Path []byte
+	Blob []byte
+}
+
+// newStateHistory constructs the reverse diff of the given diff layer, which
+// is about to be persisted on top of the parent state.
+func newStateHistory(parent common.Hash, dl *diffLayer) *stateHistory {
+	h := &stateHistory{
+		Parent: parent,
+		Root:   dl.root,
+	}
+	owners := make([]common.Hash, 0, len(dl.prevs))
+	for owner := range dl.prevs {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return bytes.Compare(owners[i][:], owners[j][:]) < 0
+	})
+	for _, owner := range owners {
+		trie := historyTrie{Owner: owner}
+		for path, blob := range dl.prevs[owner] {
+			trie.Nodes = append(trie.Nodes, historyNode{Path: []byte(path), Blob: blob})
+		}
+		sort.Slice(trie.Nodes, func(i, j int) bool {
+			return bytes.Compare(trie.Nodes[i].Path, trie.Nodes[j].Path) < 0
+		})
+		h.Tries = append(h.Tries, trie)
+	}
+	return h
+}
+
+// encode serializes the reverse diff for storing it in the database.
+func (h *stateHistory) encode() ([]byte, error) {
+	return rlp.EncodeToBytes(h)
+}
+
+// decodeStateHistory deserializes a reverse diff stored in the database.
+func decodeStateHistory(blob []byte) (*stateHistory, error) {
+	h := new(stateHistory)
+	if err := rlp.DecodeBytes(blob, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
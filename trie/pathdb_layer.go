@@ -0,0 +1,265 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var (
+	// errLayerStale is returned from data accessors if the underlying layer
+	// had been invalidated due to the chain progressing forward far enough
+	// to not maintain the layer's original state.
+	errLayerStale = errors.New("layer stale")
+
+	// errUnexpectedNode is returned if the node stored at the requested path
+	// doesn't have the requested hash, or isn't present at all.
+	errUnexpectedNode = errors.New("unexpected node")
+)
+
+// layer is a state of the path-based trie database, either the single disk
+// layer persisted in the database or one of the in-memory diff layers stacked
+// on top of it.
+type layer interface {
+	// rootHash returns the root hash of the state represented by the layer.
+	rootHash() common.Hash
+
+	// stateID returns the id of the state represented by the layer. The ids
+	// are increased by one for every state transition.
+	stateID() uint64
+
+	// parentLayer returns the layer the diff layer is based on, or nil for
+	// the disk layer.
+	parentLayer() layer
+
+	// node retrieves the trie node with the provided owner and path, checking
+	// that its hash matches the requested one. An error is returned if the
+	// node can't be found or if the layer is stale.
+	node(owner common.Hash, path []byte, hash common.Hash, depth int) (*memoryNode, error)
+}
+
+// unexpectedNodeError assembles the error returned if the node found at the
+// requested path doesn't match the requested hash.
+func unexpectedNodeError(loc string, want common.Hash, have common.Hash, owner common.Hash, path []byte) error {
+	return fmt.Errorf("%w, loc: %s, node: (%x %v), %x!=%x", errUnexpectedNode, loc, owner, path, want, have)
+}
+
+// diffLayer is an in-memory state transition on top of another layer, holding
+// the trie nodes modified by it along with their previous values.
+type diffLayer struct {
+	root  common.Hash                            // Root hash of the state after the transition
+	id    uint64                                 // Id of the state after the transition
+	nodes map[common.Hash]map[string]*memoryNode // Modified nodes keyed by owner and path, empty for deleted ones
+	prevs map[common.Hash]map[string][]byte      // Node values prior to the transition, nil for new nodes
+	size  common.StorageSize                     // Approximate memory used by the modified nodes
+
+	parent layer // Parent layer modified by this one, never nil
+	lock   sync.RWMutex
+}
+
+// newDiffLayer creates a diff layer on top of the given parent, holding the
+// dirty nodes collected from the tries of the state transition.
+func newDiffLayer(parent layer, root common.Hash, nodes *MergedNodeSet) *diffLayer {
+	dl := &diffLayer{
+		root:   root,
+		id:     parent.stateID() + 1,
+		nodes:  make(map[common.Hash]map[string]*memoryNode),
+		prevs:  make(map[common.Hash]map[string][]byte),
+		parent: parent,
+	}
+	for owner, set := range nodes.sets {
+		subset := make(map[string]*memoryNode)
+		prevs := make(map[string][]byte)
+		for path, prev := range set.deletes {
+			subset[path] = &memoryNode{}
+			prevs[path] = prev
+			dl.size += common.StorageSize(len(path) + len(prev))
+		}
+		for path, n := range set.updates.nodes {
+			subset[path] = n.unwrap()
+			prevs[path] = n.prev
+			dl.size += common.StorageSize(n.memorySize(len(path)))
+		}
+		dl.nodes[owner] = subset
+		dl.prevs[owner] = prevs
+	}
+	return dl
+}
+
+// rootHash implements the layer interface, returning the root hash of the
+// state after the transition.
+func (dl *diffLayer) rootHash() common.Hash {
+	return dl.root
+}
+
+// stateID implements the layer interface, returning the id of the state after
+// the transition.
+func (dl *diffLayer) stateID() uint64 {
+	return dl.id
+}
+
+// parentLayer implements the layer interface, returning the layer the diff
+// is based on.
+func (dl *diffLayer) parentLayer() layer {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.parent
+}
+
+// node implements the layer interface, retrieving the trie node from the diff
+// if it was modified by the transition, or from the parent layer otherwise.
+func (dl *diffLayer) node(owner common.Hash, path []byte, hash common.Hash, depth int) (*memoryNode, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if subset, ok := dl.nodes[owner]; ok {
+		if n, ok := subset[string(path)]; ok {
+			// If the trie node is not hash matched, or marked as removed,
+			// bubble up an error here. It shouldn't happen at all.
+			if n.hash != hash {
+				return nil, unexpectedNodeError("diff", hash, n.hash, owner, path)
+			}
+			pathdbDirtyHitMeter.Mark(1)
+			pathdbDirtyHitDepthHist.Update(int64(depth))
+			pathdbDirtyReadMeter.Mark(int64(n.size))
+			return n, nil
+		}
+	}
+	// Trie node unknown to this layer, resolve from parent
+	return dl.parent.node(owner, path, hash, depth+1)
+}
+
+// setParent replaces the parent of the diff layer, used when the parent got
+// flattened into the disk layer.
+func (dl *diffLayer) setParent(parent layer) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.parent = parent
+}
+
+// diskLayer is the state persisted in the database. Its trie nodes are keyed
+// by owner and path, so every persisted transition overwrites the nodes of the
+// previous state in place.
+type diskLayer struct {
+	root   common.Hash      // Root hash of the persisted state
+	id     uint64           // Id of the persisted state
+	diskdb ethdb.Database   // Key-value store containing the persisted nodes
+	cleans *fastcache.Cache // Cache of clean node RLPs keyed by owner and path, verified by hash
+
+	stale bool // Signals that the layer became stale (state progressed)
+	lock  sync.RWMutex
+}
+
+// rootHash implements the layer interface, returning the root hash of the
+// persisted state.
+func (dl *diskLayer) rootHash() common.Hash {
+	return dl.root
+}
+
+// stateID implements the layer interface, returning the id of the persisted
+// state.
+func (dl *diskLayer) stateID() uint64 {
+	return dl.id
+}
+
+// parentLayer implements the layer interface, returning nil as there's no
+// layer below the disk one.
+func (dl *diskLayer) parentLayer() layer {
+	return nil
+}
+
+// isStale reports whether the persisted state moved on from this layer.
+func (dl *diskLayer) isStale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// markStale flags the layer as stale, rejecting all further reads.
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	if dl.stale {
+		panic("triedb disk layer is stale") // we've committed into the same base from two children, boom
+	}
+	dl.stale = true
+}
+
+// node implements the layer interface, retrieving the trie node from the clean
+// cache or the database and checking it against the requested hash.
+func (dl *diskLayer) node(owner common.Hash, path []byte, hash common.Hash, depth int) (*memoryNode, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, errLayerStale
+	}
+	pathdbDirtyMissMeter.Mark(1)
+
+	// Try to retrieve the trie node from the clean memory cache
+	key := cacheKey(owner, path)
+	if dl.cleans != nil {
+		if blob := dl.cleans.Get(nil, key); len(blob) > 0 {
+			if crypto.Keccak256Hash(blob) == hash {
+				pathdbCleanHitMeter.Mark(1)
+				pathdbCleanReadMeter.Mark(int64(len(blob)))
+				return &memoryNode{hash: hash, size: uint16(len(blob)), node: rawNode(blob)}, nil
+			}
+			pathdbCleanFalseMeter.Mark(1)
+		}
+		pathdbCleanMissMeter.Mark(1)
+	}
+	// Try to retrieve the trie node from the disk
+	var (
+		blob  []byte
+		nHash common.Hash
+	)
+	if owner == (common.Hash{}) {
+		blob, nHash = rawdb.ReadAccountTrieNode(dl.diskdb, path)
+	} else {
+		blob, nHash = rawdb.ReadStorageTrieNode(dl.diskdb, owner, path)
+	}
+	if nHash != hash {
+		return nil, unexpectedNodeError("disk", hash, nHash, owner, path)
+	}
+	if dl.cleans != nil && len(blob) > 0 {
+		dl.cleans.Set(key, blob)
+		pathdbCleanWriteMeter.Mark(int64(len(blob)))
+	}
+	return &memoryNode{hash: hash, size: uint16(len(blob)), node: rawNode(blob)}, nil
+}
+
+// cacheKey constructs the unique key of the clean cache for the node with the
+// given owner and path.
+func cacheKey(owner common.Hash, path []byte) []byte {
+	if owner == (common.Hash{}) {
+		return path
+	}
+	return append(owner.Bytes(), path...)
+}
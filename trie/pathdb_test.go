@@ -0,0 +1,213 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// pathTester builds a chain of random state transitions on top of a path-based
+// trie database, tracking the expected content of every state.
+type pathTester struct {
+	diskdb ethdb.Database
+	db     *Database
+	roots  []common.Hash
+	states []map[string][]byte
+}
+
+func newPathTester(t *testing.T, history uint64, transitions int) *pathTester {
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		tester = &pathTester{
+			diskdb: diskdb,
+			db:     NewDatabaseWithConfig(diskdb, &Config{Scheme: PathScheme, StateHistory: history}),
+		}
+		rng    = rand.New(rand.NewSource(1))
+		parent = emptyRoot
+		state  = make(map[string][]byte)
+	)
+	for i := 0; i < transitions; i++ {
+		tr, err := New(TrieID(parent), tester.db)
+		if err != nil {
+			t.Fatalf("Failed to open trie %d: %v", i, err)
+		}
+		next := make(map[string][]byte)
+		for k, v := range state {
+			next[k] = v
+		}
+		for j := 0; j < 20; j++ {
+			key := string(crypto.Keccak256([]byte{byte(rng.Intn(64))}))
+			if _, ok := next[key]; ok && rng.Intn(3) == 0 {
+				tr.Delete([]byte(key))
+				delete(next, key)
+				continue
+			}
+			val := make([]byte, 32)
+			rng.Read(val)
+			tr.Update([]byte(key), val)
+			next[key] = val
+		}
+		root, nodes, err := tr.Commit(false)
+		if err != nil {
+			t.Fatalf("Failed to commit trie %d: %v", i, err)
+		}
+		if nodes != nil {
+			if err := tester.db.Update(root, parent, NewWithNodeSet(nodes)); err != nil {
+				t.Fatalf("Failed to update database %d: %v", i, err)
+			}
+		}
+		tester.roots = append(tester.roots, root)
+		tester.states = append(tester.states, next)
+		parent, state = root, next
+	}
+	return tester
+}
+
+// verify checks that the i-th state is accessible and has the expected content.
+func (tester *pathTester) verify(t *testing.T, i int) {
+	t.Helper()
+
+	tr, err := New(TrieID(tester.roots[i]), tester.db)
+	if err != nil {
+		t.Fatalf("State %d unavailable: %v", i, err)
+	}
+	for key, want := range tester.states[i] {
+		have, err := tr.TryGet([]byte(key))
+		if err != nil {
+			t.Fatalf("State %d: failed to read %x: %v", i, key, err)
+		}
+		if !bytes.Equal(have, want) {
+			t.Fatalf("State %d: value mismatch for %x: have %x, want %x", i, key, have, want)
+		}
+	}
+}
+
+// verifyDisk checks that the disk only contains the nodes of the i-th state,
+// the stale nodes of the previous states being overwritten or deleted.
+func (tester *pathTester) verifyDisk(t *testing.T, i int) {
+	t.Helper()
+
+	tr, _ := New(TrieID(tester.roots[i]), tester.db)
+	var want int
+	for it := tr.NodeIterator(nil); it.Next(true); {
+		if it.Hash() != (common.Hash{}) {
+			want++
+		}
+	}
+	var have int
+	it := tester.diskdb.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		if ok, _ := rawdb.IsAccountTrieNode(it.Key()); ok {
+			have++
+		}
+	}
+	if have != want {
+		t.Fatalf("Persisted node count mismatch: have %d, want %d", have, want)
+	}
+}
+
+func TestPathDatabaseCommit(t *testing.T) {
+	tester := newPathTester(t, 0, 10)
+	for i := range tester.roots {
+		tester.verify(t, i)
+	}
+	if err := tester.db.Commit(tester.roots[5], false, nil); err != nil {
+		t.Fatalf("Failed to commit state: %v", err)
+	}
+	// The persisted state and the ones built on top of it are available
+	for i := 5; i < len(tester.roots); i++ {
+		tester.verify(t, i)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := New(TrieID(tester.roots[i]), tester.db); err == nil {
+			t.Fatalf("State %d available after being overwritten", i)
+		}
+	}
+	tester.verifyDisk(t, 5)
+
+	// Reopen the database, only the persisted state should be available
+	tester.db = NewDatabaseWithConfig(tester.diskdb, &Config{Scheme: PathScheme})
+	tester.verify(t, 5)
+	if _, err := New(TrieID(tester.roots[6]), tester.db); err == nil {
+		t.Fatalf("In-memory state available after restart")
+	}
+}
+
+func TestPathDatabaseFlatten(t *testing.T) {
+	tester := newPathTester(t, 0, maxDiffLayers+10)
+
+	// The bottom-most diff layers are flattened into disk
+	persisted := len(tester.roots) - maxDiffLayers - 1
+	tester.verifyDisk(t, persisted)
+	for i := persisted; i < len(tester.roots); i++ {
+		tester.verify(t, i)
+	}
+	if id := rawdb.ReadPersistentStateID(tester.diskdb); id != uint64(persisted+1) {
+		t.Fatalf("Persistent state id mismatch: have %d, want %d", id, persisted+1)
+	}
+}
+
+func TestPathDatabaseRecover(t *testing.T) {
+	tester := newPathTester(t, 0, 10)
+	if err := tester.db.Commit(tester.roots[len(tester.roots)-1], false, nil); err != nil {
+		t.Fatalf("Failed to commit state: %v", err)
+	}
+	if tester.db.Recoverable(tester.roots[len(tester.roots)-1]) {
+		t.Fatalf("Persisted state reported as recoverable")
+	}
+	if tester.db.Recoverable(common.Hash{0x1}) {
+		t.Fatalf("Unknown state reported as recoverable")
+	}
+	for _, i := range []int{7, 2} {
+		if !tester.db.Recoverable(tester.roots[i]) {
+			t.Fatalf("State %d not recoverable", i)
+		}
+		if err := tester.db.Recover(tester.roots[i]); err != nil {
+			t.Fatalf("Failed to recover state %d: %v", i, err)
+		}
+		tester.verify(t, i)
+		tester.verifyDisk(t, i)
+		if tester.db.Recoverable(tester.roots[i+1]) {
+			t.Fatalf("Reverted state %d reported as recoverable", i+1)
+		}
+	}
+}
+
+func TestPathDatabaseHistoryPruning(t *testing.T) {
+	tester := newPathTester(t, 3, 10)
+	if err := tester.db.Commit(tester.roots[9], false, nil); err != nil {
+		t.Fatalf("Failed to commit state: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		if tester.db.Recoverable(tester.roots[i]) {
+			t.Fatalf("Pruned state %d reported as recoverable", i)
+		}
+	}
+	for i := 6; i < 9; i++ {
+		if !tester.db.Recoverable(tester.roots[i]) {
+			t.Fatalf("State %d not recoverable", i)
+		}
+	}
+}
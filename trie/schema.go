@@ -17,6 +17,8 @@
 package trie
 
 import (
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -24,9 +26,7 @@ import (
 
 const (
 	HashScheme = "hashScheme" // Identifier of hash based node scheme
-
-	// Path-based scheme will be introduced in the following PRs.
-	// PathScheme = "pathScheme" // Identifier of path based node scheme
+	PathScheme = "pathScheme" // Identifier of path based node scheme
 )
 
 // NodeScheme describes the scheme for interacting nodes in disk.
@@ -94,3 +94,102 @@ func (scheme *hashScheme) IsTrieNode(key []byte) (bool, []byte) {
 	}
 	return false, nil
 }
+
+type pathScheme struct{}
+
+// Name returns the identifier of path based scheme.
+func (scheme *pathScheme) Name() string {
+	return PathScheme
+}
+
+// HasTrieNode checks the trie node presence with the provided node info and
+// the associated node hash.
+func (scheme *pathScheme) HasTrieNode(db ethdb.KeyValueReader, owner common.Hash, path []byte, hash common.Hash) bool {
+	if owner == (common.Hash{}) {
+		return rawdb.HasAccountTrieNode(db, path, hash)
+	}
+	return rawdb.HasStorageTrieNode(db, owner, path, hash)
+}
+
+// ReadTrieNode retrieves the trie node from database with the provided node info
+// and associated node hash. Nil is returned if the node stored at the path has
+// a different hash.
+func (scheme *pathScheme) ReadTrieNode(db ethdb.KeyValueReader, owner common.Hash, path []byte, hash common.Hash) []byte {
+	var (
+		blob  []byte
+		nHash common.Hash
+	)
+	if owner == (common.Hash{}) {
+		blob, nHash = rawdb.ReadAccountTrieNode(db, path)
+	} else {
+		blob, nHash = rawdb.ReadStorageTrieNode(db, owner, path)
+	}
+	if nHash != hash {
+		return nil
+	}
+	return blob
+}
+
+// WriteTrieNode writes the trie node into database with the provided node info
+// and associated node hash.
+func (scheme *pathScheme) WriteTrieNode(db ethdb.KeyValueWriter, owner common.Hash, path []byte, hash common.Hash, node []byte) {
+	if owner == (common.Hash{}) {
+		rawdb.WriteAccountTrieNode(db, path, node)
+	} else {
+		rawdb.WriteStorageTrieNode(db, owner, path, node)
+	}
+}
+
+// DeleteTrieNode deletes the trie node from database with the provided node info
+// and associated node hash.
+func (scheme *pathScheme) DeleteTrieNode(db ethdb.KeyValueWriter, owner common.Hash, path []byte, hash common.Hash) {
+	if owner == (common.Hash{}) {
+		rawdb.DeleteAccountTrieNode(db, path)
+	} else {
+		rawdb.DeleteStorageTrieNode(db, owner, path)
+	}
+}
+
+// IsTrieNode returns an indicator if the given database key is the key of trie
+// node according to the scheme.
+func (scheme *pathScheme) IsTrieNode(key []byte) (bool, []byte) {
+	if ok, path := rawdb.IsAccountTrieNode(key); ok {
+		return true, path
+	}
+	if ok, _, path := rawdb.IsStorageTrieNode(key); ok {
+		return true, path
+	}
+	return false, nil
+}
+
+// ReadStateScheme reads the node scheme of the state persisted in the given
+// database. The path scheme is reported if the root node of the account trie
+// is stored by path, the hash scheme otherwise (including empty databases).
+func ReadStateScheme(db ethdb.KeyValueReader) string {
+	if blob, _ := rawdb.ReadAccountTrieNode(db, nil); len(blob) != 0 {
+		return PathScheme
+	}
+	return HashScheme
+}
+
+// ParseStateScheme checks the node scheme requested by the user against the
+// one of the state already persisted in the database, returning the scheme to
+// use. An empty request picks the scheme of the persisted state.
+func ParseStateScheme(provided string, db ethdb.Database) (string, error) {
+	// Databases without any state can be initialized with either scheme
+	stored := ReadStateScheme(db)
+	if stored == HashScheme && rawdb.ReadCanonicalHash(db, 0) == (common.Hash{}) {
+		stored = ""
+	}
+	switch {
+	case provided == "" && stored == "":
+		return HashScheme, nil
+	case provided == "":
+		return stored, nil
+	case provided != HashScheme && provided != PathScheme:
+		return "", fmt.Errorf("unknown state scheme %q", provided)
+	case stored != "" && stored != provided:
+		return "", fmt.Errorf("incompatible state scheme, stored: %s, provided: %s", stored, provided)
+	}
+	return provided, nil
+}
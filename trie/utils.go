@@ -53,7 +53,7 @@ func newTracer() *tracer {
 // onRead tracks the newly loaded trie node and caches the rlp-encoded blob internally.
 // Don't change the value outside of function since it's not deep-copied.
 func (t *tracer) onRead(path []byte, val []byte) {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return
 	}
@@ -63,7 +63,7 @@ func (t *tracer) onRead(path []byte, val []byte) {
 // onInsert tracks the newly inserted trie node. If it's already in the deletion set
 // (resurrected node), then just wipe it from the deletion set as the "untouched".
 func (t *tracer) onInsert(path []byte) {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return
 	}
@@ -78,7 +78,7 @@ func (t *tracer) onInsert(path []byte) {
 // in the addition set, then just wipe it from the addition set
 // as it's untouched.
 func (t *tracer) onDelete(path []byte) {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return
 	}
@@ -91,7 +91,7 @@ func (t *tracer) onDelete(path []byte) {
 
 // insertList returns the tracked inserted trie nodes in list format.
 func (t *tracer) insertList() [][]byte {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return nil
 	}
@@ -104,7 +104,7 @@ func (t *tracer) insertList() [][]byte {
 
 // deleteList returns the tracked deleted trie nodes in list format.
 func (t *tracer) deleteList() [][]byte {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return nil
 	}
@@ -117,7 +117,7 @@ func (t *tracer) deleteList() [][]byte {
 
 // prevList returns the tracked node blobs in list format.
 func (t *tracer) prevList() ([][]byte, [][]byte) {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return nil, nil
 	}
@@ -134,7 +134,7 @@ func (t *tracer) prevList() ([][]byte, [][]byte) {
 
 // getPrev returns the cached original value of the specified node.
 func (t *tracer) getPrev(path []byte) []byte {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return nil
 	}
@@ -143,7 +143,7 @@ func (t *tracer) getPrev(path []byte) []byte {
 
 // reset clears the content tracked by tracer.
 func (t *tracer) reset() {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return
 	}
@@ -154,7 +154,7 @@ func (t *tracer) reset() {
 
 // copy returns a deep copied tracer instance.
 func (t *tracer) copy() *tracer {
-	// Tracer isn't used right now, remove this check later.
+	// Tracer is nil for the tries which are never committed, e.g. in proofs.
 	if t == nil {
 		return nil
 	}